@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/providers"
+	"scrum-master/internal/services"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func runInit(cmd *cobra.Command, args []string) error {
+	user, _ := cmd.Flags().GetBool("user")
+	force, _ := cmd.Flags().GetBool("force")
+
+	path := initPath(user)
+	if helpers.FileExists(path) && !force && !confirm(fmt.Sprintf("%s already exists. Overwrite it?", path)) {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	}
+
+	helpers.PrintTitle("Setting up scrum-master")
+	cfg := config.Defaults()
+	ctx := cmd.Context()
+
+	if err := askAnthropic(ctx, cfg); err != nil {
+		return err
+	}
+	if err := askJira(ctx, cfg); err != nil {
+		return err
+	}
+	mapping, err := services.NewJiraService(&cfg.Jira, &cfg.HTTP).DiscoverFields(ctx)
+	if err != nil {
+		helpers.PrintWarning("Failed to discover the issue types of project %s, they are detected on every run instead: %v", cfg.Jira.ProjectKey, err)
+	} else {
+		applyFieldMapping(cfg, mapping)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("the configuration is not valid: %w", err)
+	}
+
+	written := *cfg
+	if confirm("Store the API key and token in the OS keyring instead of the configuration file?") {
+		if err := storeInitSecrets(cfg); err != nil {
+			helpers.PrintWarning("Could not use the OS keyring, writing them to the configuration file instead: %v", err)
+		} else {
+			written.Anthropic.APIKey, written.Jira.APIToken = "", ""
+		}
+	}
+
+	data, err := yaml.Marshal(initSettings(&written))
+	if err != nil {
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// The file may hold credentials
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	helpers.PrintSuccess("Wrote %s", path)
+	helpers.PrintInfo("See sample-config.yaml for every other setting, then run: scrum-master process <project.md>")
+	return nil
+}
+
+// initPath returns the file init writes: the user configuration with --user, the file named with
+// --config, or the project configuration of the working directory
+func initPath(user bool) string {
+	switch {
+	case user:
+		return config.UserConfigPath()
+	case configFile != "":
+		return configFile
+	default:
+		return config.ProjectConfigName
+	}
+}
+
+// askAnthropic prompts for the Anthropic API key until the API accepts it
+func askAnthropic(ctx context.Context, cfg *config.Config) error {
+	helpers.PrintSeparator()
+	helpers.PrintInfo("Anthropic API keys are created at https://console.anthropic.com/")
+	for {
+		key, err := readSecret("Anthropic API key: ")
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("no Anthropic API key entered")
+		}
+		cfg.Provider = "anthropic"
+		cfg.Anthropic.APIKey = key
+
+		helpers.PrintInfo("Checking the key with model %s...", cfg.Anthropic.Model)
+		err = testProvider(ctx, cfg)
+		if err == nil {
+			helpers.PrintSuccess("Anthropic API key works")
+			return nil
+		}
+		helpers.PrintError("Anthropic API check failed: %v", err)
+		if !confirm("Enter the key again?") {
+			return fmt.Errorf("anthropic API check failed: %w", err)
+		}
+	}
+}
+
+// testProvider checks the credentials of the configured AI provider
+func testProvider(ctx context.Context, cfg *config.Config) error {
+	provider, err := providers.New(cfg)
+	if err != nil {
+		return err
+	}
+	tester, ok := provider.(providers.ConnectionTester)
+	if !ok {
+		return nil
+	}
+	return tester.TestConnection(ctx)
+}
+
+// askJira prompts for the JIRA site, credentials and project until the connection works and the
+// project is accessible
+func askJira(ctx context.Context, cfg *config.Config) error {
+	for {
+		helpers.PrintSeparator()
+		baseURL, err := ask("JIRA URL (e.g. https://your-domain.atlassian.net)", cfg.Jira.BaseURL)
+		if err != nil {
+			return err
+		}
+		cfg.Jira.BaseURL = strings.TrimSuffix(baseURL, "/")
+
+		cfg.Jira.AuthType = config.JiraAuthBasic
+		cfg.Jira.Username = ""
+		if !strings.HasSuffix(cfg.Jira.BaseURL, ".atlassian.net") &&
+			confirm("Sign in with a personal access token (JIRA Server or Data Center)?") {
+			cfg.Jira.AuthType = config.JiraAuthPAT
+		}
+
+		if cfg.Jira.AuthType == config.JiraAuthBasic {
+			helpers.PrintInfo("API tokens are created at https://id.atlassian.com/manage-profile/security/api-tokens")
+			if cfg.Jira.Username, err = ask("JIRA email or username", ""); err != nil {
+				return err
+			}
+			cfg.Jira.APIToken, err = readSecret("JIRA API token: ")
+		} else {
+			cfg.Jira.APIToken, err = readSecret("JIRA personal access token: ")
+		}
+		if err != nil {
+			return err
+		}
+
+		projectKey, err := ask("JIRA project key (e.g. PROJ)", cfg.Jira.ProjectKey)
+		if err != nil {
+			return err
+		}
+		cfg.Jira.ProjectKey = strings.ToUpper(projectKey)
+
+		if err := cfg.Validate(); err != nil {
+			helpers.PrintError("%v", err)
+		} else if err := services.NewJiraService(&cfg.Jira, &cfg.HTTP).TestConnection(ctx); err != nil {
+			helpers.PrintError("JIRA check failed: %v", err)
+		} else {
+			return nil
+		}
+		if !confirm("Enter the JIRA settings again?") {
+			return fmt.Errorf("JIRA check failed")
+		}
+	}
+}
+
+// ask prompts for a required line of text, offering a default answer when one is given
+func ask(question, defaultAnswer string) (string, error) {
+	for {
+		if defaultAnswer != "" {
+			fmt.Printf("%s [%s]: ", question, defaultAnswer)
+		} else {
+			fmt.Printf("%s: ", question)
+		}
+
+		line, err := promptReader().ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", fmt.Errorf("no answer to '%s'", question)
+		}
+		if answer := strings.TrimSpace(line); answer != "" {
+			return answer, nil
+		}
+		if defaultAnswer != "" {
+			return defaultAnswer, nil
+		}
+	}
+}
+
+// applyFieldMapping configures the issue types and fields discovered in the project
+func applyFieldMapping(cfg *config.Config, mapping *models.JiraFieldMapping) {
+	cfg.Jira.IssueTypeMapping = config.IssueTypesConfig{
+		Epic:    mapping.EpicType,
+		Story:   mapping.StoryType,
+		Subtask: mapping.SubtaskType,
+	}
+	helpers.PrintSuccess("Epics are created as '%s' and stories as '%s' (available: %s)",
+		mapping.EpicType, mapping.StoryType, strings.Join(mapping.AvailableTypes, ", "))
+
+	if mapping.StoryPointsField != "" {
+		cfg.Jira.FieldMapping = map[string]string{config.FieldStoryPoints: mapping.StoryPointsField}
+		helpers.PrintSuccess("Story points go to %s", mapping.StoryPointsField)
+	}
+	for _, key := range []string{config.CustomFieldEpicName, config.CustomFieldEpicLink} {
+		if field, ok := mapping.CustomFields[key]; ok {
+			if cfg.Jira.CustomFields == nil {
+				cfg.Jira.CustomFields = make(map[string]string)
+			}
+			cfg.Jira.CustomFields[key] = field.ID
+		}
+	}
+	for issueType, fields := range mapping.RequiredFields {
+		for _, field := range fields {
+			helpers.PrintWarning("%s issues require %s (%s), which scrum-master does not fill; give it a default in JIRA", issueType, field.Name, field.FieldID)
+		}
+	}
+}
+
+// storeInitSecrets stores the API key and JIRA token of the wizard in the OS keyring
+func storeInitSecrets(cfg *config.Config) error {
+	if err := config.StoreSecret(profile, "anthropic.api_key", cfg.Anthropic.APIKey); err != nil {
+		return err
+	}
+	if err := config.StoreSecret(profile, "jira.api_token", cfg.Jira.APIToken); err != nil {
+		return err
+	}
+	helpers.PrintSuccess("Stored anthropic.api_key and jira.api_token in the OS keyring")
+	return nil
+}
+
+// initSettings returns the settings the wizard asked for, in the order of sample-config.yaml
+func initSettings(cfg *config.Config) yaml.MapSlice {
+	jira := yaml.MapSlice{{Key: "base_url", Value: cfg.Jira.BaseURL}}
+	if cfg.Jira.AuthType == config.JiraAuthBasic {
+		jira = append(jira, yaml.MapItem{Key: "username", Value: cfg.Jira.Username})
+	}
+	jira = append(jira,
+		yaml.MapItem{Key: "api_token", Value: cfg.Jira.APIToken},
+		yaml.MapItem{Key: "auth_type", Value: cfg.Jira.AuthType},
+		yaml.MapItem{Key: "project_key", Value: cfg.Jira.ProjectKey},
+	)
+
+	issueTypes := yaml.MapSlice{}
+	for _, issueType := range []yaml.MapItem{
+		{Key: "epic", Value: cfg.Jira.IssueTypeMapping.Epic},
+		{Key: "story", Value: cfg.Jira.IssueTypeMapping.Story},
+		{Key: "subtask", Value: cfg.Jira.IssueTypeMapping.Subtask},
+	} {
+		if issueType.Value != "" {
+			issueTypes = append(issueTypes, issueType)
+		}
+	}
+	if len(issueTypes) > 0 {
+		jira = append(jira, yaml.MapItem{Key: "issue_type_mapping", Value: issueTypes})
+	}
+	if len(cfg.Jira.FieldMapping) > 0 {
+		jira = append(jira, yaml.MapItem{Key: "field_mapping", Value: cfg.Jira.FieldMapping})
+	}
+	if len(cfg.Jira.CustomFields) > 0 {
+		jira = append(jira, yaml.MapItem{Key: "custom_fields", Value: cfg.Jira.CustomFields})
+	}
+
+	return yaml.MapSlice{
+		{Key: "provider", Value: cfg.Provider},
+		{Key: "anthropic", Value: yaml.MapSlice{
+			{Key: "api_key", Value: cfg.Anthropic.APIKey},
+			{Key: "model", Value: cfg.Anthropic.Model},
+		}},
+		{Key: "jira", Value: jira},
+	}
+}
@@ -2,21 +2,35 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"scrum-master/internal/config"
+	"scrum-master/internal/demo"
 	"scrum-master/internal/helpers"
 	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
 	"scrum-master/internal/services"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
 	configFile string
+	profile    string
 	dryRun     bool
+	accessible bool
 )
 
 func main() {
@@ -28,19 +42,70 @@ and automatically create JIRA epics and stories with proper linking.`,
 	}
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "config.yaml", "Configuration file path")
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Configuration file path, merged over the user configuration (default: the nearest .scrum-master.yaml, or config.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "Configuration profile merged over the shared settings (default: default_profile)")
+	rootCmd.PersistentFlags().BoolVar(&ciMode, "ci", false, "CI mode: print a JSON result to stdout, log to stderr, exit with a code per failure class")
+	rootCmd.PersistentFlags().StringVar(&ciSummaryFile, "ci-summary", "", "Append a markdown summary to this file in CI mode (default: $GITHUB_STEP_SUMMARY)")
+	rootCmd.PersistentFlags().BoolVar(&accessible, "accessible", false, "Screen-reader friendly output: no colors or emoji, textual status prefixes")
+	rootCmd.PersistentFlags().StringVar(&recordSession, "record-session", "", "Record HTTP traffic, config and log with credentials removed into this bundle for a bug report")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay-dir", "", "Answer HTTP requests from the session extracted into this directory (used by replay-session)")
+	rootCmd.PersistentFlags().MarkHidden("replay-dir")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		helpers.SetAccessible(accessible)
+		if wantsResult() {
+			if err := startResult(cmd); err != nil {
+				return err
+			}
+		}
+		return startSession()
+	}
+
+	// Init command
+	var initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Create a configuration with a guided setup",
+		Long:  "Prompt for the Anthropic API key, JIRA site, credentials and project, check each connection as it is entered, discover the project's issue types and fields, and write a validated configuration",
+		Args:  cobra.NoArgs,
+		RunE:  runInit,
+	}
+	initCmd.Flags().Bool("user", false, "Write the user configuration ($XDG_CONFIG_HOME/scrum-master/config.yaml) instead of .scrum-master.yaml")
+	initCmd.Flags().Bool("force", false, "Overwrite an existing configuration without asking")
+	rootCmd.AddCommand(initCmd)
 
 	// Process command
 	var processCmd = &cobra.Command{
 		Use:   "process",
-		Short: "Process a project description file",
-		Long:  "Analyze a project description and create a breakdown of epics and stories",
-		Args:  cobra.ExactArgs(1),
+		Short: "Process a project description file or project manifest",
+		Long:  "Analyze a project description (or a project.yaml manifest of several documents, or a Confluence or Notion page with --confluence or --notion) and create a breakdown of epics and stories",
+		Args:  cobra.MaximumNArgs(1),
 		RunE:  runProcess,
 	}
 	processCmd.Flags().StringP("mode", "m", "full", "Processing mode (analyze-only, full)")
+	processCmd.Flags().StringSlice("sections", nil, "Only analyze these markdown sections (comma-separated headings)")
+	processCmd.Flags().StringSlice("exclude-sections", nil, "Skip these markdown sections (comma-separated headings)")
+	processCmd.Flags().String("since", "", "Previous analysis file; only analyze sections added to the document since then")
+	processCmd.Flags().String("confluence", "", "Analyze this Confluence page, given by page ID or URL, instead of an input file")
+	processCmd.Flags().String("notion", "", "Analyze this Notion page or database, given by ID or URL, instead of an input file")
+	processCmd.Flags().String("server", "", "Run the analysis on a scrum-master server at this URL (see serve)")
+	processCmd.Flags().Bool("with-subtasks", false, "Break every story into implementation subtasks (backend, frontend, tests)")
+	processCmd.Flags().Bool("with-risks", false, "Also identify the technical and delivery risks of the project")
+	processCmd.Flags().Bool("split-large", false, "Propose splitting stories in the upper third of the estimation scale (8 or more Fibonacci points) into smaller ones, for approval")
+	processCmd.Flags().Bool("force", false, "Analyze the input even if it does not look like a project description")
+	processCmd.Flags().Bool("estimate", false, "Print the chunk count and approximate AI cost without calling the AI")
+	processCmd.Flags().StringVar(&outputFormat, "output", "", "Print the result to stdout as json or yaml; progress goes to stderr")
 	rootCmd.AddCommand(processCmd)
 
+	// Demo command
+	var demoCmd = &cobra.Command{
+		Use:   "demo",
+		Short: "Run the whole pipeline on a sample project without credentials",
+		Long:  "Analyze a bundled sample project with recorded AI responses and create its tickets in a simulated JIRA, writing the real output files and an HTML report. No configuration or credentials are needed.",
+		Args:  cobra.NoArgs,
+		RunE:  runDemo,
+	}
+	demoCmd.Flags().String("output-dir", "scrum-master-demo", "Directory for the demo's output files")
+	rootCmd.AddCommand(demoCmd)
+
 	// Create from analysis command
 	var createFromAnalysisCmd = &cobra.Command{
 		Use:   "create-from-analysis",
@@ -50,56 +115,772 @@ and automatically create JIRA epics and stories with proper linking.`,
 		RunE:  runCreateFromAnalysis,
 	}
 	createFromAnalysisCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show what would be created without actually creating JIRA tickets")
+	createFromAnalysisCmd.Flags().String("automation-level", "", "Override processing.automation_level (suggest, review, auto)")
+	createFromAnalysisCmd.Flags().String("resume", "", "Run ledger of an earlier run; issues it created are skipped")
+	createFromAnalysisCmd.Flags().String("edit", "", "Edit the breakdown in $EDITOR as yaml or json before creating it (--edit defaults to yaml)")
+	createFromAnalysisCmd.Flags().Lookup("edit").NoOptDefVal = services.EditFormatYAML
+	createFromAnalysisCmd.Flags().StringSlice("epics", nil, "Only create these epics, by number or ID (comma-separated)")
+	createFromAnalysisCmd.Flags().String("min-priority", "", "Only create stories of at least this priority (High, Medium, Low)")
+	createFromAnalysisCmd.Flags().Int("max-points", 0, "Only create stories of at most this many story points")
+	createFromAnalysisCmd.Flags().Bool("fill-sprints", false, "Move created stories into the board's future sprints by weighted shortest job first")
+	createFromAnalysisCmd.Flags().Bool("create-sprints", false, "Create sprints for the stories that do not fit into the board's future sprints (implies --fill-sprints)")
+	createFromAnalysisCmd.Flags().BoolP("yes", "y", false, "Create the issues without the confirmation prompt (required with --ci at automation level review)")
+	createFromAnalysisCmd.Flags().Bool("assign", false, "Assign created stories to the team members suggested for them (needs team.members)")
+	createFromAnalysisCmd.Flags().StringVar(&outputFormat, "output", "", "Print the result to stdout as json or yaml; progress goes to stderr")
 	rootCmd.AddCommand(createFromAnalysisCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	// Sync command
+	var syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Sync an analysis with the existing JIRA backlog",
+		Long:  "Match the epics and stories of an analysis against the project's existing issues by traceability label or summary, update the descriptions of existing issues and create only the missing ones",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSync,
+	}
+	syncCmd.Flags().BoolP("dry-run", "d", false, "Show what would be created and updated without changing JIRA")
+	syncCmd.Flags().String("jql", "", "JQL selecting the existing issues to match (default: the whole project)")
+	syncCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	rootCmd.AddCommand(syncCmd)
+
+	// Export command
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export an analysis for import into other tools",
+		Long:  "Convert an analysis into a file other tools can import, such as a CSV for JIRA's external importer when no API token is available, or an HTML report to share",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runExport,
+	}
+	exportCmd.Flags().StringP("format", "f", services.ExportJiraCSV, "Export format (jira-csv, html, mermaid, dot)")
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default: a timestamped file in the output directory)")
+
+	var exportConfluenceCmd = &cobra.Command{
+		Use:   "confluence <analysis.json>",
+		Short: "Publish the summary of an analysis as a Confluence page",
+		Long:  "Publish the markdown summary of an analysis as a page of the confluence.space_key space, or a new version of the page published before, and link it from the created epics",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runExportConfluence,
+	}
+	exportConfluenceCmd.Flags().String("ledger", "", "Run ledger of the run that created the epics in JIRA, to link the page from them")
+	exportCmd.AddCommand(exportConfluenceCmd)
+	rootCmd.AddCommand(exportCmd)
+
+	// Rollback command
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback",
+		Short: "Remove the JIRA issues created by a run",
+		Long:  "Read a run ledger and delete every issue the run created, stories before epics, or move them to a workflow status with --status",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runRollback,
+	}
+	rollbackCmd.Flags().BoolP("dry-run", "d", false, "List the issues that would be rolled back without changing them")
+	rollbackCmd.Flags().String("status", "", "Move issues to this workflow status (e.g. Rollback) instead of deleting them")
+	rollbackCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	rootCmd.AddCommand(rollbackCmd)
+
+	// Ask command
+	var askCmd = &cobra.Command{
+		Use:   "ask <analysis-file> <question>",
+		Short: "Ask a follow-up question about an analysis",
+		Long:  "Continue the AI conversation of an analysis run with full context, e.g. to ask why stories were split a certain way",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runAsk,
+	}
+	rootCmd.AddCommand(askCmd)
+
+	// Serve command
+	var serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Run in server mode and learn from JIRA webhooks",
+		Long:  "Start an HTTP server that receives JIRA issue webhooks and records human edits to issues created by scrum-master",
+		Args:  cobra.NoArgs,
+		RunE:  runServe,
+	}
+	serveCmd.Flags().String("addr", "", "Listen address (overrides server.listen_addr)")
+	rootCmd.AddCommand(serveCmd)
+
+	// Patterns command
+	var patternsCmd = &cobra.Command{
+		Use:   "patterns",
+		Short: "Show how humans edited generated issues",
+		Long:  "Aggregate the human edits recorded from JIRA webhooks, such as estimate changes and summary rewrites per epic",
+		Args:  cobra.NoArgs,
+		RunE:  runPatterns,
+	}
+	rootCmd.AddCommand(patternsCmd)
+
+	// Feedback command
+	var feedbackCmd = &cobra.Command{
+		Use:   "feedback <analysis-file>",
+		Short: "Rate and annotate a generated epic or story",
+		Long:  "Store a rating and note for a generated item; ratings are used as examples and counter-examples in future prompts",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runFeedback,
+	}
+	feedbackCmd.Flags().String("story", "", "Story to rate by ID (e.g. E3-S2) or as EPIC.STORY (e.g. 3.2)")
+	feedbackCmd.Flags().String("epic", "", "Epic to rate by ID (e.g. E3) or number (e.g. 3)")
+	feedbackCmd.Flags().String("rating", "", "Rating: good or bad")
+	feedbackCmd.Flags().String("note", "", "Why the item is good or bad")
+	feedbackCmd.MarkFlagRequired("rating")
+	feedbackCmd.MarkFlagsMutuallyExclusive("story", "epic")
+	feedbackCmd.MarkFlagsOneRequired("story", "epic")
+	rootCmd.AddCommand(feedbackCmd)
+
+	// Verify command
+	var verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify code changes against a story's acceptance criteria (experimental)",
+		Long:  "Use AI to evaluate whether a git diff plausibly satisfies each acceptance criterion of a JIRA story, producing a confidence-scored checklist",
+		Args:  cobra.NoArgs,
+		RunE:  runVerify,
+	}
+	verifyCmd.Flags().String("story", "", "JIRA story key to verify (e.g. PROJ-456)")
+	verifyCmd.Flags().String("diff", "", "Git revision range containing the delivered code (e.g. main..feature)")
+	verifyCmd.MarkFlagRequired("story")
+	verifyCmd.MarkFlagRequired("diff")
+	rootCmd.AddCommand(verifyCmd)
+
+	// Generate tests command
+	var generateTestsCmd = &cobra.Command{
+		Use:   "generate-tests <analysis-file>",
+		Short: "Write QA test cases for the stories of an analysis",
+		Long:  "Use AI to write positive, negative and edge test cases for every story of an analysis, saved as JSON and markdown next to it and optionally appended to the JIRA descriptions of the stories",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runGenerateTests,
+	}
+	generateTestsCmd.Flags().StringSlice("items", nil, "Only write test cases for these epics and stories, by ID or position (comma-separated)")
+	generateTestsCmd.Flags().Bool("update-jira", false, "Write the test cases into the JIRA descriptions of the stories")
+	generateTestsCmd.Flags().String("ledger", "", "Run ledger of the run that created the stories in JIRA, for --update-jira")
+	rootCmd.AddCommand(generateTestsCmd)
+
+	// Lint command
+	var lintCmd = &cobra.Command{
+		Use:   "lint <analysis-file>",
+		Short: "Score the stories of an analysis against INVEST",
+		Long:  "Score every story of an analysis against the INVEST criteria (independent, negotiable, valuable, estimable, small, testable) and fail when stories are too large, have no acceptance criteria or score below --min-score",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runLint,
+		// Failing the quality gates is not a usage error
+		SilenceUsage: true,
+	}
+	lintCmd.Flags().Int("max-points", 8, "Fail stories of more story points")
+	lintCmd.Flags().Int("min-score", 0, "Fail stories with a lower INVEST score, 0 to 6 (0 = no score gate)")
+	rootCmd.AddCommand(lintCmd)
+
+	// Plan command
+	var planCmd = &cobra.Command{
+		Use:   "plan <analysis-file>",
+		Short: "Plan the stories of an analysis into sprints",
+		Long:  "Distribute the stories of an analysis over numbered sprints of --velocity story points by weighted shortest job first, keeping every story in or after the sprints of the stories it depends on, and save the plan as JSON and markdown. With --create-sprints, the sprints are created on the JIRA board and the stories that have issues are moved into them.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runPlan,
+	}
+	planCmd.Flags().Int("velocity", 0, "Story points per sprint (default: jira.sprints.capacity)")
+	planCmd.Flags().Int("sprints", 0, "Number of sprints to plan (0 = as many as the stories need)")
+	planCmd.Flags().Bool("create-sprints", false, "Create the planned sprints in JIRA and move the stories into them")
+	planCmd.Flags().BoolP("yes", "y", false, "Create the sprints without the confirmation prompt (required with --create-sprints and --ci)")
+	planCmd.Flags().String("ledger", "", "Run ledger of the run that created the stories in JIRA, for --create-sprints")
+	rootCmd.AddCommand(planCmd)
+
+	// Roadmap command
+	var roadmapCmd = &cobra.Command{
+		Use:   "roadmap <analysis-file>",
+		Short: "Phase the epics of an analysis into a quarterly roadmap",
+		Long:  "Schedule the epics of an analysis one after another at the team's velocity, after the epics they depend on and otherwise by priority, and save the roadmap by quarter as markdown and as a Mermaid gantt diagram",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runRoadmap,
+	}
+	roadmapCmd.Flags().Int("velocity", 0, "Story points per sprint (default: jira.sprints.capacity)")
+	roadmapCmd.Flags().String("start", "", "Date work starts, as YYYY-MM-DD (default: today)")
+	rootCmd.AddCommand(roadmapCmd)
+
+	// Doctor command
+	var doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration and connections before a run",
+		Long:  "Check that the configuration is valid, the AI provider and JIRA accept their credentials, the project is accessible, its issue types and required fields suit scrum-master, and issues may be created in it, printing a pass/fail report",
+		Args:  cobra.NoArgs,
+		RunE:  runDoctor,
+	}
+	rootCmd.AddCommand(doctorCmd)
+
+	// Config commands
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Validate or show the configuration",
+	}
+
+	var configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration and check it against the AI provider and JIRA",
+		Long:  "Load and validate the configuration, then check the AI provider and JIRA credentials, the project, its issue types and required fields, and the permission to create issues, without processing anything",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigValidate,
+	}
+	configValidateCmd.Flags().Bool("offline", false, "Only validate the configuration files, without contacting the AI provider or JIRA")
+	configCmd.AddCommand(configValidateCmd)
+
+	var configShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration with secrets redacted",
+		Long:  "Print the configuration a run would use, with the configuration files and profile merged, environment variables and secrets resolved and defaults filled in. Credentials are redacted.",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigShow,
+	}
+	configCmd.AddCommand(configShowCmd)
+
+	rootCmd.AddCommand(configCmd)
+
+	// Clean command
+	var cleanCmd = &cobra.Command{
+		Use:   "clean",
+		Short: "Archive or delete old runs from the output directory",
+		Long:  "Remove runs beyond a retention policy from the output directory, optionally archiving them to a tar.gz first",
+		Args:  cobra.NoArgs,
+		RunE:  runClean,
+	}
+	cleanCmd.Flags().Int("keep-last", 0, "Keep only the newest N runs (overrides processing.retention.keep_last)")
+	cleanCmd.Flags().Int("max-age-days", 0, "Remove runs older than N days (overrides processing.retention.max_age_days)")
+	cleanCmd.Flags().String("archive", "", "Archive removed runs to this tar.gz file instead of deleting them")
+	cleanCmd.Flags().BoolP("dry-run", "d", false, "Show which runs would be removed without changing anything")
+	rootCmd.AddCommand(cleanCmd)
+
+	// History command
+	var historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "List runs in the output directory, including archived ones",
+		Long:  "List the files written by each run, newest first, including runs moved into archives by clean",
+		Args:  cobra.NoArgs,
+		RunE:  runHistory,
+	}
+	rootCmd.AddCommand(historyCmd)
+
+	// Search command
+	var searchCmd = &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search epics and stories across past analyses",
+		Long:  "Full-text search titles, descriptions and acceptance criteria of every stored analysis, showing where the scope appeared and whether it was created in JIRA",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSearch,
+	}
+	rootCmd.AddCommand(searchCmd)
+
+	// Workspace commands
+	var workspaceCmd = &cobra.Command{
+		Use:   "workspace",
+		Short: "Export or import a complete planning workspace",
+	}
+
+	var workspaceExportCmd = &cobra.Command{
+		Use:   "export <bundle.tar.gz>",
+		Short: "Export config, analyses, inputs and feedback into one archive",
+		Long:  "Bundle the configuration (with credentials stripped), the output directory and the given input documents into a single archive to hand over or move between machines",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWorkspaceExport,
+	}
+	workspaceExportCmd.Flags().StringSlice("input", nil, "Input documents or project manifests to include (manifests bring their documents)")
+	workspaceCmd.AddCommand(workspaceExportCmd)
+
+	var workspaceImportCmd = &cobra.Command{
+		Use:   "import <bundle.tar.gz>",
+		Short: "Import a workspace archive",
+		Long:  "Extract a workspace archive created by 'workspace export' into a directory",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWorkspaceImport,
+	}
+	workspaceImportCmd.Flags().String("dir", ".", "Directory to import the workspace into")
+	workspaceImportCmd.Flags().Bool("force", false, "Overwrite existing files")
+	workspaceCmd.AddCommand(workspaceImportCmd)
+
+	rootCmd.AddCommand(workspaceCmd)
+
+	// Board command
+	var boardCmd = &cobra.Command{
+		Use:   "board",
+		Short: "Track an analysis on a local kanban board",
+		Long:  "Show the stories of an analysis on a To Do / Doing / Done board in the terminal and move them between columns. Progress is saved locally, no tracker needed.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runBoard,
+	}
+	boardCmd.Flags().Bool("print", false, "Print the board and exit")
+	rootCmd.AddCommand(boardCmd)
+
+	// JIRA commands
+	var jiraCmd = &cobra.Command{
+		Use:   "jira",
+		Short: "Inspect the JIRA instance",
+	}
+
+	var discoverFieldsCmd = &cobra.Command{
+		Use:   "discover-fields",
+		Short: "Generate the JIRA field-mapping config for the project",
+		Long:  "Query the project's create metadata and the instance's fields and print a ready-to-paste jira config section with issue types, the story points field and custom field IDs",
+		Args:  cobra.NoArgs,
+		RunE:  runDiscoverFields,
+	}
+	jiraCmd.AddCommand(discoverFieldsCmd)
+
+	rootCmd.AddCommand(jiraCmd)
+
+	// Signing commands
+	var signingCmd = &cobra.Command{
+		Use:   "signing",
+		Short: "Sign analyses and run ledgers and verify their signatures",
+	}
+
+	var signingKeygenCmd = &cobra.Command{
+		Use:   "keygen",
+		Short: "Create a signing key pair",
+		Long:  "Create an Ed25519 private key for signing and its public key in minisign format for verifying",
+		Args:  cobra.NoArgs,
+		RunE:  runSigningKeygen,
+	}
+	signingKeygenCmd.Flags().String("key", "scrum-master.key", "Private key file to write")
+	signingKeygenCmd.Flags().String("public-key", "scrum-master.pub", "Public key file to write")
+	signingKeygenCmd.Flags().Bool("force", false, "Replace existing key files")
+	signingCmd.AddCommand(signingKeygenCmd)
+
+	var signingSignCmd = &cobra.Command{
+		Use:   "sign [files...]",
+		Short: "Sign files with signing.key_file",
+		Long:  "Write a minisign signature next to each file, e.g. an analysis after it was reviewed and approved",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runSigningSign,
+	}
+	signingCmd.AddCommand(signingSignCmd)
+
+	var signingVerifyCmd = &cobra.Command{
+		Use:   "verify [files...]",
+		Short: "Verify file signatures with signing.public_key_file",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runSigningVerify,
+	}
+	signingCmd.AddCommand(signingVerifyCmd)
+
+	rootCmd.AddCommand(signingCmd)
+
+	// Prompt template commands
+	var templatesCmd = &cobra.Command{
+		Use:   "templates",
+		Short: "Customize the prompts sent to the AI",
+	}
+
+	var templatesExportCmd = &cobra.Command{
+		Use:   "export [dir]",
+		Short: "Write the default prompt templates for customizing",
+		Long:  "Write the default prompt templates to dir (default: templates, the default processing.templates_dir); templates found there replace the defaults",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runTemplatesExport,
+	}
+	templatesExportCmd.Flags().Bool("force", false, "Overwrite existing templates")
+	templatesCmd.AddCommand(templatesExportCmd)
+
+	rootCmd.AddCommand(templatesCmd)
+
+	// Auth commands
+	var authCmd = &cobra.Command{
+		Use:   "auth",
+		Short: "Keep API tokens in the OS keyring instead of the configuration",
+	}
+
+	var authLoginCmd = &cobra.Command{
+		Use:   "login [secret]",
+		Short: "Store an API token in the OS keyring",
+		Long: fmt.Sprintf("Prompt for a secret and store it in the OS keyring (%s; default jira.api_token). "+
+			"Configurations that leave the setting empty use it.", strings.Join(config.KeyringSecrets, ", ")),
+		Args: cobra.MaximumNArgs(1),
+		RunE: runAuthLogin,
+	}
+	authCmd.AddCommand(authLoginCmd)
+
+	var authLogoutCmd = &cobra.Command{
+		Use:   "logout [secret]",
+		Short: "Remove an API token from the OS keyring",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  runAuthLogout,
+	}
+	authCmd.AddCommand(authLogoutCmd)
+
+	rootCmd.AddCommand(authCmd)
+
+	// Replay session command
+	var replaySessionCmd = &cobra.Command{
+		Use:   "replay-session <bundle.tar.gz>",
+		Short: "Reproduce a run recorded with --record-session",
+		Long:  "Extract a session bundle and rerun its command with the recorded configuration and inputs, answering every HTTP request from the recording so nothing reaches JIRA or the AI provider",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runReplaySession,
+	}
+	replaySessionCmd.Flags().String("dir", "", "Directory to extract the session into (default: a new temporary directory)")
+	rootCmd.AddCommand(replaySessionCmd)
+
+	// Ctrl-C cancels the command's context so in-flight work can wind down cleanly.
+	// A second Ctrl-C quits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		helpers.PrintWarning("Interrupted - finishing the current request (press Ctrl-C again to quit immediately)")
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil {
 		helpers.PrintError("Error: %v", err)
+	}
+	finishSession(err)
+
+	if wantsResult() {
+		os.Exit(finishResult(err))
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
+// splitLargeStories proposes splits of the oversized stories of a breakdown and applies those
+// the user approves
+func splitLargeStories(ctx context.Context, analysisService *services.AnalysisService, breakdown *models.ProjectBreakdown) error {
+	splits, err := analysisService.SuggestSplits(ctx, breakdown)
+	if err != nil {
+		return fmt.Errorf("failed to propose story splits: %w", err)
+	}
+
+	for _, split := range splits {
+		services.DisplaySplit(split)
+		if !confirm(fmt.Sprintf("Replace %s with these %d stories?", split.StoryID, len(split.Stories))) {
+			helpers.PrintInfo("Keeping %s", split.StoryID)
+			continue
+		}
+		if err := services.ApplySplit(breakdown, split); err != nil {
+			return err
+		}
+		helpers.PrintSuccess("Split %s into %d stories", split.StoryID, len(split.Stories))
+	}
+	return nil
+}
+
 func runProcess(cmd *cobra.Command, args []string) error {
-	inputFile := args[0]
 	mode, _ := cmd.Flags().GetString("mode")
+	since, _ := cmd.Flags().GetString("since")
+	estimate, _ := cmd.Flags().GetBool("estimate")
+	confluencePage, _ := cmd.Flags().GetString("confluence")
+	notionPage, _ := cmd.Flags().GetString("notion")
+
+	inputs := 0
+	for _, given := range []bool{len(args) > 0, confluencePage != "", notionPage != ""} {
+		if given {
+			inputs++
+		}
+	}
+	if inputs != 1 {
+		return classify(exitConfig, fmt.Errorf("give one input: a file, a Confluence page with --confluence or a Notion page with --notion"))
+	}
+	remotePage := confluencePage + notionPage
+	inputFile := remotePage
+	if len(args) > 0 {
+		inputFile = args[0]
+	}
+
+	runResult.InputFile = inputFile
+
+	if since != "" && services.IsManifestFile(inputFile) {
+		return classify(exitConfig, fmt.Errorf("--since is not supported for project manifests"))
+	}
+	documentURL := ""
+	if remotePage == "" && services.IsDocumentURL(inputFile) {
+		if services.IsManifestFile(inputFile) {
+			return classify(exitConfig, fmt.Errorf("project manifests cannot be processed from a URL, download the manifest and its documents first"))
+		}
+		documentURL = inputFile
+	}
+	if estimate && (since != "" || services.IsManifestFile(inputFile)) {
+		return classify(exitConfig, fmt.Errorf("--estimate is not supported with --since or for project manifests"))
+	}
 
 	// Load configuration
-	cfg, err := config.LoadConfig(configFile)
+	cfg, err := config.LoadConfig(configFile, profile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	if cmd.Flags().Changed("sections") {
+		cfg.Processing.IncludeSections, _ = cmd.Flags().GetStringSlice("sections")
+	}
+	if cmd.Flags().Changed("exclude-sections") {
+		cfg.Processing.ExcludeSections, _ = cmd.Flags().GetStringSlice("exclude-sections")
+	}
+	if withSubtasks, _ := cmd.Flags().GetBool("with-subtasks"); withSubtasks {
+		cfg.Processing.Subtasks = true
+	}
+	if withRisks, _ := cmd.Flags().GetBool("with-risks"); withRisks {
+		cfg.Processing.Risks = true
+	}
+	if splitLarge, _ := cmd.Flags().GetBool("split-large"); splitLarge {
+		cfg.Processing.SplitStories = true
+	}
+
+	if since != "" {
+		if err := services.NewHistoryService(cfg).CheckArchived(since); err != nil {
+			return classify(exitConfig, err)
+		}
 	}
 
 	helpers.PrintTitle("Processing Project Description")
-	helpers.PrintInfo("Input file: %s", inputFile)
+	switch {
+	case confluencePage != "":
+		helpers.PrintInfo("Confluence page: %s", confluencePage)
+	case notionPage != "":
+		helpers.PrintInfo("Notion page: %s", notionPage)
+	default:
+		helpers.PrintInfo("Input file: %s", inputFile)
+	}
 	helpers.PrintInfo("Mode: %s", mode)
+	helpers.PrintInfo("AI provider: %s", cfg.Provider)
+
+	// A document given by URL, or a Confluence or Notion page converted to markdown, is
+	// downloaded and analyzed like a file, and the analysis records the URL so that created
+	// issues can link to it
+	switch {
+	case confluencePage != "":
+		inputFile, documentURL, err = services.NewConfluenceService(cfg).FetchPage(cmd.Context(), confluencePage, cfg.Processing.OutputDir)
+	case notionPage != "":
+		inputFile, documentURL, err = services.NewNotionService(cfg).FetchPage(cmd.Context(), notionPage, cfg.Processing.OutputDir)
+	case documentURL != "":
+		inputFile, err = services.FetchDocument(cmd.Context(), cfg, documentURL)
+	}
+	if err != nil {
+		return classify(fetchExitCode(err), err)
+	}
+
+	// Refuse log files, code and other inputs that are clearly not project descriptions before
+	// any AI request turns them into tickets
+	problems, err := services.CheckInput(inputFile)
+	if err != nil {
+		return classify(exitConfig, err)
+	}
+	for _, problem := range problems {
+		helpers.PrintWarning("%s", problem)
+	}
+	if force, _ := cmd.Flags().GetBool("force"); len(problems) > 0 && !force {
+		return classify(exitConfig, fmt.Errorf("the input does not look like a project description; use --force to analyze it anyway"))
+	}
+
+	// A server runs the analysis with its own AI provider, so no local one is needed
+	if serverURL, _ := cmd.Flags().GetString("server"); serverURL != "" && !estimate {
+		return runProcessRemote(cmd, cfg, serverURL, inputFile)
+	}
 
 	// Create analysis service
-	analysisService := services.NewAnalysisService(cfg)
+	analysisService, err := services.NewAnalysisService(cfg)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to create analysis service: %w", err))
+	}
+	defer func() { runResult.Usage = analysisService.Usage() }()
+
+	if estimate {
+		return runProcessEstimate(analysisService, inputFile)
+	}
 
 	// Process the project with AI
-	breakdown, err := analysisService.ProcessProject(inputFile)
+	var breakdown *models.ProjectBreakdown
+	if services.IsManifestFile(inputFile) {
+		breakdown, err = analysisService.ProcessManifest(cmd.Context(), inputFile)
+	} else if since != "" {
+		helpers.PrintInfo("Incremental update of: %s", since)
+		breakdown, err = analysisService.ProcessIncremental(cmd.Context(), inputFile, since)
+	} else {
+		breakdown, err = analysisService.ProcessProject(cmd.Context(), inputFile)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to process project: %w", err)
+		var budgetErr *services.BudgetError
+		if errors.As(err, &budgetErr) {
+			return classify(exitBudget, fmt.Errorf("failed to process project: %w", err))
+		}
+		return classify(exitAI, fmt.Errorf("failed to process project: %w", err))
+	}
+	if documentURL != "" {
+		analysisService.SetSourceFile(documentURL)
+	}
+
+	if cfg.Processing.SplitStories {
+		if err := splitLargeStories(cmd.Context(), analysisService, breakdown); err != nil {
+			return classify(exitAI, err)
+		}
 	}
 
 	// Display breakdown
 	analysisService.DisplayProjectBreakdown(breakdown)
+	recordBreakdown(breakdown)
 
 	// Save results
-	if err := analysisService.SaveAnalysisResult(breakdown, cfg.Processing.OutputDir); err != nil {
+	analysisPath, err := analysisService.SaveAnalysisResult(breakdown, cfg.Processing.OutputDir)
+	if err != nil {
 		return fmt.Errorf("failed to save analysis result: %w", err)
 	}
+	runResult.AnalysisFile = analysisPath
 
+	printUsage(analysisService.Usage())
+	if len(breakdown.SkippedChunks) > 0 {
+		return classify(exitBudget, fmt.Errorf("the AI budget ran out: %s leaves out %d of %d chunks of the document",
+			analysisPath, len(breakdown.SkippedChunks), breakdown.ProcessedChunks+len(breakdown.SkippedChunks)))
+	}
 	helpers.PrintSuccess("Processing completed successfully!")
 	return nil
 }
 
+// runProcessEstimate prints how many chunks a document is analyzed in and what that
+// approximately costs, without calling the AI
+func runProcessEstimate(analysisService *services.AnalysisService, inputFile string) error {
+	estimate, err := analysisService.Estimate(inputFile)
+	if err != nil {
+		return classify(exitConfig, err)
+	}
+	runResult.Estimate = estimate
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("Model: %s", estimate.Model)
+	helpers.PrintInfo("Chunks: %d (%d AI requests without retries)", estimate.Chunks, estimate.Requests)
+	helpers.PrintInfo("Input tokens: ~%d", estimate.InputTokens)
+	helpers.PrintInfo("Output tokens: up to %d", estimate.MaxOutputTokens)
+	if estimate.MaxCostUSD > 0 {
+		helpers.PrintSuccess("Approximate cost: $%.4f - $%.4f", estimate.MinCostUSD, estimate.MaxCostUSD)
+	} else {
+		helpers.PrintInfo("No price is known for model %s; set processing.pricing to estimate the cost", estimate.Model)
+	}
+	return nil
+}
+
+// printUsage prints the AI tokens and cost of a run per analyzed chunk and in total
+func printUsage(usage models.TokenUsage) {
+	if usage.Requests == 0 {
+		return
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("AI usage:")
+	for _, chunk := range usage.Chunks {
+		line := formatUsage(chunk.Requests, chunk.InputTokens, chunk.OutputTokens, chunk.EstimatedCostUSD)
+		if chunk.CacheReadTokens > 0 {
+			line += fmt.Sprintf(" (%d input tokens from the cache)", chunk.CacheReadTokens)
+		}
+		helpers.PrintInfo("  Chunk %d: %s", chunk.Chunk, line)
+	}
+	helpers.PrintInfo("  Total: %s", formatUsage(usage.Requests, usage.InputTokens, usage.OutputTokens, usage.EstimatedCostUSD))
+	if usage.CacheReadTokens > 0 {
+		helpers.PrintInfo("  Prompt cache: %d tokens written, %d read, $%.4f saved", usage.CacheWriteTokens, usage.CacheReadTokens, usage.CacheSavingsUSD)
+	}
+	if usage.TokensEstimated {
+		helpers.PrintInfo("  The provider did not report every token count; the missing ones are estimated from the text")
+	}
+}
+
+// formatUsage describes the requests, tokens and cost of AI usage
+func formatUsage(requests, inputTokens, outputTokens int, costUSD float64) string {
+	text := fmt.Sprintf("%d requests, %d input and %d output tokens", requests, inputTokens, outputTokens)
+	if costUSD > 0 {
+		text += fmt.Sprintf(", $%.4f", costUSD)
+	}
+	return text
+}
+
+func runDemo(cmd *cobra.Command, args []string) error {
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+
+	// The demo server replays recorded AI responses and simulates JIRA
+	server, err := demo.NewServer()
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	cfg, err := demo.Config(server.URL, outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to load demo config: %w", err)
+	}
+
+	inputFile, err := demo.WriteSample(outputDir)
+	if err != nil {
+		return err
+	}
+
+	helpers.PrintTitle("Scrum Master Demo")
+	helpers.PrintInfo("Sample project: %s", inputFile)
+	helpers.PrintInfo("AI responses are recorded and JIRA is simulated - nothing leaves this machine")
+
+	analysisService, err := services.NewAnalysisService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create analysis service: %w", err)
+	}
+
+	breakdown, err := analysisService.ProcessProject(cmd.Context(), inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to process project: %w", err)
+	}
+	analysisService.DisplayProjectBreakdown(breakdown)
+
+	analysisPath, err := analysisService.SaveAnalysisResult(breakdown, outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to save analysis result: %w", err)
+	}
+
+	helpers.PrintTitle("Creating JIRA Tickets in the Simulated Project")
+	jiraService := services.NewJiraService(&cfg.Jira, &cfg.HTTP)
+	if err := jiraService.TestConnection(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to connect to the simulated JIRA: %w", err)
+	}
+	if err := jiraService.ResolveIssueTypes(cmd.Context()); err != nil {
+		return err
+	}
+
+	if err := jiraService.ResolveEpicLinking(cmd.Context()); err != nil {
+		return err
+	}
+	if err := jiraService.ResolvePriorities(cmd.Context()); err != nil {
+		return err
+	}
+
+	ledger := repositories.NewLedgerRepository(outputDir, analysisPath, cfg.Jira.ProjectKey)
+	jiraService.SetLedger(ledger)
+	if err := jiraService.CreateTicketsFromBreakdown(cmd.Context(), breakdown); err != nil {
+		return fmt.Errorf("failed to create JIRA tickets: %w", err)
+	}
+
+	// The simulated JIRA is gone once the demo exits, so the report does not link to it
+	cfg.Jira.BaseURL = ""
+	report, err := services.NewExportService(cfg).Export(breakdown, services.ExportHTML)
+	if err != nil {
+		return err
+	}
+	reportPath := helpers.GetOutputPath(outputDir, services.ExportFilename(services.ExportHTML))
+	if err := helpers.WriteFile(reportPath, string(report)); err != nil {
+		return err
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintSuccess("Demo complete: %d issues created in the simulated JIRA", server.Created())
+	helpers.PrintInfo("Analysis: %s", analysisPath)
+	helpers.PrintInfo("Run ledger: %s", ledger.Path())
+	helpers.PrintInfo("HTML report: %s", reportPath)
+	helpers.PrintInfo("To use your own project, run scrum-master init to set up your credentials, then: scrum-master process <project.md>")
+	return nil
+}
+
 func runCreateFromAnalysis(cmd *cobra.Command, args []string) error {
 	analysisFile := args[0]
+	runResult.AnalysisFile = analysisFile
 
 	// Load configuration
-	cfg, err := config.LoadConfig(configFile)
+	cfg, err := config.LoadConfig(configFile, profile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	signing := services.NewSigningService(cfg)
+	if err := signing.CheckFile(analysisFile); err != nil {
+		return classify(exitConfig, err)
 	}
 
 	helpers.PrintTitle("Creating JIRA Tickets from Analysis")
@@ -108,44 +889,1581 @@ func runCreateFromAnalysis(cmd *cobra.Command, args []string) error {
 	// Load analysis result
 	var result models.AnalysisResult
 	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
-		return fmt.Errorf("failed to load analysis file: %w", err)
+		return classify(exitConfig, fmt.Errorf("failed to load analysis file: %w", err))
 	}
 
 	helpers.PrintSuccess("Loaded analysis for project: %s", result.ProjectBreakdown.ProjectName)
 
+	applyAnalysisDirectives(cfg, &result)
+	source := services.SourceFilesOf(&result, analysisFile)
+
+	// Analyses saved before items had IDs are numbered by position
+	services.AssignItemIDs(&result.ProjectBreakdown)
+
+	analysisService, err := services.NewAnalysisService(cfg)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to create analysis service: %w", err))
+	}
+
+	if editFormat, _ := cmd.Flags().GetString("edit"); editFormat != "" {
+		if ciMode {
+			return classify(exitConfig, fmt.Errorf("--edit cannot be used with --ci"))
+		}
+
+		edited, err := services.EditBreakdown(&result.ProjectBreakdown, editFormat, func(err error) bool {
+			helpers.PrintError("%v", err)
+			return confirm("Edit the breakdown again?")
+		})
+		if err != nil {
+			return classify(exitConfig, fmt.Errorf("failed to edit breakdown: %w", err))
+		}
+
+		if edited != &result.ProjectBreakdown {
+			// Keep the edits so the run ledger and --resume refer to what was actually created
+			result.ProjectBreakdown = *edited
+			analysisFile, err = analysisService.SaveEditedAnalysis(&result, cfg.Processing.OutputDir)
+			if err != nil {
+				return err
+			}
+			runResult.AnalysisFile = analysisFile
+			source.Analysis = analysisFile
+		}
+	}
+
+	// Contradictory dependencies are caught before any issue is created
+	if err := services.CheckDependencies(&result.ProjectBreakdown); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	// Select the part of the breakdown to create
+	var filter services.BreakdownFilter
+	filter.Epics, _ = cmd.Flags().GetStringSlice("epics")
+	filter.MinPriority, _ = cmd.Flags().GetString("min-priority")
+	filter.MaxPoints, _ = cmd.Flags().GetInt("max-points")
+	if len(filter.Epics) > 0 || filter.MinPriority != "" || filter.MaxPoints > 0 {
+		filtered, err := services.FilterBreakdown(&result.ProjectBreakdown, filter)
+		if err != nil {
+			return classify(exitConfig, err)
+		}
+
+		helpers.PrintInfo("Selected %d of %d epics and %d of %d stories", len(filtered.Epics), len(result.ProjectBreakdown.Epics),
+			filtered.TotalStories, countStories(&result.ProjectBreakdown))
+		result.ProjectBreakdown = *filtered
+	}
+
+	// Record created issues so a failed run can be resumed without duplicates
+	var ledger *repositories.LedgerRepository
+	if resumeFile, _ := cmd.Flags().GetString("resume"); resumeFile != "" {
+		if err := signing.CheckFile(resumeFile); err != nil {
+			return classify(exitConfig, err)
+		}
+
+		ledger, err = repositories.OpenLedgerRepository(resumeFile)
+		if err != nil {
+			return classify(exitConfig, err)
+		}
+
+		previous := ledger.Ledger()
+		if filepath.Base(previous.AnalysisFile) != filepath.Base(args[0]) {
+			helpers.PrintWarning("Run ledger was recorded for %s, not %s", previous.AnalysisFile, args[0])
+		}
+
+		// Check the recorded issues against JIRA so re-applying converges: deleted issues are
+		// created again, everything else is skipped
+		helpers.PrintInfo("Verifying %d recorded issues in JIRA...", len(previous.Entries))
+		drifts, err := services.NewJiraService(&cfg.Jira, &cfg.HTTP).VerifyLedger(cmd.Context(), ledger)
+		if err != nil {
+			return classify(exitJira, fmt.Errorf("failed to verify run ledger: %w", err))
+		}
+		services.DisplayLedgerDrift(drifts)
+
+		matched := services.ApplyLedger(&result.ProjectBreakdown, ledger.Ledger())
+		helpers.PrintInfo("Resuming run %s: %d of %d recorded issues already exist and will be skipped", previous.RunID, matched, len(previous.Entries))
+	} else {
+		ledger = repositories.NewLedgerRepository(cfg.Processing.OutputDir, analysisFile, cfg.Jira.ProjectKey)
+	}
+
+	// Suggest assignees for the stories the analysis left unassigned
+	if assign, _ := cmd.Flags().GetBool("assign"); assign {
+		cfg.Team.Assign = true
+	}
+	if cfg.Team.Assign {
+		if len(cfg.Team.Members) == 0 {
+			return classify(exitConfig, fmt.Errorf("assigning stories needs team.members in the configuration"))
+		}
+		if assigned := services.SuggestAssignees(&result.ProjectBreakdown, cfg.Team.Members); assigned > 0 {
+			helpers.PrintInfo("Suggested assignees for %d more stories", assigned)
+		}
+	}
+
 	// Display breakdown
-	analysisService := services.NewAnalysisService(cfg)
 	analysisService.DisplayProjectBreakdown(&result.ProjectBreakdown)
+	recordBreakdown(&result.ProjectBreakdown)
 
 	if dryRun {
 		helpers.PrintInfo("Dry run mode - no JIRA tickets will be created")
 		return nil
 	}
 
-	// Confirm with user
-	if !confirmCreation() {
-		helpers.PrintInfo("Operation cancelled by user")
-		return nil
+	automationLevel, _ := cmd.Flags().GetString("automation-level")
+	if automationLevel == "" {
+		automationLevel = cfg.Processing.AutomationLevel
 	}
 
-	// Test JIRA connection
-	jiraService := services.NewJiraService(&cfg.Jira)
-	if err := jiraService.TestConnection(); err != nil {
-		return fmt.Errorf("failed to create JIRA tickets: %w", err)
-	}
+	breakdown := &result.ProjectBreakdown
+	var review *models.ProjectBreakdown
+	var createErr error
 
-	// Create tickets
-	if err := jiraService.CreateTicketsFromBreakdown(&result.ProjectBreakdown); err != nil {
-		return fmt.Errorf("failed to create JIRA tickets: %w", err)
+	switch automationLevel {
+	case config.AutomationSuggest:
+		helpers.PrintInfo("Automation level 'suggest' - no JIRA tickets will be created")
+		return nil
+	case config.AutomationAuto:
+		breakdown, review = services.SplitByConfidence(breakdown, cfg.Processing.ConfidenceThreshold)
+		helpers.PrintInfo("Automation level 'auto' - creating %d stories with confidence >= %d%% without confirmation",
+			breakdown.TotalStories, cfg.Processing.ConfidenceThreshold)
+		helpers.PrintInfo("%d stories will be queued for human review", review.TotalStories)
+	case config.AutomationReview:
+		// Confirm with user
+		yes, _ := cmd.Flags().GetBool("yes")
+		confirmed, err := confirmChange("Do you want to create these tickets in JIRA?", yes, "--yes")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			helpers.PrintInfo("Operation cancelled by user")
+			return nil
+		}
+	default:
+		return classify(exitConfig, fmt.Errorf("invalid automation level '%s' (must be suggest, review or auto)", automationLevel))
 	}
 
-	return nil
-}
+	// The result carries the keys of what is created; held-back stories go to the review queue
+	runResult.Breakdown = breakdown
+
+	if len(breakdown.Epics) > 0 {
+		// Test JIRA connection
+		jiraService := services.NewJiraService(&cfg.Jira, &cfg.HTTP)
+		if err := jiraService.TestConnection(cmd.Context()); err != nil {
+			return classify(exitJira, fmt.Errorf("failed to create JIRA tickets: %w", err))
+		}
+
+		if err := jiraService.ResolveTeams(cmd.Context(), breakdown); err != nil {
+			return classify(exitJira, fmt.Errorf("failed to resolve teams: %w", err))
+		}
+
+		if err := jiraService.ResolveIssueTypes(cmd.Context()); err != nil {
+			return classify(exitConfig, err)
+		}
+
+		if services.CountSubtasks(breakdown) > 0 {
+			if err := jiraService.ResolveSubtaskType(cmd.Context()); err != nil {
+				return classify(exitConfig, err)
+			}
+		}
+
+		if err := jiraService.ResolveEpicLinking(cmd.Context()); err != nil {
+			return classify(exitConfig, err)
+		}
+
+		if err := jiraService.ResolvePriorities(cmd.Context()); err != nil {
+			return classify(exitConfig, err)
+		}
+
+		if err := jiraService.ResolveTimeTracking(cmd.Context()); err != nil {
+			return classify(exitConfig, err)
+		}
+
+		if err := jiraService.ResolveComponents(cmd.Context(), breakdown); err != nil {
+			return classify(exitConfig, err)
+		}
+
+		if cfg.Team.Assign {
+			if err := jiraService.ResolveAssignees(breakdown, cfg.Team.Members); err != nil {
+				return classify(exitConfig, err)
+			}
+		}
+
+		// Read the sprints before creating anything, so a board that cannot be filled fails early
+		var sprintPlan *models.SprintPlan
+		createSprints, _ := cmd.Flags().GetBool("create-sprints")
+		if createSprints {
+			cfg.Jira.Sprints.Create = true
+		}
+		fillSprints, _ := cmd.Flags().GetBool("fill-sprints")
+		if (fillSprints || createSprints) && breakdown.Methodology == config.MethodologyKanban {
+			helpers.PrintWarning("Kanban stories have no story points to fill sprints with, skipping sprints")
+			fillSprints, createSprints = false, false
+		}
+		if fillSprints || createSprints {
+			sprintPlan, err = jiraService.LoadSprints(cmd.Context())
+			if err != nil {
+				return classify(exitJira, fmt.Errorf("failed to load sprints: %w", err))
+			}
+		}
+
+		// Versions are only created once everything else checked out
+		if err := jiraService.ResolveReleases(cmd.Context(), breakdown); err != nil {
+			return classify(exitJira, err)
+		}
+
+		// Create tickets
+		jiraService.SetLedger(ledger)
+		jiraService.SetSource(source)
+		jiraService.Events().Subscribe(recordResultEvent)
+		createErr = jiraService.CreateTicketsFromBreakdown(cmd.Context(), breakdown)
+
+		if sprintPlan != nil && createErr == nil {
+			helpers.PrintTitle("Filling Sprints")
+			services.PlanSprints(sprintPlan, breakdown)
+			services.DisplaySprintPlan(sprintPlan)
+			runResult.Sprints = sprintPlan
+
+			if _, err := jiraService.ApplySprintPlan(cmd.Context(), sprintPlan); err != nil {
+				createErr = err
+			}
+		}
+
+		// Track whatever was created so human edits can be learned from
+		if err := services.NewFeedbackService(cfg).TrackCreatedIssues(breakdown); err != nil {
+			helpers.PrintWarning("Failed to track created issues: %v", err)
+		}
+
+		// Now that stories have issues, the matrix traces every requirement to its ticket
+		if len(breakdown.Requirements) > 0 {
+			if _, err := services.SaveTraceability(breakdown, cfg.Processing.OutputDir); err != nil {
+				helpers.PrintWarning("Failed to save traceability matrix: %v", err)
+			}
+		}
+	}
+
+	if review != nil && len(review.Epics) > 0 {
+		services.LinkCreatedEpics(breakdown, review)
+
+		reviewPath, err := analysisService.SaveReviewQueue(review, cfg.Processing.OutputDir)
+		if err != nil {
+			return err
+		}
+
+		helpers.PrintWarning("%d low-confidence stories were queued for review: %s", review.TotalStories, reviewPath)
+		runResult.ReviewFile = reviewPath
+		runResult.Counts.Queued = review.TotalStories
+		helpers.PrintInfo("After reviewing, create them with: scrum-master create-from-analysis --automation-level review %s", reviewPath)
+	}
+
+	created := len(ledger.Ledger().Entries)
+	if created > 0 {
+		runResult.LedgerFile = ledger.Path()
+		signing.SignIfEnabled(ledger.Path())
+	}
+
+	if createErr != nil {
+		if created > 0 {
+			helpers.PrintWarning("Issues created so far are recorded in: %s", ledger.Path())
+			helpers.PrintInfo("Resume with: scrum-master create-from-analysis %s --resume %s", analysisFile, ledger.Path())
+		}
+		if runResult.Counts.Created > 0 {
+			return classify(exitPartial, fmt.Errorf("failed to create JIRA tickets: %w", createErr))
+		}
+		return classify(exitJira, fmt.Errorf("failed to create JIRA tickets: %w", createErr))
+	}
+
+	if created > 0 {
+		helpers.PrintInfo("Run ledger: %s", ledger.Path())
+	}
+
+	return nil
+}
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	question := args[1]
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return err
+	}
+
+	helpers.PrintTitle("Asking About Analysis")
+	helpers.PrintInfo("Analysis file: %s", analysisFile)
+	helpers.PrintInfo("Question: %s", question)
+
+	analysisService, err := services.NewAnalysisService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create analysis service: %w", err)
+	}
+
+	answer, err := analysisService.Ask(cmd.Context(), analysisFile, question)
+	if err != nil {
+		return fmt.Errorf("failed to answer question: %w", err)
+	}
+
+	helpers.PrintSeparator()
+	fmt.Println(answer)
+	helpers.PrintSeparator()
+	return nil
+}
+
+// runProcessRemote analyzes a document on a scrum-master server and saves the result locally
+func runProcessRemote(cmd *cobra.Command, cfg *config.Config, serverURL, inputFile string) error {
+	if services.IsManifestFile(inputFile) || cmd.Flags().Changed("since") || cmd.Flags().Changed("with-subtasks") || cmd.Flags().Changed("with-risks") || cmd.Flags().Changed("split-large") {
+		return classify(exitConfig, fmt.Errorf("--server does not support project manifests, --since, --with-subtasks, --with-risks or --split-large (set processing.subtasks and processing.risks on the server)"))
+	}
+	if cfg.Server.APIToken == "" {
+		return classify(exitConfig, fmt.Errorf("--server needs the server's API token (set server.api_token)"))
+	}
+
+	helpers.PrintInfo("Server: %s", serverURL)
+	analysisPath, breakdown, err := services.NewRemoteService(cfg, serverURL).Process(cmd.Context(), inputFile)
+	if err != nil {
+		return classify(exitAI, fmt.Errorf("failed to process project on the server: %w", err))
+	}
+
+	services.DisplayBreakdown(breakdown)
+	recordBreakdown(breakdown)
+	runResult.AnalysisFile = analysisPath
+
+	helpers.PrintSuccess("Analysis saved to: %s", analysisPath)
+	return nil
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	addr, _ := cmd.Flags().GetString("addr")
+	if addr == "" {
+		addr = cfg.Server.ListenAddr
+	}
+
+	feedbackService := services.NewFeedbackService(cfg)
+
+	uploads := repositories.NewUploadRepository(cfg.Processing.OutputDir)
+	jobs := services.NewJobService(cmd.Context(), cfg, uploads)
+
+	mux := http.NewServeMux()
+	// Like the analysis API without a token, webhooks are refused without a secret to check
+	// their signature against
+	if cfg.Server.WebhookSecret != "" {
+		mux.Handle("/webhooks/jira", services.NewWebhookHandler(feedbackService, cfg.Server.WebhookSecret))
+	}
+	mux.Handle("/api/", services.NewJobHandler(jobs, uploads, &cfg.Server))
+
+	helpers.PrintTitle("Scrum Master Server")
+	helpers.PrintInfo("Listening on %s", addr)
+	if cfg.Server.WebhookSecret == "" {
+		helpers.PrintWarning("server.webhook_secret is not set - JIRA webhooks are disabled")
+	} else {
+		helpers.PrintInfo("JIRA webhook URL: http://<host>%s/webhooks/jira", addr)
+	}
+	if cfg.Server.APIToken == "" {
+		helpers.PrintWarning("server.api_token is not set - the analysis API rejects every request")
+	} else {
+		helpers.PrintInfo("Analysis API: http://<host>%s/api/", addr)
+	}
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	// Stop accepting webhooks on Ctrl-C but let requests being handled finish
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-cmd.Context().Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			helpers.PrintWarning("Failed to shut down cleanly: %v", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	<-shutdownDone
+	helpers.PrintSuccess("Server stopped")
+	return nil
+}
+
+func runPatterns(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	helpers.PrintTitle("Human Edit Patterns")
+
+	feedbackService := services.NewFeedbackService(cfg)
+
+	patterns, err := feedbackService.Patterns()
+	if err != nil {
+		return fmt.Errorf("failed to aggregate edits: %w", err)
+	}
+
+	feedbackService.DisplayPatterns(patterns)
+	return nil
+}
+
+func runFeedback(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	itemRef, _ := cmd.Flags().GetString("story")
+	if itemRef == "" {
+		itemRef, _ = cmd.Flags().GetString("epic")
+	}
+	rating, _ := cmd.Flags().GetString("rating")
+	note, _ := cmd.Flags().GetString("note")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return err
+	}
+
+	feedback, err := services.NewFeedbackService(cfg).RateItem(analysisFile, itemRef, strings.ToLower(rating), note)
+	if err != nil {
+		return fmt.Errorf("failed to store feedback: %w", err)
+	}
+
+	helpers.PrintSuccess("Rated %s %s '%s' as %s", feedback.ItemType, feedback.ItemRef, feedback.Title, feedback.Rating)
+	return nil
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	storyKey, _ := cmd.Flags().GetString("story")
+	diffRange, _ := cmd.Flags().GetString("diff")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	helpers.PrintTitle("Verifying Acceptance Criteria (experimental)")
+	helpers.PrintInfo("Story: %s", storyKey)
+	helpers.PrintInfo("Diff range: %s", diffRange)
+
+	verificationService, err := services.NewVerificationService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create verification service: %w", err)
+	}
+
+	result, err := verificationService.VerifyStory(cmd.Context(), storyKey, diffRange)
+	if err != nil {
+		return fmt.Errorf("failed to verify story: %w", err)
+	}
+
+	verificationService.DisplayVerificationResult(result)
+
+	if err := verificationService.SaveVerificationResult(result, cfg.Processing.OutputDir); err != nil {
+		return err
+	}
+
+	helpers.PrintWarning("AI verification is advisory only - reviewers and QA should confirm each criterion")
+	return nil
+}
+
+func runGenerateTests(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	items, _ := cmd.Flags().GetStringSlice("items")
+	updateJira, _ := cmd.Flags().GetBool("update-jira")
+	ledgerFile, _ := cmd.Flags().GetString("ledger")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	signing := services.NewSigningService(cfg)
+	if err := signing.CheckFile(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	helpers.PrintTitle("Generating Test Cases")
+	helpers.PrintInfo("Analysis file: %s", analysisFile)
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load analysis file: %w", err))
+	}
+	services.AssignItemIDs(&result.ProjectBreakdown)
+
+	// The ledger of the run that created the stories tells their JIRA keys
+	if ledgerFile != "" {
+		if err := signing.CheckFile(ledgerFile); err != nil {
+			return classify(exitConfig, err)
+		}
+		ledger, err := repositories.OpenLedgerRepository(ledgerFile)
+		if err != nil {
+			return classify(exitConfig, err)
+		}
+		services.ApplyLedger(&result.ProjectBreakdown, ledger.Ledger())
+	}
+
+	testCaseService, err := services.NewTestCaseService(cfg)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to create test case service: %w", err))
+	}
+
+	suite, err := testCaseService.Generate(cmd.Context(), &result.ProjectBreakdown, items)
+	if suite == nil {
+		return classify(exitConfig, err)
+	}
+	suite.AnalysisFile = analysisFile
+	if err != nil {
+		// Keep the test cases written before the failure
+		if len(suite.Stories) > 0 {
+			if _, saveErr := testCaseService.SaveTestSuite(suite, cfg.Processing.OutputDir); saveErr != nil {
+				helpers.PrintError("%v", saveErr)
+			}
+		}
+		var budgetErr *services.BudgetError
+		if errors.As(err, &budgetErr) {
+			return classify(exitBudget, err)
+		}
+		return classify(exitAI, err)
+	}
+
+	testCaseService.DisplayTestSuite(suite)
+	if _, err := testCaseService.SaveTestSuite(suite, cfg.Processing.OutputDir); err != nil {
+		return err
+	}
+	printUsage(testCaseService.Usage())
+
+	if updateJira {
+		helpers.PrintSeparator()
+		updated, err := testCaseService.UpdateJira(cmd.Context(), &result.ProjectBreakdown, suite)
+		if err != nil {
+			return classify(exitJira, fmt.Errorf("failed to update JIRA stories: %w", err))
+		}
+		helpers.PrintSuccess("Added test cases to %d JIRA stories", updated)
+	}
+	return nil
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	runResult.AnalysisFile = analysisFile
+
+	var options services.LintOptions
+	options.MaxPoints, _ = cmd.Flags().GetInt("max-points")
+	options.MinScore, _ = cmd.Flags().GetInt("min-score")
+
+	helpers.PrintTitle("Linting Analysis")
+	helpers.PrintInfo("Analysis file: %s", analysisFile)
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load analysis file: %w", err))
+	}
+	services.AssignItemIDs(&result.ProjectBreakdown)
+	recordBreakdown(&result.ProjectBreakdown)
+	helpers.PrintSeparator()
+
+	report := services.LintBreakdown(&result.ProjectBreakdown, options)
+	runResult.Lint = report
+	services.DisplayLintReport(report)
+
+	if report.Failed > 0 {
+		return classify(exitQuality, fmt.Errorf("%d of %d stories fail the quality gates", report.Failed, len(report.Stories)))
+	}
+	return nil
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	velocity, _ := cmd.Flags().GetInt("velocity")
+	sprints, _ := cmd.Flags().GetInt("sprints")
+	createSprints, _ := cmd.Flags().GetBool("create-sprints")
+	ledgerFile, _ := cmd.Flags().GetString("ledger")
+	runResult.AnalysisFile = analysisFile
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	if velocity == 0 {
+		velocity = cfg.Jira.Sprints.Capacity
+	}
+	if velocity <= 0 {
+		return classify(exitConfig, fmt.Errorf("planning needs the story points a sprint holds (set --velocity or jira.sprints.capacity)"))
+	}
+	if sprints < 0 {
+		return classify(exitConfig, fmt.Errorf("--sprints cannot be negative"))
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	signing := services.NewSigningService(cfg)
+	if err := signing.CheckFile(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	helpers.PrintTitle("Planning Sprints")
+	helpers.PrintInfo("Analysis file: %s", analysisFile)
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load analysis file: %w", err))
+	}
+	breakdown := &result.ProjectBreakdown
+	if breakdown.Methodology == config.MethodologyKanban {
+		return classify(exitConfig, fmt.Errorf("Kanban stories have no story points to plan sprints with"))
+	}
+	services.AssignItemIDs(breakdown)
+	recordBreakdown(breakdown)
+
+	// The ledger of the run that created the stories tells their JIRA keys
+	if ledgerFile != "" {
+		if err := signing.CheckFile(ledgerFile); err != nil {
+			return classify(exitConfig, err)
+		}
+		ledger, err := repositories.OpenLedgerRepository(ledgerFile)
+		if err != nil {
+			return classify(exitConfig, err)
+		}
+		services.ApplyLedger(breakdown, ledger.Ledger())
+	}
+
+	// Planned sprints follow the board's last sprint when they are created in JIRA
+	var jiraService *services.JiraService
+	var boardID int
+	var previous *models.JiraSprint
+	if createSprints {
+		jiraService = services.NewJiraService(&cfg.Jira, &cfg.HTTP)
+		boardID, previous, err = jiraService.LastBoardSprint(cmd.Context())
+		if err != nil {
+			return classify(exitJira, err)
+		}
+	}
+
+	plan := services.PlanBacklog(breakdown, velocity, sprints, cfg.Jira.Sprints.LengthDays, previous)
+	plan.BoardID = boardID
+	runResult.Sprints = plan
+	helpers.PrintSeparator()
+	services.DisplaySprintPlan(plan)
+
+	if _, err := services.SaveSprintPlan(plan, breakdown, cfg.Processing.OutputDir); err != nil {
+		return err
+	}
+
+	if !createSprints {
+		return nil
+	}
+	planned, unkeyed := 0, 0
+	for _, assignment := range plan.Assignments {
+		if assignment.SprintID == 0 {
+			continue
+		}
+		planned++
+		if assignment.Key == "" {
+			unkeyed++
+		}
+	}
+	if planned > 0 && unkeyed == planned {
+		return classify(exitConfig, fmt.Errorf("none of the %d planned stories has a JIRA issue, so no sprints are created (pass the run ledger with --ledger)", planned))
+	}
+	if unkeyed > 0 {
+		helpers.PrintWarning("%d planned stories have no JIRA issue and stay out of the sprints (pass the run ledger with --ledger)", unkeyed)
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	confirmed, err := confirmChange(fmt.Sprintf("Create %d sprints in JIRA and move the stories into them?", len(plan.Sprints)), yes, "--yes")
+	if err != nil || !confirmed {
+		return err
+	}
+	moved, err := jiraService.ApplySprintPlan(cmd.Context(), plan)
+	if err != nil {
+		return classify(exitJira, err)
+	}
+	helpers.PrintSuccess("Moved %d stories into sprints", moved)
+	return nil
+}
+
+func runRoadmap(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	velocity, _ := cmd.Flags().GetInt("velocity")
+	startDate, _ := cmd.Flags().GetString("start")
+	runResult.AnalysisFile = analysisFile
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	if velocity == 0 {
+		velocity = cfg.Jira.Sprints.Capacity
+	}
+	if velocity <= 0 {
+		return classify(exitConfig, fmt.Errorf("a roadmap needs the story points a sprint holds (set --velocity or jira.sprints.capacity)"))
+	}
+	start := time.Now()
+	if startDate != "" {
+		if start, err = time.Parse("2006-01-02", startDate); err != nil {
+			return classify(exitConfig, fmt.Errorf("invalid --start date '%s' (expected YYYY-MM-DD)", startDate))
+		}
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+	if err := services.NewSigningService(cfg).CheckFile(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load analysis file: %w", err))
+	}
+	if result.ProjectBreakdown.Methodology == config.MethodologyKanban {
+		return classify(exitConfig, fmt.Errorf("Kanban stories have no story points to phase a roadmap with"))
+	}
+	services.AssignItemIDs(&result.ProjectBreakdown)
+	recordBreakdown(&result.ProjectBreakdown)
+
+	roadmap := services.BuildRoadmap(&result.ProjectBreakdown, velocity, cfg.Jira.Sprints.LengthDays, start)
+	services.DisplayRoadmap(roadmap)
+	_, err = services.SaveRoadmap(roadmap, cfg.Processing.OutputDir)
+	return err
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	helpers.PrintTitle("Checking scrum-master")
+	return runChecks(cmd, true)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	offline, _ := cmd.Flags().GetBool("offline")
+
+	helpers.PrintTitle("Validating Configuration")
+	return runChecks(cmd, !offline)
+}
+
+// runChecks validates the configuration and, with remote, checks it against the AI provider
+// and JIRA, printing a pass/fail report
+func runChecks(cmd *cobra.Command, remote bool) error {
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		printChecks([]models.DiagnosticCheck{{Name: services.CheckConfig, Status: models.CheckFailed, Detail: err.Error()}})
+		return classify(exitConfig, fmt.Errorf("the configuration is not valid"))
+	}
+
+	checks := []models.DiagnosticCheck{{Name: services.CheckConfig, Status: models.CheckPassed, Detail: strings.Join(cfg.Files, ", ")}}
+	if remote {
+		checks = append(checks, services.DiagnoseAI(cmd.Context(), cfg))
+		checks = append(checks, services.NewJiraService(&cfg.Jira, &cfg.HTTP).Diagnose(cmd.Context())...)
+		helpers.PrintSeparator()
+	}
+	printChecks(checks)
+
+	failed := 0
+	for _, check := range checks {
+		if check.Status == models.CheckFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	helpers.PrintSuccess("All checks passed")
+	return nil
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	data, err := cfg.Redacted()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("# Files: %s\n", strings.Join(cfg.Files, ", "))
+	if cfg.Profile != "" {
+		fmt.Printf("# Profile: %s\n", cfg.Profile)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// printChecks prints the outcome of every diagnostic check
+func printChecks(checks []models.DiagnosticCheck) {
+	for _, check := range checks {
+		switch check.Status {
+		case models.CheckPassed:
+			helpers.PrintSuccess("%s: %s", check.Name, check.Detail)
+		case models.CheckFailed:
+			helpers.PrintError("%s: %s", check.Name, check.Detail)
+		default:
+			helpers.PrintInfo("%s: skipped, %s", check.Name, check.Detail)
+		}
+	}
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	retention := cfg.Processing.Retention
+	if cmd.Flags().Changed("keep-last") {
+		retention.KeepLast, _ = cmd.Flags().GetInt("keep-last")
+	}
+	if cmd.Flags().Changed("max-age-days") {
+		retention.MaxAgeDays, _ = cmd.Flags().GetInt("max-age-days")
+	}
+
+	archivePath, _ := cmd.Flags().GetString("archive")
+	if archivePath == "" && retention.ArchiveDir != "" {
+		archivePath = helpers.GetOutputPath(retention.ArchiveDir, helpers.GenerateOutputFilename("runs", "tar.gz"))
+	}
+
+	preview, _ := cmd.Flags().GetBool("dry-run")
+
+	helpers.PrintTitle("Cleaning Output Directory")
+	helpers.PrintInfo("Output directory: %s", cfg.Processing.OutputDir)
+
+	removed, err := services.NewHistoryService(cfg).Clean(retention.KeepLast, retention.MaxAgeDays, archivePath, preview)
+	if err != nil {
+		return fmt.Errorf("failed to clean output directory: %w", err)
+	}
+
+	if len(removed) == 0 {
+		helpers.PrintSuccess("Nothing to clean")
+		return nil
+	}
+
+	for _, run := range removed {
+		helpers.PrintInfo("  %s (%d files)", run.Timestamp, len(run.Files))
+	}
+
+	switch {
+	case preview:
+		helpers.PrintWarning("Dry run - %d runs would be removed", len(removed))
+	case archivePath != "":
+		helpers.PrintSuccess("Archived %d runs to %s", len(removed), archivePath)
+	default:
+		helpers.PrintSuccess("Deleted %d runs", len(removed))
+	}
+	return nil
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	helpers.PrintTitle("Run History")
+
+	historyService := services.NewHistoryService(cfg)
+
+	runs, err := historyService.ListRuns()
+	if err != nil {
+		return fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	historyService.DisplayRuns(runs)
+	return nil
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query := args[0]
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	helpers.PrintTitle("Searching Analyses")
+	helpers.PrintInfo("Query: %s", query)
+
+	searchService := services.NewSearchService(cfg)
+
+	matches, err := searchService.Search(query)
+	if err != nil {
+		return fmt.Errorf("failed to search analyses: %w", err)
+	}
+
+	searchService.DisplayMatches(matches)
+
+	if archived, err := searchService.ArchivedAnalyses(); err != nil {
+		helpers.PrintWarning("Failed to read archive index: %v", err)
+	} else if archived > 0 {
+		helpers.PrintInfo("%d archived analyses were not searched (see 'scrum-master history')", archived)
+	}
+	return nil
+}
+
+func runBoard(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	printOnly, _ := cmd.Flags().GetBool("print")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return err
+	}
+
+	boardService, err := services.NewBoardService(cfg, analysisFile)
+	if err != nil {
+		return err
+	}
+
+	if printOnly {
+		boardService.Display()
+		return nil
+	}
+
+	if err := boardService.Run(cmd.Context(), os.Stdin); err != nil {
+		return err
+	}
+
+	helpers.PrintInfo("Board saved to %s", boardService.StatePath())
+	return nil
+}
+
+func runWorkspaceExport(cmd *cobra.Command, args []string) error {
+	bundlePath := args[0]
+	inputs, _ := cmd.Flags().GetStringSlice("input")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	helpers.PrintTitle("Exporting Workspace")
+
+	bundle, err := services.NewWorkspaceService(cfg).Export(bundlePath, inputs)
+	if err != nil {
+		return fmt.Errorf("failed to export workspace: %w", err)
+	}
+
+	helpers.PrintInfo("Bundled %d files (%d inputs)", len(bundle.Files), len(bundle.Inputs))
+	helpers.PrintInfo("Credentials were stripped from %s", bundle.Config)
+	helpers.PrintSuccess("Exported workspace to: %s", bundlePath)
+	return nil
+}
+
+func runWorkspaceImport(cmd *cobra.Command, args []string) error {
+	bundlePath := args[0]
+	dir, _ := cmd.Flags().GetString("dir")
+	force, _ := cmd.Flags().GetBool("force")
+
+	helpers.PrintTitle("Importing Workspace")
+
+	extracted, skipped, err := services.ImportWorkspace(bundlePath, dir, force)
+	if err != nil {
+		return fmt.Errorf("failed to import workspace: %w", err)
+	}
+
+	for _, name := range skipped {
+		helpers.PrintWarning("Kept existing %s (use --force to overwrite)", name)
+	}
+
+	helpers.PrintSuccess("Imported %d files into %s", len(extracted), dir)
+	helpers.PrintInfo("Fill in the API keys and tokens in config.yaml before running commands")
+	return nil
+}
+
+func runDiscoverFields(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	helpers.PrintTitle("Discovering JIRA Fields")
+	helpers.PrintInfo("Project: %s", cfg.Jira.ProjectKey)
+
+	mapping, err := services.NewJiraService(&cfg.Jira, &cfg.HTTP).DiscoverFields(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to discover fields: %w", err)
+	}
+
+	configPath := cfg.Files[len(cfg.Files)-1]
+	if cfg.Profile != "" {
+		helpers.PrintSuccess("Paste this into profile %s of %s:", cfg.Profile, configPath)
+	} else {
+		helpers.PrintSuccess("Paste this into %s:", configPath)
+	}
+	helpers.PrintSeparator()
+	fmt.Print(services.RenderFieldMapping(mapping))
+	helpers.PrintSeparator()
+	return nil
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	ledgerFile := args[0]
+	rollbackDryRun, _ := cmd.Flags().GetBool("dry-run")
+	status, _ := cmd.Flags().GetString("status")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ledger, err := repositories.OpenLedgerRepository(ledgerFile)
+	if err != nil {
+		return err
+	}
+
+	run := ledger.Ledger()
+	if run.ProjectKey != "" && run.ProjectKey != cfg.Jira.ProjectKey {
+		return fmt.Errorf("run ledger was recorded for project %s, but the config targets %s", run.ProjectKey, cfg.Jira.ProjectKey)
+	}
+
+	helpers.PrintTitle("Rolling Back Run")
+	helpers.PrintInfo("Run: %s (%s)", run.RunID, run.AnalysisFile)
+
+	jiraService := services.NewJiraService(&cfg.Jira, &cfg.HTTP)
+
+	pending, err := jiraService.Rollback(cmd.Context(), ledger, status, true)
+	if err != nil {
+		return err
+	}
+	if pending == 0 {
+		helpers.PrintInfo("Nothing to roll back")
+		return nil
+	}
+	if rollbackDryRun {
+		helpers.PrintSeparator()
+		helpers.PrintInfo("%d issues would be rolled back", pending)
+		return nil
+	}
+
+	question := fmt.Sprintf("Delete these %d issues from JIRA?", pending)
+	if status != "" {
+		question = fmt.Sprintf("Move these %d issues to '%s'?", pending, status)
+	}
+	confirmed, err := confirmChange(question, yes, "--yes")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		helpers.PrintInfo("Operation cancelled by user")
+		return nil
+	}
+
+	if err := jiraService.TestConnection(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+
+	rolledBack, err := jiraService.Rollback(cmd.Context(), ledger, status, false)
+	if rolledBack > 0 {
+		// The ledger now marks rolled back issues, so it needs a new signature
+		services.NewSigningService(cfg).SignIfEnabled(ledger.Path())
+	}
+	helpers.PrintSeparator()
+	helpers.PrintInfo("%d issues rolled back", rolledBack)
+	if err != nil {
+		helpers.PrintInfo("Run the same command again to roll back the rest")
+		return err
+	}
+
+	helpers.PrintSuccess("Rollback complete")
+	return nil
+}
+
+func runSigningKeygen(cmd *cobra.Command, args []string) error {
+	keyPath, _ := cmd.Flags().GetString("key")
+	publicKeyPath, _ := cmd.Flags().GetString("public-key")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if err := services.GenerateSigningKeys(keyPath, publicKeyPath, force); err != nil {
+		return err
+	}
+
+	helpers.PrintSuccess("Private key: %s (keep it secret)", keyPath)
+	helpers.PrintSuccess("Public key: %s", publicKeyPath)
+	helpers.PrintInfo("Set signing.key_file where analyses are created and signing.public_key_file where they are applied")
+	return nil
+}
+
+func runTemplatesExport(cmd *cobra.Command, args []string) error {
+	dir := "templates"
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	force, _ := cmd.Flags().GetBool("force")
+
+	written, err := services.ExportTemplates(dir, force)
+	for _, path := range written {
+		helpers.PrintSuccess("Wrote %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	helpers.PrintInfo("Edit them to customize the prompts; templates missing from %s use the defaults", dir)
+	if dir != "templates" {
+		helpers.PrintInfo("Set processing.templates_dir: %s to use them", dir)
+	}
+	return nil
+}
+
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	name := secretName(args)
+	if !slices.Contains(config.KeyringSecrets, name) {
+		return fmt.Errorf("unknown secret '%s' (must be one of %s)", name, strings.Join(config.KeyringSecrets, ", "))
+	}
+
+	secret, err := readSecret(name + ": ")
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return fmt.Errorf("no %s entered", name)
+	}
+
+	if err := config.StoreSecret(profile, name, secret); err != nil {
+		return err
+	}
+	helpers.PrintSuccess("Stored %s in the OS keyring", secretLabel(name))
+	helpers.PrintInfo("Leave %s empty in the configuration to use it", name)
+	return nil
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	name := secretName(args)
+	removed, err := config.DeleteSecret(profile, name)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		helpers.PrintInfo("The OS keyring has no %s", secretLabel(name))
+		return nil
+	}
+	helpers.PrintSuccess("Removed %s from the OS keyring", secretLabel(name))
+	return nil
+}
+
+// secretLabel names a secret of the keyring, with the profile it is stored for
+func secretLabel(name string) string {
+	if profile == "" {
+		return name
+	}
+	return fmt.Sprintf("%s of profile %s", name, profile)
+}
+
+// secretName returns the secret an auth command names, jira.api_token by default
+func secretName(args []string) string {
+	if len(args) == 0 {
+		return "jira.api_token"
+	}
+	return args[0]
+}
+
+// readSecret prompts for a secret without echoing it, or reads a line from stdin when it is not
+// a terminal, so a token can be piped in
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		secret, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	line, err := promptReader().ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func runSigningSign(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	signing := services.NewSigningService(cfg)
+	for _, path := range args {
+		signaturePath, err := signing.SignFile(path)
+		if err != nil {
+			return err
+		}
+		helpers.PrintSuccess("Signed: %s", signaturePath)
+	}
+	return nil
+}
+
+func runSigningVerify(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	signing := services.NewSigningService(cfg)
+	failed := 0
+	for _, path := range args {
+		trustedComment, err := signing.VerifyFile(path)
+		if err != nil {
+			helpers.PrintError("%s: %v", path, err)
+			failed++
+			continue
+		}
+		helpers.PrintSuccess("%s: valid signature (%s)", path, trustedComment)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed verification", failed, len(args))
+	}
+	return nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return err
+	}
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return fmt.Errorf("failed to load analysis file: %w", err)
+	}
+
+	applyAnalysisDirectives(cfg, &result)
+
+	// Analyses saved before items had IDs are numbered by position
+	services.AssignItemIDs(&result.ProjectBreakdown)
+
+	data, err := services.NewExportService(cfg).Export(&result.ProjectBreakdown, format)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		if err := helpers.EnsureDir(cfg.Processing.OutputDir); err != nil {
+			return err
+		}
+		outputPath = helpers.GetOutputPath(cfg.Processing.OutputDir, services.ExportFilename(format))
+	}
+
+	if err := helpers.WriteFile(outputPath, string(data)); err != nil {
+		return err
+	}
+
+	helpers.PrintSuccess("Exported %d epics and %d stories to %s", len(result.ProjectBreakdown.Epics), result.ProjectBreakdown.TotalStories, outputPath)
+	return nil
+}
+
+func runExportConfluence(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	ledgerFile, _ := cmd.Flags().GetString("ledger")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return err
+	}
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return fmt.Errorf("failed to load analysis file: %w", err)
+	}
+
+	applyAnalysisDirectives(cfg, &result)
+
+	// Analyses saved before items had IDs are numbered by position
+	services.AssignItemIDs(&result.ProjectBreakdown)
+	breakdown := &result.ProjectBreakdown
+
+	// The ledger of the run that created the epics tells their JIRA keys
+	if ledgerFile != "" {
+		if err := services.NewSigningService(cfg).CheckFile(ledgerFile); err != nil {
+			return err
+		}
+		ledger, err := repositories.OpenLedgerRepository(ledgerFile)
+		if err != nil {
+			return err
+		}
+		services.ApplyLedger(breakdown, ledger.Ledger())
+	}
+
+	confluenceService := services.NewConfluenceService(cfg)
+	publication, err := confluenceService.Publish(cmd.Context(), breakdown)
+	if err != nil {
+		return err
+	}
+	if publication.Created {
+		helpers.PrintSuccess("Published %s to: %s", publication.Page.Title, publication.URL)
+	} else {
+		helpers.PrintSuccess("Published version %d of %s to: %s", publication.Page.Version.Number, publication.Page.Title, publication.URL)
+	}
+
+	if linked := confluenceService.LinkEpics(cmd.Context(), breakdown, publication); linked > 0 {
+		helpers.PrintSuccess("Linked the page from %d epics", linked)
+	} else if ledgerFile == "" {
+		helpers.PrintInfo("No epic of the analysis has a JIRA issue; pass --ledger to link the page from the created epics")
+	}
+	return nil
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	analysisFile := args[0]
+	syncDryRun, _ := cmd.Flags().GetBool("dry-run")
+	jql, _ := cmd.Flags().GetString("jql")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	// Load configuration
+	cfg, err := config.LoadConfig(configFile, profile)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	if err := services.NewHistoryService(cfg).CheckArchived(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	if err := services.NewSigningService(cfg).CheckFile(analysisFile); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	helpers.PrintTitle("Syncing Analysis with JIRA")
+	helpers.PrintInfo("Analysis file: %s", analysisFile)
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to load analysis file: %w", err))
+	}
+
+	applyAnalysisDirectives(cfg, &result)
+
+	// Analyses saved before items had IDs are numbered by position
+	services.AssignItemIDs(&result.ProjectBreakdown)
+	breakdown := &result.ProjectBreakdown
+
+	// Contradictory dependencies are caught before any issue is created
+	if err := services.CheckDependencies(breakdown); err != nil {
+		return classify(exitConfig, err)
+	}
+
+	jiraService := services.NewJiraService(&cfg.Jira, &cfg.HTTP)
+	if err := jiraService.TestConnection(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	if err := jiraService.ResolveTeams(cmd.Context(), breakdown); err != nil {
+		return fmt.Errorf("failed to resolve teams: %w", err)
+	}
+
+	if err := jiraService.ResolveIssueTypes(cmd.Context()); err != nil {
+		return err
+	}
+
+	if services.CountSubtasks(breakdown) > 0 {
+		if err := jiraService.ResolveSubtaskType(cmd.Context()); err != nil {
+			return err
+		}
+	}
+
+	if err := jiraService.ResolveEpicLinking(cmd.Context()); err != nil {
+		return err
+	}
+
+	if err := jiraService.ResolvePriorities(cmd.Context()); err != nil {
+		return err
+	}
+
+	if err := jiraService.ResolveTimeTracking(cmd.Context()); err != nil {
+		return err
+	}
+
+	if err := jiraService.ResolveComponents(cmd.Context(), breakdown); err != nil {
+		return err
+	}
+
+	// Preview the changes before making them
+	plan, err := jiraService.Sync(cmd.Context(), breakdown, jql, true)
+	if err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+	services.DisplaySyncActions(plan, true)
+
+	if syncDryRun {
+		helpers.PrintInfo("Dry run mode - JIRA was not changed")
+		return nil
+	}
+
+	changes := 0
+	for _, action := range plan {
+		if action.Action != models.SyncUnchanged {
+			changes++
+		}
+	}
+	if changes == 0 {
+		helpers.PrintSuccess("JIRA is already in sync")
+		return nil
+	}
+
+	confirmed, err := confirmChange(fmt.Sprintf("Apply these %d changes to JIRA?", changes), yes, "--yes")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		helpers.PrintInfo("Operation cancelled by user")
+		return nil
+	}
+
+	if cfg.Team.Assign {
+		if err := jiraService.ResolveAssignees(breakdown, cfg.Team.Members); err != nil {
+			return err
+		}
+	}
+
+	// Versions are only created once the changes are confirmed and checked
+	if err := jiraService.ResolveReleases(cmd.Context(), breakdown); err != nil {
+		return err
+	}
+
+	ledger := repositories.NewLedgerRepository(cfg.Processing.OutputDir, analysisFile, cfg.Jira.ProjectKey)
+	jiraService.SetLedger(ledger)
+	jiraService.SetSource(services.SourceFilesOf(&result, analysisFile))
+
+	actions, syncErr := jiraService.Sync(cmd.Context(), breakdown, jql, false)
+
+	// Track whatever was created so human edits can be learned from
+	if err := services.NewFeedbackService(cfg).TrackCreatedIssues(breakdown); err != nil {
+		helpers.PrintWarning("Failed to track created issues: %v", err)
+	}
+
+	if len(ledger.Ledger().Entries) > 0 {
+		helpers.PrintInfo("Run ledger: %s", ledger.Path())
+	}
+
+	if syncErr != nil {
+		helpers.PrintInfo("Run sync again to continue; issues created so far will be matched")
+		return fmt.Errorf("failed to sync: %w", syncErr)
+	}
+
+	services.DisplaySyncActions(actions, false)
+	helpers.PrintSuccess("Sync complete")
+	return nil
+}
+
+// applyAnalysisDirectives applies the front-matter directives saved with an analysis, which
+// override the config for that document
+func applyAnalysisDirectives(cfg *config.Config, result *models.AnalysisResult) {
+	if result.Directives == nil {
+		return
+	}
+
+	if result.Directives.ProjectKey != "" {
+		helpers.PrintInfo("Using project key from document directives: %s", result.Directives.ProjectKey)
+		cfg.Jira.ProjectKey = result.Directives.ProjectKey
+	}
+	if len(result.Directives.Labels) > 0 {
+		cfg.Jira.Labels = result.Directives.Labels
+	}
+	if result.Directives.Team != "" {
+		cfg.Jira.Team = result.Directives.Team
+	}
+}
+
+// countStories returns the number of stories in a breakdown, which may not match its saved total
+func countStories(breakdown *models.ProjectBreakdown) int {
+	count := 0
+	for _, epic := range breakdown.Epics {
+		count += len(epic.Stories)
+	}
+	return count
+}
+
+// confirmChange asks before a command changes JIRA. yes is the flag, named flag, that confirms
+// the change up front; without it CI mode fails instead of asking, so a pipeline that forgot it
+// stops rather than silently doing nothing.
+func confirmChange(question string, yes bool, flag string) (bool, error) {
+	if yes {
+		return true, nil
+	}
+	if ciMode {
+		return false, classify(exitConfig, fmt.Errorf("%s needs %s with --ci", strings.TrimSuffix(question, "?"), flag))
+	}
+	return confirm(question), nil
+}
+
+// stdinReader reads the answers to prompts. Prompts share it so answers piped in ahead are not
+// lost to the buffering of an earlier prompt; it is replaced when stdin is.
+var (
+	stdinReader *bufio.Reader
+	stdinFile   *os.File
+)
+
+// promptReader returns the reader of the answers to prompts
+func promptReader() *bufio.Reader {
+	if stdinReader == nil || stdinFile != os.Stdin {
+		stdinFile = os.Stdin
+		stdinReader = bufio.NewReader(os.Stdin)
+	}
+	return stdinReader
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no. CI mode answers no, as it never
+// implies consent to a change.
+func confirm(question string) bool {
+	if ciMode {
+		helpers.PrintInfo("%s no (--ci)", question)
+		return false
+	}
 
-func confirmCreation() bool {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Do you want to create these tickets in JIRA? (y/N): ")
-	response, _ := reader.ReadString('\n')
+	fmt.Printf("%s (y/N): ", question)
+	response, _ := promptReader().ReadString('\n')
 	response = strings.TrimSpace(strings.ToLower(response))
 	return response == "y" || response == "yes"
 }
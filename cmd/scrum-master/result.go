@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"scrum-master/internal/models"
+	"scrum-master/internal/services"
+	"scrum-master/internal/transport"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Result output formats
+const (
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// Exit codes of --ci mode per failure class
+const (
+	exitFailure     = 1   // any other failure
+	exitConfig      = 2   // invalid configuration, flags or input files
+	exitAI          = 3   // the AI provider failed
+	exitJira        = 4   // JIRA failed before any issue was created
+	exitPartial     = 5   // JIRA failed after some issues were created
+	exitBudget      = 6   // the AI budget ran out
+	exitQuality     = 7   // stories failed the quality gates of lint
+	exitFetch       = 8   // the input document or page could not be downloaded
+	exitInterrupted = 130 // cancelled with Ctrl-C or SIGTERM
+)
+
+// errorClasses names the failure class of each exit code in the JSON result
+var errorClasses = map[int]string{
+	exitFailure:     "error",
+	exitConfig:      "config",
+	exitAI:          "ai",
+	exitJira:        "jira",
+	exitPartial:     "partial",
+	exitBudget:      "budget",
+	exitQuality:     "quality",
+	exitFetch:       "fetch",
+	exitInterrupted: "interrupted",
+}
+
+var (
+	ciMode        bool
+	ciSummaryFile string
+	outputFormat  string
+
+	// runResult collects the outcome of the command; it is only printed with --ci or --output
+	runResult     = &models.RunResult{}
+	resultStdout  = os.Stdout
+	resultStarted = time.Now()
+)
+
+// ciError tags an error with the exit code of its failure class
+type ciError struct {
+	code int
+	err  error
+}
+
+func (e *ciError) Error() string { return e.err.Error() }
+
+func (e *ciError) Unwrap() error { return e.err }
+
+// classify tags err with the exit code of its failure class
+func classify(code int, err error) error {
+	return &ciError{code: code, err: err}
+}
+
+// fetchExitCode returns the exit code of a failure to fetch the input document or page: a
+// download that failed or a server that refused it, as opposed to a URL or page reference that
+// is not valid or allowed
+func fetchExitCode(err error) int {
+	var download *services.DownloadError
+	var status *transport.StatusError
+	var request *url.Error
+	if errors.As(err, &download) || errors.As(err, &status) || errors.As(err, &request) {
+		return exitFetch
+	}
+	return exitConfig
+}
+
+// wantsResult reports whether the command prints a machine-readable result
+func wantsResult() bool {
+	return ciMode || outputFormat != ""
+}
+
+// startResult sends all human-readable output to stderr so stdout carries only the result
+func startResult(cmd *cobra.Command) error {
+	resultStdout = os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = color.Error
+
+	runResult.Command = cmd.Name()
+	resultStarted = time.Now()
+
+	if outputFormat != "" && outputFormat != outputJSON && outputFormat != outputYAML {
+		return classify(exitConfig, fmt.Errorf("invalid output format '%s' (must be json or yaml)", outputFormat))
+	}
+	return nil
+}
+
+// finishResult completes the result with the outcome of the command, prints it to stdout and,
+// in CI mode, writes the markdown summary. It returns the exit code: per failure class in CI
+// mode, 1 for any failure otherwise.
+func finishResult(err error) int {
+	result := runResult
+	result.Status = models.ResultSuccess
+	result.DurationSeconds = time.Since(resultStarted).Round(time.Millisecond).Seconds()
+
+	if err != nil {
+		result.ExitCode = exitCode(err)
+		result.ErrorClass = errorClasses[result.ExitCode]
+		result.Error = err.Error()
+		result.Status = models.ResultFailed
+		if result.ExitCode == exitPartial || (result.ExitCode == exitBudget && result.AnalysisFile != "") {
+			result.Status = models.ResultPartial
+		}
+	}
+
+	data, encodeErr := encodeResult(result, outputFormat)
+	if encodeErr != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode result: %v\n", encodeErr)
+		return exitFailure
+	}
+	fmt.Fprint(resultStdout, string(data))
+
+	if !ciMode {
+		if err != nil {
+			return exitFailure
+		}
+		return 0
+	}
+
+	summaryFile := ciSummaryFile
+	if summaryFile == "" {
+		summaryFile = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+	if summaryFile != "" {
+		if err := appendCISummary(summaryFile, result); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write CI summary: %v\n", err)
+		}
+	}
+
+	return result.ExitCode
+}
+
+// encodeResult renders the result as JSON (the default) or as YAML with the same field names
+func encodeResult(result *models.RunResult, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format != outputYAML {
+		return append(data, '\n'), nil
+	}
+
+	// JSON is valid YAML, so decoding it keeps the JSON field names and their order
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(document)
+}
+
+// exitCode returns the exit code of the failure class of err. Cancellation wins over the class
+// of the step that was interrupted.
+func exitCode(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return exitInterrupted
+	}
+
+	var classified *ciError
+	if errors.As(err, &classified) {
+		return classified.code
+	}
+	return exitFailure
+}
+
+// appendCISummary appends the result as markdown to a summary file such as $GITHUB_STEP_SUMMARY
+func appendCISummary(path string, result *models.RunResult) error {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("## scrum-master %s: %s\n\n", result.Command, result.Status))
+	if result.ProjectName != "" {
+		sb.WriteString(fmt.Sprintf("**Project:** %s\n\n", result.ProjectName))
+	}
+	if result.Error != "" {
+		sb.WriteString(fmt.Sprintf("**Error (%s):** %s\n\n", result.ErrorClass, result.Error))
+	}
+
+	sb.WriteString("| Epics | Stories | Story points | Created | Failed | Queued for review |\n")
+	sb.WriteString("|---|---|---|---|---|---|\n")
+	sb.WriteString(fmt.Sprintf("| %d | %d | %d | %d | %d | %d |\n\n",
+		result.Counts.Epics, result.Counts.Stories, result.Counts.StoryPoints, result.Counts.Created, result.Counts.Failed, result.Counts.Queued))
+
+	if len(result.Issues) > 0 {
+		sb.WriteString("| ID | Type | Title | Key |\n")
+		sb.WriteString("|---|---|---|---|\n")
+		for _, issue := range result.Issues {
+			title := strings.ReplaceAll(issue.Title, "|", "\\|")
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", issue.ItemID, issue.ItemType, title, issue.Key))
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, issue := range result.Failed {
+		sb.WriteString(fmt.Sprintf("- Failed to create %s %s %s: %s\n", issue.ItemType, issue.ItemID, issue.Title, issue.Error))
+	}
+
+	for _, file := range []struct{ label, path string }{
+		{"Analysis", result.AnalysisFile},
+		{"Run ledger", result.LedgerFile},
+		{"Review queue", result.ReviewFile},
+	} {
+		if file.path != "" {
+			sb.WriteString(fmt.Sprintf("- %s: `%s`\n", file.label, file.path))
+		}
+	}
+
+	if result.Lint != nil {
+		sb.WriteString(fmt.Sprintf("- Lint: %d of %d stories fail the quality gates, average INVEST score %.1f\n",
+			result.Lint.Failed, len(result.Lint.Stories), result.Lint.AverageScore))
+	}
+	if result.Usage.Requests > 0 {
+		sb.WriteString(fmt.Sprintf("- AI usage: %s\n", formatUsage(result.Usage.Requests,
+			result.Usage.InputTokens, result.Usage.OutputTokens, result.Usage.EstimatedCostUSD)))
+	}
+	sb.WriteString(fmt.Sprintf("- Duration: %.1fs\n\n", result.DurationSeconds))
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(sb.String())
+	return err
+}
+
+// recordBreakdown records a breakdown and its size in the result
+func recordBreakdown(breakdown *models.ProjectBreakdown) {
+	runResult.Breakdown = breakdown
+	runResult.ProjectName = breakdown.ProjectName
+	runResult.Counts.Epics = len(breakdown.Epics)
+	for _, epic := range breakdown.Epics {
+		runResult.Counts.Stories += len(epic.Stories)
+		for _, story := range epic.Stories {
+			runResult.Counts.StoryPoints += story.StoryPoints
+		}
+	}
+}
+
+// recordResultEvent records the issues created, or failed to be created, in the result
+func recordResultEvent(event models.Event) {
+	switch e := event.(type) {
+	case models.IssueCreated:
+		runResult.Issues = append(runResult.Issues, models.ResultIssue{
+			ItemID:   e.ItemID,
+			ItemType: e.ItemType,
+			Title:    e.Title,
+			Key:      e.Key,
+		})
+		runResult.Counts.Created++
+	case models.IssueFailed:
+		runResult.Failed = append(runResult.Failed, models.ResultIssue{
+			ItemID:   e.ItemID,
+			ItemType: e.ItemType,
+			Title:    e.Title,
+			Error:    e.Err.Error(),
+		})
+		runResult.Counts.Failed++
+	}
+}
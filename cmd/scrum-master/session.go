@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/services"
+	"scrum-master/internal/transport"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordSession string
+	replayDir     string
+
+	// recording and replayer are set while a session is recorded or replayed
+	recording *services.SessionRecording
+	replayer  *transport.Replayer
+)
+
+// startSession starts recording the run with --record-session, or answers its HTTP requests
+// from a recorded session when replay-session runs it
+func startSession() error {
+	if recordSession != "" {
+		if helpers.FileExists(recordSession) {
+			return classify(exitConfig, fmt.Errorf("session bundle %s already exists", recordSession))
+		}
+
+		var err error
+		if recording, err = services.StartSessionRecording(configFile, profile, os.Args[1:]); err != nil {
+			return classify(exitConfig, err)
+		}
+	}
+
+	if replayDir != "" {
+		var err error
+		if replayer, err = services.StartSessionReplay(replayDir); err != nil {
+			return classify(exitConfig, err)
+		}
+	}
+	return nil
+}
+
+// finishSession writes the session bundle of a recorded run, or reports how closely a replayed
+// run followed its recording
+func finishSession(runErr error) {
+	if recording != nil {
+		bundle, err := recording.Finish(recordSession, runErr)
+		if err != nil {
+			helpers.PrintError("Failed to write session bundle: %v", err)
+			return
+		}
+		helpers.PrintSuccess("Session recorded to %s (%d HTTP requests); attach it to your bug report", recordSession, bundle.Exchanges)
+	}
+
+	if replayer != nil {
+		used, recorded, missed := replayer.Replayed()
+		helpers.PrintInfo("Replayed %d of %d recorded HTTP requests", used, recorded)
+		if missed > 0 || used < recorded {
+			helpers.PrintWarning("The replay took a different path than the recorded run: %d requests were not recorded, %d recorded requests were not made", missed, recorded-used)
+		}
+	}
+}
+
+func runReplaySession(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "scrum-master-replay-"); err != nil {
+			return fmt.Errorf("failed to create replay directory: %w", err)
+		}
+	}
+
+	bundle, err := services.OpenSession(args[0], dir)
+	if err != nil {
+		return classify(exitConfig, err)
+	}
+	replayArgs, err := services.ReplayArgs(bundle)
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("refusing to replay %s: %w", args[0], err))
+	}
+
+	helpers.PrintTitle("Replaying Session")
+	helpers.PrintInfo("Recorded: %s", bundle.CreatedAt.Format("2006-01-02 15:04:05"))
+	helpers.PrintInfo("Command: scrum-master %s", strings.Join(bundle.Args, " "))
+	helpers.PrintInfo("Directory: %s", dir)
+	if bundle.Error != "" {
+		helpers.PrintInfo("The recorded run failed with: %s", bundle.Error)
+	}
+	helpers.PrintSeparator()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the scrum-master executable: %w", err)
+	}
+
+	// The replay reads the answers typed during the recorded run
+	stdin, err := os.Open(filepath.Join(dir, bundle.Stdin))
+	if err != nil {
+		return classify(exitConfig, fmt.Errorf("failed to open recorded input: %w", err))
+	}
+	defer stdin.Close()
+
+	replay := exec.CommandContext(cmd.Context(), executable, replayArgs...)
+	replay.Dir = dir
+	// The recorded configuration already holds the settings of the user configuration
+	replay.Env = append(os.Environ(), "XDG_CONFIG_HOME="+dir)
+	replay.Stdin, replay.Stdout, replay.Stderr = stdin, os.Stdout, os.Stderr
+
+	err = replay.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("replayed run failed with exit code %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to replay session: %w", err)
+	}
+	return nil
+}
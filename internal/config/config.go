@@ -1,86 +1,1198 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 // Config represents the application configuration
 type Config struct {
+	Provider   string           `yaml:"provider"`
 	Anthropic  AnthropicConfig  `yaml:"anthropic"`
+	OpenAI     OpenAIConfig     `yaml:"openai"`
+	Ollama     OllamaConfig     `yaml:"ollama"`
+	Gemini     GeminiConfig     `yaml:"gemini"`
 	Jira       JiraConfig       `yaml:"jira"`
+	Confluence ConfluenceConfig `yaml:"confluence"`
+	Notion     NotionConfig     `yaml:"notion"`
+	Fetch      FetchConfig      `yaml:"fetch"`
 	Processing ProcessingConfig `yaml:"processing"`
+	Server     ServerConfig     `yaml:"server"`
+	HTTP       HTTPConfig       `yaml:"http"`
+	Signing    SigningConfig    `yaml:"signing"`
+	Team       TeamConfig       `yaml:"team"`
+
+	// Files are the configuration files merged into the settings, and Profile the name of the
+	// profile merged over them, if any
+	Files   []string `yaml:"-"`
+	Profile string   `yaml:"-"`
 }
 
 // AnthropicConfig represents Anthropic API configuration
 type AnthropicConfig struct {
-	APIKey            string `yaml:"api_key"`
-	Model             string `yaml:"model"`
-	TimeoutSeconds    int    `yaml:"timeout_seconds"`
-	MaxTokens         int    `yaml:"max_tokens"`
-	ChunkSizeChars    int    `yaml:"chunk_size_chars"`
-	RetryCount        int    `yaml:"retry_count"`
-	RetryDelaySeconds int    `yaml:"retry_delay_seconds"`
+	APIKey            string  `yaml:"api_key"`
+	Model             string  `yaml:"model"`
+	TimeoutSeconds    int     `yaml:"timeout_seconds"`
+	MaxTokens         int     `yaml:"max_tokens"`
+	ChunkSizeTokens   int     `yaml:"chunk_size_tokens"`
+	ChunkSizeChars    int     `yaml:"chunk_size_chars"` // Deprecated: use chunk_size_tokens
+	RetryCount        int     `yaml:"retry_count"`
+	RetryDelaySeconds int     `yaml:"retry_delay_seconds"`
+	MaxCostUSD        float64 `yaml:"max_cost_usd"` // Stop a run before its AI spend can exceed this (0 = no limit)
+}
+
+// OpenAIConfig represents OpenAI API configuration
+type OpenAIConfig struct {
+	APIKey         string `yaml:"api_key"`
+	Model          string `yaml:"model"`
+	BaseURL        string `yaml:"base_url"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+	MaxTokens      int    `yaml:"max_tokens"`
+}
+
+// OllamaConfig represents local Ollama server configuration
+type OllamaConfig struct {
+	BaseURL        string `yaml:"base_url"`
+	Model          string `yaml:"model"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+	MaxTokens      int    `yaml:"max_tokens"`
+}
+
+// GeminiConfig represents Google Generative Language API configuration
+type GeminiConfig struct {
+	APIKey         string `yaml:"api_key"`
+	Model          string `yaml:"model"`
+	BaseURL        string `yaml:"base_url"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+	MaxTokens      int    `yaml:"max_tokens"`
 }
 
 // JiraConfig represents JIRA API configuration
 type JiraConfig struct {
-	BaseURL    string `yaml:"base_url"`
-	Username   string `yaml:"username"`
-	APIToken   string `yaml:"api_token"`
-	ProjectKey string `yaml:"project_key"`
-	Timeout    int    `yaml:"timeout_seconds"`
+	BaseURL            string            `yaml:"base_url"`
+	Username           string            `yaml:"username"`
+	APIToken           string            `yaml:"api_token"`
+	AuthType           string            `yaml:"auth_type"`
+	ProjectKey         string            `yaml:"project_key"`
+	APIVersion         int               `yaml:"api_version"`
+	Timeout            int               `yaml:"timeout_seconds"`
+	IncludeRationale   bool              `yaml:"include_rationale"`
+	Labels             []string          `yaml:"labels"`
+	Components         []string          `yaml:"components"`
+	ComponentMapping   map[string]string `yaml:"component_mapping"`
+	ProjectStyle       string            `yaml:"project_style"`
+	IssueTypeMapping   IssueTypesConfig  `yaml:"issue_type_mapping"`
+	IssueTypes         IssueTypesConfig  `yaml:"issue_types"`
+	StoryPointsField   string            `yaml:"story_points_field"`
+	HoursPerPoint      float64           `yaml:"hours_per_point"`
+	FieldMapping       map[string]string `yaml:"field_mapping"`
+	PriorityMapping    map[string]string `yaml:"priority_mapping"`
+	CustomFields       map[string]string `yaml:"custom_fields"`
+	Team               string            `yaml:"team"`
+	Teams              map[string]string `yaml:"teams"`
+	TraceLabels        bool              `yaml:"trace_labels"`
+	Sprints            SprintsConfig     `yaml:"sprints"`
+	Releases           []ReleaseConfig   `yaml:"releases"`
+	RequestsPerMinute  int               `yaml:"requests_per_minute"`
+	DefinitionOfDone   DoneConfig        `yaml:"definition_of_done"`
+	SpikeTimebox       string            `yaml:"spike_timebox"` // Time research spikes are limited to, e.g. "2 days"
+	Source             SourceConfig      `yaml:"source"`
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"` // Skip certificate verification of the JIRA server only (testing only)
+}
+
+// ConfluenceConfig configures the Confluence site pages are read from and breakdown summaries are
+// published to. The JIRA credentials are used when the username and token are empty, as they
+// work for both on Atlassian Cloud.
+type ConfluenceConfig struct {
+	BaseURL  string `yaml:"base_url"` // e.g. https://your-domain.atlassian.net/wiki
+	Username string `yaml:"username"`
+	APIToken string `yaml:"api_token"`
+	SpaceKey string `yaml:"space_key"`
+	ParentID string `yaml:"parent_id"` // ID of the page new pages are created under
+	Timeout  int    `yaml:"timeout_seconds"`
+
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"` // Skip certificate verification of the Confluence server only (testing only)
+}
+
+// NotionConfig configures the Notion API pages and databases are read from
+type NotionConfig struct {
+	APIToken string `yaml:"api_token"` // Secret of an internal integration the pages are shared with
+	BaseURL  string `yaml:"base_url"`
+	Timeout  int    `yaml:"timeout_seconds"`
+}
+
+// AnyDomain in fetch.allowed_domains allows downloads from any host
+const AnyDomain = "*"
+
+// FetchConfig restricts the web pages and documents process downloads when given a URL
+type FetchConfig struct {
+	AllowedDomains []string `yaml:"allowed_domains"` // Hosts URLs may point to, with their subdomains; empty allows none, "*" any
+	MaxSizeMB      int      `yaml:"max_size_mb"`     // Largest document or page downloaded
+}
+
+// SourceConfig sets how created issues point back to the document and analysis their breakdown
+// came from
+type SourceConfig struct {
+	Attach  bool   `yaml:"attach"`   // Attach the source document and the analysis JSON to created epics
+	LinkURL string `yaml:"link_url"` // Web link added to created epics and stories; {file} and {analysis} name the files
+}
+
+// Placeholders of jira.source.link_url
+const (
+	SourceFilePlaceholder     = "{file}"
+	SourceAnalysisPlaceholder = "{analysis}"
+)
+
+// DoneConfig is the definition of done checklist appended to the JIRA descriptions of created
+// stories and epics
+type DoneConfig struct {
+	Story    []string `yaml:"story"`
+	Epic     []string `yaml:"epic"`
+	Validate bool     `yaml:"validate"` // have the AI report the story checklist items each story leaves unmet
+}
+
+// IssueTypesConfig names the JIRA issue types used for generated epics, stories and subtasks.
+// Types left empty are detected from the project when connecting.
+type IssueTypesConfig struct {
+	Epic       string `yaml:"epic"`
+	Story      string `yaml:"story"`
+	Subtask    string `yaml:"subtask"`
+	Risk       string `yaml:"risk"`       // Issue type risks are created as; empty creates none
+	Spike      string `yaml:"spike"`      // Issue type of research spikes; empty creates them as stories
+	Initiative string `yaml:"initiative"` // Issue type above epics; empty creates no initiatives
+}
+
+// Issue types used when they are neither configured nor detected
+const (
+	DefaultEpicType    = "Epic"
+	DefaultStoryType   = "Task"
+	DefaultSubtaskType = "Sub-task"
+)
+
+// EpicType returns the issue type of generated epics
+func (t IssueTypesConfig) EpicType() string {
+	if t.Epic != "" {
+		return t.Epic
+	}
+	return DefaultEpicType
+}
+
+// StoryType returns the issue type of generated stories
+func (t IssueTypesConfig) StoryType() string {
+	if t.Story != "" {
+		return t.Story
+	}
+	return DefaultStoryType
+}
+
+// SpikeType returns the issue type of research spikes
+func (t IssueTypesConfig) SpikeType() string {
+	if t.Spike != "" {
+		return t.Spike
+	}
+	return t.StoryType()
+}
+
+// SubtaskType returns the issue type of generated subtasks
+func (t IssueTypesConfig) SubtaskType() string {
+	if t.Subtask != "" {
+		return t.Subtask
+	}
+	return DefaultSubtaskType
+}
+
+// SprintsConfig configures filling future sprints with created stories
+type SprintsConfig struct {
+	BoardID    int  `yaml:"board_id"`    // 0 uses the project's first scrum board
+	Capacity   int  `yaml:"capacity"`    // story points per sprint, the team's velocity
+	Create     bool `yaml:"create"`      // create sprints for stories that fit in no existing one
+	LengthDays int  `yaml:"length_days"` // length of created sprints
+}
+
+// ReleaseConfig is a planned release that stories are assigned to, created in JIRA as a version
+type ReleaseConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	ReleaseDate string `yaml:"release_date"` // YYYY-MM-DD
+}
+
+// DefaultSprintLengthDays is the length of created sprints when jira.sprints.length_days is not set
+const DefaultSprintLengthDays = 14
+
+// Project styles, which decide how stories are linked to their epics
+const (
+	ProjectStyleTeamManaged    = "team-managed"
+	ProjectStyleCompanyManaged = "company-managed"
+)
+
+// JIRA REST API versions: v2 takes plain-text descriptions, v3 (JIRA Cloud) takes Atlassian
+// Document Format
+const (
+	JiraAPIv2 = 2
+	JiraAPIv3 = 3
+)
+
+// JIRA authentication types: basic sends the username with an API token (JIRA Cloud) or
+// password, pat sends a personal access token as bearer token (JIRA Server and Data Center)
+const (
+	JiraAuthBasic = "basic"
+	JiraAuthPAT   = "pat"
+)
+
+// RunIDPlaceholder in a jira.labels label is replaced with the ID of the run creating the issue
+const RunIDPlaceholder = "{run_id}"
+
+// Custom field names understood in jira.custom_fields
+const (
+	CustomFieldEpicName = "epic_name"
+	CustomFieldEpicLink = "epic_link"
+	CustomFieldTeam     = "team"
+	// CustomFieldParentLink is the Advanced Roadmaps Parent Link field linking epics to initiatives
+	CustomFieldParentLink = "parent_link"
+)
+
+// Breakdown fields that jira.field_mapping can write to custom fields
+const (
+	FieldStoryPoints        = "story_points"
+	FieldConfidence         = "confidence"
+	FieldRationale          = "rationale"
+	FieldAcceptanceCriteria = "acceptance_criteria"
+	FieldDependencies       = "dependencies"
+	FieldItemID             = "item_id"
+	FieldSize               = "size"
+)
+
+// MappableFields lists the breakdown fields jira.field_mapping accepts
+var MappableFields = []string{FieldStoryPoints, FieldConfidence, FieldRationale, FieldAcceptanceCriteria, FieldDependencies, FieldItemID, FieldSize}
+
+// Automation levels controlling how much is created in JIRA without confirmation
+const (
+	AutomationSuggest = "suggest"
+	AutomationReview  = "review"
+	AutomationAuto    = "auto"
+)
+
+// Chunking strategies for splitting large documents
+const (
+	ChunkingHeadings = "headings"
+	ChunkingTokens   = "tokens"
+)
+
+// Formats of the acceptance criteria of generated stories
+const (
+	CriteriaList    = "list"
+	CriteriaGherkin = "gherkin"
+)
+
+// Methodologies of processing.methodology
+const (
+	MethodologyScrum  = "scrum"
+	MethodologyKanban = "kanban"
+)
+
+// Sizings of Kanban stories in processing.sizing
+const (
+	SizingClasses = "classes"
+	SizingNone    = "none"
+)
+
+// Estimation scales of processing.estimation_scale; a list of story points is a custom scale
+const (
+	ScaleFibonacci   = "fibonacci"
+	ScalePowersOfTwo = "powers_of_two"
+	ScaleLinear      = "linear"
+	ScaleTShirt      = "tshirt"
+	ScaleCustom      = "custom"
+)
+
+// scalePoints are the story points of the named estimation scales
+var scalePoints = map[string][]int{
+	ScaleFibonacci:   {1, 2, 3, 5, 8, 13, 21},
+	ScalePowersOfTwo: {1, 2, 4, 8, 16},
+	ScaleLinear:      {1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	ScaleTShirt:      {1, 2, 3, 5, 8},
+}
+
+// TShirtSizes are the sizes of the tshirt scale, worth the story points of the scale in order
+var TShirtSizes = []string{"XS", "S", "M", "L", "XL"}
+
+// EstimationScale is the scale stories are estimated on: a named scale or a custom list of
+// story points
+type EstimationScale struct {
+	Name   string
+	Points []int
+}
+
+// UnmarshalYAML reads the name of a scale or a list of story points. Unknown names and lists
+// that do not ascend from 1 or more are rejected.
+func (e *EstimationScale) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var points []int
+	if err := unmarshal(&points); err == nil {
+		if len(points) < 2 {
+			return fmt.Errorf("a custom estimation_scale needs at least 2 story point values")
+		}
+		for i, value := range points {
+			if value < 1 || (i > 0 && value <= points[i-1]) {
+				return fmt.Errorf("invalid estimation_scale %v (story points must be 1 or more and ascending)", points)
+			}
+		}
+		*e = EstimationScale{Name: ScaleCustom, Points: points}
+		return nil
+	}
+
+	var name string
+	if err := unmarshal(&name); err != nil {
+		return fmt.Errorf("estimation_scale must be a scale name or a list of story points")
+	}
+	points, exists := scalePoints[name]
+	if !exists {
+		return fmt.Errorf("invalid estimation_scale '%s' (must be %s, %s, %s, %s or a list of story points)",
+			name, ScaleFibonacci, ScalePowersOfTwo, ScaleLinear, ScaleTShirt)
+	}
+	*e = EstimationScale{Name: name, Points: points}
+	return nil
+}
+
+// MarshalYAML writes the scale as it is configured: its name, or the story points of a custom scale
+func (e EstimationScale) MarshalYAML() (interface{}, error) {
+	if e.Name == ScaleCustom {
+		return e.Points, nil
+	}
+	return e.Name, nil
+}
+
+// SizePoints returns the story points of a size of the tshirt scale, or 0 for other sizes
+func (e EstimationScale) SizePoints(size string) int {
+	if e.Name != ScaleTShirt {
+		return 0
+	}
+	for i, tshirt := range TShirtSizes {
+		if tshirt == size {
+			return e.Points[i]
+		}
+	}
+	return 0
 }
 
 // ProcessingConfig represents processing configuration
 type ProcessingConfig struct {
-	Mode             string `yaml:"mode"`
-	OutputDir        string `yaml:"output_dir"`
-	SaveIntermediate bool   `yaml:"save_intermediate"`
+	Mode                string            `yaml:"mode"`
+	OutputDir           string            `yaml:"output_dir"`
+	SaveIntermediate    bool              `yaml:"save_intermediate"`
+	AutomationLevel     string            `yaml:"automation_level"`
+	ConfidenceThreshold int               `yaml:"confidence_threshold"`
+	IncludeSections     []string          `yaml:"include_sections"`
+	ExcludeSections     []string          `yaml:"exclude_sections"`
+	MaxConcurrency      int               `yaml:"max_concurrency"`
+	ChunkingStrategy    string            `yaml:"chunking_strategy"`
+	Synthesis           bool              `yaml:"synthesis"`
+	Subtasks            bool              `yaml:"subtasks"`
+	SuggestLabels       bool              `yaml:"suggest_labels"`
+	SuggestReleases     bool              `yaml:"suggest_releases"`
+	SplitStories        bool              `yaml:"split_stories"`    // Propose splits of stories in the upper third of estimation_scale
+	Risks               bool              `yaml:"risks"`            // Identify the risks of the project
+	NFREpic             bool              `yaml:"nfr_epic"`         // Collect non-functional requirements in their own epic
+	Spikes              bool              `yaml:"spikes"`           // Mark research spikes among the stories
+	HierarchyLevels     int               `yaml:"hierarchy_levels"` // 2 for epics and stories, 3 adds initiatives above epics
+	Traceability        bool              `yaml:"traceability"`     // Trace stories to the document sections they are derived from
+	RequestsPerMinute   int               `yaml:"requests_per_minute"`
+	Retention           RetentionConfig   `yaml:"retention"`
+	Pricing             PricingConfig     `yaml:"pricing"`
+	TemplatesDir        string            `yaml:"templates_dir"`              // Directory of prompt template overrides
+	Glossary            map[string]string `yaml:"glossary"`                   // Organization terms the prompts define
+	ContextFiles        []string          `yaml:"context_files"`              // Organization context given to every prompt
+	ExamplesFile        string            `yaml:"examples_file"`              // Example epics and stories the breakdown prompts imitate
+	Language            string            `yaml:"language"`                   // Language of the generated epics and stories, e.g. "de"
+	CriteriaFormat      string            `yaml:"acceptance_criteria_format"` // Format of generated acceptance criteria: list or gherkin
+	Methodology         string            `yaml:"methodology"`                // scrum estimates in story points, kanban does not
+	Sizing              string            `yaml:"sizing"`                     // Kanban sizing: classes (S, M, L) or none
+	EstimationScale     EstimationScale   `yaml:"estimation_scale"`           // Story points stories are estimated in
+}
+
+// Kanban reports whether stories are sliced for flow instead of estimated in story points
+func (p ProcessingConfig) Kanban() bool {
+	return p.Methodology == MethodologyKanban
+}
+
+// SizeClasses reports whether stories are sized S, M or L instead of estimated in story points
+func (p ProcessingConfig) SizeClasses() bool {
+	return p.Kanban() && p.Sizing == SizingClasses
 }
 
-// LoadConfig loads configuration from a YAML file
-func LoadConfig(configPath string) (*Config, error) {
-	data, err := os.ReadFile(configPath)
+// PricingConfig holds the AI model's prices used to compute the cost of a run, overriding the
+// list price of the model
+type PricingConfig struct {
+	InputPerMillion  float64 `yaml:"input_per_million"`  // USD per million input tokens
+	OutputPerMillion float64 `yaml:"output_per_million"` // USD per million output tokens
+}
+
+// RetentionConfig controls which runs the clean command removes from the output directory
+type RetentionConfig struct {
+	KeepLast   int    `yaml:"keep_last"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+	ArchiveDir string `yaml:"archive_dir"`
+}
+
+// ServerConfig represents server mode configuration
+type ServerConfig struct {
+	ListenAddr       string `yaml:"listen_addr"`
+	WebhookSecret    string `yaml:"webhook_secret"`
+	APIToken         string `yaml:"api_token"`         // required by the analysis API, and sent by process --server
+	MaxUploadMB      int    `yaml:"max_upload_mb"`     // largest document the analysis API accepts
+	HeartbeatSeconds int    `yaml:"heartbeat_seconds"` // interval of job heartbeats and progress stream keep-alives
+	MaxJobs          int    `yaml:"max_jobs"`          // analyses run at the same time; more are refused
+	RetentionHours   int    `yaml:"retention_hours"`   // finished jobs and their uploads are removed after this
+}
+
+// HTTPConfig configures the HTTP clients of the AI, JIRA and Confluence APIs. Certificate
+// verification can only be skipped for the client of one server, with WithInsecureSkipVerify.
+type HTTPConfig struct {
+	ProxyURL           string `yaml:"proxy_url"`
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // Rejected: it would apply to every API
+	RetryCount         int    `yaml:"retry_count"`
+	RetryDelaySeconds  int    `yaml:"retry_delay_seconds"`
+	AuditLog           string `yaml:"audit_log"`
+
+	skipVerify bool
+}
+
+// WithInsecureSkipVerify returns the settings for the client of a server whose certificate is
+// not verified when skip is set
+func (h HTTPConfig) WithInsecureSkipVerify(skip bool) *HTTPConfig {
+	h.skipVerify = skip
+	return &h
+}
+
+// Proxy returns the proxy of the HTTP clients: the configured proxy, or the one of the
+// HTTP_PROXY and HTTPS_PROXY environment variables
+func (h HTTPConfig) Proxy() func(*http.Request) (*url.URL, error) {
+	if proxy, err := url.Parse(h.ProxyURL); err == nil && h.ProxyURL != "" {
+		return http.ProxyURL(proxy)
+	}
+	return http.ProxyFromEnvironment
+}
+
+// TLSConfig returns the TLS settings of the HTTP clients, trusting the certificates of the CA
+// file in addition to the system's, or nil to keep the defaults. The proxy URL and CA file are
+// checked when the configuration is loaded.
+func (h HTTPConfig) TLSConfig() *tls.Config {
+	if h.CAFile == "" && !h.skipVerify {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: h.skipVerify}
+	if h.CAFile != "" {
+		if pem, err := os.ReadFile(h.CAFile); err == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			pool.AppendCertsFromPEM(pem)
+			tlsConfig.RootCAs = pool
+		}
+	}
+	return tlsConfig
+}
+
+// SigningConfig configures the signing of analyses and run ledgers
+type SigningConfig struct {
+	KeyFile       string `yaml:"key_file"`
+	PublicKeyFile string `yaml:"public_key_file"`
+	Require       bool   `yaml:"require"`
+}
+
+// TeamConfig lists the members of the delivery team with their skills, used to suggest an
+// assignee for every story
+type TeamConfig struct {
+	Members []TeamMember `yaml:"members"`
+	Assign  bool         `yaml:"assign"` // set suggested assignees on created stories
+}
+
+// TeamMember is a member of the delivery team. Stories are assigned in JIRA by account ID on JIRA
+// Cloud, or by username on JIRA Server and Data Center.
+type TeamMember struct {
+	Name      string   `yaml:"name"`
+	AccountID string   `yaml:"account_id"`
+	Username  string   `yaml:"username"`
+	Skills    []string `yaml:"skills"`
+}
+
+// LoadConfig loads the configuration of a run from the user's configuration file and the
+// project's (or configPath when set), merging the settings of a profile (or of the default
+// profile when profile is empty) over the shared ones. Secrets the files leave empty are read
+// from the OS keyring, and secret references are read from their secret store.
+func LoadConfig(configPath, profile string) (*Config, error) {
+	files, err := ConfigFiles(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
+	}
+	data, err := ReadConfigFiles(files)
+	if err != nil {
+		return nil, err
 	}
 
-	var config Config
+	config, err := parse(data, profile, true)
+	if err != nil {
+		return nil, err
+	}
+	config.Files = files
+	return config, nil
+}
+
+// Parse parses, completes and validates a YAML configuration. ${NAME} references in text
+// settings are replaced with environment variables.
+func Parse(data []byte) (*Config, error) {
+	return parse(data, "", false)
+}
+
+// parse parses, completes and validates a YAML configuration with a profile, with external
+// filling empty secrets from the OS keyring and resolving secret references
+func parse(data []byte, profile string, external bool) (*Config, error) {
+	data, profile, err := applyProfile(data, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{Profile: profile}
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := config.expandEnv(); err != nil {
+		return nil, err
+	}
+
+	config.applyDefaults()
+	if external {
+		config.readKeyring()
+	}
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
+	if external {
+		if err := config.resolveSecretReferences(); err != nil {
+			return nil, err
+		}
+	}
 
 	return &config, nil
 }
 
+// secretKeys are the configuration keys holding credentials
+var secretKeys = map[string]bool{
+	"api_key":        true,
+	"api_token":      true,
+	"webhook_secret": true,
+	"proxy_url":      true,
+}
+
+// RedactedValue replaces credentials in recorded sessions
+const RedactedValue = "REDACTED"
+
+// StripSecrets returns a copy of a YAML configuration with every credential blanked and the
+// output directory replaced by outputDir, so it can be shared
+func StripSecrets(data []byte, outputDir string) ([]byte, error) {
+	return replaceSecrets(data, "", outputDir)
+}
+
+// RedactSecrets returns a copy of a YAML configuration with every credential replaced by
+// RedactedValue, so it still validates, the proxy removed and the output directory replaced by
+// outputDir
+func RedactSecrets(data []byte, outputDir string) ([]byte, error) {
+	return replaceSecrets(data, RedactedValue, outputDir)
+}
+
+// replaceSecrets sets every credential of a YAML configuration to placeholder and its output
+// directory to outputDir
+func replaceSecrets(data []byte, placeholder, outputDir string) ([]byte, error) {
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	stripSecrets(raw, placeholder)
+	raw = setValue(raw, "processing", "output_dir", outputDir)
+
+	out, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, nil
+}
+
+// SetProcessingFiles returns a copy of a YAML configuration with processing settings naming files
+// or directories set to the given values, such as the paths a bundle carries those files at
+func SetProcessingFiles(data []byte, values map[string]interface{}) ([]byte, error) {
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		raw = setValue(raw, "processing", key, values[key])
+	}
+
+	out, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, nil
+}
+
+// stripSecrets sets credentials anywhere in a YAML mapping to placeholder. The proxy is always
+// blanked, as a placeholder is not a valid proxy URL.
+func stripSecrets(mapping yaml.MapSlice, placeholder string) {
+	for i, item := range mapping {
+		if nested, ok := item.Value.(yaml.MapSlice); ok {
+			stripSecrets(nested, placeholder)
+			continue
+		}
+
+		key, _ := item.Key.(string)
+		switch {
+		case key == "proxy_url":
+			mapping[i].Value = ""
+		case secretKeys[key]:
+			mapping[i].Value = placeholder
+		}
+	}
+}
+
+// Redacted returns the effective configuration as YAML with every credential that is set
+// replaced by RedactedValue and the password of the proxy URL hidden
+func (c *Config) Redacted() ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var raw yaml.MapSlice
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	redactSecrets(raw)
+	out, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, nil
+}
+
+// redactSecrets replaces the credentials that are set anywhere in a YAML mapping with
+// RedactedValue, keeping empty ones empty so unset credentials stand out
+func redactSecrets(mapping yaml.MapSlice) {
+	for i, item := range mapping {
+		if nested, ok := item.Value.(yaml.MapSlice); ok {
+			redactSecrets(nested)
+			continue
+		}
+
+		key, _ := item.Key.(string)
+		value, _ := item.Value.(string)
+		switch {
+		case value == "":
+		case key == "proxy_url":
+			if proxy, err := url.Parse(value); err == nil {
+				mapping[i].Value = proxy.Redacted()
+			} else {
+				mapping[i].Value = RedactedValue
+			}
+		case secretKeys[key]:
+			mapping[i].Value = RedactedValue
+		}
+	}
+}
+
+// Secrets returns the credentials of the configuration, so they can be removed from recordings
+func (c *Config) Secrets() []string {
+	var secrets []string
+	for _, secret := range []string{c.Anthropic.APIKey, c.OpenAI.APIKey, c.Gemini.APIKey, c.Jira.APIToken,
+		c.Confluence.APIToken, c.Notion.APIToken, c.Server.WebhookSecret, c.Server.APIToken, c.HTTP.ProxyURL} {
+		if secret != "" && secret != RedactedValue {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// Clone returns a deep copy of the configuration, so changes made for one analysis, such as
+// front-matter directives, leave the original and other copies untouched
+func (c *Config) Clone() *Config {
+	clone := *c
+	copyValue(reflect.ValueOf(&clone).Elem(), reflect.ValueOf(c).Elem())
+	return &clone
+}
+
+// copyValue copies src into dst, giving dst its own copy of the slices, maps and pointers nested
+// in src. Unexported fields are left as they are in dst.
+func copyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				copyValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		for _, key := range src.MapKeys() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			copyValue(value, src.MapIndex(key))
+			dst.SetMapIndex(key, value)
+		}
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		copyValue(dst.Elem(), src.Elem())
+	default:
+		dst.Set(src)
+	}
+}
+
+// setValue sets section.key in a YAML mapping, adding the section or key if missing
+func setValue(mapping yaml.MapSlice, section, key string, value interface{}) yaml.MapSlice {
+	for i, item := range mapping {
+		if item.Key != section {
+			continue
+		}
+
+		nested, _ := item.Value.(yaml.MapSlice)
+		for j, nestedItem := range nested {
+			if nestedItem.Key == key {
+				nested[j].Value = value
+				return mapping
+			}
+		}
+
+		mapping[i].Value = append(nested, yaml.MapItem{Key: key, Value: value})
+		return mapping
+	}
+
+	return append(mapping, yaml.MapItem{Key: section, Value: yaml.MapSlice{{Key: key, Value: value}}})
+}
+
+// Defaults returns a configuration with every setting at its default
+func Defaults() *Config {
+	var config Config
+	config.applyDefaults()
+	return &config
+}
+
+// applyDefaults fills in settings that were omitted from the configuration file
+func (c *Config) applyDefaults() {
+	if c.Provider == "" {
+		c.Provider = "anthropic"
+	}
+
+	// Chunking and retry settings live in the anthropic section but apply to every provider
+	if c.Anthropic.TimeoutSeconds == 0 {
+		c.Anthropic.TimeoutSeconds = 120
+	}
+	if c.Anthropic.Model == "" {
+		c.Anthropic.Model = "claude-sonnet-4-20250514"
+	}
+	if c.Anthropic.MaxTokens == 0 {
+		c.Anthropic.MaxTokens = 4000
+	}
+	if c.Anthropic.ChunkSizeTokens == 0 {
+		if c.Anthropic.ChunkSizeChars > 0 {
+			// Older configs size chunks in characters
+			c.Anthropic.ChunkSizeTokens = c.Anthropic.ChunkSizeChars / 4
+		} else {
+			c.Anthropic.ChunkSizeTokens = 4000
+		}
+	}
+	if c.Anthropic.RetryCount == 0 {
+		c.Anthropic.RetryCount = 3
+	}
+	if c.Anthropic.RetryDelaySeconds == 0 {
+		c.Anthropic.RetryDelaySeconds = 5
+	}
+
+	if c.OpenAI.Model == "" {
+		c.OpenAI.Model = "gpt-4o"
+	}
+	if c.OpenAI.BaseURL == "" {
+		c.OpenAI.BaseURL = "https://api.openai.com/v1"
+	}
+	if c.OpenAI.TimeoutSeconds == 0 {
+		c.OpenAI.TimeoutSeconds = c.Anthropic.TimeoutSeconds
+	}
+	if c.OpenAI.MaxTokens == 0 {
+		c.OpenAI.MaxTokens = c.Anthropic.MaxTokens
+	}
+
+	if c.Jira.APIVersion == 0 {
+		c.Jira.APIVersion = JiraAPIv2
+	}
+	if c.Jira.AuthType == "" {
+		c.Jira.AuthType = JiraAuthBasic
+	}
+	if c.Jira.Sprints.LengthDays == 0 {
+		c.Jira.Sprints.LengthDays = DefaultSprintLengthDays
+	}
+
+	if c.Confluence.Timeout == 0 {
+		c.Confluence.Timeout = 30
+	}
+	if c.Notion.BaseURL == "" {
+		c.Notion.BaseURL = "https://api.notion.com/v1"
+	}
+	if c.Notion.Timeout == 0 {
+		c.Notion.Timeout = 30
+	}
+
+	// story_points_field predates field_mapping
+	if c.Jira.StoryPointsField != "" && c.Jira.FieldMapping[FieldStoryPoints] == "" {
+		if c.Jira.FieldMapping == nil {
+			c.Jira.FieldMapping = make(map[string]string)
+		}
+		c.Jira.FieldMapping[FieldStoryPoints] = c.Jira.StoryPointsField
+	}
+
+	// issue_types predates issue_type_mapping
+	if c.Jira.IssueTypeMapping.Epic == "" {
+		c.Jira.IssueTypeMapping.Epic = c.Jira.IssueTypes.Epic
+	}
+	if c.Jira.IssueTypeMapping.Story == "" {
+		c.Jira.IssueTypeMapping.Story = c.Jira.IssueTypes.Story
+	}
+	if c.Jira.IssueTypeMapping.Subtask == "" {
+		c.Jira.IssueTypeMapping.Subtask = c.Jira.IssueTypes.Subtask
+	}
+	if c.Jira.IssueTypeMapping.Risk == "" {
+		c.Jira.IssueTypeMapping.Risk = c.Jira.IssueTypes.Risk
+	}
+	if c.Jira.IssueTypeMapping.Spike == "" {
+		c.Jira.IssueTypeMapping.Spike = c.Jira.IssueTypes.Spike
+	}
+	if c.Jira.IssueTypeMapping.Initiative == "" {
+		c.Jira.IssueTypeMapping.Initiative = c.Jira.IssueTypes.Initiative
+	}
+	if c.Jira.SpikeTimebox == "" {
+		c.Jira.SpikeTimebox = "2 days"
+	}
+
+	if c.Processing.AutomationLevel == "" {
+		c.Processing.AutomationLevel = AutomationReview
+	}
+	if c.Processing.ConfidenceThreshold == 0 {
+		c.Processing.ConfidenceThreshold = 80
+	}
+	if c.Processing.MaxConcurrency == 0 {
+		c.Processing.MaxConcurrency = 4
+	}
+	if c.Processing.ChunkingStrategy == "" {
+		c.Processing.ChunkingStrategy = ChunkingHeadings
+	}
+	if c.Processing.TemplatesDir == "" {
+		c.Processing.TemplatesDir = "templates"
+	}
+	if c.Processing.CriteriaFormat == "" {
+		c.Processing.CriteriaFormat = CriteriaList
+	}
+	if c.Processing.Methodology == "" {
+		c.Processing.Methodology = MethodologyScrum
+	}
+	if c.Processing.Sizing == "" {
+		c.Processing.Sizing = SizingClasses
+	}
+	if c.Processing.EstimationScale.Name == "" {
+		c.Processing.EstimationScale = EstimationScale{Name: ScaleFibonacci, Points: scalePoints[ScaleFibonacci]}
+	}
+	if c.Processing.HierarchyLevels == 0 {
+		c.Processing.HierarchyLevels = 2
+	}
+
+	if c.Gemini.Model == "" {
+		c.Gemini.Model = "gemini-1.5-pro"
+	}
+	if c.Gemini.BaseURL == "" {
+		c.Gemini.BaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	if c.Gemini.TimeoutSeconds == 0 {
+		c.Gemini.TimeoutSeconds = c.Anthropic.TimeoutSeconds
+	}
+	if c.Gemini.MaxTokens == 0 {
+		c.Gemini.MaxTokens = c.Anthropic.MaxTokens
+	}
+
+	if c.Server.ListenAddr == "" {
+		c.Server.ListenAddr = ":8080"
+	}
+	if c.Server.MaxUploadMB == 0 {
+		c.Server.MaxUploadMB = 50
+	}
+	if c.Fetch.MaxSizeMB == 0 {
+		c.Fetch.MaxSizeMB = 20
+	}
+	if c.Server.HeartbeatSeconds == 0 {
+		c.Server.HeartbeatSeconds = 15
+	}
+	if c.Server.MaxJobs == 0 {
+		c.Server.MaxJobs = 2
+	}
+	if c.Server.RetentionHours == 0 {
+		c.Server.RetentionHours = 24
+	}
+
+	if c.HTTP.RetryCount == 0 {
+		c.HTTP.RetryCount = 2
+	}
+	if c.HTTP.RetryDelaySeconds == 0 {
+		c.HTTP.RetryDelaySeconds = 2
+	}
+
+	if c.Ollama.BaseURL == "" {
+		c.Ollama.BaseURL = "http://localhost:11434"
+	}
+	if c.Ollama.Model == "" {
+		c.Ollama.Model = "llama3.1"
+	}
+	if c.Ollama.TimeoutSeconds == 0 {
+		// Local models are much slower than hosted APIs
+		c.Ollama.TimeoutSeconds = 600
+	}
+	if c.Ollama.MaxTokens == 0 {
+		c.Ollama.MaxTokens = c.Anthropic.MaxTokens
+	}
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
-	if c.Anthropic.APIKey == "" {
-		return fmt.Errorf("anthropic API key is required")
+	switch c.Provider {
+	case "anthropic":
+		if c.Anthropic.APIKey == "" {
+			return fmt.Errorf("anthropic API key is required (anthropic.api_key, or 'scrum-master auth login anthropic.api_key')")
+		}
+	case "openai":
+		if c.OpenAI.APIKey == "" {
+			return fmt.Errorf("OpenAI API key is required (openai.api_key, or 'scrum-master auth login openai.api_key')")
+		}
+	case "gemini":
+		if c.Gemini.APIKey == "" {
+			return fmt.Errorf("gemini API key is required (gemini.api_key, or 'scrum-master auth login gemini.api_key')")
+		}
+	}
+
+	if c.Anthropic.MaxCostUSD < 0 {
+		return fmt.Errorf("anthropic.max_cost_usd must not be negative")
 	}
 
 	if c.Jira.BaseURL == "" {
 		return fmt.Errorf("JIRA base URL is required")
 	}
 
-	if c.Jira.Username == "" {
+	if c.Jira.AuthType != JiraAuthBasic && c.Jira.AuthType != JiraAuthPAT {
+		return fmt.Errorf("invalid jira.auth_type '%s' (must be %s or %s)", c.Jira.AuthType, JiraAuthBasic, JiraAuthPAT)
+	}
+
+	if c.Jira.Username == "" && c.Jira.AuthType == JiraAuthBasic {
 		return fmt.Errorf("JIRA username is required")
 	}
 
 	if c.Jira.APIToken == "" {
-		return fmt.Errorf("JIRA API token is required")
+		return fmt.Errorf("JIRA API token is required (jira.api_token, or 'scrum-master auth login')")
 	}
 
 	if c.Jira.ProjectKey == "" {
 		return fmt.Errorf("JIRA project key is required")
 	}
 
+	if c.Jira.APIVersion != JiraAPIv2 && c.Jira.APIVersion != JiraAPIv3 {
+		return fmt.Errorf("invalid jira.api_version %d (must be 2 or 3)", c.Jira.APIVersion)
+	}
+
+	for name := range c.Jira.FieldMapping {
+		if !slices.Contains(MappableFields, name) {
+			return fmt.Errorf("unknown jira.field_mapping field '%s' (must be one of %s)", name, strings.Join(MappableFields, ", "))
+		}
+	}
+
+	for _, label := range c.Jira.Labels {
+		if label == "" || strings.ContainsAny(label, " \t") {
+			return fmt.Errorf("invalid jira.labels label '%s' (labels cannot be empty or contain spaces)", label)
+		}
+	}
+
+	switch c.Jira.ProjectStyle {
+	case "", ProjectStyleTeamManaged, ProjectStyleCompanyManaged:
+	default:
+		return fmt.Errorf("invalid jira.project_style '%s' (must be team-managed or company-managed, or empty to detect it)", c.Jira.ProjectStyle)
+	}
+
+	if c.Jira.Sprints.Capacity < 0 {
+		return fmt.Errorf("jira.sprints.capacity must not be negative")
+	}
+
+	if c.Jira.HoursPerPoint < 0 {
+		return fmt.Errorf("jira.hours_per_point must not be negative")
+	}
+
+	if c.Jira.Sprints.LengthDays < 0 {
+		return fmt.Errorf("jira.sprints.length_days must not be negative")
+	}
+
+	if link := c.Jira.Source.LinkURL; link != "" && !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return fmt.Errorf("invalid jira.source.link_url '%s' (must be an http or https URL)", link)
+	}
+
+	if link := c.Confluence.BaseURL; link != "" && !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return fmt.Errorf("invalid confluence.base_url '%s' (must be an http or https URL)", link)
+	}
+
+	if link := c.Notion.BaseURL; !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return fmt.Errorf("invalid notion.base_url '%s' (must be an http or https URL)", link)
+	}
+
+	if c.Fetch.MaxSizeMB < 0 {
+		return fmt.Errorf("fetch.max_size_mb cannot be negative")
+	}
+	for _, domain := range c.Fetch.AllowedDomains {
+		if domain == "" || strings.ContainsAny(domain, "/:@ ") {
+			return fmt.Errorf("invalid fetch.allowed_domains entry '%s' (must be a host name such as wiki.example.com)", domain)
+		}
+	}
+
+	if c.Server.MaxUploadMB < 0 {
+		return fmt.Errorf("server.max_upload_mb cannot be negative")
+	}
+	if c.Server.HeartbeatSeconds < 0 {
+		return fmt.Errorf("server.heartbeat_seconds cannot be negative")
+	}
+	if c.Server.MaxJobs < 0 {
+		return fmt.Errorf("server.max_jobs cannot be negative")
+	}
+	if c.Server.RetentionHours < 0 {
+		return fmt.Errorf("server.retention_hours cannot be negative")
+	}
+
+	members := make(map[string]bool)
+	for _, member := range c.Team.Members {
+		if member.Name == "" {
+			return fmt.Errorf("every team.members member needs a name")
+		}
+		if members[strings.ToLower(member.Name)] {
+			return fmt.Errorf("team.members has member '%s' more than once", member.Name)
+		}
+		members[strings.ToLower(member.Name)] = true
+		if len(member.Skills) == 0 {
+			return fmt.Errorf("team member '%s' has no skills to match stories against", member.Name)
+		}
+	}
+
+	if c.Jira.DefinitionOfDone.Validate && len(c.Jira.DefinitionOfDone.Story) == 0 {
+		return fmt.Errorf("jira.definition_of_done.validate needs a story checklist")
+	}
+
+	releases := make(map[string]bool)
+	for _, release := range c.Jira.Releases {
+		if release.Name == "" {
+			return fmt.Errorf("every jira.releases release needs a name")
+		}
+		if releases[strings.ToLower(release.Name)] {
+			return fmt.Errorf("jira.releases has release '%s' more than once", release.Name)
+		}
+		releases[strings.ToLower(release.Name)] = true
+		if release.ReleaseDate != "" {
+			if _, err := time.Parse(time.DateOnly, release.ReleaseDate); err != nil {
+				return fmt.Errorf("invalid release_date '%s' of release '%s' (expected YYYY-MM-DD)", release.ReleaseDate, release.Name)
+			}
+		}
+	}
+
+	for priority := range c.Jira.PriorityMapping {
+		if priority != "High" && priority != "Medium" && priority != "Low" {
+			return fmt.Errorf("unknown jira.priority_mapping priority '%s' (must be High, Medium or Low)", priority)
+		}
+	}
+
+	switch c.Processing.AutomationLevel {
+	case AutomationSuggest, AutomationReview, AutomationAuto:
+	default:
+		return fmt.Errorf("invalid automation level '%s' (must be suggest, review or auto)", c.Processing.AutomationLevel)
+	}
+
+	if c.Processing.ConfidenceThreshold < 0 || c.Processing.ConfidenceThreshold > 100 {
+		return fmt.Errorf("confidence threshold must be between 0 and 100")
+	}
+
+	if c.Processing.MaxConcurrency < 1 {
+		return fmt.Errorf("max concurrency must be at least 1")
+	}
+
+	if c.Processing.Retention.KeepLast < 0 || c.Processing.Retention.MaxAgeDays < 0 {
+		return fmt.Errorf("retention keep_last and max_age_days cannot be negative")
+	}
+
+	if c.Processing.ChunkingStrategy != ChunkingHeadings && c.Processing.ChunkingStrategy != ChunkingTokens {
+		return fmt.Errorf("invalid chunking strategy '%s' (must be %s or %s)", c.Processing.ChunkingStrategy, ChunkingHeadings, ChunkingTokens)
+	}
+	if c.Processing.CriteriaFormat != CriteriaList && c.Processing.CriteriaFormat != CriteriaGherkin {
+		return fmt.Errorf("invalid acceptance criteria format '%s' (must be %s or %s)", c.Processing.CriteriaFormat, CriteriaList, CriteriaGherkin)
+	}
+	if c.Processing.Methodology != MethodologyScrum && c.Processing.Methodology != MethodologyKanban {
+		return fmt.Errorf("invalid methodology '%s' (must be %s or %s)", c.Processing.Methodology, MethodologyScrum, MethodologyKanban)
+	}
+	if c.Processing.Sizing != SizingClasses && c.Processing.Sizing != SizingNone {
+		return fmt.Errorf("invalid sizing '%s' (must be %s or %s)", c.Processing.Sizing, SizingClasses, SizingNone)
+	}
+	if c.Processing.HierarchyLevels != 2 && c.Processing.HierarchyLevels != 3 {
+		return fmt.Errorf("invalid hierarchy levels %d (must be 2 for epics and stories or 3 for initiatives, epics and stories)", c.Processing.HierarchyLevels)
+	}
+
+	if c.Signing.Require && c.Signing.PublicKeyFile == "" {
+		return fmt.Errorf("signing.require needs signing.public_key_file")
+	}
+
+	if c.HTTP.InsecureSkipVerify {
+		return fmt.Errorf("http.insecure_skip_verify is not supported, as it would apply to every API; set jira.insecure_skip_verify or confluence.insecure_skip_verify for a self-hosted server, or trust its CA with http.ca_file")
+	}
+
+	if c.HTTP.ProxyURL != "" {
+		if proxy, err := url.Parse(c.HTTP.ProxyURL); err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return fmt.Errorf("invalid http.proxy_url (expected a URL such as http://proxy.example.com:3128)")
+		}
+	}
+
+	if c.HTTP.CAFile != "" {
+		pem, err := os.ReadFile(c.HTTP.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read http.ca_file: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("http.ca_file %s contains no PEM certificates", c.HTTP.CAFile)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestEstimationScaleUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    EstimationScale
+		wantErr string
+	}{
+		{
+			name: "named scale",
+			yaml: "estimation_scale: powers_of_two",
+			want: EstimationScale{Name: ScalePowersOfTwo, Points: []int{1, 2, 4, 8, 16}},
+		},
+		{
+			name: "tshirt scale",
+			yaml: "estimation_scale: tshirt",
+			want: EstimationScale{Name: ScaleTShirt, Points: []int{1, 2, 3, 5, 8}},
+		},
+		{
+			name: "custom scale",
+			yaml: "estimation_scale: [1, 3, 5, 10]",
+			want: EstimationScale{Name: ScaleCustom, Points: []int{1, 3, 5, 10}},
+		},
+		{
+			name:    "unknown name",
+			yaml:    "estimation_scale: Fibonacci",
+			wantErr: "invalid estimation_scale 'Fibonacci'",
+		},
+		{
+			name:    "number instead of a scale",
+			yaml:    "estimation_scale: 5",
+			wantErr: "invalid estimation_scale '5'",
+		},
+		{
+			name:    "single story point",
+			yaml:    "estimation_scale: [3]",
+			wantErr: "at least 2 story point values",
+		},
+		{
+			name:    "points not ascending",
+			yaml:    "estimation_scale: [1, 5, 3]",
+			wantErr: "must be 1 or more and ascending",
+		},
+		{
+			name:    "repeated points",
+			yaml:    "estimation_scale: [1, 2, 2]",
+			wantErr: "must be 1 or more and ascending",
+		},
+		{
+			name:    "zero points",
+			yaml:    "estimation_scale: [0, 1, 2]",
+			wantErr: "must be 1 or more and ascending",
+		},
+		{
+			name:    "mapping",
+			yaml:    "estimation_scale: {name: fibonacci}",
+			wantErr: "must be a scale name or a list of story points",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var processing struct {
+				Scale EstimationScale `yaml:"estimation_scale"`
+			}
+			err := yaml.Unmarshal([]byte(tt.yaml), &processing)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("UnmarshalYAML(%q) error = %v, want one containing %q", tt.yaml, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalYAML(%q) error = %v", tt.yaml, err)
+			}
+			if !reflect.DeepEqual(processing.Scale, tt.want) {
+				t.Errorf("UnmarshalYAML(%q) = %+v, want %+v", tt.yaml, processing.Scale, tt.want)
+			}
+		})
+	}
+}
+
+func TestClone(t *testing.T) {
+	original := Defaults()
+	original.Jira.Labels = []string{"shop"}
+	original.Jira.FieldMapping = map[string]string{"team": "customfield_10001"}
+	original.Jira.Releases = []ReleaseConfig{{Name: "MVP"}}
+
+	clone := original.Clone()
+	if !reflect.DeepEqual(clone, original) {
+		t.Fatalf("Clone() = %+v, want %+v", clone, original)
+	}
+
+	clone.Jira.Labels[0] = "changed"
+	clone.Jira.FieldMapping["team"] = "changed"
+	clone.Jira.Releases[0].Name = "changed"
+	clone.Processing.EstimationScale.Points[0] = 99
+	if original.Jira.Labels[0] != "shop" || original.Jira.FieldMapping["team"] != "customfield_10001" ||
+		original.Jira.Releases[0].Name != "MVP" || original.Processing.EstimationScale.Points[0] == 99 {
+		t.Errorf("Clone() shares settings with the original: %+v", original.Jira)
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "settings given", server: "{max_upload_mb: 10, heartbeat_seconds: 60, max_jobs: 4, retention_hours: 1}"},
+		{name: "negative upload size", server: "{max_upload_mb: -1}", wantErr: "server.max_upload_mb cannot be negative"},
+		{name: "negative heartbeat", server: "{heartbeat_seconds: -5}", wantErr: "server.heartbeat_seconds cannot be negative"},
+		{name: "negative job limit", server: "{max_jobs: -1}", wantErr: "server.max_jobs cannot be negative"},
+		{name: "negative retention", server: "{retention_hours: -24}", wantErr: "server.retention_hours cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "provider: ollama\njira: {base_url: https://example.atlassian.net, username: me, api_token: token, project_key: SHOP}\n"
+			if tt.server != "" {
+				data += "server: " + tt.server + "\n"
+			}
+			_, err := Parse([]byte(data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse(%q) error = %v, want one containing %q", tt.server, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.server, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectConfigName is the configuration file of a project, found in the working directory or
+// the nearest directory above it that has one
+const ProjectConfigName = ".scrum-master.yaml"
+
+// legacyConfigName is the configuration file of the working directory used when no project
+// configuration is found
+const legacyConfigName = "config.yaml"
+
+// UserConfigPath returns the path of the user's configuration,
+// $XDG_CONFIG_HOME/scrum-master/config.yaml, or ~/.config/scrum-master/config.yaml when
+// XDG_CONFIG_HOME is not set
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "scrum-master", "config.yaml")
+}
+
+// ConfigFiles returns the configuration files of a run in the order they are merged: the user's
+// configuration, then the project's configuration, or configPath instead of it when set.
+// Discovered files that do not exist are left out; configPath must exist.
+func ConfigFiles(configPath string) ([]string, error) {
+	var files []string
+	if userPath := UserConfigPath(); userPath != "" && fileExists(userPath) {
+		files = append(files, userPath)
+	}
+
+	if configPath != "" {
+		if !fileExists(configPath) {
+			return nil, fmt.Errorf("config file %s does not exist", configPath)
+		}
+		return append(files, configPath), nil
+	}
+
+	if projectPath := findProjectConfig(); projectPath != "" {
+		files = append(files, projectPath)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no configuration found: create %s in the project or %s, or pass --config",
+			ProjectConfigName, UserConfigPath())
+	}
+	return files, nil
+}
+
+// findProjectConfig returns the project configuration nearest to the working directory, or the
+// working directory's config.yaml, or "" without either
+func findProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if path := filepath.Join(dir, ProjectConfigName); fileExists(path) {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if fileExists(legacyConfigName) {
+		return legacyConfigName
+	}
+	return ""
+}
+
+// ReadConfigFiles reads the configuration files of a run and merges them into one YAML
+// configuration, with the settings of later files over those of earlier ones. A single file is
+// returned as it is.
+func ReadConfigFiles(files []string) ([]byte, error) {
+	switch len(files) {
+	case 0:
+		return nil, fmt.Errorf("no config files to read")
+	case 1:
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return data, nil
+	}
+
+	merged := map[interface{}]interface{}{}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		var settings map[interface{}]interface{}
+		if err := yaml.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		mergeSettings(merged, settings)
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
+	}
+	return data, nil
+}
+
+// fileExists reports whether a regular file exists at path
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
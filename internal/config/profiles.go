@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Profile keys of a configuration file
+const (
+	profilesKey       = "profiles"
+	defaultProfileKey = "default_profile"
+)
+
+// applyProfile returns a YAML configuration with the settings of a profile merged over the
+// shared settings. Without a profile name the default_profile is used, and without either the
+// shared settings alone. Sections are merged key by key; lists and other values of the profile
+// replace the shared ones.
+func applyProfile(data []byte, profile string) ([]byte, string, error) {
+	var settings map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, "", fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	profiles, ok := settings[profilesKey].(map[interface{}]interface{})
+	if settings[profilesKey] != nil && !ok {
+		return nil, "", fmt.Errorf("profiles must map profile names to settings")
+	}
+	if profile == "" {
+		profile, _ = settings[defaultProfileKey].(string)
+	}
+	delete(settings, profilesKey)
+	delete(settings, defaultProfileKey)
+
+	if profile == "" {
+		return data, "", nil
+	}
+	overrides, ok := profiles[profile]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown profile '%s' (available: %s)", profile, profileNames(profiles))
+	}
+	if overrides != nil {
+		overrideSettings, ok := overrides.(map[interface{}]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("profile '%s' must be a mapping of settings", profile)
+		}
+		mergeSettings(settings, overrideSettings)
+	}
+
+	merged, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to merge profile '%s': %w", profile, err)
+	}
+	return merged, profile, nil
+}
+
+// mergeSettings merges overrides into settings, recursing into sections both of them have
+func mergeSettings(settings, overrides map[interface{}]interface{}) {
+	for key, override := range overrides {
+		section, isSection := settings[key].(map[interface{}]interface{})
+		overrideSection, overridesSection := override.(map[interface{}]interface{})
+		if isSection && overridesSection {
+			mergeSettings(section, overrideSection)
+			continue
+		}
+		settings[key] = override
+	}
+}
+
+// profileNames returns the sorted names of the profiles, or "none"
+func profileNames(profiles map[interface{}]interface{}) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, fmt.Sprint(name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergeSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  string
+		overrides string
+		want      string
+	}{
+		{
+			name:      "sections merged key by key",
+			settings:  "jira:\n  base_url: https://a.example.com\n  project_key: SHOP\n",
+			overrides: "jira:\n  project_key: PAY\n  timeout_seconds: 60\n",
+			want:      "jira:\n  base_url: https://a.example.com\n  project_key: PAY\n  timeout_seconds: 60\n",
+		},
+		{
+			name:      "nested sections merged",
+			settings:  "jira:\n  issue_types:\n    epic: Epic\n    story: Story\n",
+			overrides: "jira:\n  issue_types:\n    story: Task\n",
+			want:      "jira:\n  issue_types:\n    epic: Epic\n    story: Task\n",
+		},
+		{
+			name:      "lists replaced",
+			settings:  "jira:\n  labels: [a, b]\n",
+			overrides: "jira:\n  labels: [c]\n",
+			want:      "jira:\n  labels: [c]\n",
+		},
+		{
+			name:      "new sections added",
+			settings:  "jira:\n  project_key: SHOP\n",
+			overrides: "processing:\n  language: German\n",
+			want:      "jira:\n  project_key: SHOP\nprocessing:\n  language: German\n",
+		},
+		{
+			name:      "section replaced by a value and a value by a section",
+			settings:  "jira:\n  project_key: SHOP\nfetch: none\n",
+			overrides: "jira: null\nfetch:\n  max_size_mb: 5\n",
+			want:      "jira: null\nfetch:\n  max_size_mb: 5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var settings, overrides, want map[interface{}]interface{}
+			for _, document := range []struct {
+				yaml   string
+				target *map[interface{}]interface{}
+			}{{tt.settings, &settings}, {tt.overrides, &overrides}, {tt.want, &want}} {
+				if err := yaml.Unmarshal([]byte(document.yaml), document.target); err != nil {
+					t.Fatalf("failed to parse %q: %v", document.yaml, err)
+				}
+			}
+
+			mergeSettings(settings, overrides)
+			if !reflect.DeepEqual(settings, want) {
+				t.Errorf("mergeSettings() = %v, want %v", settings, want)
+			}
+		})
+	}
+}
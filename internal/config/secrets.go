@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringService is the service secrets are stored under in the OS keyring
+const KeyringService = "scrum-master"
+
+// KeyringSecrets are the settings 'scrum-master auth login' stores in the OS keyring. A loaded
+// configuration that leaves one of them empty takes it from the keyring, preferring the secret
+// stored for its profile.
+var KeyringSecrets = []string{"jira.api_token", "anthropic.api_key", "openai.api_key", "gemini.api_key", "confluence.api_token", "notion.api_token"}
+
+// envReference matches a ${NAME} reference to an environment variable
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references in the text settings of the configuration with the
+// environment variables they name. Settings are expanded after parsing, so a variable holding
+// YAML syntax cannot change the structure of the configuration.
+func (c *Config) expandEnv() error {
+	var missing []string
+	expandValue(reflect.ValueOf(c).Elem(), &missing)
+	if len(missing) > 0 {
+		return fmt.Errorf("config references unset environment variables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// expandValue replaces environment variable references in the strings of a value and everything
+// nested in it, collecting the names of unset variables in missing
+func expandValue(value reflect.Value, missing *[]string) {
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				expandValue(value.Field(i), missing)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			expandValue(value.Index(i), missing)
+		}
+	case reflect.Map:
+		if value.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range value.MapKeys() {
+			expanded := reflect.New(value.Type().Elem()).Elem()
+			expanded.SetString(expandString(value.MapIndex(key).String(), missing))
+			value.SetMapIndex(key, expanded)
+		}
+	case reflect.String:
+		value.SetString(expandString(value.String(), missing))
+	}
+}
+
+// expandString replaces environment variable references in a string
+func expandString(text string, missing *[]string) string {
+	return envReference.ReplaceAllStringFunc(text, func(reference string) string {
+		name := envReference.FindStringSubmatch(reference)[1]
+		variable, ok := os.LookupEnv(name)
+		if !ok && !slices.Contains(*missing, name) {
+			*missing = append(*missing, name)
+		}
+		return variable
+	})
+}
+
+// keyringFields returns the settings of KeyringSecrets by name
+func (c *Config) keyringFields() map[string]*string {
+	return map[string]*string{
+		"jira.api_token":       &c.Jira.APIToken,
+		"anthropic.api_key":    &c.Anthropic.APIKey,
+		"openai.api_key":       &c.OpenAI.APIKey,
+		"gemini.api_key":       &c.Gemini.APIKey,
+		"confluence.api_token": &c.Confluence.APIToken,
+		"notion.api_token":     &c.Notion.APIToken,
+	}
+}
+
+// readKeyring fills the secrets the configuration leaves empty from the OS keyring, trying the
+// secret of the profile before the shared one. Secrets the keyring does not have, or a keyring
+// that is not available, leave them empty.
+func (c *Config) readKeyring() {
+	for name, field := range c.keyringFields() {
+		if *field != "" {
+			continue
+		}
+		for _, entry := range []string{keyringEntry(c.Profile, name), name} {
+			if secret, err := keyring.Get(KeyringService, entry); err == nil {
+				*field = secret
+				break
+			}
+		}
+	}
+}
+
+// StoreSecret stores a secret of KeyringSecrets in the OS keyring, for a profile or, without
+// one, shared by all profiles
+func StoreSecret(profile, name, secret string) error {
+	if !isKeyringSecret(name) {
+		return fmt.Errorf("unknown secret '%s' (must be one of %s)", name, strings.Join(KeyringSecrets, ", "))
+	}
+	if err := keyring.Set(KeyringService, keyringEntry(profile, name), secret); err != nil {
+		return fmt.Errorf("failed to store %s in the OS keyring: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteSecret removes a secret of KeyringSecrets of a profile, or the shared one, from the OS
+// keyring. It reports whether the keyring had it.
+func DeleteSecret(profile, name string) (bool, error) {
+	if !isKeyringSecret(name) {
+		return false, fmt.Errorf("unknown secret '%s' (must be one of %s)", name, strings.Join(KeyringSecrets, ", "))
+	}
+	err := keyring.Delete(KeyringService, keyringEntry(profile, name))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to remove %s from the OS keyring: %w", name, err)
+	}
+	return true, nil
+}
+
+// keyringEntry returns the keyring entry of a secret of a profile, such as teamA/jira.api_token
+func keyringEntry(profile, name string) string {
+	if profile == "" {
+		return name
+	}
+	return profile + "/" + name
+}
+
+// isKeyringSecret reports whether name is one of KeyringSecrets
+func isKeyringSecret(name string) bool {
+	return slices.Contains(KeyringSecrets, name)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("SM_TEST_TOKEN", "secret-token")
+	t.Setenv("SM_TEST_HOST", "example.atlassian.net")
+	t.Setenv("SM_TEST_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    Config
+		wantErr string
+	}{
+		{
+			name:   "strings expanded",
+			config: Config{Jira: JiraConfig{BaseURL: "https://${SM_TEST_HOST}/", APIToken: "${SM_TEST_TOKEN}", Username: "me@${SM_TEST_HOST}"}},
+			want:   Config{Jira: JiraConfig{BaseURL: "https://example.atlassian.net/", APIToken: "secret-token", Username: "me@example.atlassian.net"}},
+		},
+		{
+			name:   "lists and maps expanded",
+			config: Config{Jira: JiraConfig{Labels: []string{"${SM_TEST_HOST}", "fixed"}, CustomFields: map[string]string{"customfield_1": "${SM_TEST_TOKEN}"}}},
+			want:   Config{Jira: JiraConfig{Labels: []string{"example.atlassian.net", "fixed"}, CustomFields: map[string]string{"customfield_1": "secret-token"}}},
+		},
+		{
+			name:   "set but empty variable",
+			config: Config{Jira: JiraConfig{APIToken: "x${SM_TEST_EMPTY}y"}},
+			want:   Config{Jira: JiraConfig{APIToken: "xy"}},
+		},
+		{
+			name:   "other dollar signs kept",
+			config: Config{Jira: JiraConfig{APIToken: "$SM_TEST_TOKEN ${1X} $$ ${"}},
+			want:   Config{Jira: JiraConfig{APIToken: "$SM_TEST_TOKEN ${1X} $$ ${"}},
+		},
+		{
+			name:    "unset variables named once",
+			config:  Config{Jira: JiraConfig{APIToken: "${SM_TEST_UNSET}", Username: "${SM_TEST_UNSET}", BaseURL: "${SM_TEST_OTHER_UNSET}"}},
+			wantErr: "variables: SM_TEST_OTHER_UNSET, SM_TEST_UNSET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.expandEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantErr) {
+					t.Fatalf("expandEnv() error = %v, want one ending in %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandEnv() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.config, tt.want) {
+				t.Errorf("expandEnv() = %+v, want %+v", tt.config.Jira, tt.want.Jira)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Secret references fetch a secret of KeyringSecrets from a secret store when the configuration
+// is loaded, so it is never written to disk:
+//
+//	vault:secret/data/scrum-master#anthropic   field of a HashiCorp Vault secret
+//	aws-sm:my-secret                           AWS Secrets Manager secret string
+//	aws-sm:my-secret#anthropic                 field of a JSON AWS Secrets Manager secret
+const (
+	vaultPrefix = "vault:"
+	awsPrefix   = "aws-sm:"
+)
+
+// secretStoreTimeout limits every secret store request
+const secretStoreTimeout = 30 * time.Second
+
+// resolveSecretReferences replaces secret references in the secrets of the configuration with
+// the secrets they reference
+func (c *Config) resolveSecretReferences() error {
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: c.HTTP.Proxy(), TLSClientConfig: c.HTTP.TLSConfig()},
+		Timeout:   secretStoreTimeout,
+	}
+
+	for name, field := range c.keyringFields() {
+		var (
+			secret string
+			err    error
+		)
+		switch {
+		case strings.HasPrefix(*field, vaultPrefix):
+			secret, err = readVault(client, strings.TrimPrefix(*field, vaultPrefix))
+		case strings.HasPrefix(*field, awsPrefix):
+			secret, err = readSecretsManager(client, c.HTTP, strings.TrimPrefix(*field, awsPrefix))
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s from %s: %w", name, *field, err)
+		}
+		*field = secret
+	}
+	return nil
+}
+
+// splitReference splits a secret reference into the secret and the field after '#'
+func splitReference(reference string) (string, string) {
+	secret, field, _ := strings.Cut(reference, "#")
+	return secret, field
+}
+
+// readVault reads a field of a Vault secret, authenticating with VAULT_TOKEN or the token the
+// vault CLI saved with 'vault login'. The path is the API path of the secret, such as
+// secret/data/scrum-master for the KV version 2 engine mounted at secret/.
+func readVault(client *http.Client, reference string) (string, error) {
+	path, field := splitReference(reference)
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	body, err := send(client, req, "Vault")
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	// The KV version 2 engine nests the fields of a secret under data.data
+	data := response.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	return secretField(data, field)
+}
+
+// vaultToken returns the Vault token from VAULT_TOKEN or ~/.vault-token
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if token, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+			return strings.TrimSpace(string(token)), nil
+		}
+	}
+	return "", fmt.Errorf("VAULT_TOKEN is not set and there is no ~/.vault-token (run 'vault login')")
+}
+
+// awsConfig is the AWS configuration of the default credential chain, loaded once per process
+var awsConfig struct {
+	once   sync.Once
+	config aws.Config
+	err    error
+}
+
+// loadAWSConfig loads the AWS configuration the AWS CLI and SDKs use: credentials from the
+// environment, the shared config and credentials files (with AWS_PROFILE, including SSO and
+// assumed roles), web identity tokens, or the container or instance role. Credentials are cached
+// and refreshed before they expire. Credential requests use the configured proxy and CA file.
+func loadAWSConfig(httpConfig HTTPConfig) (aws.Config, error) {
+	awsConfig.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), secretStoreTimeout)
+		defer cancel()
+
+		client := awshttp.NewBuildableClient().WithTransportOptions(func(transport *http.Transport) {
+			transport.Proxy = httpConfig.Proxy()
+			if tlsConfig := httpConfig.TLSConfig(); tlsConfig != nil {
+				transport.TLSClientConfig = tlsConfig
+			}
+		})
+		awsConfig.config, awsConfig.err = awsconfig.LoadDefaultConfig(ctx, awsconfig.WithHTTPClient(client))
+	})
+	return awsConfig.config, awsConfig.err
+}
+
+// readSecretsManager reads an AWS Secrets Manager secret, or a field of a JSON secret. Requests
+// are signed with the credentials of the default AWS credential chain and go to the region of a
+// secret ARN, or the configured region.
+func readSecretsManager(client *http.Client, httpConfig HTTPConfig, reference string) (string, error) {
+	secretID, field := splitReference(reference)
+	awsCfg, err := loadAWSConfig(httpConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the AWS configuration: %w", err)
+	}
+	region := awsRegion(secretID, awsCfg.Region)
+	if region == "" {
+		return "", fmt.Errorf("no AWS region is configured (set AWS_REGION or the region of the AWS profile)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretStoreTimeout)
+	defer cancel()
+	credentials, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("no AWS credentials found (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or AWS_PROFILE): %w", err)
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if err := signAWS(ctx, req, payload, credentials, region, time.Now()); err != nil {
+		return "", err
+	}
+
+	body, err := send(client, req, "AWS Secrets Manager")
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.SecretString == "" {
+		return "", fmt.Errorf("secret has no secret string (binary secrets are not supported)")
+	}
+	if field == "" {
+		return response.SecretString, nil
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(response.SecretString), &data); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so field '%s' cannot be read", field)
+	}
+	return secretField(data, field)
+}
+
+// awsRegion returns the region of a secret ARN, or else the configured region or the one of
+// AWS_DEFAULT_REGION
+func awsRegion(secretID, configured string) string {
+	// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+	if parts := strings.Split(secretID, ":"); len(parts) > 3 && parts[0] == "arn" {
+		return parts[3]
+	}
+	if configured != "" {
+		return configured
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// signAWS signs a request to the Secrets Manager JSON API with Signature Version 4
+func signAWS(ctx context.Context, req *http.Request, payload []byte, credentials aws.Credentials, region string, now time.Time) error {
+	sum := sha256.Sum256(payload)
+	if err := v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(sum[:]), "secretsmanager", region, now); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
+
+// send sends a secret store request and returns the body of a successful response
+func send(client *http.Client, req *http.Request, store string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d: %s", store, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// secretField returns a text field of a secret. Without a field name, a secret with a single
+// field returns that field.
+func secretField(data map[string]interface{}, field string) (string, error) {
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("secret has %d fields; name one after '#'", len(data))
+		}
+		for name := range data {
+			field = name
+		}
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field '%s'", field)
+	}
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return "", fmt.Errorf("field '%s' of the secret is not a text value", field)
+	}
+	return text, nil
+}
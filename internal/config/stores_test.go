@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// verifySignature checks the Signature Version 4 of a Secrets Manager request, computed
+// independently of the SDK from the headers the request says it signed. It only reports errors,
+// so servers can call it.
+func verifySignature(t *testing.T, req *http.Request, payload []byte, secretKey, region string) {
+	t.Helper()
+
+	authorization := req.Header.Get("Authorization")
+	const algorithm = "AWS4-HMAC-SHA256 "
+	if !strings.HasPrefix(authorization, algorithm) {
+		t.Errorf("Authorization = %q, want %s", authorization, algorithm)
+		return
+	}
+	parts := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, algorithm), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		parts[name] = value
+	}
+
+	amzDate := req.Header.Get("X-Amz-Date")
+	scope := amzDate[:8] + "/" + region + "/secretsmanager/aws4_request"
+	if !strings.HasSuffix(parts["Credential"], "/"+scope) {
+		t.Errorf("Credential = %q, want scope %s", parts["Credential"], scope)
+		return
+	}
+
+	headers := strings.Split(parts["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(headers) {
+		t.Errorf("SignedHeaders %q are not sorted", parts["SignedHeaders"])
+		return
+	}
+	var canonicalHeaders strings.Builder
+	for _, header := range headers {
+		value := req.Header.Get(header)
+		switch header {
+		case "host":
+			value = req.Host
+		case "content-length":
+			value = strconv.FormatInt(req.ContentLength, 10)
+		}
+		canonicalHeaders.WriteString(header + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	hash := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := func(key []byte, data string) []byte {
+		m := hmac.New(sha256.New, key)
+		m.Write([]byte(data))
+		return m.Sum(nil)
+	}
+
+	canonicalRequest := strings.Join([]string{req.Method, "/", "", canonicalHeaders.String(), parts["SignedHeaders"], hash(payload)}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hash([]byte(canonicalRequest))}, "\n")
+	key := mac([]byte("AWS4"+secretKey), amzDate[:8])
+	for _, part := range []string{region, "secretsmanager", "aws4_request"} {
+		key = mac(key, part)
+	}
+	if want := hex.EncodeToString(mac(key, stringToSign)); parts["Signature"] != want {
+		t.Errorf("Signature = %s, want %s", parts["Signature"], want)
+	}
+}
+
+func TestSignAWS(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		credentials  aws.Credentials
+		region       string
+		payload      string
+		wantHeaders  []string
+		wantNoHeader string
+	}{
+		{
+			name:         "access key",
+			credentials:  aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+			region:       "us-east-1",
+			payload:      `{"SecretId":"scrum-master"}`,
+			wantHeaders:  []string{"content-type", "host", "x-amz-date", "x-amz-target"},
+			wantNoHeader: "X-Amz-Security-Token",
+		},
+		{
+			name:        "session token",
+			credentials: aws.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"},
+			region:      "eu-west-1",
+			payload:     `{"SecretId":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:scrum-master"}`,
+			wantHeaders: []string{"content-type", "host", "x-amz-date", "x-amz-security-token", "x-amz-target"},
+		},
+		{
+			name:        "empty payload",
+			credentials: aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"},
+			region:      "ap-southeast-2",
+			wantHeaders: []string{"host", "x-amz-date"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "https://secretsmanager."+tt.region+".amazonaws.com/", bytes.NewReader([]byte(tt.payload)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.payload != "" {
+				req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+				req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+			}
+
+			if err := signAWS(context.Background(), req, []byte(tt.payload), tt.credentials, tt.region, now); err != nil {
+				t.Fatalf("signAWS() error = %v", err)
+			}
+
+			if got := req.Header.Get("X-Amz-Date"); got != "20240305T123045Z" {
+				t.Errorf("X-Amz-Date = %q, want 20240305T123045Z", got)
+			}
+			if !strings.Contains(req.Header.Get("Authorization"), "Credential="+tt.credentials.AccessKeyID+"/20240305/") {
+				t.Errorf("Authorization = %q, want the access key %s", req.Header.Get("Authorization"), tt.credentials.AccessKeyID)
+			}
+			if tt.credentials.SessionToken != "" && req.Header.Get("X-Amz-Security-Token") != tt.credentials.SessionToken {
+				t.Errorf("X-Amz-Security-Token = %q, want %q", req.Header.Get("X-Amz-Security-Token"), tt.credentials.SessionToken)
+			}
+			if tt.wantNoHeader != "" && req.Header.Get(tt.wantNoHeader) != "" {
+				t.Errorf("%s = %q, want none", tt.wantNoHeader, req.Header.Get(tt.wantNoHeader))
+			}
+			for _, header := range tt.wantHeaders {
+				if !strings.Contains(req.Header.Get("Authorization"), header) {
+					t.Errorf("Authorization = %q, want %s signed", req.Header.Get("Authorization"), header)
+				}
+			}
+			verifySignature(t, req, []byte(tt.payload), tt.credentials.SecretAccessKey, tt.region)
+		})
+	}
+}
+
+func TestAWSRegion(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretID   string
+		configured string
+		env        string
+		want       string
+	}{
+		{name: "ARN region wins", secretID: "arn:aws:secretsmanager:eu-central-1:123456789012:secret:sm", configured: "us-east-1", want: "eu-central-1"},
+		{name: "configured region", secretID: "scrum-master/anthropic", configured: "us-west-2", env: "eu-west-1", want: "us-west-2"},
+		{name: "AWS_DEFAULT_REGION", secretID: "scrum-master", env: "eu-west-1", want: "eu-west-1"},
+		{name: "no region", secretID: "scrum-master", want: ""},
+		{name: "name with colons", secretID: "team:scrum-master", configured: "us-east-1", want: "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_DEFAULT_REGION", tt.env)
+			if got := awsRegion(tt.secretID, tt.configured); got != tt.want {
+				t.Errorf("awsRegion(%q, %q) = %q, want %q", tt.secretID, tt.configured, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSecretsManager(t *testing.T) {
+	const secretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+
+	secrets := map[string]string{
+		"plain": "sk-plain",
+		"json":  `{"anthropic":"sk-json","jira":"jira-token"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		verifySignature(t, r, payload, secretKey, "eu-west-1")
+		if r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("X-Amz-Security-Token = %q, want session", r.Header.Get("X-Amz-Security-Token"))
+		}
+
+		for id, secret := range secrets {
+			if bytes.Contains(payload, []byte(`"`+id+`"`)) {
+				w.Write([]byte(`{"SecretString":` + jsonString(secret) + `}`))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"ResourceNotFoundException"}`))
+	}))
+	defer server.Close()
+
+	// Credentials come from the environment only, and are resolved once for every test case
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", secretKey)
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_CA_BUNDLE", "")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+
+	tests := []struct {
+		name      string
+		reference string
+		want      string
+		wantErr   string
+	}{
+		{name: "secret string", reference: "plain", want: "sk-plain"},
+		{name: "field of a JSON secret", reference: "json#jira", want: "jira-token"},
+		{name: "missing field", reference: "json#confluence", wantErr: "no field 'confluence'"},
+		{name: "field of a plain secret", reference: "plain#anthropic", wantErr: "not a JSON object"},
+		{name: "unknown secret", reference: "missing", wantErr: "status 400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSecretsManager(server.Client(), HTTPConfig{}, tt.reference)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readSecretsManager(%q) error = %v, want %q", tt.reference, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readSecretsManager(%q) error = %v", tt.reference, err)
+			}
+			if got != tt.want {
+				t.Errorf("readSecretsManager(%q) = %q, want %q", tt.reference, got, tt.want)
+			}
+		})
+	}
+}
+
+// jsonString quotes a value as a JSON string
+func jsonString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,47 @@
+package demo
+
+import (
+	"embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+)
+
+// SampleFile is the file name of the bundled sample project description
+const SampleFile = "expenses-prd.md"
+
+//go:embed sample
+var sample embed.FS
+
+// Config returns the demo configuration, pointing the AI provider and JIRA at a demo server and
+// writing output to outputDir
+func Config(serverURL, outputDir string) (*config.Config, error) {
+	data, err := sample.ReadFile("sample/config.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read demo config: %w", err)
+	}
+
+	replacer := strings.NewReplacer("{{server}}", serverURL, "{{output_dir}}", outputDir)
+	return config.Parse([]byte(replacer.Replace(string(data))))
+}
+
+// WriteSample copies the sample project description to dir and returns its path
+func WriteSample(dir string) (string, error) {
+	data, err := sample.ReadFile("sample/" + SampleFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read sample project: %w", err)
+	}
+
+	if err := helpers.EnsureDir(dir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := filepath.Join(dir, SampleFile)
+	if err := helpers.WriteFile(path, string(data)); err != nil {
+		return "", fmt.Errorf("failed to write sample project: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,180 @@
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"scrum-master/internal/models"
+)
+
+// recording is an AI response replayed for prompts containing Match
+type recording struct {
+	Match    string          `json:"match"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Server answers the Ollama chat API with recorded responses and the JIRA endpoints the pipeline
+// uses with an in-memory project, so the demo runs offline
+type Server struct {
+	*httptest.Server
+
+	recordings []recording
+
+	mu     sync.Mutex
+	issues map[string]string // key -> summary
+	next   int
+}
+
+// NewServer starts a demo server on a local port. Close it when done.
+func NewServer() (*Server, error) {
+	data, err := sample.ReadFile("sample/responses.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded responses: %w", err)
+	}
+
+	s := &Server{issues: make(map[string]string), next: 1}
+	if err := json.Unmarshal(data, &s.recordings); err != nil {
+		return nil, fmt.Errorf("failed to parse recorded responses: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/chat", s.chat)
+	mux.HandleFunc("GET /rest/api/2/project", s.projects)
+	mux.HandleFunc("GET /rest/api/2/project/{key}", s.project)
+	mux.HandleFunc("GET /rest/api/2/issue/createmeta/{key}/issuetypes", s.issueTypes)
+	mux.HandleFunc("GET /rest/api/2/issue/createmeta/{key}/issuetypes/{id}", s.createFields)
+	mux.HandleFunc("POST /rest/api/2/issue", s.createIssue)
+	mux.HandleFunc("POST /rest/api/2/issue/bulk", s.createIssues)
+	mux.HandleFunc("PUT /rest/api/2/issue/{key}/properties/{property}", s.setProperty)
+	mux.HandleFunc("PUT /rest/agile/1.0/issue/rank", s.rankIssues)
+
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Created returns the number of issues created in the fake JIRA
+func (s *Server) Created() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.issues)
+}
+
+// chat replays the recording matching the last message of a chat request
+func (s *Server) chat(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Messages []models.ChatMessage `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid chat request"})
+		return
+	}
+
+	prompt := request.Messages[len(request.Messages)-1].Content
+	for _, recording := range s.recordings {
+		if strings.Contains(prompt, recording.Match) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"message": map[string]string{"role": "assistant", "content": string(recording.Response)},
+			})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "the demo has no recorded response for this prompt"})
+}
+
+func (s *Server) projects(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []models.JiraProjectInfo{demoProject})
+}
+
+func (s *Server) project(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("key") != demoProject.Key {
+		writeJSON(w, http.StatusNotFound, map[string][]string{"errorMessages": {"No project could be found"}})
+		return
+	}
+	writeJSON(w, http.StatusOK, demoProject)
+}
+
+func (s *Server) issueTypes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]models.JiraCreateMetaIssueType{"values": demoIssueTypes})
+}
+
+// createFields lists the create screen fields, which are the same for every issue type
+func (s *Server) createFields(w http.ResponseWriter, r *http.Request) {
+	priorities := []models.JiraFieldAllowedValue{{ID: "1", Name: "Highest"}, {ID: "2", Name: "High"}, {ID: "3", Name: "Medium"}, {ID: "4", Name: "Low"}, {ID: "5", Name: "Lowest"}}
+	writeJSON(w, http.StatusOK, map[string][]models.JiraCreateMetaField{"values": {
+		{FieldID: "summary", Name: "Summary", Required: true},
+		{FieldID: "description", Name: "Description"},
+		{FieldID: "priority", Name: "Priority", AllowedValues: priorities},
+	}})
+}
+
+func (s *Server) createIssue(w http.ResponseWriter, r *http.Request) {
+	var issue models.JiraIssue
+	if err := json.NewDecoder(r.Body).Decode(&issue); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"errorMessages": {"Invalid issue"}})
+		return
+	}
+
+	s.mu.Lock()
+	key := fmt.Sprintf("%s-%d", demoProject.Key, s.next)
+	s.next++
+	s.issues[key] = issue.Fields.Summary
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, models.JiraResponse{Key: key})
+}
+
+func (s *Server) createIssues(w http.ResponseWriter, r *http.Request) {
+	var bulk models.JiraBulkIssues
+	if err := json.NewDecoder(r.Body).Decode(&bulk); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string][]string{"errorMessages": {"Invalid issues"}})
+		return
+	}
+
+	s.mu.Lock()
+	var created models.JiraBulkResponse
+	for _, issue := range bulk.IssueUpdates {
+		key := fmt.Sprintf("%s-%d", demoProject.Key, s.next)
+		s.next++
+		s.issues[key] = issue.Fields.Summary
+		created.Issues = append(created.Issues, models.JiraResponse{Key: key})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) setProperty(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	_, exists := s.issues[r.PathValue("key")]
+	s.mu.Unlock()
+
+	if !exists {
+		writeJSON(w, http.StatusNotFound, map[string][]string{"errorMessages": {"Issue does not exist"}})
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// rankIssues accepts any backlog order, as the fake JIRA has no board to show it on
+func (s *Server) rankIssues(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// demoProject is the only project of the fake JIRA
+var demoProject = models.JiraProjectInfo{Key: "DEMO", Name: "Team Expenses (demo)", Style: "next-gen", Simplified: true}
+
+// demoIssueTypes are the issue types of the demo project
+var demoIssueTypes = []models.JiraCreateMetaIssueType{{ID: "1", Name: "Epic"}, {ID: "2", Name: "Task"}}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,277 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ADFNode is a node of an Atlassian Document Format document, the rich text format of the JIRA
+// Cloud REST API v3
+type ADFNode struct {
+	Type    string                 `json:"type"`
+	Version int                    `json:"version,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []ADFNode              `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []ADFMark              `json:"marks,omitempty"`
+}
+
+// ADFMark is the formatting of an ADF text node, such as strong or link
+type ADFMark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+var (
+	// adfBullet matches a bullet list item: "- ", "* " or "• "
+	adfBullet = regexp.MustCompile(`^\s*(?:[-*•])\s+(.*)$`)
+
+	// adfNumbered matches an ordered list item such as "1. " or "2) "
+	adfNumbered = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+
+	// adfExpand matches the opening line of a collapsed section: {expand:Title}
+	adfExpand = regexp.MustCompile(`^\{expand(?::([^}]*))?\}$`)
+
+	// adfInline matches inline formatting: **strong**, *strong* (JIRA wiki style), `code` and
+	// [text](url) links
+	adfInline = regexp.MustCompile("\\*\\*([^*]+)\\*\\*|\\*([^*\\s][^*]*?)\\*|`([^`]+)`|\\[([^\\]]+)\\]\\((https?://[^)\\s]+)\\)")
+)
+
+// MarkdownToADF converts a description written in markdown, or in the JIRA wiki markup
+// scrum-master generates, into an ADF document. Headings, bullet and numbered lists, code blocks,
+// {expand} sections, bold, inline code and links are kept; other text becomes paragraphs, with
+// line breaks kept inside a paragraph.
+func MarkdownToADF(text string) ADFNode {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n"), "\n")
+	content := adfBlocks(lines)
+	if len(content) == 0 {
+		content = []ADFNode{{Type: "paragraph"}}
+	}
+	return ADFNode{Type: "doc", Version: 1, Content: content}
+}
+
+// adfBlocks converts lines of text into ADF block nodes
+func adfBlocks(lines []string) []ADFNode {
+	var blocks []ADFNode
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, adfParagraph(paragraph))
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if match := adfExpand.FindStringSubmatch(trimmed); match != nil && match[1] != "" {
+			if end := adfClosing(lines[i+1:], "{expand}"); end >= 0 {
+				flush()
+				inner := adfBlocks(lines[i+1 : i+1+end])
+				if len(inner) == 0 {
+					inner = []ADFNode{{Type: "paragraph"}}
+				}
+				blocks = append(blocks, ADFNode{Type: "expand", Attrs: map[string]interface{}{"title": match[1]}, Content: inner})
+				i += end + 1
+				continue
+			}
+		}
+
+		if strings.HasPrefix(trimmed, "```") {
+			if end := adfClosing(lines[i+1:], "```"); end >= 0 {
+				flush()
+				code := ADFNode{Type: "codeBlock"}
+				if language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```")); language != "" {
+					code.Attrs = map[string]interface{}{"language": language}
+				}
+				if body := strings.Join(lines[i+1:i+1+end], "\n"); body != "" {
+					code.Content = []ADFNode{{Type: "text", Text: body}}
+				}
+				blocks = append(blocks, code)
+				i += end + 1
+				continue
+			}
+		}
+
+		if level, heading := parseHeading(trimmed); level > 0 {
+			flush()
+			blocks = append(blocks, ADFNode{Type: "heading", Attrs: map[string]interface{}{"level": level}, Content: adfInlineNodes(heading)})
+			continue
+		}
+
+		if list, pattern := adfListType(line); list != "" {
+			flush()
+			node := ADFNode{Type: list}
+			for ; i < len(lines); i++ {
+				match := pattern.FindStringSubmatch(lines[i])
+				if match == nil {
+					break
+				}
+				node.Content = append(node.Content, ADFNode{Type: "listItem", Content: []ADFNode{adfParagraph([]string{match[1]})}})
+			}
+			i--
+			blocks = append(blocks, node)
+			continue
+		}
+
+		paragraph = append(paragraph, trimmed)
+	}
+	flush()
+
+	return blocks
+}
+
+// adfListType returns the ADF list type a line starts, with the pattern of its items
+func adfListType(line string) (string, *regexp.Regexp) {
+	switch {
+	case adfBullet.MatchString(line):
+		return "bulletList", adfBullet
+	case adfNumbered.MatchString(line):
+		return "orderedList", adfNumbered
+	}
+	return "", nil
+}
+
+// adfClosing returns the index of the line closing a block, or -1
+func adfClosing(lines []string, marker string) int {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == marker {
+			return i
+		}
+	}
+	return -1
+}
+
+// adfParagraph converts lines into a paragraph with hard breaks between them
+func adfParagraph(lines []string) ADFNode {
+	paragraph := ADFNode{Type: "paragraph"}
+	for i, line := range lines {
+		if i > 0 {
+			paragraph.Content = append(paragraph.Content, ADFNode{Type: "hardBreak"})
+		}
+		paragraph.Content = append(paragraph.Content, adfInlineNodes(line)...)
+	}
+	return paragraph
+}
+
+// adfInlineNodes converts a line into text nodes with their formatting
+func adfInlineNodes(line string) []ADFNode {
+	var nodes []ADFNode
+	addText := func(text string, marks ...ADFMark) {
+		if text != "" {
+			nodes = append(nodes, ADFNode{Type: "text", Text: text, Marks: marks})
+		}
+	}
+
+	last := 0
+	for _, match := range adfInline.FindAllStringSubmatchIndex(line, -1) {
+		addText(line[last:match[0]])
+		last = match[1]
+
+		group := func(n int) string {
+			if match[2*n] < 0 {
+				return ""
+			}
+			return line[match[2*n]:match[2*n+1]]
+		}
+
+		switch {
+		case group(1) != "":
+			addText(group(1), ADFMark{Type: "strong"})
+		case group(2) != "":
+			addText(group(2), ADFMark{Type: "strong"})
+		case group(3) != "":
+			addText(group(3), ADFMark{Type: "code"})
+		default:
+			addText(group(4), ADFMark{Type: "link", Attrs: map[string]interface{}{"href": group(5)}})
+		}
+	}
+	addText(line[last:])
+
+	return nodes
+}
+
+// ADFToMarkup converts an ADF document back into the text markup MarkdownToADF reads, so a
+// description read from API v3 can be shown and compared with a generated one. Nodes without a
+// text form, such as media, are left out.
+func ADFToMarkup(node ADFNode) string {
+	switch node.Type {
+	case "text":
+		return adfMarkText(node)
+	case "hardBreak":
+		return "\n"
+	case "mention", "emoji", "status":
+		if text, ok := node.Attrs["text"].(string); ok {
+			return text
+		}
+		return ""
+	case "paragraph":
+		return adfJoin(node.Content, "")
+	case "heading":
+		level := 1
+		if l, ok := node.Attrs["level"].(float64); ok {
+			level = int(l)
+		} else if l, ok := node.Attrs["level"].(int); ok {
+			level = l
+		}
+		return strings.Repeat("#", level) + " " + adfJoin(node.Content, "")
+	case "bulletList", "orderedList":
+		var items []string
+		for i, item := range node.Content {
+			marker := "• "
+			if node.Type == "orderedList" {
+				marker = fmt.Sprintf("%d. ", i+1)
+			}
+			items = append(items, marker+adfJoin(item.Content, " "))
+		}
+		return strings.Join(items, "\n")
+	case "codeBlock":
+		language, _ := node.Attrs["language"].(string)
+		return "```" + language + "\n" + adfJoin(node.Content, "") + "\n```"
+	case "expand", "nestedExpand":
+		title, _ := node.Attrs["title"].(string)
+		return "{expand:" + title + "}\n" + adfJoin(node.Content, "\n\n") + "\n{expand}"
+	case "doc", "blockquote", "panel", "listItem", "tableCell", "tableHeader":
+		return adfJoin(node.Content, "\n\n")
+	case "table", "tableRow":
+		return adfJoin(node.Content, "\n")
+	}
+	return adfJoin(node.Content, "")
+}
+
+// adfJoin converts child nodes and joins them with sep, skipping empty ones
+func adfJoin(nodes []ADFNode, sep string) string {
+	var parts []string
+	for _, node := range nodes {
+		if text := ADFToMarkup(node); text != "" || sep == "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// adfMarkText renders a text node with its formatting
+func adfMarkText(node ADFNode) string {
+	text := node.Text
+	for _, mark := range node.Marks {
+		switch mark.Type {
+		case "strong":
+			text = "*" + text + "*"
+		case "code":
+			text = "`" + text + "`"
+		case "link":
+			if href, ok := mark.Attrs["href"].(string); ok {
+				text = "[" + text + "](" + href + ")"
+			}
+		}
+	}
+	return text
+}
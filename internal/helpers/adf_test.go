@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarkdownToADF(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // Document as JSON
+	}{
+		{
+			name: "empty text",
+			text: "  \n",
+			want: `{"type":"doc","version":1,"content":[{"type":"paragraph"}]}`,
+		},
+		{
+			name: "paragraphs with line breaks",
+			text: "As a buyer\r\nI pay by card\n\nSo that I save time",
+			want: `{"type":"doc","version":1,"content":[` +
+				`{"type":"paragraph","content":[{"type":"text","text":"As a buyer"},{"type":"hardBreak"},{"type":"text","text":"I pay by card"}]},` +
+				`{"type":"paragraph","content":[{"type":"text","text":"So that I save time"}]}]}`,
+		},
+		{
+			name: "inline formatting",
+			text: "**Pay** *now* with `card` at [the shop](https://example.com/shop)",
+			want: `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[` +
+				`{"type":"text","text":"Pay","marks":[{"type":"strong"}]},{"type":"text","text":" "},` +
+				`{"type":"text","text":"now","marks":[{"type":"strong"}]},{"type":"text","text":" with "},` +
+				`{"type":"text","text":"card","marks":[{"type":"code"}]},{"type":"text","text":" at "},` +
+				`{"type":"text","text":"the shop","marks":[{"type":"link","attrs":{"href":"https://example.com/shop"}}]}]}]}`,
+		},
+		{
+			name: "headings and lists",
+			text: "## Acceptance Criteria\n- Card accepted\n* Receipt sent\n1. Order\n2) Pay",
+			want: `{"type":"doc","version":1,"content":[` +
+				`{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Acceptance Criteria"}]},` +
+				`{"type":"bulletList","content":[` +
+				`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Card accepted"}]}]},` +
+				`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Receipt sent"}]}]}]},` +
+				`{"type":"orderedList","content":[` +
+				`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Order"}]}]},` +
+				`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Pay"}]}]}]}]}`,
+		},
+		{
+			name: "code block kept verbatim",
+			text: "```go\nif a*b == c {\n  **x**\n}\n```",
+			want: `{"type":"doc","version":1,"content":[{"type":"codeBlock","attrs":{"language":"go"},"content":[{"type":"text","text":"if a*b == c {\n  **x**\n}"}]}]}`,
+		},
+		{
+			name: "unclosed code block is text",
+			text: "```go\nfmt.Println()",
+			want: `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"` + "```go" + `"},{"type":"hardBreak"},{"type":"text","text":"fmt.Println()"}]}]}`,
+		},
+		{
+			name: "expand section",
+			text: "{expand:Test Cases}\n- Pay by card\n{expand}\nDone",
+			want: `{"type":"doc","version":1,"content":[` +
+				`{"type":"expand","attrs":{"title":"Test Cases"},"content":[{"type":"bulletList","content":[` +
+				`{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"Pay by card"}]}]}]}]},` +
+				`{"type":"paragraph","content":[{"type":"text","text":"Done"}]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(MarkdownToADF(tt.text))
+			if err != nil {
+				t.Fatalf("failed to marshal document: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarkdownToADF(%q) =\n%s\nwant\n%s", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	annotationPattern = regexp.MustCompile(`(?s)<!--\s*scrum-master:(.*?)-->`)
+	attributePattern  = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|(\S+))`)
+)
+
+// Annotation represents an inline <!-- scrum-master: key=value --> directive and the markdown
+// section it appears in. Section is empty for annotations before the first heading.
+type Annotation struct {
+	Section    string
+	Attributes map[string]string
+}
+
+// ParseAnnotations finds all scrum-master annotations in a markdown document. Attribute
+// names are lower-cased; values may be bare or quoted.
+func ParseAnnotations(content string) []Annotation {
+	var annotations []Annotation
+
+	for _, section := range SplitMarkdownSections(content) {
+		for _, match := range annotationPattern.FindAllStringSubmatch(section.Content, -1) {
+			attributes := make(map[string]string)
+			for _, attr := range attributePattern.FindAllStringSubmatch(match[1], -1) {
+				value := attr[2] + attr[3] + attr[4]
+				attributes[strings.ToLower(attr[1])] = value
+			}
+
+			if len(attributes) > 0 {
+				annotations = append(annotations, Annotation{
+					Section:    section.Heading,
+					Attributes: attributes,
+				})
+			}
+		}
+	}
+
+	return annotations
+}
@@ -0,0 +1,172 @@
+package helpers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveEntry is a file to store in an archive under Name, read from Path or, when Path is
+// empty, taken from Data
+type ArchiveEntry struct {
+	Name string
+	Path string
+	Data []byte
+}
+
+// CreateTarGz writes the named files, relative to baseDir, into a new gzipped tar archive.
+// It fails rather than overwrite an existing archive.
+func CreateTarGz(archivePath, baseDir string, files []string) error {
+	var entries []ArchiveEntry
+	for _, name := range files {
+		entries = append(entries, ArchiveEntry{Name: name, Path: filepath.Join(baseDir, name)})
+	}
+	return WriteTarGz(archivePath, entries)
+}
+
+// WriteTarGz writes entries into a new gzipped tar archive. It fails rather than overwrite an
+// existing archive.
+func WriteTarGz(archivePath string, entries []ArchiveEntry) error {
+	out, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	for _, entry := range entries {
+		var err error
+		if entry.Path != "" {
+			err = addFileToTar(tw, entry.Name, entry.Path)
+		} else {
+			err = addDataToTar(tw, entry.Name, entry.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return out.Close()
+}
+
+// addFileToTar copies a single file into a tar archive
+func addFileToTar(tw *tar.Writer, name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("failed to build archive header for %s: %w", name, err)
+	}
+	header.Name = filepath.ToSlash(name)
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
+
+// addDataToTar writes in-memory content into a tar archive as a regular file
+func addDataToTar(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    filepath.ToSlash(name),
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
+
+// ExtractTarGz extracts the regular files of a gzipped tar archive into destDir and returns their
+// names. Existing files are skipped unless overwrite is set. Entries that would escape destDir
+// are rejected.
+func ExtractTarGz(archivePath, destDir string, overwrite bool) (extracted, skipped []string, err error) {
+	in, err := os.Open(archivePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer in.Close()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return nil, nil, fmt.Errorf("archive entry '%s' escapes the destination directory", header.Name)
+		}
+
+		target := filepath.Join(destDir, name)
+		if FileExists(target) && !overwrite {
+			skipped = append(skipped, header.Name)
+			continue
+		}
+
+		if err := EnsureDir(filepath.Dir(target)); err != nil {
+			return nil, nil, err
+		}
+
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create %s: %w", header.Name, err)
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return nil, nil, fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+		if err := out.Close(); err != nil {
+			return nil, nil, fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+
+		extracted = append(extracted, header.Name)
+	}
+
+	return extracted, skipped, nil
+}
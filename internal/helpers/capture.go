@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// colorCodes matches the terminal escape codes of colored output
+var colorCodes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// syncBuffer is a buffer several writers can share
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// CaptureInput copies everything read from stdin, such as answers to prompts, while still
+// passing it on. The returned function stops capturing and returns what was read so far.
+func CaptureInput() (func() []byte, error) {
+	input := &syncBuffer{}
+	stdin := os.Stdin
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		io.Copy(writer, io.TeeReader(stdin, input))
+		writer.Close()
+	}()
+	os.Stdin = reader
+
+	return func() []byte {
+		os.Stdin = stdin
+
+		input.mu.Lock()
+		defer input.mu.Unlock()
+		return bytes.Clone(input.buf.Bytes())
+	}, nil
+}
+
+// CaptureOutput copies everything printed to stdout and stderr into a log while still showing
+// it. The returned function stops capturing and returns the log without color codes.
+func CaptureOutput() (func() []byte, error) {
+	log := &syncBuffer{}
+	stdout, stderr := os.Stdout, os.Stderr
+	colorOutput, colorError := color.Output, color.Error
+
+	var copiers sync.WaitGroup
+	tee := func(target *os.File) (*os.File, error) {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+
+		copiers.Add(1)
+		go func() {
+			defer copiers.Done()
+			io.Copy(io.MultiWriter(target, log), reader)
+			reader.Close()
+		}()
+		return writer, nil
+	}
+
+	stdoutPipe, err := tee(stdout)
+	if err != nil {
+		return nil, err
+	}
+	stderrPipe, err := tee(stderr)
+	if err != nil {
+		stdoutPipe.Close()
+		return nil, err
+	}
+
+	// Colored output goes through the same pipes, so the log keeps the order it was printed in
+	os.Stdout, os.Stderr = stdoutPipe, stderrPipe
+	color.Output, color.Error = stdoutPipe, stderrPipe
+	if colorOutput == colorError {
+		color.Output = stderrPipe
+	}
+
+	return func() []byte {
+		os.Stdout, os.Stderr = stdout, stderr
+		color.Output, color.Error = colorOutput, colorError
+		stdoutPipe.Close()
+		stderrPipe.Close()
+		copiers.Wait()
+
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		return colorCodes.ReplaceAll(log.buf.Bytes(), nil)
+	}, nil
+}
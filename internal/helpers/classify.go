@@ -0,0 +1,217 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of input documents
+const (
+	DocumentProject = "project description"
+	DocumentEmpty   = "empty or very short text"
+	DocumentBinary  = "binary file"
+	DocumentLog     = "log file"
+	DocumentCode    = "source code"
+	DocumentData    = "structured data"
+	DocumentNotes   = "notes"
+)
+
+const (
+	// minProjectWords is the fewest words a project description can have
+	minProjectWords = 20
+
+	// minNotesWords is the fewest words needed to tell notes from a short description
+	minNotesWords = 50
+)
+
+var (
+	// logLine matches lines starting with a timestamp or log level, stack frames and access log
+	// entries
+	logLine = regexp.MustCompile(`^\s*\[?(?:\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}|\d{2}:\d{2}:\d{2}[.,]\d+|[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2})` +
+		`|^\s*\[?(?:TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|CRITICAL)\]?[\s:]` +
+		`|^\s+at [\w$.<>]+\(|^goroutine \d+ \[|^Traceback \(most recent call last\)|^\s+File ".*", line \d+` +
+		`|^\d{1,3}(?:\.\d{1,3}){3} - \S+ \[`)
+
+	// codeLine matches lines that read as source code: comments, declarations, imports, struct
+	// tags and lines ending in statement or block punctuation
+	codeLine = regexp.MustCompile(`^\s*(?://|/\*|#!)|^\s*(?:package|import|func|def|class|public|private|protected|static|const|let|var|return|fn|struct|using|namespace|#include)\b` +
+		"|[;{]\\s*$|^\\s*[})\\]]+[;,)]*\\s*$|`(?:json|yaml|xml|db):\"")
+
+	// projectWords are words project descriptions use; notes without any of them are not one
+	projectWords = map[string]bool{
+		"user": true, "users": true, "customer": true, "customers": true, "feature": true,
+		"features": true, "should": true, "must": true, "need": true, "needs": true, "able": true,
+		"allow": true, "allows": true, "support": true, "build": true, "create": true,
+		"system": true, "app": true, "application": true, "service": true, "requirement": true,
+		"requirements": true, "goal": true, "goals": true, "project": true, "product": true,
+		"api": true, "page": true, "screen": true, "integrate": true, "implement": true,
+		"manage": true, "platform": true, "deliver": true, "mvp": true, "scope": true,
+	}
+
+	// englishWords are common words that tell English prose apart
+	englishWords = []string{"the", "and", "to", "of", "is", "in", "for", "with", "we", "it"}
+)
+
+// DocumentClass is the result of classifying an input document. Reason explains why a document
+// is not a project description.
+type DocumentClass struct {
+	Kind   string
+	Reason string
+}
+
+// IsProjectDescription reports whether the document may be a project description
+func (c DocumentClass) IsProjectDescription() bool {
+	return c.Kind == DocumentProject
+}
+
+// ClassifyDocument tells whether text looks like a project or requirements document, or is
+// clearly something else such as a log file, source code, data or unrelated notes. Code blocks
+// inside a markdown document are not counted, so documents with examples are not rejected.
+func ClassifyDocument(content string) DocumentClass {
+	if strings.ContainsRune(content, 0) || !utf8.ValidString(content) {
+		return DocumentClass{Kind: DocumentBinary, Reason: "the file contains binary data, not text"}
+	}
+
+	_, body := SplitFrontMatter(content)
+	body = annotationPattern.ReplaceAllString(body, "")
+	trimmed := strings.TrimSpace(body)
+
+	words := documentWords(trimmed)
+	if len(words) < minProjectWords {
+		return DocumentClass{Kind: DocumentEmpty, Reason: fmt.Sprintf("at %d words it is too short to describe a project", len(words))}
+	}
+
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return DocumentClass{Kind: DocumentData, Reason: "the file is JSON data"}
+	}
+
+	lines := proseLines(trimmed)
+	if len(lines) > 0 {
+		if count, example := countMatching(lines, logLine.MatchString); count*2 >= len(lines) {
+			return DocumentClass{Kind: DocumentLog, Reason: fmt.Sprintf("%d of %d lines look like log entries, such as %q", count, len(lines), example)}
+		}
+		if count, example := countMatching(lines, codeLine.MatchString); count*2 >= len(lines) {
+			return DocumentClass{Kind: DocumentCode, Reason: fmt.Sprintf("%d of %d lines look like source code, such as %q", count, len(lines), example)}
+		}
+		if isDelimitedData(lines) {
+			return DocumentClass{Kind: DocumentData, Reason: fmt.Sprintf("its %d lines are comma- or tab-separated records", len(lines))}
+		}
+	}
+
+	if len(words) >= minNotesWords && isEnglish(words) && !hasProjectWords(words) {
+		return DocumentClass{Kind: DocumentNotes, Reason: "it never mentions users, features, requirements or anything else a project description talks about"}
+	}
+
+	return DocumentClass{Kind: DocumentProject}
+}
+
+// documentWords returns the lower-cased words of text that contain a letter
+func documentWords(text string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		if strings.IndexFunc(field, unicode.IsLetter) >= 0 {
+			words = append(words, strings.ToLower(field))
+		}
+	}
+	return words
+}
+
+// proseLines returns the non-empty lines of a document outside of fenced code blocks
+func proseLines(text string) []string {
+	var lines []string
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if !fenced && trimmed != "" {
+			lines = append(lines, strings.TrimRight(line, "\r"))
+		}
+	}
+	return lines
+}
+
+// countMatching counts the lines match accepts and returns the first of them, shortened
+func countMatching(lines []string, match func(string) bool) (int, string) {
+	count := 0
+	example := ""
+	for _, line := range lines {
+		if match(line) {
+			if count == 0 {
+				example = shorten(strings.TrimSpace(line), 60)
+			}
+			count++
+		}
+	}
+	return count, example
+}
+
+// isDelimitedData reports whether nearly all lines are records with the same number of comma
+// or tab separated fields
+func isDelimitedData(lines []string) bool {
+	if len(lines) < 5 {
+		return false
+	}
+
+	for _, separator := range []string{",", "\t"} {
+		fields := strings.Count(lines[0], separator)
+		if fields < 2 {
+			continue
+		}
+
+		matching := 0
+		for _, line := range lines {
+			if strings.Count(line, separator) == fields {
+				matching++
+			}
+		}
+		if matching*10 >= len(lines)*9 {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnglish reports whether words read as English prose, where the absence of project words
+// means something
+func isEnglish(words []string) bool {
+	seen := make(map[string]bool)
+	for _, word := range words {
+		seen[word] = true
+	}
+
+	common := 0
+	for _, word := range englishWords {
+		if seen[word] {
+			common++
+		}
+	}
+	return common >= 3
+}
+
+// hasProjectWords reports whether any of the words is one project descriptions use
+func hasProjectWords(words []string) bool {
+	for _, word := range words {
+		if projectWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// shorten cuts text to at most max runes, marking the cut with an ellipsis
+func shorten(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
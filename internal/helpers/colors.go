@@ -25,38 +25,81 @@ var (
 	TitleColor = color.New(color.FgMagenta, color.Bold)
 )
 
+// accessible replaces colors and emoji with textual status prefixes, see SetAccessible
+var accessible bool
+
+// SetAccessible switches terminal output to an accessible mode for screen readers: no colors or
+// emoji, a textual status prefix of fixed width on every line, progress as "N of M" and blank
+// lines instead of separator rules
+func SetAccessible(enabled bool) {
+	accessible = enabled
+	if enabled {
+		color.NoColor = true
+	}
+}
+
+// Accessible reports whether accessible output is enabled
+func Accessible() bool {
+	return accessible
+}
+
+// Symbol returns an emoji or other decorative symbol, or its plain text replacement in accessible mode
+func Symbol(symbol, text string) string {
+	if accessible {
+		return text
+	}
+	return symbol
+}
+
+// prefix returns the status marker of a message: an emoji, or in accessible mode a status word
+// padded so messages line up
+func prefix(emoji, status string) string {
+	if accessible {
+		return fmt.Sprintf("%-10s", status+":")
+	}
+	return emoji
+}
+
 // PrintSuccess prints a success message
 func PrintSuccess(format string, args ...interface{}) {
-	SuccessColor.Printf("✅ "+format+"\n", args...)
+	SuccessColor.Printf(prefix("✅ ", "SUCCESS")+format+"\n", args...)
 }
 
 // PrintError prints an error message
 func PrintError(format string, args ...interface{}) {
-	ErrorColor.Printf("❌ "+format+"\n", args...)
+	ErrorColor.Printf(prefix("❌ ", "ERROR")+format+"\n", args...)
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(format string, args ...interface{}) {
-	WarningColor.Printf("⚠️  "+format+"\n", args...)
+	WarningColor.Printf(prefix("⚠️  ", "WARN")+format+"\n", args...)
 }
 
 // PrintInfo prints an info message
 func PrintInfo(format string, args ...interface{}) {
-	InfoColor.Printf("ℹ️  "+format+"\n", args...)
+	InfoColor.Printf(prefix("ℹ️  ", "INFO")+format+"\n", args...)
 }
 
 // PrintTitle prints a title
 func PrintTitle(format string, args ...interface{}) {
-	TitleColor.Printf("🎯 "+format+"\n", args...)
+	TitleColor.Printf(prefix("🎯 ", "SECTION")+format+"\n", args...)
 }
 
 // PrintProgress prints a progress message
 func PrintProgress(current, total int, message string) {
+	if accessible {
+		InfoColor.Printf("%s%d of %d: %s\n", prefix("", "PROGRESS"), current, total, message)
+		return
+	}
 	InfoColor.Printf("📊 [%d/%d] %s\n", current, total, message)
 }
 
-// PrintSeparator prints a visual separator
+// PrintSeparator prints a visual separator, or a blank line in accessible mode
 func PrintSeparator() {
+	if accessible {
+		fmt.Println()
+		return
+	}
 	fmt.Println(strings.Repeat("─", 80))
 }
 
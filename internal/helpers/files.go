@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -44,9 +45,12 @@ func EnsureDir(dir string) error {
 	return nil
 }
 
+// timestampLayout is the time format used in output filenames
+const timestampLayout = "20060102-150405"
+
 // GenerateTimestamp generates a timestamp string
 func GenerateTimestamp() string {
-	return time.Now().Format("20060102-150405")
+	return time.Now().Format(timestampLayout)
 }
 
 // GenerateOutputFilename generates a filename with timestamp
@@ -55,6 +59,30 @@ func GenerateOutputFilename(prefix, extension string) string {
 	return fmt.Sprintf("%s-%s.%s", prefix, timestamp, extension)
 }
 
+// ParseOutputTimestamp returns the timestamp embedded in a filename created by
+// GenerateOutputFilename, or false if the name has none. Files derived from an output file by
+// adding an extension, such as signatures, share its timestamp.
+func ParseOutputTimestamp(filename string) (string, bool) {
+	stem := filename
+	for ext := filepath.Ext(stem); ext != ""; ext = filepath.Ext(stem) {
+		stem = strings.TrimSuffix(stem, ext)
+	}
+	if len(stem) < len(timestampLayout)+1 || stem[len(stem)-len(timestampLayout)-1] != '-' {
+		return "", false
+	}
+
+	timestamp := stem[len(stem)-len(timestampLayout):]
+	if _, err := time.Parse(timestampLayout, timestamp); err != nil {
+		return "", false
+	}
+	return timestamp, true
+}
+
+// ParseTimestamp converts a timestamp created by GenerateTimestamp back into a local time
+func ParseTimestamp(timestamp string) (time.Time, error) {
+	return time.ParseInLocation(timestampLayout, timestamp, time.Local)
+}
+
 // GetOutputPath generates a full output path
 func GetOutputPath(outputDir, filename string) string {
 	return filepath.Join(outputDir, filename)
@@ -66,6 +94,14 @@ func FileExists(filepath string) bool {
 	return !os.IsNotExist(err)
 }
 
+// WriteFile writes text content to a file
+func WriteFile(filepath, content string) error {
+	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
 // ReadFile reads the entire contents of a file
 func ReadFile(filepath string) (string, error) {
 	data, err := os.ReadFile(filepath)
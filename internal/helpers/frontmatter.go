@@ -0,0 +1,22 @@
+package helpers
+
+import "strings"
+
+// SplitFrontMatter separates a leading YAML front-matter block delimited by "---" lines from
+// the rest of a markdown document. It returns an empty front matter if the document has none.
+func SplitFrontMatter(content string) (frontMatter, body string) {
+	normalized := strings.TrimPrefix(content, "\ufeff")
+	if !strings.HasPrefix(normalized, "---\n") && !strings.HasPrefix(normalized, "---\r\n") {
+		return "", content
+	}
+
+	lines := strings.SplitAfter(normalized, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[1:i], ""), strings.Join(lines[i+1:], "")
+		}
+	}
+
+	// An unterminated block is not front matter
+	return "", content
+}
@@ -0,0 +1,23 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitDiff returns the output of `git diff` for the given revision range
+func GitDiff(revRange string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", "diff", revRange)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git diff failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlIgnored matches comments, the doctype and the elements without text: scripts, styles,
+	// templates, graphics and frames. They are removed before parsing, as scripts may hold markup
+	// the parser cannot read.
+	htmlIgnored = regexp.MustCompile(`(?is)<!--.*?-->|<!doctype[^>]*>|<(script|style|noscript|template|svg|iframe)\b.*?</(script|style|noscript|template|svg|iframe)\s*>`)
+
+	// htmlNamespace matches namespace declarations, which would make the parser qualify the names
+	// of elements
+	htmlNamespace = regexp.MustCompile(`(?i)\sxmlns(:[\w-]+)?\s*=\s*("[^"]*"|'[^']*')`)
+
+	// htmlChromeName matches the classes and IDs of page chrome: navigation, menus, sidebars,
+	// footers, breadcrumbs and cookie banners
+	htmlChromeName = regexp.MustCompile(`(?i)^((site|page|global|main|top)[-_])?(nav|navbar|navigation|menu|sidebar|footer|breadcrumbs?|cookie[-_]?(banner|consent|notice)|skip[-_]link)$`)
+
+	// htmlChromeRoles are the ARIA roles of page chrome
+	htmlChromeRoles = map[string]bool{"navigation": true, "banner": true, "contentinfo": true, "complementary": true, "search": true}
+
+	// htmlSpace matches the whitespace of HTML text, which browsers show as one space
+	htmlSpace = regexp.MustCompile(`\s+`)
+)
+
+// HTMLToMarkdown extracts the text of a web page as markdown for analysis. Only the main content
+// is kept: the main element of the page, or its only article, or else its body without the site
+// header. Navigation, sidebars, footers, form controls and hidden elements are left out, and the
+// rest is converted like a Confluence page. It returns the title of the page and the markdown,
+// which starts with the title as top heading when the content has none.
+func HTMLToMarkdown(page string) (string, string, error) {
+	page = htmlIgnored.ReplaceAllString(page, "")
+	page = htmlNamespace.ReplaceAllString(page, "")
+	root, err := parseStorage(page, xml.HTMLAutoClose)
+	if err != nil {
+		return "", "", err
+	}
+
+	title := ""
+	if titles := htmlFind(root, func(node *storageNode) bool { return node.name == "title" }); len(titles) > 0 {
+		title = strings.Join(strings.Fields(storageRawText(titles[0])), " ")
+	}
+
+	content := htmlContent(root)
+	htmlClean(content, content.name != "body" && content != root, false)
+	blocks := storageBlocks(content.children)
+	if len(blocks) == 0 {
+		return "", "", fmt.Errorf("the page has no text content")
+	}
+
+	if headings := htmlFind(content, func(node *storageNode) bool { return node.name == "h1" }); len(headings) > 0 {
+		return storageInlineText(headings[0].children), strings.Join(blocks, "\n\n") + "\n", nil
+	}
+	if title != "" {
+		blocks = append([]string{"# " + title}, blocks...)
+	}
+	return title, strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+// htmlContent returns the element holding the main content of a page
+func htmlContent(root *storageNode) *storageNode {
+	if main := htmlFind(root, func(node *storageNode) bool { return node.name == "main" || node.attrs["role"] == "main" }); len(main) > 0 {
+		return main[0]
+	}
+	if articles := htmlFind(root, func(node *storageNode) bool { return node.name == "article" }); len(articles) == 1 {
+		return articles[0]
+	}
+	if body := htmlFind(root, func(node *storageNode) bool { return node.name == "body" }); len(body) > 0 {
+		return body[0]
+	}
+	return root
+}
+
+// htmlFind returns the outermost elements below a node that match
+func htmlFind(node *storageNode, match func(*storageNode) bool) []*storageNode {
+	var found []*storageNode
+	for _, child := range node.children {
+		if child.name == "" {
+			continue
+		}
+		if match(child) {
+			found = append(found, child)
+			continue
+		}
+		found = append(found, htmlFind(child, match)...)
+	}
+	return found
+}
+
+// htmlClean removes the page chrome below a node, and headers unless keepHeaders, which hold the
+// title of a main element or article but the site name and menu of a body. Whitespace in text
+// becomes one space, as browsers show it, except in preformatted text.
+func htmlClean(node *storageNode, keepHeaders, preformatted bool) {
+	kept := node.children[:0]
+	for _, child := range node.children {
+		if child.name == "" {
+			if !preformatted {
+				child.text = htmlSpace.ReplaceAllString(child.text, " ")
+			}
+			kept = append(kept, child)
+			continue
+		}
+		if htmlIsChrome(child, keepHeaders) {
+			continue
+		}
+		htmlClean(child, keepHeaders, preformatted || child.name == "pre")
+		kept = append(kept, child)
+	}
+	node.children = kept
+}
+
+// htmlIsChrome reports whether an element is page chrome rather than content
+func htmlIsChrome(node *storageNode, keepHeaders bool) bool {
+	switch node.name {
+	case "head", "nav", "aside", "footer", "menu", "dialog", "button", "label", "select", "textarea":
+		return true
+	case "header":
+		return !keepHeaders
+	}
+
+	if _, hidden := node.attrs["hidden"]; hidden || node.attrs["aria-hidden"] == "true" || htmlChromeRoles[node.attrs["role"]] {
+		return true
+	}
+	for _, name := range strings.Fields(node.attrs["class"] + " " + node.attrs["id"]) {
+		if htmlChromeName.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package helpers
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      string
+		wantTitle string
+		want      string
+	}{
+		{
+			name: "main element kept without chrome",
+			page: `<!DOCTYPE html><html><head><title>Checkout | Shop</title><style>p { color: red }</style></head>
+<body><header><a href="/">Shop</a></header><nav><ul><li>Home</li></ul></nav>
+<main><h2>Payment</h2><p>Pay by <b>card</b>.</p><aside>Related links</aside></main>
+<footer>Imprint</footer></body></html>`,
+			wantTitle: "Checkout | Shop",
+			want:      "# Checkout | Shop\n\n## Payment\n\nPay by **card**.\n",
+		},
+		{
+			name:      "first heading used as title",
+			page:      `<html><head><title>Docs - Shop</title></head><body><article><header><h1>Refunds</h1></header><p>Within 14 days.</p></article></body></html>`,
+			wantTitle: "Refunds",
+			want:      "# Refunds\n\nWithin 14 days.\n",
+		},
+		{
+			name: "body without site header",
+			page: `<html><body><header><h1>Shop</h1></header><div class="site-nav">Menu</div>
+<div id="content"><h1>Orders</h1><p>Listed
+   by   date.</p></div><div class="cookie-banner">We use cookies</div></body></html>`,
+			wantTitle: "Orders",
+			want:      "# Orders\n\nListed by date.\n",
+		},
+		{
+			name: "scripts, comments and hidden elements removed",
+			page: `<body><main><script>if (a < b) { document.write("<p>x</p>") }</script><!-- <p>draft</p> -->
+<p hidden>Hidden</p><p aria-hidden="true">Icon</p><div role="navigation">Menu</div>
+<form><label>Email</label><input type="text"><button>Send</button></form><p>Visible</p></main></body>`,
+			want: "Visible\n",
+		},
+		{
+			name: "void elements and entities",
+			page: `<body><main><p>Fees &amp; taxes&nbsp;apply<br>per order<img src="a.png"></p><hr><p>&copy; terms</p></main></body>`,
+			want: "Fees & taxes apply\nper order\n\n---\n\n© terms\n",
+		},
+		{
+			name: "preformatted text and namespaces",
+			page: `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Setup</title></head><body><main><pre>make  build
+make test</pre></main></body></html>`,
+			wantTitle: "Setup",
+			want:      "# Setup\n\n```\nmake  build\nmake test\n```\n",
+		},
+		{
+			name: "lists and tables",
+			page: `<body><main><ul><li>Cards<ul><li>Visa</li></ul></li><li>Invoice</li></ul>
+<table><thead><tr><th>Field</th><th>Rule</th></tr></thead><tbody><tr><td>Email</td><td>a | b</td></tr></tbody></table></main></body>`,
+			want: "- Cards\n  - Visa\n- Invoice\n\n| Field | Rule |\n|---|---|\n| Email | a \\| b |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, got, err := HTMLToMarkdown(tt.page)
+			if err != nil {
+				t.Fatalf("HTMLToMarkdown() error = %v", err)
+			}
+			if title != tt.wantTitle {
+				t.Errorf("HTMLToMarkdown() title = %q, want %q", title, tt.wantTitle)
+			}
+			if got != tt.want {
+				t.Errorf("HTMLToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToMarkdownWithoutContent(t *testing.T) {
+	page := `<html><head><title>Empty</title></head><body><nav>Menu</nav><footer>Imprint</footer></body></html>`
+	if _, _, err := HTMLToMarkdown(page); err == nil {
+		t.Errorf("HTMLToMarkdown() error = nil, want an error for a page without content")
+	}
+}
@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// randomIDBytes is the number of random bytes in an ID from RandomID
+const randomIDBytes = 12
+
+// RandomID returns a random hex ID that is hard to guess, such as the ID of an upload
+func RandomID() (string, error) {
+	id := make([]byte, randomIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// IsRandomID reports whether id could have come from RandomID, so it is safe to use in a path
+func IsRandomID(id string) bool {
+	decoded, err := hex.DecodeString(id)
+	return err == nil && len(decoded) == randomIDBytes
+}
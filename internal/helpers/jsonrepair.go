@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// maxRepairTruncations is how many trailing elements RepairJSON may drop from truncated output
+const maxRepairTruncations = 20
+
+// RepairJSON attempts a lenient repair of malformed JSON produced by a language model: it strips
+// text around the JSON value, removes trailing commas and closes truncated strings, arrays and
+// objects, dropping a partially written trailing element if needed. It returns the repaired
+// JSON and whether the result is valid.
+func RepairJSON(input string) (string, bool) {
+	start := strings.IndexAny(input, "{[")
+	if start < 0 {
+		return input, false
+	}
+	candidate := input[start:]
+
+	for attempt := 0; attempt <= maxRepairTruncations; attempt++ {
+		repaired := closeJSON(candidate)
+		if json.Valid([]byte(repaired)) {
+			return repaired, true
+		}
+
+		// Drop the last (probably partial) element and try again
+		cut := strings.LastIndex(candidate, ",")
+		if cut <= 0 {
+			break
+		}
+		candidate = candidate[:cut]
+	}
+
+	return input, false
+}
+
+// closeJSON removes trailing commas and closes any open strings, arrays and objects
+func closeJSON(input string) string {
+	var out strings.Builder
+	var stack []byte
+	inString := false
+	escaped := false
+	depthZeroEnd := -1
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+
+		if inString {
+			out.WriteByte(c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack = append(stack, c)
+		case '}', ']':
+			trimTrailingComma(&out)
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		out.WriteByte(c)
+
+		// Ignore anything after the top-level value is complete
+		if len(stack) == 0 && (c == '}' || c == ']') {
+			depthZeroEnd = out.Len()
+			break
+		}
+	}
+
+	result := out.String()
+	if depthZeroEnd >= 0 {
+		return result[:depthZeroEnd]
+	}
+
+	if inString {
+		result += `"`
+	}
+
+	result = strings.TrimRight(result, " \t\r\n")
+	result = strings.TrimSuffix(result, ",")
+	if strings.HasSuffix(result, ":") {
+		result += "null"
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			result += "}"
+		} else {
+			result += "]"
+		}
+	}
+
+	return result
+}
+
+// trimTrailingComma removes a comma (and whitespace after it) at the end of the builder
+func trimTrailingComma(out *strings.Builder) {
+	current := out.String()
+	trimmed := strings.TrimRight(current, " \t\r\n")
+	if strings.HasSuffix(trimmed, ",") {
+		out.Reset()
+		out.WriteString(strings.TrimSuffix(trimmed, ","))
+	}
+}
@@ -0,0 +1,277 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MarkdownSection represents a heading and the lines up to the next heading
+type MarkdownSection struct {
+	Heading string
+	Level   int
+	Content string
+	Number  string // Outline number such as "3.2", set by NumberSections
+}
+
+// SplitMarkdownSections splits markdown into sections at every heading. Text before the first
+// heading is returned as a section with level 0. Headings inside fenced code blocks are ignored.
+func SplitMarkdownSections(content string) []MarkdownSection {
+	var sections []MarkdownSection
+	current := MarkdownSection{}
+	var body strings.Builder
+	inFence := false
+
+	flush := func() {
+		current.Content = body.String()
+		if current.Level > 0 || strings.TrimSpace(current.Content) != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		if !inFence {
+			if level, heading := parseHeading(trimmed); level > 0 {
+				flush()
+				current = MarkdownSection{Heading: heading, Level: level}
+			}
+		}
+
+		body.WriteString(line)
+	}
+	flush()
+
+	return sections
+}
+
+// NumberSections gives the sections of a document outline numbers: "3" for the third top-level
+// section and "3.2" for the second subsection of it, whatever the level of its heading. A
+// document title, the only heading of the highest level, is not numbered, nor is text before the
+// first heading.
+func NumberSections(sections []MarkdownSection) {
+	top, count := 0, 0
+	for _, section := range sections {
+		switch {
+		case section.Level == 0:
+		case top == 0 || section.Level < top:
+			top, count = section.Level, 1
+		case section.Level == top:
+			count++
+		}
+	}
+
+	// Number below the title, if anything is
+	if count == 1 {
+		next := 0
+		for _, section := range sections {
+			if section.Level > top && (next == 0 || section.Level < next) {
+				next = section.Level
+			}
+		}
+		if next > 0 {
+			top = next
+		}
+	}
+
+	// Every section is numbered within its nearest enclosing section, so a heading that skips a
+	// level gets "1.1" rather than "1.0.1"
+	type outline struct{ level, number, children int }
+	var open []outline
+	topSections := 0
+	for i := range sections {
+		level := sections[i].Level
+		if level == 0 || level < top {
+			continue
+		}
+		for len(open) > 0 && open[len(open)-1].level >= level {
+			open = open[:len(open)-1]
+		}
+
+		var number int
+		if len(open) == 0 {
+			topSections++
+			number = topSections
+		} else {
+			open[len(open)-1].children++
+			number = open[len(open)-1].children
+		}
+		open = append(open, outline{level: level, number: number})
+
+		parts := make([]string, len(open))
+		for j, parent := range open {
+			parts[j] = strconv.Itoa(parent.number)
+		}
+		sections[i].Number = strings.Join(parts, ".")
+	}
+}
+
+// FilterMarkdownSections keeps only sections whose heading (or a parent heading) is in include,
+// and drops sections whose heading (or a parent heading) is in exclude. Headings are matched
+// case-insensitively. An empty include list keeps everything not excluded.
+// It returns the filtered content and the headings of the sections that were skipped.
+func FilterMarkdownSections(content string, include, exclude []string) (string, []string) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return content, nil
+	}
+
+	includeSet := headingSet(include)
+	excludeSet := headingSet(exclude)
+
+	type ancestor struct {
+		level    int
+		included bool
+		excluded bool
+	}
+	var stack []ancestor
+
+	var result strings.Builder
+	var skipped []string
+
+	for _, section := range SplitMarkdownSections(content) {
+		for len(stack) > 0 && stack[len(stack)-1].level >= section.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		state := ancestor{level: section.Level, included: len(includeSet) == 0}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			state.included = state.included || parent.included
+			state.excluded = parent.excluded
+		}
+
+		key := strings.ToLower(section.Heading)
+		if includeSet[key] {
+			state.included = true
+		}
+		if excludeSet[key] {
+			state.excluded = true
+		}
+
+		if section.Level > 0 {
+			stack = append(stack, state)
+		}
+
+		if state.included && !state.excluded {
+			result.WriteString(section.Content)
+		} else if section.Level > 0 {
+			skipped = append(skipped, section.Heading)
+		}
+	}
+
+	return result.String(), skipped
+}
+
+// ChunkMarkdown splits markdown into chunks of at most maxTokens estimated tokens at # and ##
+// heading boundaries, keeping sections (with their ### subsections) intact. Sections larger
+// than maxTokens fall back to ChunkByTokens with overlapTokens of shared context.
+func ChunkMarkdown(content string, maxTokens, overlapTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(content) <= maxTokens {
+		return []string{content}
+	}
+
+	// Group sections into blocks that start at top-level headings
+	var blocks []string
+	var block strings.Builder
+	for _, section := range SplitMarkdownSections(content) {
+		if section.Level > 0 && section.Level <= 2 && block.Len() > 0 {
+			blocks = append(blocks, block.String())
+			block.Reset()
+		}
+		block.WriteString(section.Content)
+	}
+	if block.Len() > 0 {
+		blocks = append(blocks, block.String())
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+
+	flush := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			chunks = append(chunks, current.String())
+		}
+		current.Reset()
+		currentTokens = 0
+	}
+
+	for _, block := range blocks {
+		blockTokens := EstimateTokens(block)
+
+		if blockTokens > maxTokens {
+			flush()
+			chunks = append(chunks, ChunkByTokens(block, maxTokens, overlapTokens)...)
+			continue
+		}
+
+		if currentTokens+blockTokens > maxTokens {
+			flush()
+		}
+		current.WriteString(block)
+		currentTokens += blockTokens
+	}
+	flush()
+
+	return chunks
+}
+
+// DiffMarkdownSections compares two versions of a markdown document section by section. It
+// returns the sections of current that do not appear unchanged in previous, and the headings of
+// sections of previous that no longer appear unchanged in current.
+func DiffMarkdownSections(previous, current string) (added []MarkdownSection, removed []string) {
+	previousSections := SplitMarkdownSections(previous)
+	currentSections := SplitMarkdownSections(current)
+
+	seen := make(map[string]bool)
+	for _, section := range previousSections {
+		seen[strings.TrimSpace(section.Content)] = true
+	}
+
+	remaining := make(map[string]bool)
+	for _, section := range currentSections {
+		text := strings.TrimSpace(section.Content)
+		remaining[text] = true
+		if !seen[text] {
+			added = append(added, section)
+		}
+	}
+
+	for _, section := range previousSections {
+		if !remaining[strings.TrimSpace(section.Content)] {
+			removed = append(removed, section.Heading)
+		}
+	}
+
+	return added, removed
+}
+
+// parseHeading returns the level and text of an ATX heading line, or level 0
+func parseHeading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, ""
+	}
+
+	heading := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	return level, heading
+}
+
+// headingSet builds a lookup of lower-cased heading names
+func headingSet(headings []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, heading := range headings {
+		if heading = strings.TrimSpace(heading); heading != "" {
+			set[strings.ToLower(heading)] = true
+		}
+	}
+	return set
+}
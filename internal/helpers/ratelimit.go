@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces out requests so no more than a fixed number start per minute, and holds
+// them back while the server asks clients to slow down. It is safe for concurrent use. A nil
+// RateLimiter never waits.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing requestsPerMinute requests per minute. When
+// requestsPerMinute is not positive, requests are only held back at the server's request.
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	limiter := &RateLimiter{}
+	if requestsPerMinute > 0 {
+		limiter.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	return limiter
+}
+
+// Wait blocks until the caller may start its next request or the context is cancelled
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	return Sleep(ctx, wait)
+}
+
+// PauseUntil holds back every request until t, such as when the server reports that no
+// requests are left
+func (l *RateLimiter) PauseUntil(t time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.next.Before(t) {
+		l.next = t
+	}
+}
+
+// SlowDown spaces requests at least interval apart from now on. It never speeds them up and
+// reports whether the rate changed.
+func (l *RateLimiter) SlowDown(interval time.Duration) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if interval <= l.interval {
+		return false
+	}
+	l.interval = interval
+	return true
+}
+
+// Jitter returns a random duration between half of d and d, so clients backing off after the
+// same failure do not all retry at once
+func Jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// Sleep pauses for the given duration, returning early with the context's error if it is
+// cancelled
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,146 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// Signatures use minisign's format with its legacy Ed25519 algorithm, which signs the file
+// itself rather than a BLAKE2b hash, so they can also be checked with `minisign -V`.
+var minisignAlgorithm = []byte("Ed")
+
+// GenerateSigningKey creates a new Ed25519 key pair
+func GenerateSigningKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// EncodeSigningKey encodes a private key as PKCS #8 PEM
+func EncodeSigningKey(key ed25519.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// DecodeSigningKey decodes a PKCS #8 PEM Ed25519 private key
+func DecodeSigningKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("not a PEM private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 key")
+	}
+	return edKey, nil
+}
+
+// EncodePublicKey encodes a public key as a minisign public key file
+func EncodePublicKey(key ed25519.PublicKey) string {
+	id := keyID(key)
+	payload := append(append(append([]byte(nil), minisignAlgorithm...), id...), key...)
+	return fmt.Sprintf("untrusted comment: minisign public key %s\n%s\n",
+		strings.ToUpper(hex.EncodeToString(reverse(id))), base64.StdEncoding.EncodeToString(payload))
+}
+
+// DecodePublicKey decodes a minisign public key file
+func DecodePublicKey(data []byte) (ed25519.PublicKey, error) {
+	lines := nonEmptyLines(data)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty public key file")
+	}
+
+	// The comment line is optional
+	payload, err := base64.StdEncoding.DecodeString(lines[len(lines)-1])
+	if err != nil || len(payload) != 2+8+ed25519.PublicKeySize || !bytes.Equal(payload[:2], minisignAlgorithm) {
+		return nil, fmt.Errorf("not a minisign Ed25519 public key")
+	}
+	return ed25519.PublicKey(payload[10:]), nil
+}
+
+// SignMinisign signs message and returns a minisign signature file. The trusted comment is
+// signed as well, so it can carry details such as the file name and time.
+func SignMinisign(key ed25519.PrivateKey, message []byte, trustedComment string) string {
+	public := key.Public().(ed25519.PublicKey)
+
+	signature := ed25519.Sign(key, message)
+	globalSignature := ed25519.Sign(key, append(append([]byte(nil), signature...), trustedComment...))
+
+	payload := append(append(append([]byte(nil), minisignAlgorithm...), keyID(public)...), signature...)
+	return fmt.Sprintf("untrusted comment: signature from scrum-master\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(payload), trustedComment, base64.StdEncoding.EncodeToString(globalSignature))
+}
+
+// VerifyMinisign checks a minisign signature file of message against a public key and returns
+// its trusted comment
+func VerifyMinisign(key ed25519.PublicKey, message, signatureFile []byte) (string, error) {
+	lines := nonEmptyLines(signatureFile)
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("malformed signature file")
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(payload) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed signature")
+	}
+	if !bytes.Equal(payload[:2], minisignAlgorithm) {
+		return "", fmt.Errorf("unsupported signature algorithm %q (re-sign the file with scrum-master)", payload[:2])
+	}
+	if !bytes.Equal(payload[2:10], keyID(key)) {
+		return "", fmt.Errorf("signed with a different key")
+	}
+
+	signature := payload[10:]
+	if !ed25519.Verify(key, message, signature) {
+		return "", fmt.Errorf("signature does not match the file; it was modified after signing")
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	globalSignature, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || !ed25519.Verify(key, append(append([]byte(nil), signature...), trustedComment...), globalSignature) {
+		return "", fmt.Errorf("trusted comment was modified after signing")
+	}
+
+	return trustedComment, nil
+}
+
+// keyID derives the 8-byte minisign key ID from a public key
+func keyID(key ed25519.PublicKey) []byte {
+	sum := sha256.Sum256(key)
+	return sum[:8]
+}
+
+// reverse returns the bytes in reverse order, as minisign prints key IDs little-endian
+func reverse(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return out
+}
+
+// nonEmptyLines splits data into lines without surrounding whitespace, dropping empty ones
+func nonEmptyLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestVerifyMinisign(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	otherKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	message := []byte(`{"project_name":"Shop"}`)
+	signature := SignMinisign(key, message, "file:analysis.json")
+	lines := strings.Split(signature, "\n")
+
+	// withPayloadAlgorithm returns the signature with the algorithm of its payload replaced
+	withPayloadAlgorithm := func(algorithm string) string {
+		payload, _ := base64.StdEncoding.DecodeString(lines[1])
+		payload = append([]byte(algorithm), payload[2:]...)
+		return strings.Replace(signature, lines[1], base64.StdEncoding.EncodeToString(payload), 1)
+	}
+
+	tests := []struct {
+		name      string
+		key       ed25519.PublicKey
+		message   []byte
+		signature string
+		want      string
+		wantErr   string
+	}{
+		{
+			name:      "valid signature",
+			signature: signature,
+			want:      "file:analysis.json",
+		},
+		{
+			name:      "CRLF line endings and blank lines",
+			signature: strings.ReplaceAll(signature, "\n", "\r\n\r\n"),
+			want:      "file:analysis.json",
+		},
+		{
+			name:      "modified message",
+			message:   []byte(`{"project_name":"Shop2"}`),
+			signature: signature,
+			wantErr:   "modified after signing",
+		},
+		{
+			name:      "modified trusted comment",
+			signature: strings.Replace(signature, "file:analysis.json", "file:other.json", 1),
+			wantErr:   "trusted comment was modified",
+		},
+		{
+			name:      "different key",
+			key:       otherKey.Public().(ed25519.PublicKey),
+			signature: signature,
+			wantErr:   "different key",
+		},
+		{
+			name:      "hashed algorithm",
+			signature: withPayloadAlgorithm("ED"),
+			wantErr:   "unsupported signature algorithm",
+		},
+		{
+			name:      "missing trusted comment",
+			signature: strings.Join(lines[:2], "\n"),
+			wantErr:   "malformed signature file",
+		},
+		{
+			name:      "signature not base64",
+			signature: strings.Replace(signature, lines[1], "not base64!", 1),
+			wantErr:   "malformed signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey := key.Public().(ed25519.PublicKey)
+			if tt.key != nil {
+				publicKey = tt.key
+			}
+			signed := message
+			if tt.message != nil {
+				signed = tt.message
+			}
+
+			got, err := VerifyMinisign(publicKey, signed, []byte(tt.signature))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyMinisign() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyMinisign() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyMinisign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,511 @@
+package helpers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// storageInline matches the inline markdown with a storage format element: **strong**,
+	// __strong__, *emphasis*, ~~strikethrough~~, `code` and [text](url) links
+	storageInline = regexp.MustCompile("\\*\\*([^*]+)\\*\\*|__([^_]+)__|\\*([^*\\s][^*]*?)\\*|~~([^~]+)~~|`([^`]+)`|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+
+	// storageCodeLanguages are the languages the Confluence code macro highlights. Code blocks in
+	// other languages, such as mermaid, are shown without highlighting.
+	storageCodeLanguages = map[string]bool{
+		"bash": true, "csharp": true, "css": true, "go": true, "java": true, "javascript": true,
+		"json": true, "python": true, "ruby": true, "sql": true, "xml": true, "yaml": true,
+	}
+)
+
+// MarkdownToStorage converts markdown, such as the summary of an analysis, into the XHTML storage
+// format of Confluence pages. Headings, bullet and numbered lists, code blocks, quotes, rules and
+// inline formatting are kept; other text becomes paragraphs, with line breaks kept inside a
+// paragraph. Text is escaped, so markdown cannot inject markup into the page.
+func MarkdownToStorage(text string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n"), "\n")
+	var out strings.Builder
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + strings.Join(paragraph, "<br />") + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") {
+			if end := adfClosing(lines[i+1:], "```"); end >= 0 {
+				flush()
+				out.WriteString(storageCodeMacro(strings.TrimSpace(strings.TrimPrefix(trimmed, "```")), strings.Join(lines[i+1:i+1+end], "\n")))
+				i += end + 1
+				continue
+			}
+		}
+
+		if level, heading := parseHeading(trimmed); level > 0 {
+			flush()
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, storageInlineMarkup(heading), level))
+			continue
+		}
+
+		if list, pattern := adfListType(line); list != "" {
+			flush()
+			tag := "ul"
+			if list == "orderedList" {
+				tag = "ol"
+			}
+			out.WriteString("<" + tag + ">")
+			for ; i < len(lines); i++ {
+				match := pattern.FindStringSubmatch(lines[i])
+				if match == nil {
+					break
+				}
+				out.WriteString("<li>" + storageInlineMarkup(match[1]) + "</li>")
+			}
+			i--
+			out.WriteString("</" + tag + ">\n")
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "> "):
+			flush()
+			out.WriteString("<blockquote><p>" + storageInlineMarkup(strings.TrimPrefix(trimmed, "> ")) + "</p></blockquote>\n")
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flush()
+			out.WriteString("<hr />\n")
+		default:
+			paragraph = append(paragraph, storageInlineMarkup(trimmed))
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// storageCodeMacro returns a code block as a Confluence code macro. The code is kept verbatim in
+// a CDATA section, split where it contains the sequence that would end the section.
+func storageCodeMacro(language, code string) string {
+	var macro strings.Builder
+	macro.WriteString(`<ac:structured-macro ac:name="code">`)
+	if storageCodeLanguages[language] {
+		macro.WriteString(`<ac:parameter ac:name="language">` + language + `</ac:parameter>`)
+	} else if language != "" {
+		macro.WriteString(`<ac:parameter ac:name="title">` + html.EscapeString(language) + `</ac:parameter>`)
+	}
+	macro.WriteString("<ac:plain-text-body><![CDATA[" + strings.ReplaceAll(code, "]]>", "]]]]><![CDATA[>") + "]]></ac:plain-text-body>")
+	macro.WriteString("</ac:structured-macro>\n")
+	return macro.String()
+}
+
+// storageInlineMarkup escapes a line and converts its inline markdown into storage format
+// elements
+func storageInlineMarkup(line string) string {
+	var out strings.Builder
+	last := 0
+	for _, match := range storageInline.FindAllStringSubmatchIndex(line, -1) {
+		out.WriteString(html.EscapeString(line[last:match[0]]))
+		last = match[1]
+
+		group := func(n int) string {
+			if match[2*n] < 0 {
+				return ""
+			}
+			return html.EscapeString(line[match[2*n]:match[2*n+1]])
+		}
+
+		switch {
+		case group(1) != "":
+			out.WriteString("<strong>" + group(1) + "</strong>")
+		case group(2) != "":
+			out.WriteString("<strong>" + group(2) + "</strong>")
+		case group(3) != "":
+			out.WriteString("<em>" + group(3) + "</em>")
+		case group(4) != "":
+			out.WriteString("<s>" + group(4) + "</s>")
+		case group(5) != "":
+			out.WriteString("<code>" + group(5) + "</code>")
+		default:
+			out.WriteString(`<a href="` + group(7) + `">` + group(6) + "</a>")
+		}
+	}
+	out.WriteString(html.EscapeString(line[last:]))
+	return out.String()
+}
+
+// storageNode is an element of a storage format document, or a text node when it has no name.
+// Names keep their namespace prefix, such as "ac:structured-macro".
+type storageNode struct {
+	name     string
+	attrs    map[string]string
+	children []*storageNode
+	text     string
+}
+
+// StorageToMarkdown converts the XHTML storage format of a Confluence page into markdown for
+// analysis. Headings, paragraphs, lists, tables, quotes, code blocks, task lists and inline
+// formatting are kept, and the bodies of macros such as info panels and expands become part of
+// the text. Macros without text, such as the table of contents, and images are left out.
+func StorageToMarkdown(storage string) (string, error) {
+	root, err := parseStorage(storage, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(storageBlocks(root.children), "\n\n") + "\n", nil
+}
+
+// parseStorage parses a storage format document into a tree of nodes. The parser accepts HTML
+// entities and the undeclared ac: and ri: namespaces of Confluence. Elements named in autoClose,
+// such as the void elements of HTML, are closed where they start.
+func parseStorage(storage string, autoClose []string) (*storageNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<storage>" + storage + "</storage>"))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.AutoClose = autoClose
+
+	root := &storageNode{name: "storage"}
+	stack := []*storageNode{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse page content: %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &storageNode{name: storageName(token.Name), attrs: make(map[string]string)}
+			for _, attr := range token.Attr {
+				node.attrs[storageName(attr.Name)] = attr.Value
+			}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &storageNode{text: string(token)})
+		}
+	}
+	return root, nil
+}
+
+// storageName returns an element or attribute name with its namespace prefix
+func storageName(name xml.Name) string {
+	if name.Space == "" {
+		return strings.ToLower(name.Local)
+	}
+	return name.Space + ":" + name.Local
+}
+
+// storageBlocks converts nodes into markdown blocks. Inline content between blocks becomes a
+// paragraph.
+func storageBlocks(nodes []*storageNode) []string {
+	var blocks []string
+	var inline []*storageNode
+
+	add := func(block string) {
+		if block = strings.TrimRight(block, " \n"); strings.TrimSpace(block) != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush := func() {
+		add(storageInlineText(inline))
+		inline = nil
+	}
+
+	for _, node := range nodes {
+		if storageIsInline(node) {
+			inline = append(inline, node)
+			continue
+		}
+		flush()
+
+		switch node.name {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			if heading := storageInlineText(node.children); heading != "" {
+				add(strings.Repeat("#", int(node.name[1]-'0')) + " " + strings.ReplaceAll(heading, "\n", " "))
+			}
+		case "p":
+			add(storageInlineText(node.children))
+		case "ul", "ol":
+			add(strings.Join(storageList(node, ""), "\n"))
+		case "table":
+			add(storageTable(node))
+		case "blockquote":
+			var quoted []string
+			for _, line := range strings.Split(strings.Join(storageBlocks(node.children), "\n\n"), "\n") {
+				quoted = append(quoted, strings.TrimRight("> "+line, " "))
+			}
+			add(strings.Join(quoted, "\n"))
+		case "pre":
+			add("```\n" + strings.Trim(storageRawText(node), "\n") + "\n```")
+		case "hr":
+			add("---")
+		case "ac:structured-macro", "ac:macro":
+			blocks = append(blocks, storageMacro(node)...)
+		case "ac:task-list":
+			add(strings.Join(storageTasks(node, ""), "\n"))
+		case "ac:image", "ac:parameter", "ac:placeholder":
+		default:
+			blocks = append(blocks, storageBlocks(node.children)...)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// storageIsInline reports whether a node is text or an element that is part of a paragraph
+func storageIsInline(node *storageNode) bool {
+	switch node.name {
+	case "", "strong", "b", "em", "i", "u", "s", "del", "code", "a", "span", "br", "sub", "sup",
+		"time", "small", "mark", "abbr", "kbd", "q", "cite", "img", "ac:link", "ac:emoticon",
+		"ac:inline-comment-marker", "ri:user":
+		return true
+	case "ac:structured-macro":
+		return node.attrs["ac:name"] == "status"
+	}
+	return false
+}
+
+// storageInlineText converts inline nodes into markdown text with whitespace collapsed and
+// line breaks kept
+func storageInlineText(nodes []*storageNode) string {
+	var text strings.Builder
+	for _, node := range nodes {
+		text.WriteString(storageInlineNode(node))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text.String(), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// storageInlineNode converts an inline node into markdown
+func storageInlineNode(node *storageNode) string {
+	// Markers go around the text only, with the whitespace at its edges kept outside of them
+	wrap := func(marker string) string {
+		var text strings.Builder
+		for _, child := range node.children {
+			text.WriteString(storageInlineNode(child))
+		}
+		inner := strings.TrimSpace(text.String())
+		if inner == "" {
+			return text.String()
+		}
+		start := strings.Index(text.String(), inner)
+		return text.String()[:start] + marker + inner + marker + text.String()[start+len(inner):]
+	}
+
+	switch node.name {
+	case "":
+		// Line breaks in the source are whitespace; only <br> breaks a line
+		return strings.ReplaceAll(node.text, "\n", " ")
+	case "br":
+		return "\n"
+	case "strong", "b":
+		return wrap("**")
+	case "em", "i":
+		return wrap("*")
+	case "s", "del":
+		return wrap("~~")
+	case "code":
+		return wrap("`")
+	case "a":
+		text := storageInlineText(node.children)
+		if href := node.attrs["href"]; href != "" && text != "" {
+			return "[" + text + "](" + href + ")"
+		}
+		return text
+	case "ac:link":
+		for _, child := range node.children {
+			switch child.name {
+			case "ac:link-body", "ac:plain-text-link-body":
+				return storageInlineText(child.children)
+			}
+		}
+		for _, child := range node.children {
+			if title := child.attrs["ri:content-title"]; title != "" {
+				return title
+			}
+			if filename := child.attrs["ri:filename"]; filename != "" {
+				return filename
+			}
+		}
+		return ""
+	case "ac:structured-macro":
+		if title := storageParameter(node, "title"); title != "" {
+			return "[" + title + "]"
+		}
+		return ""
+	case "ac:emoticon", "ri:user":
+		return ""
+	}
+	return storageInlineText(node.children)
+}
+
+// storageList converts a list into markdown list items, nested lists indented below their item
+func storageList(list *storageNode, indent string) []string {
+	var lines []string
+	number := 0
+	for _, item := range list.children {
+		if item.name != "li" {
+			continue
+		}
+		number++
+		marker := "- "
+		if list.name == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+		}
+
+		var text []string
+		var nested []string
+		for _, child := range item.children {
+			switch child.name {
+			case "ul", "ol":
+				nested = append(nested, storageList(child, indent+strings.Repeat(" ", len(marker)))...)
+			case "ac:task-list":
+				nested = append(nested, storageTasks(child, indent+strings.Repeat(" ", len(marker)))...)
+			default:
+				text = append(text, strings.Join(storageBlocks([]*storageNode{child}), " "))
+			}
+		}
+		lines = append(lines, indent+marker+strings.Join(strings.Fields(strings.Join(text, " ")), " "))
+		lines = append(lines, nested...)
+	}
+	return lines
+}
+
+// storageTasks converts a task list into markdown task items
+func storageTasks(list *storageNode, indent string) []string {
+	var lines []string
+	for _, task := range list.children {
+		if task.name != "ac:task" {
+			continue
+		}
+		box := "[ ]"
+		var text string
+		var nested []string
+		for _, child := range task.children {
+			switch child.name {
+			case "ac:task-status":
+				if strings.TrimSpace(storageRawText(child)) == "complete" {
+					box = "[x]"
+				}
+			case "ac:task-body":
+				text = strings.Join(strings.Fields(strings.Join(storageBlocks(child.children), " ")), " ")
+			case "ac:task-list":
+				nested = append(nested, storageTasks(child, indent+"  ")...)
+			}
+		}
+		lines = append(lines, indent+"- "+box+" "+text)
+		lines = append(lines, nested...)
+	}
+	return lines
+}
+
+// storageTable converts a table into a markdown table whose first row is the header
+func storageTable(table *storageNode) string {
+	var rows [][]string
+	var collect func(nodes []*storageNode)
+	collect = func(nodes []*storageNode) {
+		for _, node := range nodes {
+			switch node.name {
+			case "tr":
+				var cells []string
+				for _, cell := range node.children {
+					if cell.name == "th" || cell.name == "td" {
+						text := strings.Join(storageBlocks(cell.children), " ")
+						cells = append(cells, strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", `\|`))
+					}
+				}
+				rows = append(rows, cells)
+			case "thead", "tbody", "tfoot":
+				collect(node.children)
+			}
+		}
+	}
+	collect(table.children)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var lines []string
+	for i, row := range rows {
+		row = append(row, make([]string, columns-len(row))...)
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat("---|", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// storageMacro converts a block macro into markdown blocks: code macros become code blocks and
+// macros with a body, such as panels and expands, their body. Other macros have no text.
+func storageMacro(macro *storageNode) []string {
+	name := macro.attrs["ac:name"]
+	for _, child := range macro.children {
+		switch child.name {
+		case "ac:plain-text-body":
+			if name != "code" && name != "noformat" {
+				continue
+			}
+			return []string{"```" + storageParameter(macro, "language") + "\n" + strings.Trim(storageRawText(child), "\n") + "\n```"}
+		case "ac:rich-text-body":
+			blocks := storageBlocks(child.children)
+			if title := storageParameter(macro, "title"); title != "" && len(blocks) > 0 {
+				blocks = append([]string{"**" + title + "**"}, blocks...)
+			}
+			return blocks
+		}
+	}
+	return nil
+}
+
+// storageParameter returns a parameter of a macro
+func storageParameter(macro *storageNode, name string) string {
+	for _, child := range macro.children {
+		if child.name == "ac:parameter" && child.attrs["ac:name"] == name {
+			return strings.TrimSpace(storageRawText(child))
+		}
+	}
+	return ""
+}
+
+// storageRawText returns the text of a node and its descendants as it is
+func storageRawText(node *storageNode) string {
+	var text strings.Builder
+	if node.name == "" {
+		text.WriteString(node.text)
+	}
+	for _, child := range node.children {
+		text.WriteString(storageRawText(child))
+	}
+	return text.String()
+}
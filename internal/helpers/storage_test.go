@@ -0,0 +1,96 @@
+package helpers
+
+import "testing"
+
+func TestStorageToMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage string
+		want    string
+	}{
+		{
+			name: "headings and paragraphs",
+			storage: `<h1>Checkout</h1><p>Customers pay by <strong>card</strong> or <em>invoice</em>.</p><h3>Refunds</h3><p>Within   14
+ days.</p>`,
+			want: "# Checkout\n\nCustomers pay by **card** or *invoice*.\n\n### Refunds\n\nWithin 14 days.\n",
+		},
+		{
+			name:    "entities and line breaks",
+			storage: `<p>Fees &amp; taxes&nbsp;apply<br />per order &lt;EUR&gt;</p>`,
+			want:    "Fees & taxes apply\nper order <EUR>\n",
+		},
+		{
+			name:    "inline formatting and links",
+			storage: `<p><s>Old</s> <code>POST /orders</code> see <a href="https://example.com/spec">the spec</a> and <ac:link><ri:page ri:content-title="Payments" /></ac:link></p>`,
+			want:    "~~Old~~ `POST /orders` see [the spec](https://example.com/spec) and Payments\n",
+		},
+		{
+			name:    "spaces at the edges of formatting",
+			storage: `<p>Pay<strong> now </strong>or <em>later </em>today<strong> </strong>please</p>`,
+			want:    "Pay **now** or *later* today please\n",
+		},
+		{
+			name:    "nested lists",
+			storage: `<ul><li>Cards<ul><li>Visa</li><li>Mastercard</li></ul></li><li>Invoice</li></ul><ol><li>Order</li><li>Pay<ol><li>Confirm</li></ol></li></ol>`,
+			want:    "- Cards\n  - Visa\n  - Mastercard\n- Invoice\n\n1. Order\n2. Pay\n   1. Confirm\n",
+		},
+		{
+			name:    "list items with paragraphs",
+			storage: `<ul><li><p>First <strong>item</strong></p></li><li><p>Second</p></li></ul>`,
+			want:    "- First **item**\n- Second\n",
+		},
+		{
+			name:    "table with header",
+			storage: `<table><tbody><tr><th>Field</th><th>Rule</th></tr><tr><td><p>Email</p></td><td>Required | unique</td></tr><tr><td>Phone</td></tr></tbody></table>`,
+			want:    "| Field | Rule |\n|---|---|\n| Email | Required \\| unique |\n| Phone |  |\n",
+		},
+		{
+			name: "code macro with CDATA",
+			storage: `<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">go</ac:parameter>` +
+				`<ac:plain-text-body><![CDATA[if a < b && b > c {
+	return "<ok>"
+}]]></ac:plain-text-body></ac:structured-macro>`,
+			want: "```go\nif a < b && b > c {\n\treturn \"<ok>\"\n}\n```\n",
+		},
+		{
+			name: "info panel and expand bodies",
+			storage: `<ac:structured-macro ac:name="info"><ac:rich-text-body><p>Payments are <strong>PCI</strong> scoped.</p></ac:rich-text-body></ac:structured-macro>` +
+				`<ac:structured-macro ac:name="expand"><ac:parameter ac:name="title">Details</ac:parameter><ac:rich-text-body><p>Hidden text</p></ac:rich-text-body></ac:structured-macro>`,
+			want: "Payments are **PCI** scoped.\n\n**Details**\n\nHidden text\n",
+		},
+		{
+			name:    "macros without text are left out",
+			storage: `<ac:structured-macro ac:name="toc"><ac:parameter ac:name="maxLevel">2</ac:parameter></ac:structured-macro><p>Intro</p><ac:image><ri:attachment ri:filename="flow.png" /></ac:image>`,
+			want:    "Intro\n",
+		},
+		{
+			name:    "status macro inline",
+			storage: `<p>State: <ac:structured-macro ac:name="status"><ac:parameter ac:name="title">Draft</ac:parameter></ac:structured-macro></p>`,
+			want:    "State: [Draft]\n",
+		},
+		{
+			name: "task list",
+			storage: `<ac:task-list><ac:task><ac:task-status>complete</ac:task-status><ac:task-body>Write spec</ac:task-body></ac:task>` +
+				`<ac:task><ac:task-status>incomplete</ac:task-status><ac:task-body>Review <strong>it</strong></ac:task-body></ac:task></ac:task-list>`,
+			want: "- [x] Write spec\n- [ ] Review **it**\n",
+		},
+		{
+			name: "quote, rule and preformatted text",
+			storage: `<blockquote><p>Keep it simple</p></blockquote><hr /><pre>a  b
+c</pre>`,
+			want: "> Keep it simple\n\n---\n\n```\na  b\nc\n```\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StorageToMarkdown(tt.storage)
+			if err != nil {
+				t.Fatalf("StorageToMarkdown() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("StorageToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// charsPerToken is the average number of characters in a Claude token for English prose
+const charsPerToken = 4
+
+// EstimateTokens approximates the number of tokens a model will count for text. Words are
+// counted as one token per four characters, punctuation and symbols as one token each and
+// non-Latin letters (e.g. CJK) as one token each.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLength := 0
+
+	flushWord := func() {
+		if wordLength > 0 {
+			tokens += (wordLength + charsPerToken - 1) / charsPerToken
+			wordLength = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			wordLength++
+		case unicode.IsSpace(r):
+			flushWord()
+		case unicode.IsLetter(r) && !unicode.In(r, unicode.Latin):
+			flushWord()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLength++
+		default:
+			flushWord()
+			tokens++
+		}
+	}
+	flushWord()
+
+	return tokens
+}
+
+// ChunkByTokens splits text into chunks of at most maxTokens estimated tokens, breaking at line
+// boundaries where possible and at word boundaries otherwise, so runes and words are never cut.
+// Consecutive chunks share up to overlapTokens of trailing context.
+func ChunkByTokens(text string, maxTokens, overlapTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return []string{text}
+	}
+	if overlapTokens >= maxTokens {
+		overlapTokens = maxTokens / 4
+	}
+
+	// Break the text into pieces that each fit the budget
+	var pieces []string
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if EstimateTokens(line) <= maxTokens {
+			pieces = append(pieces, line)
+			continue
+		}
+		pieces = append(pieces, splitWords(line, maxTokens)...)
+	}
+
+	var chunks []string
+	var current []string
+	currentTokens := 0
+
+	for _, piece := range pieces {
+		pieceTokens := EstimateTokens(piece)
+
+		if currentTokens+pieceTokens > maxTokens && len(current) > 0 {
+			chunks = append(chunks, strings.Join(current, ""))
+
+			// Carry trailing pieces over as overlap
+			var overlap []string
+			overlapCount := 0
+			for i := len(current) - 1; i >= 0; i-- {
+				tokens := EstimateTokens(current[i])
+				if overlapCount+tokens > overlapTokens || overlapCount+tokens+pieceTokens > maxTokens {
+					break
+				}
+				overlap = append([]string{current[i]}, overlap...)
+				overlapCount += tokens
+			}
+
+			current = overlap
+			currentTokens = overlapCount
+		}
+
+		current = append(current, piece)
+		currentTokens += pieceTokens
+	}
+
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, ""))
+	}
+
+	return chunks
+}
+
+// TruncateToTokens cuts text at a word boundary so it fits within maxTokens estimated tokens
+func TruncateToTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	return ChunkByTokens(text, maxTokens, 0)[0]
+}
+
+// splitWords splits an oversized line into pieces of at most maxTokens at spaces
+func splitWords(line string, maxTokens int) []string {
+	var pieces []string
+	var current strings.Builder
+	currentTokens := 0
+
+	for _, word := range strings.SplitAfter(line, " ") {
+		wordTokens := EstimateTokens(word)
+		if currentTokens+wordTokens > maxTokens && current.Len() > 0 {
+			pieces = append(pieces, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+		current.WriteString(word)
+		currentTokens += wordTokens
+	}
+
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+
+	return pieces
+}
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// wikiListItem matches a markdown list item with its indentation and marker
+	wikiListItem = regexp.MustCompile(`^(\s*)([-*+•]|\d+[.)])\s+(.*)$`)
+
+	// wikiInline matches the inline markdown that differs in wiki markup: **strong**, __strong__,
+	// ~~strikethrough~~, `code` and [text](url) links
+	wikiInline = regexp.MustCompile("\\*\\*([^*]+)\\*\\*|__([^_]+)__|~~([^~]+)~~|`([^`]+)`|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+)
+
+// MarkdownToWiki converts a description written in markdown into the wiki markup of JIRA Server,
+// Data Center and REST API v2: headings become "h2.", bullet and numbered lists "*" and "#" with
+// nesting kept, code blocks {code} and inline markdown its wiki form. Text already in wiki markup,
+// such as *bold* or {expand} sections, is left as it is.
+func MarkdownToWiki(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var out []string
+	var indents []int    // indentation of the enclosing list levels
+	var markers []string // wiki list marker of each level
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			if end := adfClosing(lines[i+1:], "```"); end >= 0 {
+				open := "{code}"
+				if language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```")); language != "" {
+					open = "{code:" + language + "}"
+				}
+				out = append(out, open)
+				out = append(out, lines[i+1:i+1+end]...)
+				out = append(out, "{code}")
+				indents, markers = nil, nil
+				i += end + 1
+				continue
+			}
+		}
+
+		if level, heading := parseHeading(trimmed); level > 0 {
+			out = append(out, fmt.Sprintf("h%d. %s", level, wikiInlineMarkup(heading)))
+			indents, markers = nil, nil
+			continue
+		}
+
+		if match := wikiListItem.FindStringSubmatch(line); match != nil {
+			indent := len(strings.ReplaceAll(match[1], "\t", "    "))
+			for len(indents) > 0 && indent < indents[len(indents)-1] {
+				indents, markers = indents[:len(indents)-1], markers[:len(markers)-1]
+			}
+
+			marker := "*"
+			if !strings.ContainsAny(match[2], "-*+•") {
+				marker = "#"
+			}
+			if len(indents) == 0 || indent > indents[len(indents)-1] {
+				indents, markers = append(indents, indent), append(markers, marker)
+			}
+			markers[len(markers)-1] = marker
+
+			out = append(out, strings.Join(markers, "")+" "+wikiInlineMarkup(match[3]))
+			continue
+		}
+
+		if trimmed == "" {
+			indents, markers = nil, nil
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "> "):
+			out = append(out, "bq. "+wikiInlineMarkup(strings.TrimPrefix(trimmed, "> ")))
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			out = append(out, "----")
+		default:
+			out = append(out, wikiInlineMarkup(line))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// wikiInlineMarkup converts the inline markdown of a line into wiki markup
+func wikiInlineMarkup(line string) string {
+	return wikiInline.ReplaceAllStringFunc(line, func(match string) string {
+		group := wikiInline.FindStringSubmatch(match)
+		switch {
+		case group[1] != "":
+			return "*" + group[1] + "*"
+		case group[2] != "":
+			return "*" + group[2] + "*"
+		case group[3] != "":
+			return "-" + group[3] + "-"
+		case group[4] != "":
+			return "{{" + group[4] + "}}"
+		default:
+			return "[" + group[5] + "|" + group[6] + "]"
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package helpers
+
+import "testing"
+
+func TestMarkdownToWiki(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "headings and inline markup",
+			text: "## Payment\n**Card** or __invoice__, ~~cash~~, `POST /pay` per [spec](https://example.com/spec)",
+			want: "h2. Payment\n*Card* or *invoice*, -cash-, {{POST /pay}} per [spec|https://example.com/spec]",
+		},
+		{
+			name: "nested lists",
+			text: "- Cards\n  - Visa\n    1. Debit\n  - Mastercard\n- Invoice",
+			want: "* Cards\n** Visa\n**# Debit\n** Mastercard\n* Invoice",
+		},
+		{
+			name: "numbered list with tab indentation",
+			text: "1. Order\n\t2) Pay\n3. Ship",
+			want: "# Order\n## Pay\n# Ship",
+		},
+		{
+			name: "blank line ends a list",
+			text: "- One\n\n  - Two",
+			want: "* One\n\n* Two",
+		},
+		{
+			name: "code blocks kept verbatim",
+			text: "```go\nx := **y**\n```\n```\n- not a list\n```",
+			want: "{code:go}\nx := **y**\n{code}\n{code}\n- not a list\n{code}",
+		},
+		{
+			name: "unclosed code block is text",
+			text: "```go\nx := `y`",
+			want: "```go\nx := {{y}}",
+		},
+		{
+			name: "quotes, rules and CRLF",
+			text: "> Keep it simple\r\n---\r\nDone",
+			want: "bq. Keep it simple\n----\nDone",
+		},
+		{
+			name: "wiki markup left as it is",
+			text: "*Test Cases:*\n{expand:Details}\nh3. Setup\n{expand}",
+			want: "*Test Cases:*\n{expand:Details}\nh3. Setup\n{expand}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToWiki(tt.text); got != tt.want {
+				t.Errorf("MarkdownToWiki(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
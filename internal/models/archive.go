@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// OutputRun groups the files one run wrote to the output directory, identified by the
+// timestamp in their names
+type OutputRun struct {
+	Timestamp  string    `json:"timestamp"`
+	Files      []string  `json:"files"`
+	Archive    string    `json:"archive,omitempty"`
+	ArchivedAt time.Time `json:"archived_at,omitempty"`
+}
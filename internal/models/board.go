@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Kanban board columns
+const (
+	BoardToDo  = "todo"
+	BoardDoing = "doing"
+	BoardDone  = "done"
+)
+
+// BoardState records where the stories of an analysis are on the local kanban board
+type BoardState struct {
+	AnalysisFile string      `json:"analysis_file"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	Cards        []BoardCard `json:"cards"`
+}
+
+// BoardCard is the column of one story, identified by its ID, or by its epic and story titles
+// for analyses without IDs
+type BoardCard struct {
+	ID      string    `json:"id,omitempty"`
+	Epic    string    `json:"epic"`
+	Story   string    `json:"story"`
+	Status  string    `json:"status"`
+	MovedAt time.Time `json:"moved_at"`
+}
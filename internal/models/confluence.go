@@ -0,0 +1,43 @@
+package models
+
+// ConfluencePage represents a Confluence page as the content API returns it
+type ConfluencePage struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Version ConfluenceVersion `json:"version"`
+	Body    ConfluenceBody    `json:"body"`
+	Links   ConfluenceLinks   `json:"_links"`
+}
+
+// ConfluenceBody represents the content of a page, when it was requested
+type ConfluenceBody struct {
+	Storage ConfluenceStorage `json:"storage"`
+}
+
+// ConfluenceStorage represents content in the XHTML storage format of Confluence
+type ConfluenceStorage struct {
+	Value string `json:"value"`
+}
+
+// ConfluenceVersion represents the version of a page, which an update must increment
+type ConfluenceVersion struct {
+	Number int `json:"number"`
+}
+
+// ConfluenceLinks represents the links of a page. The web link of a page is relative to the base.
+type ConfluenceLinks struct {
+	Base  string `json:"base"`
+	WebUI string `json:"webui"`
+}
+
+// ConfluencePageList represents the pages found by a content search
+type ConfluencePageList struct {
+	Results []ConfluencePage `json:"results"`
+}
+
+// ConfluencePublication is the result of publishing a breakdown summary to Confluence
+type ConfluencePublication struct {
+	Page    ConfluencePage
+	URL     string
+	Created bool // whether the page was created, rather than a new version of it
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// ChatMessage represents a single message exchanged with the AI
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Conversation represents the persisted message history of an analysis run
+type Conversation struct {
+	Model     string        `json:"model"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Messages  []ChatMessage `json:"messages"`
+}
+
+// TokenUsage is the AI usage of a run
+type TokenUsage struct {
+	Requests         int          `json:"requests"`
+	InputTokens      int          `json:"input_tokens"`
+	OutputTokens     int          `json:"output_tokens"`
+	CacheWriteTokens int          `json:"cache_write_tokens,omitempty"`
+	CacheReadTokens  int          `json:"cache_read_tokens,omitempty"`
+	EstimatedCostUSD float64      `json:"estimated_cost_usd"`
+	CacheSavingsUSD  float64      `json:"cache_savings_usd,omitempty"` // Saved by reading prompts from the cache
+	TokensEstimated  bool         `json:"tokens_estimated,omitempty"`  // Some token counts were estimated from the text
+	Chunks           []ChunkUsage `json:"chunks,omitempty"`
+}
+
+// ChunkUsage is the AI usage of analyzing one chunk of a document, including retries and JSON
+// fixes
+type ChunkUsage struct {
+	Chunk            int     `json:"chunk"`
+	Requests         int     `json:"requests"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// CostEstimate is the expected AI usage of analyzing a document, computed without calling the AI
+type CostEstimate struct {
+	Model           string  `json:"model"`
+	Chunks          int     `json:"chunks"`
+	Requests        int     `json:"requests"`
+	InputTokens     int     `json:"input_tokens"`
+	MaxOutputTokens int     `json:"max_output_tokens"`
+	MinCostUSD      float64 `json:"min_cost_usd"` // Cost of the input tokens alone
+	MaxCostUSD      float64 `json:"max_cost_usd"` // Cost when every answer uses max_tokens
+}
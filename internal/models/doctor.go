@@ -0,0 +1,22 @@
+package models
+
+// Statuses of a diagnostic check
+const (
+	CheckPassed  = "pass"
+	CheckFailed  = "fail"
+	CheckSkipped = "skip"
+)
+
+// DiagnosticCheck is the outcome of one check of 'scrum-master doctor'
+type DiagnosticCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// JiraPermissions is the response of the mypermissions API
+type JiraPermissions struct {
+	Permissions map[string]struct {
+		HavePermission bool `json:"havePermission"`
+	} `json:"permissions"`
+}
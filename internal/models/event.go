@@ -0,0 +1,59 @@
+package models
+
+// Event is something that happened while analyzing a document or creating issues
+type Event interface {
+	EventType() string
+}
+
+// Event types
+const (
+	EventChunkAnalyzed = "chunk_analyzed"
+	EventEpicMerged    = "epic_merged"
+	EventIssueCreated  = "issue_created"
+	EventIssueFailed   = "issue_failed"
+)
+
+// ChunkAnalyzed is published when the AI has broken down one chunk of a document
+type ChunkAnalyzed struct {
+	Chunk       int `json:"chunk"`
+	TotalChunks int `json:"total_chunks"`
+	Epics       int `json:"epics"`
+	Stories     int `json:"stories"`
+}
+
+// EpicMerged is published when epics with the same title from several chunks become one epic
+type EpicMerged struct {
+	Title   string `json:"title"`
+	Chunks  int    `json:"chunks"`
+	Stories int    `json:"stories"`
+}
+
+// IssueCreated is published when the JIRA issue of an epic or story has been created
+type IssueCreated struct {
+	ItemType string `json:"item_type"`
+	ItemID   string `json:"item_id,omitempty"`
+	Epic     string `json:"epic"`
+	Title    string `json:"title"`
+	Key      string `json:"key"`
+}
+
+// IssueFailed is published when creating the JIRA issue of an epic or story failed
+type IssueFailed struct {
+	ItemType string `json:"item_type"`
+	ItemID   string `json:"item_id,omitempty"`
+	Epic     string `json:"epic"`
+	Title    string `json:"title"`
+	Err      error  `json:"-"`
+}
+
+// EventType returns the type of the event
+func (ChunkAnalyzed) EventType() string { return EventChunkAnalyzed }
+
+// EventType returns the type of the event
+func (EpicMerged) EventType() string { return EventEpicMerged }
+
+// EventType returns the type of the event
+func (IssueCreated) EventType() string { return EventIssueCreated }
+
+// EventType returns the type of the event
+func (IssueFailed) EventType() string { return EventIssueFailed }
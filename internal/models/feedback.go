@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Ratings a reviewer can give a generated item
+const (
+	RatingGood = "good"
+	RatingBad  = "bad"
+)
+
+// ItemFeedback represents a reviewer's rating of a generated epic or story
+type ItemFeedback struct {
+	AnalysisFile string    `json:"analysis_file"`
+	ItemRef      string    `json:"item_ref"`
+	ItemID       string    `json:"item_id,omitempty"`
+	ItemType     string    `json:"item_type"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Rating       string    `json:"rating"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TrackedIssue represents a JIRA issue created by scrum-master whose edits are recorded
+type TrackedIssue struct {
+	Key         string    `json:"key"`
+	IssueType   string    `json:"issue_type"`
+	Epic        string    `json:"epic"`
+	Summary     string    `json:"summary"`
+	StoryPoints int       `json:"story_points"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IssueEdit represents a human change to a field of a tracked issue
+type IssueEdit struct {
+	IssueKey  string    `json:"issue_key"`
+	IssueType string    `json:"issue_type"`
+	Epic      string    `json:"epic"`
+	Field     string    `json:"field"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Author    string    `json:"author"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+// EditPattern represents an aggregate trend in human edits for a group of issues
+type EditPattern struct {
+	Group                 string  `json:"group"`
+	EstimateEdits         int     `json:"estimate_edits"`
+	AverageEstimateChange float64 `json:"average_estimate_change_percent"`
+	SummaryRewrites       int     `json:"summary_rewrites"`
+	TrackedIssues         int     `json:"tracked_issues"`
+}
+
+// JiraWebhookEvent represents the parts of a JIRA issue webhook payload used for learning
+type JiraWebhookEvent struct {
+	WebhookEvent string `json:"webhookEvent"`
+	Timestamp    int64  `json:"timestamp"`
+	User         struct {
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	Issue struct {
+		Key string `json:"key"`
+	} `json:"issue"`
+	Changelog struct {
+		Items []JiraChangelogItem `json:"items"`
+	} `json:"changelog"`
+}
+
+// JiraChangelogItem represents a single field change in a JIRA webhook changelog
+type JiraChangelogItem struct {
+	Field      string `json:"field"`
+	FromString string `json:"fromString"`
+	ToString   string `json:"toString"`
+}
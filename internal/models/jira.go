@@ -1,5 +1,11 @@
 package models
 
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
 // JiraIssue represents a JIRA issue
 type JiraIssue struct {
 	Fields JiraFields `json:"fields"`
@@ -7,11 +13,35 @@ type JiraIssue struct {
 
 // JiraFields represents JIRA issue fields
 type JiraFields struct {
-	Project     JiraProject   `json:"project"`
-	Summary     string        `json:"summary"`
-	Description string        `json:"description"`
-	IssueType   JiraIssueType `json:"issuetype"`
-	Parent      *JiraParent   `json:"parent,omitempty"`
+	Project     JiraProject     `json:"project"`
+	Summary     string          `json:"summary"`
+	Description string          `json:"description"`
+	IssueType   JiraIssueType   `json:"issuetype"`
+	Parent      *JiraParent     `json:"parent,omitempty"`
+	Priority    *JiraPriority   `json:"priority,omitempty"`
+	Labels      []string        `json:"labels,omitempty"`
+	Components  []JiraComponent `json:"components,omitempty"`
+
+	// Custom holds instance-specific fields such as story points, keyed by field ID
+	Custom map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the standard fields together with the custom fields
+func (f JiraFields) MarshalJSON() ([]byte, error) {
+	type standardFields JiraFields
+	data, err := json.Marshal(standardFields(f))
+	if err != nil || len(f.Custom) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for id, value := range f.Custom {
+		fields[id] = value
+	}
+	return json.Marshal(fields)
 }
 
 // JiraProject represents a JIRA project
@@ -21,7 +51,8 @@ type JiraProject struct {
 
 // JiraIssueType represents a JIRA issue type
 type JiraIssueType struct {
-	Name string `json:"name"`
+	Name    string `json:"name"`
+	Subtask bool   `json:"subtask,omitempty"` // Set on issue types read from JIRA
 }
 
 // JiraParent represents a JIRA parent issue
@@ -29,17 +60,95 @@ type JiraParent struct {
 	Key string `json:"key"`
 }
 
+// JiraComponent represents a component of a JIRA project
+type JiraComponent struct {
+	Name string `json:"name"`
+}
+
+// JiraVersion represents a version of a JIRA project, which issues name as their fix version
+type JiraVersion struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Released    bool   `json:"released,omitempty"`
+	Project     string `json:"project,omitempty"`
+}
+
+// JiraUser identifies a JIRA user: by account ID on JIRA Cloud, by username on JIRA Server and
+// Data Center
+type JiraUser struct {
+	AccountID string `json:"accountId,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// JiraTimeTracking represents the time tracking of an issue; estimates are in JIRA's duration
+// format, such as "2h 30m"
+type JiraTimeTracking struct {
+	OriginalEstimate string `json:"originalEstimate,omitempty"`
+}
+
+// JiraPriority represents a JIRA issue priority
+type JiraPriority struct {
+	Name string `json:"name"`
+}
+
 // JiraResponse represents a JIRA API response
 type JiraResponse struct {
 	ID  string `json:"id"`
 	Key string `json:"key"`
 }
 
+// JiraBulkIssues is the request of the bulk create endpoint
+type JiraBulkIssues struct {
+	IssueUpdates []JiraIssue `json:"issueUpdates"`
+}
+
+// JiraBulkResponse is the response of the bulk create endpoint: the created issues in request
+// order and an error for every rejected one
+type JiraBulkResponse struct {
+	Issues []JiraResponse  `json:"issues"`
+	Errors []JiraBulkError `json:"errors"`
+}
+
+// JiraBulkError is the error of an issue the bulk create endpoint rejected; FailedElementNumber
+// is its position in the request
+type JiraBulkError struct {
+	Status              int               `json:"status"`
+	FailedElementNumber int               `json:"failedElementNumber"`
+	ElementErrors       JiraErrorMessages `json:"elementErrors"`
+}
+
+// JiraErrorMessages represents the errors JIRA returns for a rejected request, in general and by
+// field
+type JiraErrorMessages struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// String joins the errors into one message
+func (e JiraErrorMessages) String() string {
+	messages := append([]string(nil), e.ErrorMessages...)
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		messages = append(messages, field+": "+e.Errors[field])
+	}
+	return strings.Join(messages, "; ")
+}
+
 // JiraProjectInfo represents JIRA project information
 type JiraProjectInfo struct {
 	Key         string `json:"key"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Style       string `json:"style,omitempty"` // "classic" or "next-gen" on JIRA Cloud
+	Simplified  bool   `json:"simplified,omitempty"`
+
+	Components []JiraComponent `json:"components,omitempty"`
 }
 
 // JiraIssueTypeInfo represents JIRA issue type information
@@ -47,3 +156,110 @@ type JiraIssueTypeInfo struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 }
+
+// JiraIssueDetails represents an existing JIRA issue fetched from the API
+type JiraIssueDetails struct {
+	ID         string                     `json:"id"`
+	Key        string                     `json:"key"`
+	Fields     JiraIssueDetailsFields     `json:"fields"`
+	Properties map[string]json.RawMessage `json:"properties,omitempty"`
+}
+
+// JiraIssueDetailsFields represents the fields of an existing JIRA issue. API v3 returns the
+// description as an ADF document, which is kept in DescriptionADF until it is converted to text.
+type JiraIssueDetailsFields struct {
+	Summary        string          `json:"summary"`
+	Description    string          `json:"description"`
+	DescriptionADF json.RawMessage `json:"-"`
+	Labels         []string        `json:"labels,omitempty"`
+	IssueType      JiraIssueType   `json:"issuetype"`
+	Parent         *JiraParent     `json:"parent,omitempty"`
+}
+
+// UnmarshalJSON accepts the description as text (API v2) or as an ADF document (API v3)
+func (f *JiraIssueDetailsFields) UnmarshalJSON(data []byte) error {
+	type plainFields JiraIssueDetailsFields
+	var fields struct {
+		plainFields
+		Description json.RawMessage `json:"description"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*f = JiraIssueDetailsFields(fields.plainFields)
+	switch {
+	case len(fields.Description) == 0 || string(fields.Description) == "null":
+	case fields.Description[0] == '"':
+		return json.Unmarshal(fields.Description, &f.Description)
+	default:
+		f.DescriptionADF = fields.Description
+	}
+	return nil
+}
+
+// JiraTransition represents a workflow transition available for an issue
+type JiraTransition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   struct {
+		Name string `json:"name"`
+	} `json:"to"`
+}
+
+// JiraTeam represents an Advanced Roadmaps team
+type JiraTeam struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// JiraFieldInfo represents a field definition from the JIRA field API
+type JiraFieldInfo struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Custom bool            `json:"custom"`
+	Schema JiraFieldSchema `json:"schema"`
+}
+
+// JiraFieldSchema represents the value type of a JIRA field
+type JiraFieldSchema struct {
+	Type   string `json:"type"`
+	Items  string `json:"items,omitempty"` // Value type of the entries of array fields
+	Custom string `json:"custom,omitempty"`
+}
+
+// JiraCreateMetaIssueType represents an issue type that can be created in a project, with the
+// fields available on its create screen
+type JiraCreateMetaIssueType struct {
+	ID      string                `json:"id"`
+	Name    string                `json:"name"`
+	Subtask bool                  `json:"subtask"`
+	Fields  []JiraCreateMetaField `json:"fields"`
+}
+
+// JiraCreateMetaField represents a field on an issue type's create screen
+type JiraCreateMetaField struct {
+	FieldID       string                  `json:"fieldId"`
+	Name          string                  `json:"name"`
+	Required      bool                    `json:"required"`
+	Schema        JiraFieldSchema         `json:"schema"`
+	AllowedValues []JiraFieldAllowedValue `json:"allowedValues,omitempty"`
+}
+
+// JiraFieldAllowedValue represents a value a select field such as priority accepts
+type JiraFieldAllowedValue struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// JiraFieldMapping is the issue type and field configuration discovered for a JIRA project
+type JiraFieldMapping struct {
+	ProjectKey       string                           `json:"project_key"`
+	EpicType         string                           `json:"epic_type"`
+	StoryType        string                           `json:"story_type"`
+	SubtaskType      string                           `json:"subtask_type"`
+	AvailableTypes   []string                         `json:"available_types"`
+	StoryPointsField string                           `json:"story_points_field"`
+	CustomFields     map[string]JiraFieldInfo         `json:"custom_fields"`
+	RequiredFields   map[string][]JiraCreateMetaField `json:"required_fields"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Upload is a document sent to the server in chunks. Offset is the number of bytes received, so
+// an interrupted upload continues from there.
+type Upload struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Complete reports whether every byte of the upload has been received
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Size
+}
+
+// Job statuses
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is an analysis the server runs in the background. HeartbeatAt is renewed while the job is
+// running, so a client can tell a slow analysis from a dead one.
+type Job struct {
+	ID           string     `json:"id"`
+	UploadID     string     `json:"upload_id"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	Error        string     `json:"error,omitempty"`
+	AnalysisFile string     `json:"analysis_file,omitempty"`
+	Events       int        `json:"events"`
+	CreatedAt    time.Time  `json:"created_at"`
+	HeartbeatAt  time.Time  `json:"heartbeat_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+
+	// HeartbeatSeconds is the server.heartbeat_seconds of the server, the interval of the job's
+	// heartbeats and of the keep-alives of its progress stream
+	HeartbeatSeconds int `json:"heartbeat_seconds,omitempty"`
+}
+
+// Finished reports whether a job has stopped running
+func (j *Job) Finished() bool {
+	return j.Status != JobRunning
+}
+
+// Job event types, besides the analysis events a job passes on
+const (
+	EventJobStarted   = "job_started"
+	EventJobSucceeded = "job_succeeded"
+	EventJobFailed    = "job_failed"
+	EventHeartbeat    = "heartbeat"
+)
+
+// JobEvent is a progress event of a job. Events are numbered from 1, so a client that lost its
+// progress stream can resume after the last event it received.
+type JobEvent struct {
+	ID   int             `json:"id"`
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
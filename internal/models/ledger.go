@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Item types recorded in a run ledger
+const (
+	ItemTypeEpic       = "epic"
+	ItemTypeStory      = "story"
+	ItemTypeSubtask    = "subtask"
+	ItemTypeRisk       = "risk"
+	ItemTypeInitiative = "initiative"
+)
+
+// RunLedger records every JIRA issue created by one create-from-analysis run, so an interrupted
+// or failed run can be resumed without duplicates
+type RunLedger struct {
+	RunID        string        `json:"run_id"`
+	AnalysisFile string        `json:"analysis_file"`
+	ProjectKey   string        `json:"project_key"`
+	StartedAt    time.Time     `json:"started_at"`
+	Entries      []LedgerEntry `json:"entries"`
+}
+
+// LedgerEntry maps an analysis item to the JIRA issue created for it
+type LedgerEntry struct {
+	ItemID     string    `json:"item_id,omitempty"`
+	ItemType   string    `json:"item_type"`
+	Epic       string    `json:"epic"`
+	Title      string    `json:"title"`
+	Key        string    `json:"key"`
+	CreatedAt  time.Time `json:"created_at"`
+	RolledBack bool      `json:"rolled_back,omitempty"`
+	Deleted    bool      `json:"deleted,omitempty"`
+}
+
+// IssuePropertyKey is the JIRA entity property that ties a created issue to its analysis item
+const IssuePropertyKey = "scrum-master.item"
+
+// IssueProperty is the value of the entity property stored on every created issue
+type IssueProperty struct {
+	RunID        string `json:"run_id"`
+	AnalysisFile string `json:"analysis_file"`
+	ItemID       string `json:"item_id,omitempty"`
+	ItemType     string `json:"item_type"`
+	Epic         string `json:"epic"`
+	Title        string `json:"title"`
+}
+
+// Kinds of drift between a run ledger and JIRA
+const (
+	DriftDeleted  = "deleted"
+	DriftMoved    = "moved"
+	DriftRenamed  = "renamed"
+	DriftUnmarked = "unmarked"
+)
+
+// LedgerDrift is a difference between a recorded issue and its current state in JIRA
+type LedgerDrift struct {
+	Entry  LedgerEntry `json:"entry"`
+	Kind   string      `json:"kind"`
+	Detail string      `json:"detail"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// INVEST criteria stories are scored against
+const (
+	InvestIndependent = "independent"
+	InvestNegotiable  = "negotiable"
+	InvestValuable    = "valuable"
+	InvestEstimable   = "estimable"
+	InvestSmall       = "small"
+	InvestTestable    = "testable"
+)
+
+// LintFinding is an INVEST criterion a story misses
+type LintFinding struct {
+	Criterion string `json:"criterion"`
+	Message   string `json:"message"`
+	Gate      bool   `json:"gate"` // the finding fails the quality gates on its own
+}
+
+// StoryLint is the INVEST score of a story and what it misses
+type StoryLint struct {
+	StoryID  string        `json:"story_id"`
+	Story    string        `json:"story"`
+	Score    int           `json:"score"` // INVEST criteria met, 0 to 6
+	Findings []LintFinding `json:"findings,omitempty"`
+	Failed   bool          `json:"failed"` // the story fails the quality gates
+}
+
+// LintReport is the INVEST scoring of the stories of an analysis
+type LintReport struct {
+	Stories      []StoryLint `json:"stories"`
+	AverageScore float64     `json:"average_score"`
+	Failed       int         `json:"failed"` // stories failing the quality gates
+}
@@ -0,0 +1,21 @@
+package models
+
+// Document roles in a project manifest
+const (
+	RolePRD         = "prd"
+	RoleTechDesign  = "tech-design"
+	RoleResearch    = "research"
+	RoleConstraints = "constraints"
+)
+
+// ProjectManifest represents a project made of several documents with different roles
+type ProjectManifest struct {
+	Name      string             `yaml:"name"`
+	Documents []ManifestDocument `yaml:"documents"`
+}
+
+// ManifestDocument represents a single document of a project manifest
+type ManifestDocument struct {
+	Path string `yaml:"path"`
+	Role string `yaml:"role"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// NotionPage represents a Notion page, or a row of a database
+type NotionPage struct {
+	ID         string                    `json:"id"`
+	URL        string                    `json:"url"`
+	Properties map[string]NotionProperty `json:"properties"`
+}
+
+// Title returns the title of a page, the text of its title property
+func (p NotionPage) Title() string {
+	for _, property := range p.Properties {
+		if property.Type == "title" {
+			return NotionPlainText(property.Title)
+		}
+	}
+	return ""
+}
+
+// NotionProperty represents a property of a page. Only the title is read.
+type NotionProperty struct {
+	Type  string           `json:"type"`
+	Title []NotionRichText `json:"title"`
+}
+
+// NotionDatabase represents a Notion database
+type NotionDatabase struct {
+	ID    string           `json:"id"`
+	URL   string           `json:"url"`
+	Title []NotionRichText `json:"title"`
+}
+
+// NotionPageList represents a page of the results of a database query
+type NotionPageList struct {
+	Results    []NotionPage `json:"results"`
+	HasMore    bool         `json:"has_more"`
+	NextCursor string       `json:"next_cursor"`
+}
+
+// NotionBlockList represents a page of the child blocks of a block or page
+type NotionBlockList struct {
+	Results    []NotionBlock `json:"results"`
+	HasMore    bool          `json:"has_more"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// NotionBlock represents a block of a page, such as a paragraph or a list item. The content of a
+// block is stored under its type in the API, and read into Content.
+type NotionBlock struct {
+	ID          string
+	Type        string
+	HasChildren bool
+	Content     NotionBlockContent
+	Children    []NotionBlock // read separately, as the API returns blocks without their children
+}
+
+// NotionBlockContent represents the content of a block. Fields that a type does not have are
+// empty.
+type NotionBlockContent struct {
+	RichText        []NotionRichText   `json:"rich_text"`
+	Checked         bool               `json:"checked"`           // to_do
+	Language        string             `json:"language"`          // code
+	Title           string             `json:"title"`             // child_page, child_database
+	URL             string             `json:"url"`               // bookmark, embed, link_preview
+	Expression      string             `json:"expression"`        // equation
+	Cells           [][]NotionRichText `json:"cells"`             // table_row
+	HasColumnHeader bool               `json:"has_column_header"` // table
+}
+
+// UnmarshalJSON reads a block with the content stored under its type
+func (b *NotionBlock) UnmarshalJSON(data []byte) error {
+	var block struct {
+		ID          string `json:"id"`
+		Type        string `json:"type"`
+		HasChildren bool   `json:"has_children"`
+	}
+	if err := json.Unmarshal(data, &block); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*b = NotionBlock{ID: block.ID, Type: block.Type, HasChildren: block.HasChildren}
+	if content, exists := fields[block.Type]; exists {
+		if err := json.Unmarshal(content, &b.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotionRichText represents a run of text with its formatting
+type NotionRichText struct {
+	PlainText   string            `json:"plain_text"`
+	Href        string            `json:"href"`
+	Annotations NotionAnnotations `json:"annotations"`
+}
+
+// NotionAnnotations represents the formatting of a run of text
+type NotionAnnotations struct {
+	Bold          bool `json:"bold"`
+	Italic        bool `json:"italic"`
+	Strikethrough bool `json:"strikethrough"`
+	Code          bool `json:"code"`
+}
+
+// NotionPlainText returns rich text without its formatting
+func NotionPlainText(text []NotionRichText) string {
+	var plain strings.Builder
+	for _, run := range text {
+		plain.WriteString(run.PlainText)
+	}
+	return plain.String()
+}
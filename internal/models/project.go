@@ -4,37 +4,167 @@ import "time"
 
 // ProjectBreakdown represents the complete project structure
 type ProjectBreakdown struct {
-	ProjectName      string `json:"project_name"`
-	Overview         string `json:"overview"`
-	Epics            []Epic `json:"epics"`
-	TotalEpics       int    `json:"total_epics"`
-	TotalStories     int    `json:"total_stories"`
-	TotalStoryPoints int    `json:"total_story_points"`
-	ProcessedChunks  int    `json:"processed_chunks"`
+	ProjectName      string `json:"project_name" yaml:"project_name"`
+	Overview         string `json:"overview" yaml:"overview"`
+	Epics            []Epic `json:"epics" yaml:"epics"`
+	TotalEpics       int    `json:"total_epics" yaml:"total_epics"`
+	TotalStories     int    `json:"total_stories" yaml:"total_stories"`
+	TotalStoryPoints int    `json:"total_story_points" yaml:"total_story_points"`
+	ProcessedChunks  int    `json:"processed_chunks" yaml:"processed_chunks"`
+	SkippedChunks    []int  `json:"skipped_chunks,omitempty" yaml:"skipped_chunks,omitempty"` // Chunks left unanalyzed when the AI budget ran out
+
+	// CriteriaFormat is the processing.acceptance_criteria_format the stories were written in
+	CriteriaFormat string `json:"acceptance_criteria_format,omitempty" yaml:"acceptance_criteria_format,omitempty"`
+	// Methodology is the processing.methodology the stories were written for; kanban stories
+	// have no story points
+	Methodology string `json:"methodology,omitempty" yaml:"methodology,omitempty"`
+	// EstimationScale lists the story points of the processing.estimation_scale the stories
+	// were estimated on
+	EstimationScale []int `json:"estimation_scale,omitempty" yaml:"estimation_scale,omitempty"`
+	// Language is the processing.language the stories were written in; empty is English
+	Language string `json:"language,omitempty" yaml:"language,omitempty"`
+
+	Releases []Release `json:"releases,omitempty" yaml:"releases,omitempty"`
+	Risks    []Risk    `json:"risks,omitempty" yaml:"risks,omitempty"`
+
+	// Initiatives group epics one level above them, with processing.hierarchy_levels 3
+	Initiatives []Initiative `json:"initiatives,omitempty" yaml:"initiatives,omitempty"`
+
+	// Requirements are the numbered sections of the source document stories are traced to,
+	// with processing.traceability
+	Requirements []Requirement `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+}
+
+// Initiative is a theme of the project above epics, which name it by title
+type Initiative struct {
+	ID          string `json:"id,omitempty" yaml:"id,omitempty"`
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description" yaml:"description"`
+	Key         string `json:"jira_key,omitempty" yaml:"jira_key,omitempty"`
+}
+
+// Risk is a technical or delivery risk of the project, rated High, Medium or Low
+type Risk struct {
+	ID          string `json:"id,omitempty" yaml:"id,omitempty"`
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description" yaml:"description"`
+	Likelihood  string `json:"likelihood" yaml:"likelihood"`
+	Impact      string `json:"impact" yaml:"impact"`
+	Mitigation  string `json:"mitigation" yaml:"mitigation"`
+	Key         string `json:"jira_key,omitempty" yaml:"jira_key,omitempty"`
+}
+
+// Release is a planned version of the project that stories are delivered in, created in JIRA as
+// a fix version
+type Release struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty" yaml:"release_date,omitempty"`
+}
+
+// PlannedRelease is a release of an AI release plan with the stories it delivers
+type PlannedRelease struct {
+	Release
+	Stories []string `json:"stories"`
+}
+
+// ReleasePlan is the AI's assignment of stories to releases
+type ReleasePlan struct {
+	Releases []PlannedRelease `json:"releases"`
 }
 
 // Epic represents a project epic
 type Epic struct {
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Priority    string  `json:"priority"`
-	Chunk       int     `json:"chunk"`
-	Stories     []Story `json:"stories"`
+	ID          string   `json:"id,omitempty" yaml:"id,omitempty"`
+	Title       string   `json:"title" yaml:"title"`
+	Description string   `json:"description" yaml:"description"`
+	Priority    string   `json:"priority" yaml:"priority"`
+	Chunk       int      `json:"chunk" yaml:"chunk"`
+	Rationale   string   `json:"rationale,omitempty" yaml:"rationale,omitempty"`
+	Confidence  int      `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+	Key         string   `json:"jira_key,omitempty" yaml:"jira_key,omitempty"`
+	AddedIn     string   `json:"added_in,omitempty" yaml:"added_in,omitempty"`
+	Team        string   `json:"team,omitempty" yaml:"team,omitempty"`
+	Component   string   `json:"component,omitempty" yaml:"component,omitempty"`
+	Labels      []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Initiative  string   `json:"initiative,omitempty" yaml:"initiative,omitempty"`
+	Stories     []Story  `json:"stories" yaml:"stories"`
 }
 
 // Story represents a user story
 type Story struct {
-	Title              string   `json:"title"`
-	Description        string   `json:"description"`
-	StoryPoints        int      `json:"story_points"`
-	Priority           string   `json:"priority"`
-	AcceptanceCriteria []string `json:"acceptance_criteria"`
-	Dependencies       []string `json:"dependencies"`
+	ID                 string    `json:"id,omitempty" yaml:"id,omitempty"`
+	Title              string    `json:"title" yaml:"title"`
+	Description        string    `json:"description" yaml:"description"`
+	StoryPoints        int       `json:"story_points" yaml:"story_points"`
+	Priority           string    `json:"priority" yaml:"priority"`
+	AcceptanceCriteria []string  `json:"acceptance_criteria" yaml:"acceptance_criteria"`
+	Dependencies       []string  `json:"dependencies" yaml:"dependencies"`
+	Rationale          string    `json:"rationale,omitempty" yaml:"rationale,omitempty"`
+	Confidence         int       `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+	Key                string    `json:"jira_key,omitempty" yaml:"jira_key,omitempty"`
+	AddedIn            string    `json:"added_in,omitempty" yaml:"added_in,omitempty"`
+	Team               string    `json:"team,omitempty" yaml:"team,omitempty"`
+	Labels             []string  `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Release            string    `json:"release,omitempty" yaml:"release,omitempty"`
+	Assignee           string    `json:"assignee,omitempty" yaml:"assignee,omitempty"`
+	Subtasks           []Subtask `json:"subtasks,omitempty" yaml:"subtasks,omitempty"`
+	DoneGaps           []string  `json:"done_gaps,omitempty" yaml:"done_gaps,omitempty"`     // Definition of done items the story leaves unmet
+	Type               string    `json:"type,omitempty" yaml:"type,omitempty"`               // StoryTypeSpike for research spikes
+	Size               string    `json:"size,omitempty" yaml:"size,omitempty"`               // S, M or L for Kanban teams sizing instead of estimating
+	Requirement        string    `json:"requirement,omitempty" yaml:"requirement,omitempty"` // ID of the document section the story is derived from
+	Section            string    `json:"section,omitempty" yaml:"section,omitempty"`         // Heading of that section
+	Chunk              int       `json:"chunk,omitempty" yaml:"chunk,omitempty"`             // Chunk of the document the story was generated from
+}
+
+// Requirement is a numbered section of the source document that stories are traced back to
+type Requirement struct {
+	ID      string `json:"id" yaml:"id"` // REQ- and the outline number of the section, e.g. REQ-3.2
+	Heading string `json:"heading" yaml:"heading"`
+}
+
+// StoryTypeSpike marks a story that is a timeboxed research spike rather than a user story
+const StoryTypeSpike = "spike"
+
+// Subtask is an implementation task of a story, such as its backend, frontend or test work
+type Subtask struct {
+	ID          string `json:"id,omitempty" yaml:"id,omitempty"`
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Component   string `json:"component,omitempty" yaml:"component,omitempty"`
+	Key         string `json:"jira_key,omitempty" yaml:"jira_key,omitempty"`
 }
 
 // AnalysisResult represents the analysis output
 type AnalysisResult struct {
-	ProjectBreakdown ProjectBreakdown `json:"project_breakdown"`
-	AnalysisTime     time.Time        `json:"analysis_time"`
-	ProcessingMode   string           `json:"processing_mode"`
+	ProjectBreakdown ProjectBreakdown    `json:"project_breakdown"`
+	AnalysisTime     time.Time           `json:"analysis_time"`
+	ProcessingMode   string              `json:"processing_mode"`
+	ConversationFile string              `json:"conversation_file,omitempty"`
+	Directives       *DocumentDirectives `json:"directives,omitempty"`
+	SourceSnapshot   string              `json:"source_snapshot,omitempty"`
+	SourceFile       string              `json:"source_file,omitempty"` // Path or URL of the analyzed document as given
+	Revisions        []AnalysisRevision  `json:"revisions,omitempty"`
+}
+
+// AnalysisRevision records an incremental update that analyzed only the sections added to the
+// source document since the previous analysis. Epics and stories it added carry its ID in AddedIn.
+type AnalysisRevision struct {
+	ID           string    `json:"id"`
+	AnalyzedAt   time.Time `json:"analyzed_at"`
+	BaseAnalysis string    `json:"base_analysis"`
+	Sections     []string  `json:"sections"`
+}
+
+// DocumentDirectives represents the front-matter block of an input document, which overrides
+// configuration for that document
+type DocumentDirectives struct {
+	ProjectKey      string   `yaml:"project_key" json:"project_key,omitempty"`
+	Labels          []string `yaml:"labels" json:"labels,omitempty"`
+	Team            string   `yaml:"team" json:"team,omitempty"`
+	Template        string   `yaml:"template" json:"template,omitempty"`
+	Granularity     string   `yaml:"granularity" json:"granularity,omitempty"`
+	Phases          []string `yaml:"phases" json:"phases,omitempty"`
+	IncludeSections []string `yaml:"include_sections" json:"include_sections,omitempty"`
+	ExcludeSections []string `yaml:"exclude_sections" json:"exclude_sections,omitempty"`
 }
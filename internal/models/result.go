@@ -0,0 +1,50 @@
+package models
+
+// Run result statuses
+const (
+	ResultSuccess = "success"
+	ResultPartial = "partial"
+	ResultFailed  = "failed"
+)
+
+// RunResult is the machine-readable outcome of a command run with --ci or --output
+type RunResult struct {
+	Command         string            `json:"command"`
+	Status          string            `json:"status"`
+	ExitCode        int               `json:"exit_code"`
+	ErrorClass      string            `json:"error_class,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	InputFile       string            `json:"input_file,omitempty"`
+	AnalysisFile    string            `json:"analysis_file,omitempty"`
+	LedgerFile      string            `json:"ledger_file,omitempty"`
+	ReviewFile      string            `json:"review_file,omitempty"`
+	ProjectName     string            `json:"project_name,omitempty"`
+	Counts          ResultCounts      `json:"counts"`
+	Issues          []ResultIssue     `json:"issues,omitempty"`
+	Failed          []ResultIssue     `json:"failed,omitempty"`
+	Usage           TokenUsage        `json:"usage"`
+	Estimate        *CostEstimate     `json:"estimate,omitempty"`
+	DurationSeconds float64           `json:"duration_seconds"`
+	Breakdown       *ProjectBreakdown `json:"breakdown,omitempty"`
+	Sprints         *SprintPlan       `json:"sprints,omitempty"`
+	Lint            *LintReport       `json:"lint,omitempty"`
+}
+
+// ResultCounts summarizes the breakdown and what was created from it
+type ResultCounts struct {
+	Epics       int `json:"epics"`
+	Stories     int `json:"stories"`
+	StoryPoints int `json:"story_points"`
+	Created     int `json:"created"`
+	Failed      int `json:"failed"`
+	Queued      int `json:"queued_for_review"`
+}
+
+// ResultIssue is a JIRA issue created during the run, or an item whose issue failed to be created
+type ResultIssue struct {
+	ItemID   string `json:"item_id,omitempty"`
+	ItemType string `json:"item_type"`
+	Title    string `json:"title"`
+	Key      string `json:"key,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// RoadmapEpic is an epic placed on the roadmap, delivered in the quarter its work ends
+type RoadmapEpic struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Priority  string    `json:"priority"`
+	Points    int       `json:"points"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Quarter   string    `json:"quarter"`
+	DependsOn []string  `json:"depends_on,omitempty"`
+}
+
+// RoadmapMilestone is a release of the analysis, reached when the last epic with stories of the
+// release is done. Target is the release date the release was planned for, if any.
+type RoadmapMilestone struct {
+	Name   string    `json:"name"`
+	Date   time.Time `json:"date"`
+	Target string    `json:"target,omitempty"`
+}
+
+// Roadmap is the epics of an analysis phased over time at the team's velocity
+type Roadmap struct {
+	ProjectName string             `json:"project_name"`
+	Start       time.Time          `json:"start"`
+	Velocity    int                `json:"velocity"`
+	SprintDays  int                `json:"sprint_days"`
+	Epics       []RoadmapEpic      `json:"epics"`
+	Milestones  []RoadmapMilestone `json:"milestones,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SearchMatch represents an epic or story of a stored analysis that matched a search
+type SearchMatch struct {
+	AnalysisFile string    `json:"analysis_file"`
+	AnalysisTime time.Time `json:"analysis_time"`
+	ItemType     string    `json:"item_type"`
+	ItemRef      string    `json:"item_ref"`
+	Title        string    `json:"title"`
+	Epic         string    `json:"epic"`
+	MatchedIn    []string  `json:"matched_in"`
+	JiraKey      string    `json:"jira_key,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SessionBundle describes a session recorded with --record-session: the command line, with its
+// input files replaced by their copies in the bundle, and how the run ended
+type SessionBundle struct {
+	CreatedAt time.Time `json:"created_at"`
+	Args      []string  `json:"args"`
+	Config    string    `json:"config"`
+	Profile   string    `json:"profile,omitempty"`
+	Stdin     string    `json:"stdin"`
+	Inputs    []string  `json:"inputs"`
+	Exchanges int       `json:"exchanges"`
+	Error     string    `json:"error,omitempty"`
+	Files     []string  `json:"files"`
+}
+
+// SessionExchange is one HTTP request of a recorded session and the response it got, with
+// credentials removed. Error is set instead of a response when the request failed.
+type SessionExchange struct {
+	Seq             int               `json:"seq"`
+	Time            time.Time         `json:"time"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	Status          int               `json:"status,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	DurationMS      int64             `json:"duration_ms"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// StorySplit is a proposal to replace an oversized story with smaller ones
+type StorySplit struct {
+	StoryID string  `json:"story_id"`
+	Story   string  `json:"story"`
+	Stories []Story `json:"stories"`
+}
@@ -0,0 +1,66 @@
+package models
+
+// JiraBoard represents an agile board
+type JiraBoard struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// JiraSprint represents a sprint of an agile board
+type JiraSprint struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+}
+
+// JiraSprintIssue is an issue already in a sprint with its story points
+type JiraSprintIssue struct {
+	Key    string  `json:"key"`
+	Points float64 `json:"points"`
+}
+
+// SprintLoad is a future sprint with the issues and story points it already holds and the story
+// points planned into it. A sprint to be created is New and has a negative ID until it is.
+type SprintLoad struct {
+	Sprint   JiraSprint `json:"sprint"`
+	Capacity int        `json:"capacity"`
+	Used     float64    `json:"used"`
+	Planned  int        `json:"planned"`
+	Issues   []string   `json:"issues,omitempty"`
+	New      bool       `json:"new,omitempty"`
+}
+
+// Remaining returns the story points still free in a sprint
+func (l *SprintLoad) Remaining() float64 {
+	return float64(l.Capacity) - l.Used - float64(l.Planned)
+}
+
+// SprintAssignment places one story in a sprint; stories that fit in no sprint have no SprintID
+type SprintAssignment struct {
+	ID       string  `json:"id,omitempty"`
+	Key      string  `json:"key"`
+	Epic     string  `json:"epic"`
+	Title    string  `json:"title"`
+	Points   int     `json:"points"`
+	Priority string  `json:"priority,omitempty"`
+	WSJF     float64 `json:"wsjf"`
+	SprintID int     `json:"sprint_id,omitempty"`
+	Sprint   string  `json:"sprint,omitempty"`
+}
+
+// SprintPlan is how created stories are distributed over future sprints. With CreateSprints,
+// sprints of LengthDays are added after Previous, the board's last sprint, for stories that fit in
+// no existing one.
+type SprintPlan struct {
+	BoardID     int                `json:"board_id"`
+	Sprints     []SprintLoad       `json:"sprints"`
+	Assignments []SprintAssignment `json:"assignments"`
+
+	CreateSprints bool        `json:"create_sprints,omitempty"`
+	Capacity      int         `json:"-"`
+	LengthDays    int         `json:"-"`
+	Previous      *JiraSprint `json:"-"`
+}
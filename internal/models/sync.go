@@ -0,0 +1,19 @@
+package models
+
+// Sync actions for breakdown items
+const (
+	SyncCreate    = "create"
+	SyncUpdate    = "update"
+	SyncUnchanged = "unchanged"
+)
+
+// SyncAction is what a sync does for one epic, story or subtask of a breakdown
+type SyncAction struct {
+	ItemType  string `json:"item_type"`
+	Epic      string `json:"epic"`
+	Story     string `json:"story,omitempty"` // Story of a subtask
+	Title     string `json:"title"`
+	Action    string `json:"action"`
+	Key       string `json:"key,omitempty"`
+	MatchedBy string `json:"matched_by,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Kinds of generated test cases
+const (
+	TestPositive = "positive"
+	TestNegative = "negative"
+	TestEdge     = "edge"
+)
+
+// TestCase is a QA test case written for a story
+type TestCase struct {
+	Type          string   `json:"type"`
+	Title         string   `json:"title"`
+	Preconditions string   `json:"preconditions,omitempty"`
+	Steps         []string `json:"steps"`
+	Expected      string   `json:"expected"`
+}
+
+// StoryTestCases are the test cases of one story of an analysis
+type StoryTestCases struct {
+	StoryID   string     `json:"story_id"`
+	Story     string     `json:"story"`
+	JiraKey   string     `json:"jira_key,omitempty"`
+	TestCases []TestCase `json:"test_cases"`
+}
+
+// TestSuite holds the test cases generated for the stories of an analysis
+type TestSuite struct {
+	AnalysisFile string           `json:"analysis_file"`
+	ProjectName  string           `json:"project_name"`
+	Stories      []StoryTestCases `json:"stories"`
+	GeneratedAt  time.Time        `json:"generated_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Verification statuses reported for each acceptance criterion
+const (
+	CriterionSatisfied    = "satisfied"
+	CriterionPartial      = "partial"
+	CriterionNotSatisfied = "not_satisfied"
+	CriterionUnclear      = "unclear"
+)
+
+// CriterionCheck represents the AI assessment of a single acceptance criterion
+type CriterionCheck struct {
+	Criterion  string `json:"criterion"`
+	Status     string `json:"status"`
+	Confidence int    `json:"confidence"`
+	Evidence   string `json:"evidence"`
+}
+
+// VerificationResult represents an acceptance-criteria checklist for a story
+type VerificationResult struct {
+	StoryKey     string           `json:"story_key"`
+	StorySummary string           `json:"story_summary"`
+	DiffRange    string           `json:"diff_range"`
+	Summary      string           `json:"summary"`
+	Criteria     []CriterionCheck `json:"criteria"`
+	VerifiedAt   time.Time        `json:"verified_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// WorkspaceBundle describes the contents of an exported workspace archive
+type WorkspaceBundle struct {
+	CreatedAt time.Time `json:"created_at"`
+	Config    string    `json:"config"`
+	Inputs    []string  `json:"inputs"`
+	Files     []string  `json:"files"`
+}
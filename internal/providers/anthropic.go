@@ -0,0 +1,206 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+func init() {
+	Register("anthropic", func(cfg *config.Config) (AIProvider, error) {
+		return NewAnthropicProvider(&cfg.Anthropic, newClient(cfg, cfg.Anthropic.TimeoutSeconds,
+			transport.Header("x-api-key", cfg.Anthropic.APIKey),
+			transport.Header("anthropic-version", "2023-06-01"))), nil
+	})
+}
+
+// AnthropicProvider talks to the Anthropic messages API
+type AnthropicProvider struct {
+	config *config.AnthropicConfig
+	client *http.Client
+}
+
+// NewAnthropicProvider creates a new Anthropic provider that sends requests with client
+func NewAnthropicProvider(anthropicConfig *config.AnthropicConfig, client *http.Client) *AnthropicProvider {
+	return &AnthropicProvider{
+		config: anthropicConfig,
+		client: client,
+	}
+}
+
+// Name returns the provider identifier
+func (p *AnthropicProvider) Name() string {
+	return "anthropic"
+}
+
+// Model returns the configured Claude model
+func (p *AnthropicProvider) Model() string {
+	return p.config.Model
+}
+
+// Analyze sends a single prompt and returns the response text
+func (p *AnthropicProvider) Analyze(ctx context.Context, prompt string) (string, error) {
+	return p.Chat(ctx, []models.ChatMessage{{Role: "user", Content: prompt}})
+}
+
+// Chat sends a message history to the Anthropic API and returns the response text
+func (p *AnthropicProvider) Chat(ctx context.Context, messages []models.ChatMessage) (string, error) {
+	reqBody := map[string]interface{}{
+		"model":      p.config.Model,
+		"max_tokens": p.config.MaxTokens,
+		"messages":   messages,
+	}
+
+	apiResponse, err := p.send(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	for _, block := range apiResponse.Content {
+		if block.Type == "text" {
+			return strings.TrimSpace(block.Text), nil
+		}
+	}
+
+	return "", fmt.Errorf("empty response from API")
+}
+
+// AnalyzeStructured forces the model to answer through a tool call so the API validates the
+// response against the tool's JSON schema
+func (p *AnthropicProvider) AnalyzeStructured(ctx context.Context, prompt string, tool Tool) (string, error) {
+	return p.analyzeTool(ctx, map[string]interface{}{
+		"messages": []models.ChatMessage{{Role: "user", Content: prompt}},
+	}, tool)
+}
+
+// AnalyzeCached sends the instructions of the prompt as a system prompt marked for caching, so
+// requests repeating them within five minutes read them from the cache along with the tool
+func (p *AnthropicProvider) AnalyzeCached(ctx context.Context, prompt Prompt, tool Tool) (string, error) {
+	return p.analyzeTool(ctx, map[string]interface{}{
+		"system": []map[string]interface{}{{
+			"type":          "text",
+			"text":          prompt.Instructions,
+			"cache_control": map[string]string{"type": "ephemeral"},
+		}},
+		"messages": []models.ChatMessage{{Role: "user", Content: prompt.Content}},
+	}, tool)
+}
+
+// analyzeTool sends a request body forcing a call of the tool and returns the tool input as JSON
+func (p *AnthropicProvider) analyzeTool(ctx context.Context, reqBody map[string]interface{}, tool Tool) (string, error) {
+	reqBody["model"] = p.config.Model
+	reqBody["max_tokens"] = p.config.MaxTokens
+	reqBody["tools"] = []Tool{tool}
+	reqBody["tool_choice"] = map[string]string{
+		"type": "tool",
+		"name": tool.Name,
+	}
+
+	apiResponse, err := p.send(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	for _, block := range apiResponse.Content {
+		if block.Type == "tool_use" && block.Name == tool.Name {
+			return string(block.Input), nil
+		}
+	}
+
+	if apiResponse.StopReason == "max_tokens" {
+		return "", fmt.Errorf("response was truncated at %d tokens before the tool call completed", p.config.MaxTokens)
+	}
+
+	return "", fmt.Errorf("response did not contain a '%s' tool call", tool.Name)
+}
+
+// TestConnection looks up the configured model, which needs a valid API key but no tokens
+func (p *AnthropicProvider) TestConnection(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.anthropic.com/v1/models/"+p.config.Model, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("model '%s' is not available", p.config.Model)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "Anthropic API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// anthropicResponse represents the parts of a messages API response that are used
+type anthropicResponse struct {
+	StopReason string `json:"stop_reason"`
+	Content    []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// send posts a request body to the messages API and decodes the response
+func (p *AnthropicProvider) send(ctx context.Context, reqBody map[string]interface{}) (*anthropicResponse, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{API: "Anthropic API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var apiResponse anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode API response: %w", err)
+	}
+	reportUsage(ctx, Usage{
+		InputTokens:      apiResponse.Usage.InputTokens,
+		OutputTokens:     apiResponse.Usage.OutputTokens,
+		CacheWriteTokens: apiResponse.Usage.CacheCreationInputTokens,
+		CacheReadTokens:  apiResponse.Usage.CacheReadInputTokens,
+	})
+
+	if len(apiResponse.Content) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	return &apiResponse, nil
+}
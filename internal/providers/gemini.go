@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+func init() {
+	Register("gemini", func(cfg *config.Config) (AIProvider, error) {
+		return NewGeminiProvider(&cfg.Gemini, newClient(cfg, cfg.Gemini.TimeoutSeconds, transport.Header("x-goog-api-key", cfg.Gemini.APIKey))), nil
+	})
+}
+
+// GeminiProvider talks to the Google Generative Language API
+type GeminiProvider struct {
+	config *config.GeminiConfig
+	client *http.Client
+}
+
+// NewGeminiProvider creates a new Gemini provider that sends requests with client
+func NewGeminiProvider(geminiConfig *config.GeminiConfig, client *http.Client) *GeminiProvider {
+	return &GeminiProvider{
+		config: geminiConfig,
+		client: client,
+	}
+}
+
+// Name returns the provider identifier
+func (p *GeminiProvider) Name() string {
+	return "gemini"
+}
+
+// Model returns the configured Gemini model
+func (p *GeminiProvider) Model() string {
+	return p.config.Model
+}
+
+// Analyze sends a single prompt and returns the response text
+func (p *GeminiProvider) Analyze(ctx context.Context, prompt string) (string, error) {
+	return p.Chat(ctx, []models.ChatMessage{{Role: "user", Content: prompt}})
+}
+
+// Chat sends a message history to the generateContent API and returns the response text
+func (p *GeminiProvider) Chat(ctx context.Context, messages []models.ChatMessage) (string, error) {
+	type part struct {
+		Text string `json:"text"`
+	}
+	type content struct {
+		Role  string `json:"role"`
+		Parts []part `json:"parts"`
+	}
+
+	// Gemini calls the assistant role "model"
+	contents := make([]content, 0, len(messages))
+	for _, message := range messages {
+		role := message.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		contents = append(contents, content{Role: role, Parts: []part{{Text: message.Content}}})
+	}
+
+	reqBody := map[string]interface{}{
+		"contents": contents,
+		"generationConfig": map[string]interface{}{
+			"maxOutputTokens": p.config.MaxTokens,
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(p.config.BaseURL, "/"), p.config.Model)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", &transport.StatusError{API: "Gemini API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var apiResponse struct {
+		Candidates []struct {
+			Content struct {
+				Parts []part `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return "", fmt.Errorf("failed to decode API response: %w", err)
+	}
+	reportUsage(ctx, Usage{InputTokens: apiResponse.UsageMetadata.PromptTokenCount, OutputTokens: apiResponse.UsageMetadata.CandidatesTokenCount})
+
+	if len(apiResponse.Candidates) == 0 || len(apiResponse.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("empty response from API")
+	}
+
+	var text strings.Builder
+	for _, candidatePart := range apiResponse.Candidates[0].Content.Parts {
+		text.WriteString(candidatePart.Text)
+	}
+
+	return strings.TrimSpace(text.String()), nil
+}
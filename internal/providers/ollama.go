@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+func init() {
+	Register("ollama", func(cfg *config.Config) (AIProvider, error) {
+		return NewOllamaProvider(&cfg.Ollama, newClient(cfg, cfg.Ollama.TimeoutSeconds)), nil
+	})
+}
+
+// OllamaProvider talks to a local Ollama server so documents never leave the machine
+type OllamaProvider struct {
+	config *config.OllamaConfig
+	client *http.Client
+}
+
+// NewOllamaProvider creates a new Ollama provider that sends requests with client
+func NewOllamaProvider(ollamaConfig *config.OllamaConfig, client *http.Client) *OllamaProvider {
+	return &OllamaProvider{
+		config: ollamaConfig,
+		client: client,
+	}
+}
+
+// Name returns the provider identifier
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+// Model returns the configured local model
+func (p *OllamaProvider) Model() string {
+	return p.config.Model
+}
+
+// Analyze sends a single prompt and returns the response text
+func (p *OllamaProvider) Analyze(ctx context.Context, prompt string) (string, error) {
+	return p.Chat(ctx, []models.ChatMessage{{Role: "user", Content: prompt}})
+}
+
+// Chat sends a message history to the Ollama chat API and returns the response text
+func (p *OllamaProvider) Chat(ctx context.Context, messages []models.ChatMessage) (string, error) {
+	reqBody := map[string]interface{}{
+		"model":    p.config.Model,
+		"messages": messages,
+		"stream":   false,
+		"options": map[string]interface{}{
+			"num_predict": p.config.MaxTokens,
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimSuffix(p.config.BaseURL, "/") + "/api/chat"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("API request failed (is Ollama running at %s?): %w", p.config.BaseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", &transport.StatusError{API: "Ollama API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var apiResponse struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		PromptEvalCount int `json:"prompt_eval_count"`
+		EvalCount       int `json:"eval_count"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return "", fmt.Errorf("failed to decode API response: %w", err)
+	}
+	reportUsage(ctx, Usage{InputTokens: apiResponse.PromptEvalCount, OutputTokens: apiResponse.EvalCount})
+
+	if apiResponse.Message.Content == "" {
+		return "", fmt.Errorf("empty response from API")
+	}
+
+	return strings.TrimSpace(apiResponse.Message.Content), nil
+}
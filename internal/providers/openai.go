@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+func init() {
+	Register("openai", func(cfg *config.Config) (AIProvider, error) {
+		return NewOpenAIProvider(&cfg.OpenAI, newClient(cfg, cfg.OpenAI.TimeoutSeconds, transport.BearerToken(cfg.OpenAI.APIKey))), nil
+	})
+}
+
+// OpenAIProvider talks to the OpenAI chat completions API
+type OpenAIProvider struct {
+	config *config.OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAIProvider creates a new OpenAI provider that sends requests with client
+func NewOpenAIProvider(openAIConfig *config.OpenAIConfig, client *http.Client) *OpenAIProvider {
+	return &OpenAIProvider{
+		config: openAIConfig,
+		client: client,
+	}
+}
+
+// Name returns the provider identifier
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+// Model returns the configured OpenAI model
+func (p *OpenAIProvider) Model() string {
+	return p.config.Model
+}
+
+// Analyze sends a single prompt and returns the response text
+func (p *OpenAIProvider) Analyze(ctx context.Context, prompt string) (string, error) {
+	return p.Chat(ctx, []models.ChatMessage{{Role: "user", Content: prompt}})
+}
+
+// Chat sends a message history to the chat completions API and returns the response text
+func (p *OpenAIProvider) Chat(ctx context.Context, messages []models.ChatMessage) (string, error) {
+	reqBody := map[string]interface{}{
+		"model":      p.config.Model,
+		"max_tokens": p.config.MaxTokens,
+		"messages":   messages,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimSuffix(p.config.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", &transport.StatusError{API: "OpenAI API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var apiResponse struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return "", fmt.Errorf("failed to decode API response: %w", err)
+	}
+	reportUsage(ctx, Usage{InputTokens: apiResponse.Usage.PromptTokens, OutputTokens: apiResponse.Usage.CompletionTokens})
+
+	if len(apiResponse.Choices) == 0 {
+		return "", fmt.Errorf("empty response from API")
+	}
+
+	return strings.TrimSpace(apiResponse.Choices[0].Message.Content), nil
+}
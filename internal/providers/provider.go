@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+// AIProvider sends prompts to a large language model
+type AIProvider interface {
+	// Name returns the identifier used for the provider in configuration
+	Name() string
+
+	// Model returns the model the provider sends requests to
+	Model() string
+
+	// Analyze sends a single prompt and returns the response text
+	Analyze(ctx context.Context, prompt string) (string, error)
+
+	// Chat sends a message history and returns the next assistant message
+	Chat(ctx context.Context, messages []models.ChatMessage) (string, error)
+}
+
+// ConnectionTester is implemented by providers that can check their credentials without
+// sending a prompt
+type ConnectionTester interface {
+	// TestConnection checks that the API accepts the credentials and offers the configured model
+	TestConnection(ctx context.Context) error
+}
+
+// Tool describes a structured output the model must produce, as a JSON schema
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// StructuredProvider is implemented by providers that can return schema-validated JSON
+type StructuredProvider interface {
+	// AnalyzeStructured sends a prompt and forces the response to match the tool schema,
+	// returning the tool input as JSON
+	AnalyzeStructured(ctx context.Context, prompt string, tool Tool) (string, error)
+}
+
+// Prompt is a prompt split into instructions shared by many requests and the content of one
+type Prompt struct {
+	Instructions string
+	Content      string
+}
+
+// Text returns the prompt as a single text, the instructions followed by the content
+func (p Prompt) Text() string {
+	return p.Instructions + "\n\n" + p.Content
+}
+
+// CachingProvider is implemented by providers that cache the instructions of prompts, so that
+// requests repeating them are billed at the cheaper cache price
+type CachingProvider interface {
+	// AnalyzeCached is AnalyzeStructured with the instructions of the prompt cached
+	AnalyzeCached(ctx context.Context, prompt Prompt, tool Tool) (string, error)
+}
+
+// Factory creates a provider from the application configuration
+type Factory func(cfg *config.Config) (AIProvider, error)
+
+var registry = make(map[string]Factory)
+
+// Register makes a provider available under the given name
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// Names returns the names of all registered providers in sorted order
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the provider selected in the configuration
+func New(cfg *config.Config) (AIProvider, error) {
+	factory, exists := registry[cfg.Provider]
+	if !exists {
+		return nil, fmt.Errorf("unknown AI provider '%s' (available: %s)", cfg.Provider, strings.Join(Names(), ", "))
+	}
+	return factory(cfg)
+}
+
+// newClient creates the HTTP client of a provider. The AI request rate limit is shared by every
+// request the client sends, including those of concurrent chunk workers and retries.
+func newClient(cfg *config.Config, timeoutSeconds int, auth ...transport.Middleware) *http.Client {
+	middlewares := []transport.Middleware{transport.RateLimit(helpers.NewRateLimiter(cfg.Processing.RequestsPerMinute))}
+	return transport.NewClient(&cfg.HTTP, timeoutSeconds, append(middlewares, auth...)...)
+}
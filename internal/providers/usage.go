@@ -0,0 +1,29 @@
+package providers
+
+import "context"
+
+// Usage is the number of tokens the API of a provider reports for the requests of a prompt
+type Usage struct {
+	InputTokens      int // Input tokens billed at the full price
+	OutputTokens     int
+	CacheWriteTokens int // Input tokens written to the prompt cache
+	CacheReadTokens  int // Input tokens read from the prompt cache
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context that adds the token usage the API reports for requests sent with
+// it to usage
+func WithUsage(ctx context.Context, usage *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, usage)
+}
+
+// reportUsage adds the token usage the API reported for a response to the usage of the context
+func reportUsage(ctx context.Context, reported Usage) {
+	if usage, ok := ctx.Value(usageKey{}).(*Usage); ok {
+		usage.InputTokens += reported.InputTokens
+		usage.OutputTokens += reported.OutputTokens
+		usage.CacheWriteTokens += reported.CacheWriteTokens
+		usage.CacheReadTokens += reported.CacheReadTokens
+	}
+}
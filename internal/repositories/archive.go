@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// ArchiveRepository stores the index of runs archived out of the output directory
+type ArchiveRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewArchiveRepository creates a new archive repository for the output directory
+func NewArchiveRepository(outputDir string) *ArchiveRepository {
+	return &ArchiveRepository{
+		path: filepath.Join(outputDir, "archive-index.json"),
+	}
+}
+
+// AddRuns records runs that were moved into an archive
+func (r *ArchiveRepository) AddRuns(runs []models.OutputRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	if err := helpers.SaveJSON(append(existing, runs...), r.path); err != nil {
+		return fmt.Errorf("failed to save archive index: %w", err)
+	}
+	return nil
+}
+
+// GetRuns returns every archived run in the order they were archived
+func (r *ArchiveRepository) GetRuns() ([]models.OutputRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.load()
+}
+
+func (r *ArchiveRepository) load() ([]models.OutputRun, error) {
+	var runs []models.OutputRun
+	if !helpers.FileExists(r.path) {
+		return runs, nil
+	}
+
+	if err := helpers.LoadJSON(r.path, &runs); err != nil {
+		return nil, fmt.Errorf("failed to load archive index: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// BoardRepository stores the kanban board state of one analysis in the boards folder of the
+// output directory
+type BoardRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewBoardRepository creates a board repository for an analysis file
+func NewBoardRepository(outputDir, analysisFile string) *BoardRepository {
+	name := strings.TrimSuffix(filepath.Base(analysisFile), filepath.Ext(analysisFile))
+
+	return &BoardRepository{
+		path: filepath.Join(outputDir, "boards", fmt.Sprintf("board-%s.json", name)),
+	}
+}
+
+// Path returns the file the board state is saved to
+func (r *BoardRepository) Path() string {
+	return r.path
+}
+
+// GetState loads the board state, or returns an empty state if none was saved yet
+func (r *BoardRepository) GetState() (models.BoardState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var state models.BoardState
+	if _, err := os.Stat(r.path); os.IsNotExist(err) {
+		return state, nil
+	}
+
+	if err := helpers.LoadJSON(r.path, &state); err != nil {
+		return state, fmt.Errorf("failed to load board state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveState saves the board state
+func (r *BoardRepository) SaveState(state models.BoardState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := helpers.EnsureDir(filepath.Dir(r.path)); err != nil {
+		return err
+	}
+
+	if err := helpers.SaveJSON(state, r.path); err != nil {
+		return fmt.Errorf("failed to save board state: %w", err)
+	}
+	return nil
+}
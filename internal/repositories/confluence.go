@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+// ConfluenceRepository handles Confluence content API interactions
+type ConfluenceRepository struct {
+	config *config.ConfluenceConfig
+	client *http.Client
+}
+
+// NewConfluenceRepository creates a new Confluence repository whose requests authenticate with
+// the configured API token: with the username through basic authentication, or as a bearer
+// personal access token without one
+func NewConfluenceRepository(confluenceConfig *config.ConfluenceConfig, httpConfig *config.HTTPConfig) *ConfluenceRepository {
+	auth := transport.BasicAuth(confluenceConfig.Username, confluenceConfig.APIToken)
+	if confluenceConfig.Username == "" {
+		auth = transport.BearerToken(confluenceConfig.APIToken)
+	}
+
+	return &ConfluenceRepository{
+		config: confluenceConfig,
+		client: transport.NewClient(httpConfig.WithInsecureSkipVerify(confluenceConfig.InsecureSkipVerify), confluenceConfig.Timeout, auth),
+	}
+}
+
+// FindPage returns the page of a space with a title and its content, or nil when the space has
+// none
+func (r *ConfluenceRepository) FindPage(ctx context.Context, spaceKey, title string) (*models.ConfluencePage, error) {
+	query := url.Values{"spaceKey": {spaceKey}, "title": {title}, "type": {"page"}, "expand": {"body.storage,version"}}
+	req, err := http.NewRequestWithContext(ctx, "GET", r.contentURL("?"+query.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var pages models.ConfluencePageList
+	if err := r.do(req, &pages); err != nil {
+		return nil, err
+	}
+	if len(pages.Results) == 0 {
+		return nil, nil
+	}
+	return &pages.Results[0], nil
+}
+
+// GetPage returns a page with its content in storage format
+func (r *ConfluenceRepository) GetPage(ctx context.Context, pageID string) (*models.ConfluencePage, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.contentURL("/"+url.PathEscape(pageID)+"?expand=body.storage,version"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var page models.ConfluencePage
+	if err := r.do(req, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CreatePage creates a page with a body in storage format in a space, under the parent page when
+// parentID is set
+func (r *ConfluenceRepository) CreatePage(ctx context.Context, spaceKey, parentID, title, body string) (*models.ConfluencePage, error) {
+	page := map[string]interface{}{
+		"type":  "page",
+		"title": title,
+		"space": map[string]string{"key": spaceKey},
+		"body":  storageBody(body),
+	}
+	if parentID != "" {
+		page["ancestors"] = []map[string]string{{"id": parentID}}
+	}
+
+	return r.send(ctx, "POST", r.contentURL(""), page)
+}
+
+// UpdatePage replaces the title and body of a page, creating its next version
+func (r *ConfluenceRepository) UpdatePage(ctx context.Context, page *models.ConfluencePage, title, body string) (*models.ConfluencePage, error) {
+	update := map[string]interface{}{
+		"type":    "page",
+		"title":   title,
+		"version": models.ConfluenceVersion{Number: page.Version.Number + 1},
+		"body":    storageBody(body),
+	}
+
+	return r.send(ctx, "PUT", r.contentURL("/"+url.PathEscape(page.ID)), update)
+}
+
+// PageURL returns the web address of a page
+func (r *ConfluenceRepository) PageURL(page *models.ConfluencePage) string {
+	base := page.Links.Base
+	if base == "" {
+		base = strings.TrimSuffix(r.config.BaseURL, "/")
+	}
+	return base + page.Links.WebUI
+}
+
+// contentURL returns the URL of the content API with a path or query appended
+func (r *ConfluenceRepository) contentURL(suffix string) string {
+	return strings.TrimSuffix(r.config.BaseURL, "/") + "/rest/api/content" + suffix
+}
+
+// send sends a page as JSON and returns the page in the response
+func (r *ConfluenceRepository) send(ctx context.Context, method, url string, page map[string]interface{}) (*models.ConfluencePage, error) {
+	jsonData, err := json.Marshal(page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal page: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var saved models.ConfluencePage
+	if err := r.do(req, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// do sends a request and decodes the JSON response into target
+func (r *ConfluenceRepository) do(req *http.Request, target interface{}) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "Confluence API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// storageBody returns the body of a page in storage format
+func storageBody(body string) map[string]interface{} {
+	return map[string]interface{}{
+		"storage": map[string]string{"value": body, "representation": "storage"},
+	}
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// FeedbackRepository stores tracked issues and human edits as JSON files in the output directory
+type FeedbackRepository struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFeedbackRepository creates a new feedback repository rooted at the output directory
+func NewFeedbackRepository(outputDir string) *FeedbackRepository {
+	return &FeedbackRepository{
+		dir: filepath.Join(outputDir, "feedback"),
+	}
+}
+
+// TrackIssues records issues created by scrum-master so later edits can be attributed
+func (r *FeedbackRepository) TrackIssues(issues []models.TrackedIssue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracked, err := r.loadTrackedIssues()
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		tracked[issue.Key] = issue
+	}
+
+	return r.save("tracked-issues.json", tracked)
+}
+
+// GetTrackedIssues returns all tracked issues keyed by JIRA key
+func (r *FeedbackRepository) GetTrackedIssues() (map[string]models.TrackedIssue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.loadTrackedIssues()
+}
+
+// AddEdits appends human edits to the edit log
+func (r *FeedbackRepository) AddEdits(edits []models.IssueEdit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, err := r.loadEdits()
+	if err != nil {
+		return err
+	}
+
+	return r.save("edits.json", append(existing, edits...))
+}
+
+// GetEdits returns every recorded human edit
+func (r *FeedbackRepository) GetEdits() ([]models.IssueEdit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.loadEdits()
+}
+
+// AddRating appends a reviewer rating of a generated item
+func (r *FeedbackRepository) AddRating(rating models.ItemFeedback) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ratings []models.ItemFeedback
+	if err := r.load("ratings.json", &ratings); err != nil {
+		return err
+	}
+
+	return r.save("ratings.json", append(ratings, rating))
+}
+
+// GetRatings returns every reviewer rating in the order they were given
+func (r *FeedbackRepository) GetRatings() ([]models.ItemFeedback, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ratings []models.ItemFeedback
+	if err := r.load("ratings.json", &ratings); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+func (r *FeedbackRepository) loadTrackedIssues() (map[string]models.TrackedIssue, error) {
+	tracked := make(map[string]models.TrackedIssue)
+	if err := r.load("tracked-issues.json", &tracked); err != nil {
+		return nil, err
+	}
+	return tracked, nil
+}
+
+func (r *FeedbackRepository) loadEdits() ([]models.IssueEdit, error) {
+	var edits []models.IssueEdit
+	if err := r.load("edits.json", &edits); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+func (r *FeedbackRepository) load(name string, target interface{}) error {
+	path := filepath.Join(r.dir, name)
+	if !helpers.FileExists(path) {
+		return nil
+	}
+
+	if err := helpers.LoadJSON(path, target); err != nil {
+		return fmt.Errorf("failed to load %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *FeedbackRepository) save(name string, data interface{}) error {
+	if err := helpers.EnsureDir(r.dir); err != nil {
+		return err
+	}
+
+	if err := helpers.SaveJSON(data, filepath.Join(r.dir, name)); err != nil {
+		return fmt.Errorf("failed to save %s: %w", name, err)
+	}
+	return nil
+}
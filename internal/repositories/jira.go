@@ -2,42 +2,56 @@ package repositories
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"mime/multipart"
 	"net/http"
-	"time"
+	"sort"
+	"strings"
 
 	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
 	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
 )
 
+// ErrIssueNotFound is returned when an issue does not exist or is not visible to the user
+var ErrIssueNotFound = errors.New("issue not found")
+
 // JiraRepository handles JIRA API interactions
 type JiraRepository struct {
 	config *config.JiraConfig
 	client *http.Client
 }
 
-// NewJiraRepository creates a new JIRA repository
-func NewJiraRepository(jiraConfig *config.JiraConfig) *JiraRepository {
+// NewJiraRepository creates a new JIRA repository whose requests authenticate with the
+// configured API token: with the username through basic authentication, or as a bearer
+// personal access token with jira.auth_type pat
+func NewJiraRepository(jiraConfig *config.JiraConfig, httpConfig *config.HTTPConfig) *JiraRepository {
+	auth := transport.BasicAuth(jiraConfig.Username, jiraConfig.APIToken)
+	if jiraConfig.AuthType == config.JiraAuthPAT {
+		auth = transport.BearerToken(jiraConfig.APIToken)
+	}
+
 	return &JiraRepository{
 		config: jiraConfig,
-		client: &http.Client{
-			Timeout: time.Duration(jiraConfig.Timeout) * time.Second,
-		},
+		client: transport.NewClient(httpConfig.WithInsecureSkipVerify(jiraConfig.InsecureSkipVerify), jiraConfig.Timeout,
+			transport.RateLimit(helpers.NewRateLimiter(jiraConfig.RequestsPerMinute)), auth),
 	}
 }
 
 // TestConnection tests the JIRA connection and returns accessible projects
-func (r *JiraRepository) TestConnection() ([]models.JiraProjectInfo, error) {
-	url := fmt.Sprintf("%s/rest/api/2/project", r.config.BaseURL)
-	req, err := http.NewRequest("GET", url, nil)
+func (r *JiraRepository) TestConnection(ctx context.Context) ([]models.JiraProjectInfo, error) {
+	url := r.config.BaseURL + r.apiPath("/project")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(r.config.Username, r.config.APIToken)
-
 	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -46,7 +60,7 @@ func (r *JiraRepository) TestConnection() ([]models.JiraProjectInfo, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("JIRA API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var projects []models.JiraProjectInfo
@@ -58,15 +72,13 @@ func (r *JiraRepository) TestConnection() ([]models.JiraProjectInfo, error) {
 }
 
 // GetProjectInfo gets information about a specific project
-func (r *JiraRepository) GetProjectInfo(projectKey string) (*models.JiraProjectInfo, error) {
-	url := fmt.Sprintf("%s/rest/api/2/project/%s", r.config.BaseURL, projectKey)
-	req, err := http.NewRequest("GET", url, nil)
+func (r *JiraRepository) GetProjectInfo(ctx context.Context, projectKey string) (*models.JiraProjectInfo, error) {
+	url := r.config.BaseURL + r.apiPath("/project/%s", projectKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(r.config.Username, r.config.APIToken)
-
 	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -86,16 +98,41 @@ func (r *JiraRepository) GetProjectInfo(projectKey string) (*models.JiraProjectI
 	return &project, nil
 }
 
+// HasPermission reports whether the user has a project permission, such as CREATE_ISSUES
+func (r *JiraRepository) HasPermission(ctx context.Context, projectKey, permission string) (bool, error) {
+	url := r.config.BaseURL + r.apiPath("/mypermissions?projectKey=%s&permissions=%s", projectKey, permission)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var permissions models.JiraPermissions
+	if err := json.NewDecoder(resp.Body).Decode(&permissions); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return permissions.Permissions[permission].HavePermission, nil
+}
+
 // GetIssueTypes gets available issue types for a project
-func (r *JiraRepository) GetIssueTypes(projectKey string) ([]models.JiraIssueTypeInfo, error) {
-	url := fmt.Sprintf("%s/rest/api/2/project/%s", r.config.BaseURL, projectKey)
-	req, err := http.NewRequest("GET", url, nil)
+func (r *JiraRepository) GetIssueTypes(ctx context.Context, projectKey string) ([]models.JiraIssueTypeInfo, error) {
+	url := r.config.BaseURL + r.apiPath("/project/%s", projectKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(r.config.Username, r.config.APIToken)
-
 	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
@@ -117,21 +154,63 @@ func (r *JiraRepository) GetIssueTypes(projectKey string) ([]models.JiraIssueTyp
 	return projectInfo.IssueTypes, nil
 }
 
+// GetVersions lists the versions of a project
+func (r *JiraRepository) GetVersions(ctx context.Context, projectKey string) ([]models.JiraVersion, error) {
+	var versions []models.JiraVersion
+	if _, err := r.getJSON(ctx, r.apiPath("/project/%s/versions", projectKey), &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// CreateVersion creates a version in the project the version names
+func (r *JiraRepository) CreateVersion(ctx context.Context, version *models.JiraVersion) (*models.JiraVersion, error) {
+	jsonData, err := json.Marshal(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal version: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/version")
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var created models.JiraVersion
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &created, nil
+}
+
 // CreateIssue creates a new JIRA issue
-func (r *JiraRepository) CreateIssue(issue *models.JiraIssue) (*models.JiraResponse, error) {
-	jsonData, err := json.Marshal(issue)
+func (r *JiraRepository) CreateIssue(ctx context.Context, issue *models.JiraIssue) (*models.JiraResponse, error) {
+	jsonData, err := json.Marshal(r.issueRequest(issue))
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal issue: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/rest/api/2/issue", r.config.BaseURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	url := r.config.BaseURL + r.apiPath("/issue")
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(r.config.Username, r.config.APIToken)
 
 	resp, err := r.client.Do(req)
 	if err != nil {
@@ -141,7 +220,7 @@ func (r *JiraRepository) CreateIssue(issue *models.JiraIssue) (*models.JiraRespo
 
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("JIRA API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var jiraResp models.JiraResponse
@@ -151,3 +230,792 @@ func (r *JiraRepository) CreateIssue(issue *models.JiraIssue) (*models.JiraRespo
 
 	return &jiraResp, nil
 }
+
+// MaxBulkIssues is the most issues JIRA creates in one bulk request
+const MaxBulkIssues = 50
+
+// CreateIssues creates up to MaxBulkIssues issues in one request through the bulk endpoint. It
+// returns the key of every created issue and the error of every rejected one, by position in
+// issues. An error means the request as a whole failed.
+func (r *JiraRepository) CreateIssues(ctx context.Context, issues []*models.JiraIssue) ([]string, []error, error) {
+	var bulk models.JiraBulkIssues
+	for _, issue := range issues {
+		bulk.IssueUpdates = append(bulk.IssueUpdates, *r.issueRequest(issue))
+	}
+
+	jsonData, err := json.Marshal(bulk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal issues: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/issue/bulk")
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// JIRA answers 201 when some issues were created and 400 when all were rejected, listing
+	// the rejected ones either way
+	var created models.JiraBulkResponse
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusBadRequest {
+		return nil, nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.Unmarshal(body, &created); err != nil || (resp.StatusCode == http.StatusBadRequest && len(created.Errors) == 0) {
+		return nil, nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	keys := make([]string, len(issues))
+	errs := make([]error, len(issues))
+	for _, rejected := range created.Errors {
+		if rejected.FailedElementNumber >= 0 && rejected.FailedElementNumber < len(issues) {
+			errs[rejected.FailedElementNumber] = &transport.StatusError{API: "JIRA API", StatusCode: rejected.Status, Body: rejected.ElementErrors.String()}
+		}
+	}
+
+	next := 0
+	for i := range issues {
+		if errs[i] != nil {
+			continue
+		}
+		if next >= len(created.Issues) {
+			errs[i] = fmt.Errorf("JIRA API did not return the created issue")
+			continue
+		}
+		keys[i] = created.Issues[next].Key
+		next++
+	}
+	return keys, errs, nil
+}
+
+// issueRequest returns an issue as it is sent to JIRA, with the description in the format of the
+// API version
+func (r *JiraRepository) issueRequest(issue *models.JiraIssue) *models.JiraIssue {
+	fields := issue.Fields
+	if r.config.APIVersion == config.JiraAPIv3 {
+		fields.Custom = maps.Clone(fields.Custom)
+		if fields.Custom == nil {
+			fields.Custom = make(map[string]interface{})
+		}
+		fields.Custom["description"] = r.description(fields.Description)
+	} else {
+		fields.Description = helpers.MarkdownToWiki(fields.Description)
+	}
+	return &models.JiraIssue{Fields: fields}
+}
+
+// GetIssue gets the summary and description of an existing issue
+func (r *JiraRepository) GetIssue(ctx context.Context, issueKey string) (*models.JiraIssueDetails, error) {
+	url := r.config.BaseURL + r.apiPath("/issue/%s?fields=summary,description", issueKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var issue models.JiraIssueDetails
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	readDescription(&issue.Fields)
+
+	return &issue, nil
+}
+
+// GetFields lists every system and custom field defined on the instance
+func (r *JiraRepository) GetFields(ctx context.Context) ([]models.JiraFieldInfo, error) {
+	var fields []models.JiraFieldInfo
+	if _, err := r.getJSON(ctx, r.apiPath("/field"), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// GetCreateMeta lists the issue types that can be created in a project with the fields of their
+// create screens. It uses the per-issue-type createmeta endpoints of JIRA Cloud and newer JIRA
+// Server versions, falling back to the older expanded createmeta endpoint.
+func (r *JiraRepository) GetCreateMeta(ctx context.Context, projectKey string) ([]models.JiraCreateMetaIssueType, error) {
+	var typesPage struct {
+		Values     []models.JiraCreateMetaIssueType `json:"values"`
+		IssueTypes []models.JiraCreateMetaIssueType `json:"issueTypes"`
+	}
+
+	status, err := r.getJSON(ctx, r.apiPath("/issue/createmeta/%s/issuetypes?maxResults=100", projectKey), &typesPage)
+	if status == http.StatusNotFound {
+		return r.getLegacyCreateMeta(ctx, projectKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issueTypes := append(typesPage.Values, typesPage.IssueTypes...)
+	for i := range issueTypes {
+		var fieldsPage struct {
+			Values []models.JiraCreateMetaField `json:"values"`
+			Fields []models.JiraCreateMetaField `json:"fields"`
+		}
+
+		path := r.apiPath("/issue/createmeta/%s/issuetypes/%s?maxResults=200", projectKey, issueTypes[i].ID)
+		if _, err := r.getJSON(ctx, path, &fieldsPage); err != nil {
+			return nil, fmt.Errorf("failed to get fields of issue type '%s': %w", issueTypes[i].Name, err)
+		}
+		issueTypes[i].Fields = append(fieldsPage.Values, fieldsPage.Fields...)
+	}
+
+	return issueTypes, nil
+}
+
+// getLegacyCreateMeta reads createmeta from the single expanded endpoint of older JIRA versions
+func (r *JiraRepository) getLegacyCreateMeta(ctx context.Context, projectKey string) ([]models.JiraCreateMetaIssueType, error) {
+	var meta struct {
+		Projects []struct {
+			IssueTypes []struct {
+				ID      string `json:"id"`
+				Name    string `json:"name"`
+				Subtask bool   `json:"subtask"`
+				Fields  map[string]struct {
+					Name          string                         `json:"name"`
+					Required      bool                           `json:"required"`
+					Schema        models.JiraFieldSchema         `json:"schema"`
+					AllowedValues []models.JiraFieldAllowedValue `json:"allowedValues"`
+				} `json:"fields"`
+			} `json:"issuetypes"`
+		} `json:"projects"`
+	}
+
+	path := r.apiPath("/issue/createmeta?projectKeys=%s&expand=projects.issuetypes.fields", projectKey)
+	if _, err := r.getJSON(ctx, path, &meta); err != nil {
+		return nil, err
+	}
+
+	if len(meta.Projects) == 0 {
+		return nil, fmt.Errorf("project '%s' not found in createmeta", projectKey)
+	}
+
+	var issueTypes []models.JiraCreateMetaIssueType
+	for _, issueType := range meta.Projects[0].IssueTypes {
+		converted := models.JiraCreateMetaIssueType{
+			ID:      issueType.ID,
+			Name:    issueType.Name,
+			Subtask: issueType.Subtask,
+		}
+		for id, field := range issueType.Fields {
+			converted.Fields = append(converted.Fields, models.JiraCreateMetaField{
+				FieldID:       id,
+				Name:          field.Name,
+				Required:      field.Required,
+				Schema:        field.Schema,
+				AllowedValues: field.AllowedValues,
+			})
+		}
+		sort.Slice(converted.Fields, func(i, j int) bool {
+			return converted.Fields[i].FieldID < converted.Fields[j].FieldID
+		})
+		issueTypes = append(issueTypes, converted)
+	}
+
+	return issueTypes, nil
+}
+
+// apiPath returns the path of a REST API resource in the configured API version
+func (r *JiraRepository) apiPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("/rest/api/%d", r.config.APIVersion) + fmt.Sprintf(format, args...)
+}
+
+// description returns a description in the form the API version takes: wiki markup for v2, an
+// ADF document for v3, which takes no description as null
+func (r *JiraRepository) description(text string) interface{} {
+	if r.config.APIVersion != config.JiraAPIv3 {
+		return helpers.MarkdownToWiki(text)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return helpers.MarkdownToADF(text)
+}
+
+// readDescription turns the ADF description API v3 returns into text
+func readDescription(fields *models.JiraIssueDetailsFields) {
+	if fields.DescriptionADF == nil {
+		return
+	}
+
+	var document helpers.ADFNode
+	if err := json.Unmarshal(fields.DescriptionADF, &document); err == nil {
+		fields.Description = helpers.ADFToMarkup(document)
+	}
+}
+
+// StoredDescription returns a description as JIRA will return it after storing it: converted to
+// wiki markup for API v2, to ADF and back for v3. Comparing it with the description read from an
+// issue tells whether the issue needs an update.
+func (r *JiraRepository) StoredDescription(text string) string {
+	if r.config.APIVersion == config.JiraAPIv3 {
+		return helpers.ADFToMarkup(helpers.MarkdownToADF(text))
+	}
+	return helpers.MarkdownToWiki(text)
+}
+
+// RawDescription returns the field value of a description already in the form StoredDescription
+// returns and GetIssue reads, so that parts of a description read from an issue are written back
+// as they are rather than converted a second time
+func (r *JiraRepository) RawDescription(stored string) interface{} {
+	if r.config.APIVersion == config.JiraAPIv3 {
+		return r.description(stored)
+	}
+	return wikiMarkup(stored)
+}
+
+// wikiMarkup is a description in wiki markup already, which UpdateIssue sends unconverted
+type wikiMarkup string
+
+// getJSON performs an authenticated GET request and decodes the JSON response into target.
+// It returns the HTTP status code alongside any error.
+func (r *JiraRepository) getJSON(ctx context.Context, path string, target interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.config.BaseURL+path, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// DeleteIssue deletes an issue and its subtasks
+func (r *JiraRepository) DeleteIssue(ctx context.Context, issueKey string) error {
+	url := r.config.BaseURL + r.apiPath("/issue/%s?deleteSubtasks=true", issueKey)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrIssueNotFound
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// GetTransitions lists the workflow transitions currently available for an issue
+func (r *JiraRepository) GetTransitions(ctx context.Context, issueKey string) ([]models.JiraTransition, error) {
+	var response struct {
+		Transitions []models.JiraTransition `json:"transitions"`
+	}
+
+	status, err := r.getJSON(ctx, r.apiPath("/issue/%s/transitions", issueKey), &response)
+	if status == http.StatusNotFound {
+		return nil, ErrIssueNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Transitions, nil
+}
+
+// TransitionIssue moves an issue through a workflow transition
+func (r *JiraRepository) TransitionIssue(ctx context.Context, issueKey, transitionID string) error {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"transition": map[string]string{"id": transitionID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal transition: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/issue/%s/transitions", issueKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// FindTeams lists the Advanced Roadmaps teams visible to the user
+func (r *JiraRepository) FindTeams(ctx context.Context) ([]models.JiraTeam, error) {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"query":      "",
+		"maxResults": 1000,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal team query: %w", err)
+	}
+
+	url := r.config.BaseURL + "/rest/teams/1.0/teams/find"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var response struct {
+		Teams []models.JiraTeam `json:"teams"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return response.Teams, nil
+}
+
+// SearchIssues returns every issue matching a JQL query, following pagination
+func (r *JiraRepository) SearchIssues(ctx context.Context, jql string, fields []string) ([]models.JiraIssueDetails, error) {
+	var issues []models.JiraIssueDetails
+
+	for {
+		jsonData, err := json.Marshal(map[string]interface{}{
+			"jql":        jql,
+			"startAt":    len(issues),
+			"maxResults": 100,
+			"fields":     fields,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal search: %w", err)
+		}
+
+		url := r.config.BaseURL + r.apiPath("/search")
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+		}
+
+		var page struct {
+			Total  int                       `json:"total"`
+			Issues []models.JiraIssueDetails `json:"issues"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		for i := range page.Issues {
+			readDescription(&page.Issues[i].Fields)
+		}
+		issues = append(issues, page.Issues...)
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// UpdateIssue sets fields of an existing issue. A description given as text is converted from
+// markdown; one from RawDescription is sent as it is.
+func (r *JiraRepository) UpdateIssue(ctx context.Context, issueKey string, fields map[string]interface{}) error {
+	if text, ok := fields["description"].(string); ok {
+		fields = maps.Clone(fields)
+		fields["description"] = r.description(text)
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return fmt.Errorf("failed to marshal issue: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/issue/%s", issueKey)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// AddAttachment uploads a file as an attachment of an issue
+func (r *JiraRepository) AddAttachment(ctx context.Context, issueKey, filename string, content []byte) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return fmt.Errorf("failed to create form: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return fmt.Errorf("failed to create form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to create form: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/issue/%s/attachments", issueKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// JIRA rejects uploads without this header as cross-site requests
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// AddRemoteLink adds a link to a web page to an issue. The URL identifies the link, so adding
+// it again, as publishing a page again does, updates the link rather than adding another.
+func (r *JiraRepository) AddRemoteLink(ctx context.Context, issueKey, linkURL, title string) error {
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"globalId": linkURL,
+		"object":   map[string]string{"url": linkURL, "title": title},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal remote link: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/issue/%s/remotelink", issueKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// GetIssueWithProperty gets the summary of an issue together with one of its entity properties
+func (r *JiraRepository) GetIssueWithProperty(ctx context.Context, issueKey, propertyKey string) (*models.JiraIssueDetails, error) {
+	var issue models.JiraIssueDetails
+
+	status, err := r.getJSON(ctx, r.apiPath("/issue/%s?fields=summary&properties=%s", issueKey, propertyKey), &issue)
+	if status == http.StatusNotFound {
+		return nil, ErrIssueNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
+
+// SetIssueProperty stores an entity property on an issue
+func (r *JiraRepository) SetIssueProperty(ctx context.Context, issueKey, propertyKey string, value interface{}) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal property: %w", err)
+	}
+
+	url := r.config.BaseURL + r.apiPath("/issue/%s/properties/%s", issueKey, propertyKey)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// GetScrumBoards lists the scrum boards of a project
+func (r *JiraRepository) GetScrumBoards(ctx context.Context, projectKey string) ([]models.JiraBoard, error) {
+	var boards []models.JiraBoard
+
+	for {
+		var page struct {
+			IsLast bool               `json:"isLast"`
+			Values []models.JiraBoard `json:"values"`
+		}
+
+		path := fmt.Sprintf("/rest/agile/1.0/board?projectKeyOrId=%s&type=scrum&startAt=%d", projectKey, len(boards))
+		if _, err := r.getJSON(ctx, path, &page); err != nil {
+			return nil, err
+		}
+
+		boards = append(boards, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return boards, nil
+		}
+	}
+}
+
+// GetSprints lists the sprints of a board in the given state (future, active or closed) in
+// board order
+func (r *JiraRepository) GetSprints(ctx context.Context, boardID int, state string) ([]models.JiraSprint, error) {
+	var sprints []models.JiraSprint
+
+	for {
+		var page struct {
+			IsLast bool                `json:"isLast"`
+			Values []models.JiraSprint `json:"values"`
+		}
+
+		path := fmt.Sprintf("/rest/agile/1.0/board/%d/sprint?state=%s&startAt=%d", boardID, state, len(sprints))
+		if _, err := r.getJSON(ctx, path, &page); err != nil {
+			return nil, err
+		}
+
+		sprints = append(sprints, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return sprints, nil
+		}
+	}
+}
+
+// CreateSprint creates a future sprint on a board. Dates are only set when both are given.
+func (r *JiraRepository) CreateSprint(ctx context.Context, boardID int, sprint models.JiraSprint) (*models.JiraSprint, error) {
+	fields := map[string]interface{}{"name": sprint.Name, "originBoardId": boardID}
+	if sprint.StartDate != "" && sprint.EndDate != "" {
+		fields["startDate"] = sprint.StartDate
+		fields["endDate"] = sprint.EndDate
+	}
+
+	jsonData, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sprint: %w", err)
+	}
+
+	url := r.config.BaseURL + "/rest/agile/1.0/sprint"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var created models.JiraSprint
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &created, nil
+}
+
+// GetSprintIssues lists the issues of a sprint with the story points read from pointsField
+func (r *JiraRepository) GetSprintIssues(ctx context.Context, sprintID int, pointsField string) ([]models.JiraSprintIssue, error) {
+	var issues []models.JiraSprintIssue
+
+	for {
+		var page struct {
+			Total  int `json:"total"`
+			Issues []struct {
+				Key    string                 `json:"key"`
+				Fields map[string]interface{} `json:"fields"`
+			} `json:"issues"`
+		}
+
+		path := fmt.Sprintf("/rest/agile/1.0/sprint/%d/issue?fields=%s&startAt=%d", sprintID, pointsField, len(issues))
+		if _, err := r.getJSON(ctx, path, &page); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range page.Issues {
+			points, _ := issue.Fields[pointsField].(float64)
+			issues = append(issues, models.JiraSprintIssue{Key: issue.Key, Points: points})
+		}
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// MoveIssuesToSprint moves issues into a sprint, at most 50 per request
+func (r *JiraRepository) MoveIssuesToSprint(ctx context.Context, sprintID int, issueKeys []string) error {
+	for start := 0; start < len(issueKeys); start += 50 {
+		end := min(start+50, len(issueKeys))
+
+		jsonData, err := json.Marshal(map[string]interface{}{"issues": issueKeys[start:end]})
+		if err != nil {
+			return fmt.Errorf("failed to marshal issues: %w", err)
+		}
+
+		url := fmt.Sprintf("%s/rest/agile/1.0/sprint/%d/issue", r.config.BaseURL, sprintID)
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusNoContent {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+		}
+		resp.Body.Close()
+	}
+
+	return nil
+}
+
+// RankIssues orders issues in the backlog as listed by ranking each after the one before it,
+// at most 50 per request. The first issue keeps its rank.
+func (r *JiraRepository) RankIssues(ctx context.Context, issueKeys []string) error {
+	for start := 1; start < len(issueKeys); start += 50 {
+		end := min(start+50, len(issueKeys))
+
+		jsonData, err := json.Marshal(map[string]interface{}{
+			"issues":         issueKeys[start:end],
+			"rankAfterIssue": issueKeys[start-1],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal issues: %w", err)
+		}
+
+		url := r.config.BaseURL + "/rest/agile/1.0/issue/rank"
+		req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+
+		// 207 Multi-Status means some issues could not be ranked
+		if resp.StatusCode != http.StatusNoContent {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return &transport.StatusError{API: "JIRA API", StatusCode: resp.StatusCode, Body: string(body)}
+		}
+		resp.Body.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// LedgerRepository persists a run ledger, saving it after every recorded issue
+type LedgerRepository struct {
+	path   string
+	mu     sync.Mutex
+	ledger models.RunLedger
+}
+
+// NewLedgerRepository starts a new run ledger in the ledgers folder of the output directory
+func NewLedgerRepository(outputDir, analysisFile, projectKey string) *LedgerRepository {
+	runID := helpers.GenerateTimestamp()
+
+	return &LedgerRepository{
+		path: filepath.Join(outputDir, "ledgers", fmt.Sprintf("run-ledger-%s.json", runID)),
+		ledger: models.RunLedger{
+			RunID:        runID,
+			AnalysisFile: analysisFile,
+			ProjectKey:   projectKey,
+			StartedAt:    time.Now(),
+		},
+	}
+}
+
+// OpenLedgerRepository loads an existing run ledger so a run can continue recording into it
+func OpenLedgerRepository(path string) (*LedgerRepository, error) {
+	r := &LedgerRepository{path: path}
+	if err := helpers.LoadJSON(path, &r.ledger); err != nil {
+		return nil, fmt.Errorf("failed to load run ledger: %w", err)
+	}
+	return r, nil
+}
+
+// Path returns the file the ledger is saved to
+func (r *LedgerRepository) Path() string {
+	return r.path
+}
+
+// Ledger returns a copy of the ledger
+func (r *LedgerRepository) Ledger() models.RunLedger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ledger := r.ledger
+	ledger.Entries = append([]models.LedgerEntry(nil), r.ledger.Entries...)
+	return ledger
+}
+
+// MarkRolledBack flags the entry of a rolled back issue and saves the ledger
+func (r *LedgerRepository) MarkRolledBack(key string) error {
+	return r.updateEntry(key, func(entry *models.LedgerEntry) {
+		entry.RolledBack = true
+	})
+}
+
+// MarkDeleted flags the entry of an issue that was deleted in JIRA and saves the ledger
+func (r *LedgerRepository) MarkDeleted(key string) error {
+	return r.updateEntry(key, func(entry *models.LedgerEntry) {
+		entry.Deleted = true
+	})
+}
+
+// updateEntry applies update to the entries of an issue and saves the ledger
+func (r *LedgerRepository) updateEntry(key string, update func(entry *models.LedgerEntry)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.ledger.Entries {
+		if r.ledger.Entries[i].Key == key {
+			update(&r.ledger.Entries[i])
+		}
+	}
+
+	if err := helpers.SaveJSON(r.ledger, r.path); err != nil {
+		return fmt.Errorf("failed to save run ledger: %w", err)
+	}
+	return nil
+}
+
+// Record appends a created issue to the ledger and saves it
+func (r *LedgerRepository) Record(entry models.LedgerEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ledger.Entries = append(r.ledger.Entries, entry)
+
+	if err := helpers.EnsureDir(filepath.Dir(r.path)); err != nil {
+		return err
+	}
+	if err := helpers.SaveJSON(r.ledger, r.path); err != nil {
+		return fmt.Errorf("failed to save run ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+const (
+	// notionVersion is the version of the Notion API the requests are written for
+	notionVersion = "2022-06-28"
+
+	// notionRequestsPerMinute keeps requests within the average rate the Notion API allows
+	notionRequestsPerMinute = 180
+
+	// notionPageSize is the largest number of results the Notion API returns per request
+	notionPageSize = 100
+)
+
+// NotionRepository handles Notion API interactions
+type NotionRepository struct {
+	config *config.NotionConfig
+	client *http.Client
+}
+
+// NewNotionRepository creates a new Notion repository whose requests authenticate with the
+// secret of the configured integration
+func NewNotionRepository(notionConfig *config.NotionConfig, httpConfig *config.HTTPConfig) *NotionRepository {
+	return &NotionRepository{
+		config: notionConfig,
+		client: transport.NewClient(httpConfig, notionConfig.Timeout,
+			transport.RateLimit(helpers.NewRateLimiter(notionRequestsPerMinute)),
+			transport.BearerToken(notionConfig.APIToken),
+			transport.Header("Notion-Version", notionVersion)),
+	}
+}
+
+// GetPage returns a page with its properties
+func (r *NotionRepository) GetPage(ctx context.Context, pageID string) (*models.NotionPage, error) {
+	var page models.NotionPage
+	if err := r.do(ctx, "GET", "/pages/"+url.PathEscape(pageID), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetDatabase returns a database with its title
+func (r *NotionRepository) GetDatabase(ctx context.Context, databaseID string) (*models.NotionDatabase, error) {
+	var database models.NotionDatabase
+	if err := r.do(ctx, "GET", "/databases/"+url.PathEscape(databaseID), nil, &database); err != nil {
+		return nil, err
+	}
+	return &database, nil
+}
+
+// QueryDatabase returns every page of a database, in the order of its default view
+func (r *NotionRepository) QueryDatabase(ctx context.Context, databaseID string) ([]models.NotionPage, error) {
+	var pages []models.NotionPage
+	cursor := ""
+	for {
+		query := map[string]interface{}{"page_size": notionPageSize}
+		if cursor != "" {
+			query["start_cursor"] = cursor
+		}
+
+		var list models.NotionPageList
+		if err := r.do(ctx, "POST", "/databases/"+url.PathEscape(databaseID)+"/query", query, &list); err != nil {
+			return nil, err
+		}
+		pages = append(pages, list.Results...)
+		if !list.HasMore || list.NextCursor == "" {
+			return pages, nil
+		}
+		cursor = list.NextCursor
+	}
+}
+
+// GetBlockChildren returns the child blocks of a block or page, without their own children
+func (r *NotionRepository) GetBlockChildren(ctx context.Context, blockID string) ([]models.NotionBlock, error) {
+	var blocks []models.NotionBlock
+	cursor := ""
+	for {
+		query := url.Values{"page_size": {fmt.Sprint(notionPageSize)}}
+		if cursor != "" {
+			query.Set("start_cursor", cursor)
+		}
+
+		var list models.NotionBlockList
+		if err := r.do(ctx, "GET", "/blocks/"+url.PathEscape(blockID)+"/children?"+query.Encode(), nil, &list); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, list.Results...)
+		if !list.HasMore || list.NextCursor == "" {
+			return blocks, nil
+		}
+		cursor = list.NextCursor
+	}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into target
+func (r *NotionRepository) do(ctx context.Context, method, path string, body, target interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.config.BaseURL, "/")+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return &transport.StatusError{API: "Notion API", StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+// ErrOffsetMismatch is returned when the server expects a chunk at a different offset; the
+// returned upload has the offset to continue from
+var ErrOffsetMismatch = errors.New("server expects a different upload offset")
+
+// ServerRepository calls the analysis API of a scrum-master server
+type ServerRepository struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewServerRepository creates a client for the server at baseURL. Requests have no overall
+// timeout, since progress streams stay open for the whole analysis.
+func NewServerRepository(baseURL, apiToken string, httpConfig *config.HTTPConfig) *ServerRepository {
+	return &ServerRepository{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  transport.NewClient(httpConfig, 0, transport.BearerToken(apiToken)),
+	}
+}
+
+// CreateUpload starts an upload of a document of size bytes
+func (r *ServerRepository) CreateUpload(ctx context.Context, name string, size int64) (*models.Upload, error) {
+	var upload models.Upload
+	if err := r.send(ctx, "POST", "/api/uploads", map[string]interface{}{"name": name, "size": size}, http.StatusCreated, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// GetUpload returns an upload with the offset the server has received
+func (r *ServerRepository) GetUpload(ctx context.Context, id string) (*models.Upload, error) {
+	var upload models.Upload
+	if err := r.send(ctx, "GET", "/api/uploads/"+id, nil, http.StatusOK, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// AppendUpload sends a chunk starting at offset. On ErrOffsetMismatch the returned upload has
+// the offset the server expects.
+func (r *ServerRepository) AppendUpload(ctx context.Context, id string, offset int64, chunk []byte) (*models.Upload, error) {
+	req, err := http.NewRequestWithContext(ctx, "PATCH", r.baseURL+"/api/uploads/"+id, bytes.NewReader(chunk))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		return nil, apiError(resp)
+	}
+
+	var upload models.Upload
+	if err := json.NewDecoder(resp.Body).Decode(&upload); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.StatusCode == http.StatusConflict {
+		return &upload, ErrOffsetMismatch
+	}
+	return &upload, nil
+}
+
+// StartJob starts the analysis of a complete upload
+func (r *ServerRepository) StartJob(ctx context.Context, uploadID string) (*models.Job, error) {
+	var job models.Job
+	if err := r.send(ctx, "POST", "/api/jobs", map[string]string{"upload_id": uploadID}, http.StatusAccepted, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob returns a job with its status and heartbeat
+func (r *ServerRepository) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	var job models.Job
+	if err := r.send(ctx, "GET", "/api/jobs/"+id, nil, http.StatusOK, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// StreamEvents reads the progress events of a job after the event numbered after and passes
+// each one, heartbeats included, to handle. It returns when the server ends the stream, which
+// it does once the job has finished, or when the connection breaks.
+func (r *ServerRepository) StreamEvents(ctx context.Context, id string, after int, handle func(models.JobEvent)) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/api/jobs/"+id+"/events", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if after > 0 {
+		req.Header.Set("Last-Event-ID", strconv.Itoa(after))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+
+	var event models.JobEvent
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ": ")
+
+		switch field {
+		case "id":
+			event.ID, _ = strconv.Atoi(value)
+		case "event":
+			event.Type = value
+		case "data":
+			event.Data = json.RawMessage(value)
+		case "":
+			if event.Type != "" {
+				handle(event)
+			}
+			event = models.JobEvent{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("progress stream broke off: %w", err)
+	}
+	return nil
+}
+
+// DownloadResult returns the analysis file of a finished job
+func (r *ServerRepository) DownloadResult(ctx context.Context, id string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", r.baseURL+"/api/jobs/"+id+"/result", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result: %w", err)
+	}
+	return data, nil
+}
+
+// send makes a JSON request and decodes the response when it has the expected status
+func (r *ServerRepository) send(ctx context.Context, method, path string, body interface{}, expected int, target interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return apiError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// apiError returns the error of a failed API response
+func apiError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, body.Error)
+	}
+	return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(data))
+}
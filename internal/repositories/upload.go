@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// ErrUploadNotFound is returned for an unknown upload ID
+var ErrUploadNotFound = errors.New("upload not found")
+
+// ErrUploadOffset is returned when a chunk does not start where the upload stopped
+var ErrUploadOffset = errors.New("chunk does not start at the upload offset")
+
+// ErrUploadTooLarge is returned when a chunk goes past the announced size of an upload
+var ErrUploadTooLarge = errors.New("chunk exceeds the upload size")
+
+// ErrUploadBusy is returned when another chunk of an upload is still being written
+var ErrUploadBusy = errors.New("another chunk of the upload is being written")
+
+// UploadRepository stores documents uploaded in chunks in the uploads folder of the output
+// directory. The received bytes are the upload's offset, so uploads survive a server restart.
+// One chunk of an upload is written at a time, while chunks of different uploads are written
+// side by side.
+type UploadRepository struct {
+	dir string
+
+	mu        sync.Mutex
+	appending map[string]bool // Uploads a chunk is being written to
+}
+
+// NewUploadRepository creates a repository for uploads under outputDir
+func NewUploadRepository(outputDir string) *UploadRepository {
+	return &UploadRepository{dir: filepath.Join(outputDir, "uploads"), appending: make(map[string]bool)}
+}
+
+// Create starts an empty upload of a document of size bytes
+func (r *UploadRepository) Create(name string, size int64) (*models.Upload, error) {
+	if err := helpers.EnsureDir(r.dir); err != nil {
+		return nil, fmt.Errorf("failed to create uploads directory: %w", err)
+	}
+
+	id, err := helpers.RandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &models.Upload{ID: id, Name: filepath.Base(name), Size: size, CreatedAt: time.Now()}
+	if err := helpers.SaveJSON(upload, r.metadataPath(id)); err != nil {
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+	if err := os.WriteFile(r.Path(id), nil, 0600); err != nil {
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	return upload, nil
+}
+
+// Get returns an upload with the number of bytes received so far
+func (r *UploadRepository) Get(id string) (*models.Upload, error) {
+	if !helpers.IsRandomID(id) || !helpers.FileExists(r.metadataPath(id)) {
+		return nil, ErrUploadNotFound
+	}
+
+	var upload models.Upload
+	if err := helpers.LoadJSON(r.metadataPath(id), &upload); err != nil {
+		return nil, fmt.Errorf("failed to load upload: %w", err)
+	}
+
+	info, err := os.Stat(r.Path(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload file: %w", err)
+	}
+	upload.Offset = info.Size()
+	return &upload, nil
+}
+
+// Append writes a chunk that starts at offset. Bytes received before the chunk broke off are
+// kept, so the client continues from the returned upload's offset. The chunk is read without
+// holding up other uploads, and the upload is not pruned while it is written.
+func (r *UploadRepository) Append(id string, offset int64, chunk io.Reader) (*models.Upload, error) {
+	r.mu.Lock()
+	if r.appending[id] {
+		r.mu.Unlock()
+		return nil, ErrUploadBusy
+	}
+	r.appending[id] = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.appending, id)
+		r.mu.Unlock()
+	}()
+
+	upload, err := r.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrUploadOffset
+	}
+
+	file, err := os.OpenFile(r.Path(id), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer file.Close()
+
+	remaining := upload.Size - upload.Offset
+	written, copyErr := io.Copy(file, io.LimitReader(chunk, remaining+1))
+	if written > remaining {
+		if err := file.Truncate(upload.Offset); err != nil {
+			return nil, fmt.Errorf("failed to discard chunk: %w", err)
+		}
+		return upload, ErrUploadTooLarge
+	}
+
+	upload.Offset += written
+	if copyErr != nil {
+		return upload, fmt.Errorf("chunk interrupted: %w", copyErr)
+	}
+	return upload, nil
+}
+
+// Prune removes the uploads created before cutoff, except those in keep and those a chunk is
+// being written to, and returns how many it removed
+func (r *UploadRepository) Prune(cutoff time.Time, keep map[string]bool) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := os.ReadDir(r.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read uploads directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		id, isMetadata := strings.CutSuffix(entry.Name(), ".json")
+		if !isMetadata || !helpers.IsRandomID(id) || keep[id] || r.appending[id] {
+			continue
+		}
+
+		upload, err := r.Get(id)
+		if err != nil || !upload.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(r.Path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove upload %s: %w", id, err)
+		}
+		if err := os.Remove(r.metadataPath(id)); err != nil {
+			return removed, fmt.Errorf("failed to remove upload %s: %w", id, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Path returns the file an upload's content is written to
+func (r *UploadRepository) Path(id string) string {
+	return filepath.Join(r.dir, id+".part")
+}
+
+// metadataPath returns the file an upload's name and size are saved to
+func (r *UploadRepository) metadataPath(id string) string {
+	return filepath.Join(r.dir, id+".json")
+}
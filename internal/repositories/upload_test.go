@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadAppendStalled(t *testing.T) {
+	repo := NewUploadRepository(t.TempDir())
+	stalled, err := repo.Create("stalled.md", 10)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	other, err := repo.Create("other.md", 5)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// A chunk whose sender stops after a few bytes
+	reader, writer := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := repo.Append(stalled.ID, 0, reader)
+		done <- err
+	}()
+	writer.Write([]byte("abc"))
+
+	if _, err := repo.Append(stalled.ID, 3, strings.NewReader("defgh")); !errors.Is(err, ErrUploadBusy) {
+		t.Errorf("Append() to the stalled upload error = %v, want %v", err, ErrUploadBusy)
+	}
+
+	appended := make(chan error)
+	go func() {
+		_, err := repo.Append(other.ID, 0, strings.NewReader("12345"))
+		appended <- err
+	}()
+	select {
+	case err := <-appended:
+		if err != nil {
+			t.Errorf("Append() to another upload error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Append() to another upload waited for the stalled chunk")
+	}
+
+	if removed, err := repo.Prune(time.Now().Add(time.Hour), nil); err != nil || removed != 1 {
+		t.Errorf("Prune() = %d, %v, want 1 upload removed and the stalled one kept", removed, err)
+	}
+
+	writer.CloseWithError(errors.New("connection reset"))
+	if err := <-done; err == nil {
+		t.Errorf("Append() of the stalled chunk error = nil, want the interruption")
+	}
+	upload, err := repo.Get(stalled.ID)
+	if err != nil || upload.Offset != 3 {
+		t.Errorf("Get() = %+v, %v, want the 3 bytes received kept", upload, err)
+	}
+}
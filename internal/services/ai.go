@@ -1,203 +1,716 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"scrum-master/internal/config"
 	"scrum-master/internal/helpers"
 	"scrum-master/internal/models"
+	"scrum-master/internal/providers"
+	"scrum-master/internal/transport"
 )
 
 // AIService handles AI-powered project analysis
 type AIService struct {
-	config *config.AnthropicConfig
-	client *http.Client
+	config    *config.AnthropicConfig
+	provider  providers.AIProvider
+	mu        sync.Mutex
+	history   []models.ChatMessage
+	guidance  []string
+	glossary  map[string]string
+	context   []contextDocument
+	examples  string
+	language  string
+	criteria  string
+	scale     config.EstimationScale
+	templates promptTemplates
+	usage     models.TokenUsage
+	chunks    map[int]*models.ChunkUsage
+	pricing   config.PricingConfig
+	budget    float64
+	reserved  float64
+	released  *sync.Cond
+	fields    breakdownFields
 }
 
-// NewAIService creates a new AI service
-func NewAIService(anthropicConfig *config.AnthropicConfig) *AIService {
-	return &AIService{
-		config: anthropicConfig,
-		client: &http.Client{
-			Timeout: time.Duration(anthropicConfig.TimeoutSeconds) * time.Second,
+// chunkKey is the context key of the number of the chunk a prompt analyzes
+type chunkKey struct{}
+
+// overloadedDelay is the least time to wait before retrying a provider that reported being
+// overloaded, as retrying sooner rarely helps
+const overloadedDelay = 15 * time.Second
+
+// sizeClasses are the sizes Kanban stories get instead of story points, from small to large
+var sizeClasses = []string{"S", "M", "L"}
+
+// NFREpicTitle is the title of the epic non-functional requirements are collected in
+const NFREpicTitle = "Non-Functional Requirements"
+
+// subtaskComponents are the parts of the implementation subtasks are generated for
+var subtaskComponents = []string{"backend", "frontend", "tests", "infrastructure", "documentation"}
+
+// NewAIService creates a new AI service backed by the configured provider
+func NewAIService(cfg *config.Config) (*AIService, error) {
+	provider, err := providers.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := loadTemplates(cfg.Processing.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := loadContext(cfg.Processing.ContextFiles)
+	if err != nil {
+		return nil, err
+	}
+	examples, err := loadExamples(cfg.Processing.ExamplesFile)
+	if err != nil {
+		return nil, err
+	}
+	warnIncompleteOverrides(cfg.Processing.TemplatesDir, map[string]bool{
+		"processing.context_files":              len(documents) > 0,
+		"processing.glossary":                   len(cfg.Processing.Glossary) > 0,
+		"processing.examples_file":              examples != "",
+		"processing.language":                   cfg.Processing.Language != "",
+		"processing.acceptance_criteria_format": cfg.Processing.CriteriaFormat == config.CriteriaGherkin,
+	})
+
+	service := &AIService{
+		config:    &cfg.Anthropic,
+		provider:  provider,
+		chunks:    make(map[int]*models.ChunkUsage),
+		pricing:   modelPricing(cfg.Provider, provider.Model(), cfg.Processing.Pricing),
+		budget:    cfg.Anthropic.MaxCostUSD,
+		glossary:  cfg.Processing.Glossary,
+		context:   documents,
+		examples:  examples,
+		language:  cfg.Processing.Language,
+		criteria:  cfg.Processing.CriteriaFormat,
+		scale:     cfg.Processing.EstimationScale,
+		templates: templates,
+		fields: breakdownFields{
+			Subtasks:    cfg.Processing.Subtasks,
+			Labels:      cfg.Processing.SuggestLabels,
+			DoneGaps:    cfg.Jira.DefinitionOfDone.Validate,
+			Risks:       cfg.Processing.Risks,
+			Spikes:      cfg.Processing.Spikes,
+			Initiatives: cfg.Processing.HierarchyLevels == 3,
+			Sections:    cfg.Processing.Traceability,
+			Kanban:      cfg.Processing.Kanban(),
+			Sizes:       cfg.Processing.SizeClasses(),
+			TShirt:      !cfg.Processing.Kanban() && cfg.Processing.EstimationScale.Name == config.ScaleTShirt,
+			Points:      cfg.Processing.EstimationScale.Points,
+			Teams:       teamNames(cfg.Jira.Teams),
 		},
 	}
+
+	service.released = sync.NewCond(&service.mu)
+
+	if service.budget > 0 && service.pricing == (config.PricingConfig{}) && cfg.Provider != "ollama" {
+		return nil, fmt.Errorf("anthropic.max_cost_usd needs the price of model %s; set processing.pricing", provider.Model())
+	}
+
+	if service.fields.Subtasks {
+		service.AddGuidance(fmt.Sprintf(`Subtasks: break every story into the implementation subtasks a developer would pick up, in a "subtasks" list on the story. Each subtask has a "title", a short "description" and a "component" (one of %s). Typically a story has backend, frontend and tests subtasks; leave out components the story does not need.`,
+			strings.Join(subtaskComponents, ", ")))
+	}
+	if service.fields.Labels {
+		service.AddGuidance(`Labels: give every epic and story a "labels" list of one to three short, lowercase, hyphenated labels for the areas it touches, such as "payments" or "mobile", reusing the same labels across items. Give every epic a "component" naming the single area of the product it belongs to, such as "backend", "web" or "billing", using the same name for epics of the same area.`)
+	}
+	if service.fields.DoneGaps {
+		service.AddGuidance(fmt.Sprintf("Definition of done: every story must meet this checklist:\n- %s\nGive every story a \"done_gaps\" list of the checklist items it cannot meet as described, for example because its acceptance criteria leave them out, and an empty list when it meets all of them.",
+			strings.Join(cfg.Jira.DefinitionOfDone.Story, "\n- ")))
+	}
+	if cfg.Processing.NFREpic {
+		service.AddGuidance(fmt.Sprintf(`Non-functional requirements: collect every performance, security, accessibility, observability, reliability and scalability requirement of the description in one epic titled exactly %q, with one story per requirement, instead of leaving them out or scattering them over feature stories. Every acceptance criterion of these stories states a measurable target and how it is checked, such as "95%% of API requests complete within 300 ms at 200 requests per second" or "every page passes WCAG 2.1 AA checks in axe". Leave the epic out when the description has no such requirements.`, NFREpicTitle))
+	}
+	if service.fields.Spikes {
+		service.AddGuidance(fmt.Sprintf(`Spikes: when delivering a story depends on an unknown that has to be researched first, such as the feasibility of an approach, the choice between technologies or the behaviour of an external system, add a separate story with "type": "spike" for that research, timeboxed to %s. The acceptance criteria of a spike are the questions it answers and the decision or prototype it produces. Give every other story "type": "story".`,
+			spikeTimebox(cfg.Processing)))
+	}
+	if service.fields.Initiatives {
+		service.AddGuidance(`Initiatives: group the epics under two to five initiatives, the strategic themes of the project such as "Self-service onboarding" or "Payments modernization". List them in a top-level "initiatives" list, each with a "title" and a "description" of the outcome it pursues, and give every epic an "initiative" field naming its initiative by title exactly.`)
+	}
+	if service.fields.Sections {
+		service.AddGuidance(`Traceability: give every story a "section" naming the heading of the document section it is derived from, copied exactly as it appears in the document without the leading # characters. When a story draws on several sections, name the one that asks for it most directly.`)
+	}
+	if service.fields.Risks {
+		service.AddGuidance(`Risks: add a top-level "risks" list of the technical and delivery risks the description reveals, such as unproven technology, external dependencies, unclear requirements, tight deadlines or missing skills. Each risk has a "title", a "description" of what could go wrong, a "likelihood" and an "impact" (High, Medium or Low), and a "mitigation" the team can plan for. Only list risks the description gives reason for.`)
+	}
+	return service, nil
 }
 
 // ProcessWithAI analyzes project content and returns a breakdown
-func (s *AIService) ProcessWithAI(content string, chunkIndex, totalChunks int) (*models.ProjectBreakdown, error) {
-	var prompt string
-
-	if totalChunks == 1 {
-		prompt = fmt.Sprintf(`You are a senior project manager and technical lead. Analyze the following project description and break it down into actionable epics and user stories for a development team.
-
-Project Description:
-%s
-
-Please respond with a JSON object that follows this exact structure:
-{
-  "project_name": "string",
-  "overview": "brief project overview",
-  "epics": [
-    {
-      "title": "Epic title",
-      "description": "Detailed epic description",
-      "priority": "High|Medium|Low",
-      "stories": [
-        {
-          "title": "User story title",
-          "description": "As a [user type], I want [goal] so that [benefit]",
-          "priority": "High|Medium|Low",
-          "story_points": 1-8,
-          "acceptance_criteria": ["criteria1", "criteria2"],
-          "dependencies": ["optional dependency references"]
-        }
-      ]
-    }
-  ]
-}
-
-Guidelines:
-- Create 3-7 epics that represent major functional areas
-- Each epic should have 3-8 user stories
-- Story points should follow Fibonacci sequence (1,2,3,5,8)
-- Write clear acceptance criteria for each story
-- Identify dependencies between stories where relevant
-- Prioritize based on business value and technical dependencies
-- Use proper user story format: "As a [persona], I want [goal] so that [benefit]"
-
-Respond ONLY with valid JSON. Do not include any markdown formatting or explanations.`, content)
-	} else {
-		prompt = fmt.Sprintf(`You are analyzing chunk %d of %d from a larger project description. Focus on the content in this chunk while being aware it's part of a larger project.
-
-Content to analyze:
-%s
-
-Please respond with a JSON object focusing on epics and stories that can be derived from THIS SPECIFIC CONTENT:
-{
-  "project_name": "string (extract from content or use generic name)",
-  "overview": "brief overview based on this chunk",
-  "epics": [
-    {
-      "title": "Epic title (specific to this chunk's content)",
-      "description": "Detailed epic description",
-      "priority": "High|Medium|Low",
-      "stories": [
-        {
-          "title": "User story title",
-          "description": "As a [user type], I want [goal] so that [benefit]",
-          "priority": "High|Medium|Low", 
-          "story_points": 1-8,
-          "acceptance_criteria": ["criteria1", "criteria2"],
-          "dependencies": ["optional dependency references"]
-        }
-      ]
-    }
-  ]
-}
-
-Guidelines for chunk processing:
-- Focus only on what's clearly described in this chunk
-- Create 1-4 epics based on the chunk content
-- Each epic should have 2-6 user stories
-- Use story points (1,2,3,5,8) appropriate for individual stories
-- Be specific about acceptance criteria based on chunk content
-- If the chunk seems incomplete, create stories for what IS described
-
-Respond ONLY with valid JSON. Do not include any markdown formatting or explanations.`, chunkIndex, totalChunks, content)
-	}
-
-	// Call Anthropic API
-	reqBody := map[string]interface{}{
-		"model":      s.config.Model,
-		"max_tokens": s.config.MaxTokens,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
+func (s *AIService) ProcessWithAI(ctx context.Context, content string, chunkIndex, totalChunks int) (*models.ProjectBreakdown, error) {
+	prompt, err := s.chunkPrompt(content, chunkIndex, totalChunks)
+	if err != nil {
+		return nil, err
 	}
+	ctx = context.WithValue(ctx, chunkKey{}, chunkIndex)
 
-	jsonData, err := json.Marshal(reqBody)
+	responseText, err := s.sendCachedPrompt(ctx, prompt, breakdownTool(s.fields))
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Parse the AI response
+	var breakdown models.ProjectBreakdown
+	if err := s.decodeJSONResponse(ctx, prompt.Text(), responseText, &breakdown); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", s.config.APIKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	for i := range breakdown.Epics {
+		s.sizePoints(breakdown.Epics[i].Stories)
+	}
+	return &breakdown, nil
+}
 
-	resp, err := s.client.Do(req)
+// EstimateChunk returns the estimated input tokens of analyzing a chunk and the most output
+// tokens its answer may use
+func (s *AIService) EstimateChunk(content string, chunkIndex, totalChunks int) (inputTokens, maxOutputTokens int, err error) {
+	prompt, err := s.chunkPrompt(content, chunkIndex, totalChunks)
 	if err != nil {
-		return nil, fmt.Errorf("API request failed: %w", err)
+		return 0, 0, err
 	}
-	defer resp.Body.Close()
+	return helpers.EstimateTokens(prompt.Text()), s.config.MaxTokens, nil
+}
+
+// Pricing returns the prices the usage of the service is charged at
+func (s *AIService) Pricing() config.PricingConfig {
+	return s.pricing
+}
+
+// Model returns the model prompts are sent to
+func (s *AIService) Model() string {
+	return s.provider.Model()
+}
+
+// CachesPrompts reports whether the provider caches the instructions of chunk prompts
+func (s *AIService) CachesPrompts() bool {
+	_, ok := s.provider.(providers.CachingProvider)
+	return ok
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+// chunkPrompt returns the prompt analyzing a chunk of project content. Its instructions are the
+// same for every chunk of a run, so they can be cached.
+func (s *AIService) chunkPrompt(content string, chunkIndex, totalChunks int) (providers.Prompt, error) {
+	data := promptData{
+		ChunkIndex:  chunkIndex,
+		TotalChunks: totalChunks,
+		Content:     content,
 	}
 
-	var apiResponse struct {
-		Content []struct {
-			Text string `json:"text"`
-		} `json:"content"`
+	instructions, err := s.render(TemplateBreakdown, data)
+	if err != nil {
+		return providers.Prompt{}, err
+	}
+	chunk, err := s.render(TemplateChunk, data)
+	if err != nil {
+		return providers.Prompt{}, err
 	}
+	return providers.Prompt{Instructions: instructions, Content: chunk}, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode API response: %w", err)
+// SynthesizeBreakdown asks the AI to unify the breakdowns of separately analyzed chunks into one
+// coherent breakdown with a single overview and consolidated epics
+func (s *AIService) SynthesizeBreakdown(ctx context.Context, chunkBreakdowns []*models.ProjectBreakdown) (*models.ProjectBreakdown, error) {
+	chunksJSON, err := json.MarshalIndent(chunkBreakdowns, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chunk breakdowns: %w", err)
 	}
 
-	if len(apiResponse.Content) == 0 {
-		return nil, fmt.Errorf("empty response from API")
+	prompt, err := s.render(TemplateSynthesis, promptData{
+		TotalChunks: len(chunkBreakdowns),
+		Breakdowns:  string(chunksJSON),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responseText, err := s.sendStructuredPrompt(ctx, prompt, breakdownTool(s.fields))
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse the AI response
 	var breakdown models.ProjectBreakdown
-	responseText := strings.TrimSpace(apiResponse.Content[0].Text)
+	if err := s.decodeJSONResponse(ctx, prompt, responseText, &breakdown); err != nil {
+		return nil, err
+	}
 
-	// Remove any potential markdown formatting
-	responseText = strings.TrimPrefix(responseText, "```json")
-	responseText = strings.TrimSuffix(responseText, "```")
-	responseText = strings.TrimSpace(responseText)
+	for i := range breakdown.Epics {
+		s.sizePoints(breakdown.Epics[i].Stories)
+	}
+	return &breakdown, nil
+}
 
-	if err := json.Unmarshal([]byte(responseText), &breakdown); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response as JSON: %w\nResponse: %s", err, responseText)
+// PlanReleases asks the AI which release delivers each story of a breakdown. The plan refers to
+// stories as S1, S2, … in breakdown order. Without releases, the AI proposes them.
+func (s *AIService) PlanReleases(ctx context.Context, breakdown *models.ProjectBreakdown, releases []models.Release) (*models.ReleasePlan, error) {
+	var stories strings.Builder
+	n := 0
+	for _, epic := range breakdown.Epics {
+		stories.WriteString(fmt.Sprintf("Epic: %s\n", epic.Title))
+		for _, story := range epic.Stories {
+			n++
+			line := fmt.Sprintf("- S%d: %s (%s priority, %d points)", n, story.Title, story.Priority, story.StoryPoints)
+			if len(story.Dependencies) > 0 {
+				line += fmt.Sprintf(", depends on: %s", strings.Join(story.Dependencies, "; "))
+			}
+			stories.WriteString(line + "\n")
+		}
 	}
 
-	return &breakdown, nil
+	prompt, err := s.render(TemplateReleases, promptData{Stories: stories.String(), Releases: releases})
+	if err != nil {
+		return nil, err
+	}
+
+	responseText, err := s.sendStructuredPrompt(ctx, prompt, releaseTool())
+	if err != nil {
+		return nil, err
+	}
+
+	var plan models.ReleasePlan
+	if err := s.decodeJSONResponse(ctx, prompt, responseText, &plan); err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// VerifyAcceptanceCriteria asks the AI whether a code diff plausibly satisfies the acceptance
+// criteria found in a story description
+func (s *AIService) VerifyAcceptanceCriteria(ctx context.Context, summary, description, diff string) (*models.VerificationResult, error) {
+	prompt, err := s.render(TemplateVerify, promptData{Story: summary, Description: description, Diff: diff})
+	if err != nil {
+		return nil, err
+	}
+
+	responseText, err := s.sendPrompt(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var result models.VerificationResult
+	if err := s.decodeJSONResponse(ctx, prompt, responseText, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GenerateTestCases asks the AI for positive, negative and edge test cases of a story
+func (s *AIService) GenerateTestCases(ctx context.Context, story *models.Story) ([]models.TestCase, error) {
+	prompt, err := s.render(TemplateTests, promptData{Story: story.Title, Description: story.Description, Criteria: story.AcceptanceCriteria})
+	if err != nil {
+		return nil, err
+	}
+
+	responseText, err := s.sendStructuredPrompt(ctx, prompt, testCaseTool())
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		TestCases []models.TestCase `json:"test_cases"`
+	}
+	if err := s.decodeJSONResponse(ctx, prompt, responseText, &result); err != nil {
+		return nil, err
+	}
+
+	return result.TestCases, nil
 }
 
-// ProcessWithRetry processes content with retry logic
-func (s *AIService) ProcessWithRetry(content string, chunkIndex, totalChunks int) (*models.ProjectBreakdown, error) {
+// SplitStory asks the AI to split an oversized story into smaller ones. A story the AI finds no
+// sensible split for comes back as the only story.
+func (s *AIService) SplitStory(ctx context.Context, story *models.Story) ([]models.Story, error) {
+	prompt, err := s.render(TemplateSplit, promptData{Story: story.Title, Description: story.Description, Criteria: story.AcceptanceCriteria})
+	if err != nil {
+		return nil, err
+	}
+
+	responseText, err := s.sendStructuredPrompt(ctx, prompt, splitTool(s.fields))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Stories []models.Story `json:"stories"`
+	}
+	if err := s.decodeJSONResponse(ctx, prompt, responseText, &result); err != nil {
+		return nil, err
+	}
+
+	s.sizePoints(result.Stories)
+	return result.Stories, nil
+}
+
+// sizePoints gives stories estimated in T-shirt sizes the story points of their size, so totals,
+// sprints and the JIRA story points field work as with any other scale
+func (s *AIService) sizePoints(stories []models.Story) {
+	if !s.fields.TShirt {
+		return
+	}
+	for i := range stories {
+		stories[i].StoryPoints = s.scale.SizePoints(stories[i].Size)
+	}
+}
+
+// Summarize condenses content into a short bullet list focused on the given topic
+func (s *AIService) Summarize(ctx context.Context, content, focus string) (string, error) {
+	prompt, err := s.render(TemplateSummarize, promptData{Focus: focus, Content: content})
+	if err != nil {
+		return "", err
+	}
+
+	return s.sendPrompt(ctx, prompt)
+}
+
+// spikeTimebox describes the largest estimate of a spike: 3 story points, or the largest point of
+// the scale up to 3 (its smallest point when all are larger), size S, or a few days in Kanban
+func spikeTimebox(processing config.ProcessingConfig) string {
+	switch {
+	case processing.Kanban():
+		return "a few days"
+	case processing.EstimationScale.Name == config.ScaleTShirt:
+		return `size "S" at most`
+	}
+
+	points := processing.EstimationScale.Points
+	limit := points[0]
+	for _, point := range points {
+		if point <= 3 {
+			limit = point
+		}
+	}
+	return fmt.Sprintf("at most %d story points", limit)
+}
+
+// render renders a prompt template with the organization context, glossary, examples, output
+// language, acceptance criteria format and guidance of the service added to data
+func (s *AIService) render(name string, data promptData) (string, error) {
+	data.Context = s.context
+	data.Glossary = s.glossary
+	data.Examples = s.examples
+	data.Language = s.language
+	data.CriteriaFormat = s.criteria
+	data.Methodology = config.MethodologyScrum
+	if s.fields.Kanban {
+		data.Methodology = config.MethodologyKanban
+	}
+	data.Sizing = config.SizingNone
+	if s.fields.Sizes {
+		data.Sizing = config.SizingClasses
+	}
+	data.Scale = s.scale.Name
+	for _, points := range s.scale.Points {
+		data.ScalePoints = append(data.ScalePoints, strconv.Itoa(points))
+	}
+	data.Guidance = s.guidance
+	return s.templates.render(name, data)
+}
+
+// UseTemplates replaces the prompt templates with those of dirs, taking each from the first
+// directory that has it and from the embedded default otherwise
+func (s *AIService) UseTemplates(dirs ...string) error {
+	templates, err := loadTemplates(dirs...)
+	if err != nil {
+		return err
+	}
+	s.templates = templates
+	return nil
+}
+
+// AddGuidance adds extra instructions to every breakdown prompt
+func (s *AIService) AddGuidance(guidance string) {
+	s.guidance = append(s.guidance, guidance)
+}
+
+// Ask continues a persisted conversation with a follow-up question and returns the answer.
+// The question and answer are appended to the conversation.
+func (s *AIService) Ask(ctx context.Context, conversation *models.Conversation, question string) (string, error) {
+	messages := append(conversation.Messages, models.ChatMessage{Role: "user", Content: question})
+
+	answer, err := s.provider.Chat(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+
+	conversation.Messages = append(messages, models.ChatMessage{Role: "assistant", Content: answer})
+	conversation.UpdatedAt = time.Now()
+	return answer, nil
+}
+
+// Usage returns the tokens and cost of the prompts answered so far, in total and per analyzed
+// chunk. Tokens are those the provider reports, or estimated from the text when it reports none.
+func (s *AIService) Usage() models.TokenUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage := s.usage
+	usage.EstimatedCostUSD = usageCost(s.pricing, usage)
+	if usage.CacheReadTokens > 0 {
+		usage.CacheSavingsUSD = cacheSavings(s.pricing, usage.CacheWriteTokens, usage.CacheReadTokens)
+	}
+	usage.Chunks = nil
+	for _, chunk := range s.chunks {
+		chunkUsage := *chunk
+		chunkUsage.EstimatedCostUSD = cost(s.pricing, chunk.InputTokens, chunk.OutputTokens) +
+			cacheCost(s.pricing, chunk.CacheWriteTokens, chunk.CacheReadTokens)
+		usage.Chunks = append(usage.Chunks, chunkUsage)
+	}
+	sort.Slice(usage.Chunks, func(i, j int) bool { return usage.Chunks[i].Chunk < usage.Chunks[j].Chunk })
+	return usage
+}
+
+// Conversation returns the message history recorded by this service
+func (s *AIService) Conversation() *models.Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	return &models.Conversation{
+		Model:     s.provider.Model(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Messages:  append([]models.ChatMessage(nil), s.history...),
+	}
+}
+
+// sendPrompt sends a single prompt to the AI provider and records the exchange in the history
+func (s *AIService) sendPrompt(ctx context.Context, prompt string) (string, error) {
+	reserved, err := s.reserve(prompt)
+	if err != nil {
+		return "", err
+	}
+	defer s.release(reserved)
+
+	var reported providers.Usage
+	responseText, err := s.provider.Analyze(providers.WithUsage(ctx, &reported), prompt)
+	if err != nil {
+		return "", err
+	}
+
+	s.record(ctx, prompt, responseText, reported)
+	return responseText, nil
+}
+
+// sendStructuredPrompt sends a prompt whose response must match the tool schema. Providers with
+// structured output support have the schema enforced by the API; others fall back to a plain prompt.
+func (s *AIService) sendStructuredPrompt(ctx context.Context, prompt string, tool providers.Tool) (string, error) {
+	structured, ok := s.provider.(providers.StructuredProvider)
+	if !ok {
+		return s.sendPrompt(ctx, prompt)
+	}
+
+	reserved, err := s.reserve(prompt)
+	if err != nil {
+		return "", err
+	}
+	defer s.release(reserved)
+
+	var reported providers.Usage
+	responseText, err := structured.AnalyzeStructured(providers.WithUsage(ctx, &reported), prompt, tool)
+	if err != nil {
+		return "", err
+	}
+
+	s.record(ctx, prompt, responseText, reported)
+	return responseText, nil
+}
+
+// sendCachedPrompt sends a prompt whose response must match the tool schema, with its
+// instructions cached by providers that support prompt caching. Other providers are sent the
+// prompt as a single text.
+func (s *AIService) sendCachedPrompt(ctx context.Context, prompt providers.Prompt, tool providers.Tool) (string, error) {
+	caching, ok := s.provider.(providers.CachingProvider)
+	if !ok {
+		return s.sendStructuredPrompt(ctx, prompt.Text(), tool)
+	}
+
+	reserved, err := s.reserve(prompt.Text())
+	if err != nil {
+		return "", err
+	}
+	defer s.release(reserved)
+
+	var reported providers.Usage
+	responseText, err := caching.AnalyzeCached(providers.WithUsage(ctx, &reported), prompt, tool)
+	if err != nil {
+		return "", err
+	}
+
+	s.record(ctx, prompt.Text(), responseText, reported)
+	return responseText, nil
+}
+
+// decodeJSONResponse decodes a JSON response into target. Malformed JSON is first repaired
+// leniently and, failing that, sent back to the model with a request to fix it.
+func (s *AIService) decodeJSONResponse(ctx context.Context, prompt, responseText string, target interface{}) error {
+	responseText = cleanJSONResponse(responseText)
+
+	parseErr := json.Unmarshal([]byte(responseText), target)
+	if parseErr == nil {
+		return nil
+	}
+
+	if repaired, ok := helpers.RepairJSON(responseText); ok {
+		if err := json.Unmarshal([]byte(repaired), target); err == nil {
+			helpers.PrintWarning("Repaired malformed JSON in AI response (%v)", parseErr)
+			s.replaceAnswer(responseText, repaired)
+			return nil
+		}
+	}
+
+	helpers.PrintWarning("AI response was not valid JSON (%v), asking the model to fix it...", parseErr)
+
+	fixRequest := fmt.Sprintf("Your previous response was not valid JSON (%v). Reply with the complete, corrected JSON only, with no markdown formatting or explanations.", parseErr)
+	reserved, err := s.reserve(prompt + responseText + fixRequest)
+	if err != nil {
+		return fmt.Errorf("failed to parse AI response as JSON: %w (fix request not sent: %w)", parseErr, err)
+	}
+	defer s.release(reserved)
+
+	var reported providers.Usage
+	fixed, err := s.provider.Chat(providers.WithUsage(ctx, &reported), []models.ChatMessage{
+		{Role: "user", Content: prompt},
+		{Role: "assistant", Content: responseText},
+		{Role: "user", Content: fixRequest},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse AI response as JSON: %w (fix request failed: %v)\nResponse: %s", parseErr, err, responseText)
+	}
+	s.count(ctx, prompt+responseText+fixRequest, fixed, reported)
+
+	fixed = cleanJSONResponse(fixed)
+	if err := json.Unmarshal([]byte(fixed), target); err != nil {
+		repaired, ok := helpers.RepairJSON(fixed)
+		if !ok || json.Unmarshal([]byte(repaired), target) != nil {
+			return fmt.Errorf("failed to parse AI response as JSON after asking for a fix: %w\nResponse: %s", err, fixed)
+		}
+		fixed = repaired
+	}
+
+	helpers.PrintSuccess("Model returned corrected JSON")
+	s.replaceAnswer(responseText, fixed)
+	return nil
+}
+
+// record appends a prompt and its answer to the history and counts their tokens
+func (s *AIService) record(ctx context.Context, prompt, answer string, reported providers.Usage) {
+	s.mu.Lock()
+	s.history = append(s.history,
+		models.ChatMessage{Role: "user", Content: prompt},
+		models.ChatMessage{Role: "assistant", Content: answer})
+	s.mu.Unlock()
+
+	s.count(ctx, prompt, answer, reported)
+}
+
+// count adds the tokens of a prompt and its answer to the usage of the run and of the chunk the
+// context analyzes. Tokens are estimated from the text when the provider reported none.
+func (s *AIService) count(ctx context.Context, prompt, answer string, reported providers.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reported == (providers.Usage{}) {
+		reported.InputTokens = helpers.EstimateTokens(prompt)
+		reported.OutputTokens = helpers.EstimateTokens(answer)
+		s.usage.TokensEstimated = true
+	}
+
+	s.usage.Requests++
+	s.usage.InputTokens += reported.InputTokens
+	s.usage.OutputTokens += reported.OutputTokens
+	s.usage.CacheWriteTokens += reported.CacheWriteTokens
+	s.usage.CacheReadTokens += reported.CacheReadTokens
+
+	if chunkIndex, ok := ctx.Value(chunkKey{}).(int); ok {
+		chunk := s.chunks[chunkIndex]
+		if chunk == nil {
+			chunk = &models.ChunkUsage{Chunk: chunkIndex}
+			s.chunks[chunkIndex] = chunk
+		}
+		chunk.Requests++
+		chunk.InputTokens += reported.InputTokens
+		chunk.OutputTokens += reported.OutputTokens
+		chunk.CacheWriteTokens += reported.CacheWriteTokens
+		chunk.CacheReadTokens += reported.CacheReadTokens
+	}
+}
+
+// replaceAnswer replaces a recorded assistant message with a corrected answer
+func (s *AIService) replaceAnswer(original, corrected string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if s.history[i].Role == "assistant" && cleanJSONResponse(s.history[i].Content) == original {
+			s.history[i].Content = corrected
+			return
+		}
+	}
+}
+
+// cleanJSONResponse removes any markdown code fences around a JSON response
+func cleanJSONResponse(responseText string) string {
+	responseText = strings.TrimSpace(responseText)
+	responseText = strings.TrimPrefix(responseText, "```json")
+	responseText = strings.TrimSuffix(responseText, "```")
+	return strings.TrimSpace(responseText)
+}
+
+// ProcessWithRetry processes content with retry logic. An error retrying cannot fix, such as an
+// invalid API key or a rejected request, fails at once. The wait between attempts doubles, with
+// jitter, and is at least overloadedDelay while the provider is overloaded.
+func (s *AIService) ProcessWithRetry(ctx context.Context, content string, chunkIndex, totalChunks int) (*models.ProjectBreakdown, error) {
 	var lastErr error
 
 	for attempt := 1; attempt <= s.config.RetryCount; attempt++ {
 		helpers.PrintInfo("Processing chunk %d/%d (attempt %d/%d)...", chunkIndex, totalChunks, attempt, s.config.RetryCount)
 
-		breakdown, err := s.ProcessWithAI(content, chunkIndex, totalChunks)
+		breakdown, err := s.ProcessWithAI(ctx, content, chunkIndex, totalChunks)
 		if err == nil {
 			return breakdown, nil
 		}
 
+		// Retrying is pointless once the run was interrupted
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var budgetErr *BudgetError
+		if errors.As(err, &budgetErr) {
+			return nil, err
+		}
+
 		lastErr = err
 		helpers.PrintWarning("Attempt %d failed: %v", attempt, err)
 
+		if !transport.Retryable(err) {
+			return nil, fmt.Errorf("failed without retrying: %w", err)
+		}
+
 		if attempt < s.config.RetryCount {
-			helpers.PrintInfo("Retrying in %d seconds...", s.config.RetryDelaySeconds)
-			time.Sleep(time.Duration(s.config.RetryDelaySeconds) * time.Second)
+			wait := transport.Backoff(time.Duration(s.config.RetryDelaySeconds)*time.Second, attempt)
+			if transport.Overloaded(err) {
+				wait = max(wait, overloadedDelay)
+				helpers.PrintWarning("The AI provider is overloaded")
+			}
+
+			helpers.PrintInfo("Retrying in %s...", wait.Round(time.Second))
+			if err := helpers.Sleep(ctx, wait); err != nil {
+				return nil, err
+			}
 		}
 	}
 
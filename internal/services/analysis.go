@@ -1,77 +1,215 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"scrum-master/internal/config"
 	"scrum-master/internal/helpers"
 	"scrum-master/internal/models"
+
+	"gopkg.in/yaml.v2"
 )
 
 // AnalysisService handles project analysis and breakdown
 type AnalysisService struct {
-	config    *config.Config
-	aiService *AIService
+	config      *config.Config
+	aiService   *AIService
+	directives  *models.DocumentDirectives
+	pinnedEpics map[string]string // lower-cased epic title -> pinned priority
+	source      string            // raw input document, snapshotted for incremental updates
+	sourceFile  string            // path or URL of the input document
+	revisions   []models.AnalysisRevision
+	releases    []models.Release // releases of the analysis being extended
+	events      *EventBus
 }
 
 // NewAnalysisService creates a new analysis service
-func NewAnalysisService(config *config.Config) *AnalysisService {
+func NewAnalysisService(config *config.Config) (*AnalysisService, error) {
+	aiService, err := NewAIService(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &AnalysisService{
 		config:    config,
-		aiService: NewAIService(&config.Anthropic),
-	}
+		aiService: aiService,
+		events:    newConsoleEventBus(),
+	}, nil
+}
+
+// Events returns the bus analysis progress is published on
+func (s *AnalysisService) Events() *EventBus {
+	return s.events
 }
 
 // DisplayProjectBreakdown displays the project breakdown in a formatted way
 func (s *AnalysisService) DisplayProjectBreakdown(breakdown *models.ProjectBreakdown) {
+	DisplayBreakdown(breakdown)
+}
+
+// DisplayBreakdown displays a project breakdown in a formatted way, such as one analyzed on a
+// server, without an analysis service
+func DisplayBreakdown(breakdown *models.ProjectBreakdown) {
 	helpers.PrintTitle("Project Breakdown: %s", breakdown.ProjectName)
 	helpers.PrintInfo("Overview: %s", breakdown.Overview)
 	helpers.PrintInfo("Processed in %d chunks", breakdown.ProcessedChunks)
+	if len(breakdown.SkippedChunks) > 0 {
+		helpers.PrintWarning("%d chunks were not analyzed, as the AI budget ran out", len(breakdown.SkippedChunks))
+	}
+	for _, release := range breakdown.Releases {
+		helpers.PrintInfo("Release %s", strings.TrimSuffix(releaseLabel(release)+": "+release.Description, ": "))
+	}
+	for i, initiative := range breakdown.Initiatives {
+		helpers.PrintInfo("Initiative %s: %s", itemLabel(initiative.ID, i+1, 0), initiative.Title)
+		helpers.PrintInfo("  %s", initiative.Description)
+	}
 	helpers.PrintSeparator()
 
 	for i, epic := range breakdown.Epics {
-		helpers.PrintInfo("Epic %d: %s", i+1, epic.Title)
-		helpers.PrintInfo("Priority: %s | Chunk: %d", epic.Priority, epic.Chunk)
+		helpers.PrintInfo("Epic %s: %s", itemLabel(epic.ID, i+1, 0), epic.Title)
+		helpers.PrintInfo("Priority: %s | Chunk: %d | Confidence: %d%%", epic.Priority, epic.Chunk, epic.Confidence)
+		if epic.Initiative != "" {
+			helpers.PrintInfo("Initiative: %s", epic.Initiative)
+		}
+		if epic.Team != "" {
+			helpers.PrintInfo("Team: %s", epic.Team)
+		}
+		if epic.Component != "" {
+			helpers.PrintInfo("Component: %s", epic.Component)
+		}
+		if len(epic.Labels) > 0 {
+			helpers.PrintInfo("Labels: %s", strings.Join(epic.Labels, ", "))
+		}
 		helpers.PrintInfo("Description: %s", epic.Description)
+		if epic.Rationale != "" {
+			helpers.PrintInfo("Rationale: %s", epic.Rationale)
+		}
 		helpers.PrintSeparator()
 
 		for j, story := range epic.Stories {
-			helpers.PrintInfo("  Story %d.%d: %s", i+1, j+1, story.Title)
-			helpers.PrintInfo("    Points: %d | Priority: %s", story.StoryPoints, story.Priority)
+			helpers.PrintInfo("  %s %s: %s", storyKind(&story), itemLabel(story.ID, i+1, j+1), story.Title)
+			estimate := ""
+			if name, value := storyEstimate(breakdown, &story); name != "" {
+				estimate = fmt.Sprintf("%s: %s | ", name, value)
+			}
+			helpers.PrintInfo("    %sPriority: %s | Confidence: %d%%", estimate, story.Priority, story.Confidence)
+			if story.Team != "" {
+				helpers.PrintInfo("    Team: %s", story.Team)
+			}
+			if len(story.Labels) > 0 {
+				helpers.PrintInfo("    Labels: %s", strings.Join(story.Labels, ", "))
+			}
+			if story.Release != "" {
+				helpers.PrintInfo("    Release: %s", story.Release)
+			}
+			if story.Assignee != "" {
+				helpers.PrintInfo("    Assignee: %s", story.Assignee)
+			}
 			helpers.PrintInfo("    Description: %s", story.Description)
 			helpers.PrintSeparator()
 
 			if len(story.AcceptanceCriteria) > 0 {
 				helpers.PrintInfo("    Acceptance Criteria:")
 				for _, criteria := range story.AcceptanceCriteria {
-					helpers.PrintInfo("      • %s", criteria)
+					helpers.PrintInfo("      %s %s", helpers.Symbol("•", "-"), criteria)
 				}
 			}
 
 			if len(story.Dependencies) > 0 {
 				helpers.PrintInfo("    Dependencies: %s", strings.Join(story.Dependencies, ", "))
 			}
+
+			if story.Requirement != "" {
+				helpers.PrintInfo("    Requirement: %s %s", story.Requirement, story.Section)
+			}
+
+			if len(story.DoneGaps) > 0 {
+				helpers.PrintWarning("    Definition of done not met: %s", strings.Join(story.DoneGaps, "; "))
+			}
+
+			if len(story.Subtasks) > 0 {
+				helpers.PrintInfo("    Subtasks:")
+				for _, subtask := range story.Subtasks {
+					helpers.PrintInfo("      %s %s", helpers.Symbol("•", "-"), subtaskLabel(subtask))
+				}
+			}
+
+			if story.Rationale != "" {
+				helpers.PrintInfo("    Rationale: %s", story.Rationale)
+			}
 			helpers.PrintSeparator()
 		}
 	}
 
-	helpers.PrintInfo("Summary: %d epics, %d stories, %d story points total",
-		breakdown.TotalEpics, breakdown.TotalStories, breakdown.TotalStoryPoints)
+	for i, risk := range breakdown.Risks {
+		helpers.PrintInfo("Risk %s: %s", itemLabel(risk.ID, i+1, 0), risk.Title)
+		helpers.PrintInfo("  Likelihood: %s | Impact: %s", risk.Likelihood, risk.Impact)
+		helpers.PrintInfo("  Description: %s", risk.Description)
+		helpers.PrintInfo("  Mitigation: %s", risk.Mitigation)
+		helpers.PrintSeparator()
+	}
+
+	if breakdown.Methodology == config.MethodologyKanban {
+		helpers.PrintInfo("Summary: %d epics, %d stories", breakdown.TotalEpics, breakdown.TotalStories)
+	} else {
+		helpers.PrintInfo("Summary: %d epics, %d stories, %d story points total",
+			breakdown.TotalEpics, breakdown.TotalStories, breakdown.TotalStoryPoints)
+	}
+	if len(breakdown.Risks) > 0 {
+		helpers.PrintInfo("Risks: %d", len(breakdown.Risks))
+	}
 }
 
-// SaveAnalysisResult saves the analysis result to files
-func (s *AnalysisService) SaveAnalysisResult(breakdown *models.ProjectBreakdown, outputDir string) error {
+// SaveAnalysisResult saves the analysis result to files and returns the path of the full analysis
+func (s *AnalysisService) SaveAnalysisResult(breakdown *models.ProjectBreakdown, outputDir string) (string, error) {
 	if err := helpers.EnsureDir(outputDir); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	// Number new items; existing IDs are kept
+	AssignItemIDs(breakdown)
+
 	// Create analysis result
 	result := &models.AnalysisResult{
 		ProjectBreakdown: *breakdown,
 		AnalysisTime:     time.Now(),
 		ProcessingMode:   s.config.Processing.Mode,
+		Directives:       s.directives,
+		Revisions:        s.revisions,
+	}
+
+	// Snapshot the source document so later runs can analyze only what was added
+	if s.source != "" {
+		snapshotFilename := helpers.GenerateOutputFilename("project-desc-source", "md")
+		if err := helpers.WriteFile(helpers.GetOutputPath(outputDir, snapshotFilename), s.source); err != nil {
+			return "", fmt.Errorf("failed to save source snapshot: %w", err)
+		}
+		result.SourceSnapshot = snapshotFilename
+		result.SourceFile = s.sourceFile
+	}
+
+	// Save the conversation so follow-up questions keep the full context
+	conversation := s.aiService.Conversation()
+	if len(conversation.Messages) > 0 {
+		conversationFilename := helpers.GenerateOutputFilename("project-desc-conversation", "json")
+		conversationPath := helpers.GetOutputPath(outputDir, conversationFilename)
+
+		if err := helpers.SaveJSON(conversation, conversationPath); err != nil {
+			return "", fmt.Errorf("failed to save conversation: %w", err)
+		}
+
+		result.ConversationFile = conversationFilename
+		helpers.PrintSuccess("Saved conversation to: %s", conversationPath)
 	}
 
 	// Save full analysis
@@ -79,41 +217,272 @@ func (s *AnalysisService) SaveAnalysisResult(breakdown *models.ProjectBreakdown,
 	fullAnalysisPath := helpers.GetOutputPath(outputDir, fullAnalysisFilename)
 
 	if err := helpers.SaveJSON(result, fullAnalysisPath); err != nil {
-		return fmt.Errorf("failed to save full analysis: %w", err)
+		return "", fmt.Errorf("failed to save full analysis: %w", err)
 	}
 
 	helpers.PrintSuccess("Saved full analysis to: %s", fullAnalysisPath)
+	NewSigningService(s.config).SignIfEnabled(fullAnalysisPath)
 
 	// Save summary
 	summaryFilename := helpers.GenerateOutputFilename("project-desc-summary", "md")
 	summaryPath := helpers.GetOutputPath(outputDir, summaryFilename)
 
 	if err := s.saveSummary(breakdown, summaryPath); err != nil {
-		return fmt.Errorf("failed to save summary: %w", err)
+		return "", fmt.Errorf("failed to save summary: %w", err)
 	}
 
 	helpers.PrintSuccess("Saved summary to: %s", summaryPath)
-	return nil
+
+	if len(breakdown.Requirements) > 0 {
+		if _, err := SaveTraceability(breakdown, outputDir); err != nil {
+			return "", err
+		}
+	}
+
+	// Save dependency graph
+	if graph := DependencyMermaid(breakdown); graph != "" {
+		graphPath := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("project-desc-dependencies", "mmd"))
+		if err := helpers.WriteFile(graphPath, graph); err != nil {
+			return "", fmt.Errorf("failed to save dependency graph: %w", err)
+		}
+		helpers.PrintSuccess("Saved dependency graph to: %s", graphPath)
+	}
+	return fullAnalysisPath, nil
+}
+
+// SaveEditedAnalysis saves an analysis whose breakdown was edited by hand as a new analysis
+// file, keeping the original, and returns its path
+func (s *AnalysisService) SaveEditedAnalysis(result *models.AnalysisResult, outputDir string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("project-desc-analysis", "json"))
+	if err := helpers.SaveJSON(result, path); err != nil {
+		return "", fmt.Errorf("failed to save edited analysis: %w", err)
+	}
+
+	helpers.PrintSuccess("Saved edited analysis to: %s", path)
+	NewSigningService(s.config).SignIfEnabled(path)
+
+	return path, nil
+}
+
+// Usage returns the AI usage of the analysis so far
+func (s *AnalysisService) Usage() models.TokenUsage {
+	return s.aiService.Usage()
+}
+
+// Estimate chunks a project description the way ProcessProject does and estimates the tokens and
+// cost of analyzing it, without calling the AI. The cost ranges from the input tokens alone to
+// every answer using max_tokens, including the synthesis pass of a multi-chunk document.
+func (s *AnalysisService) Estimate(inputFile string) (*models.CostEstimate, error) {
+	content, err := helpers.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	content, err = s.applyDirectives(content)
+	if err != nil {
+		return nil, err
+	}
+	content, _ = helpers.FilterMarkdownSections(content, s.config.Processing.IncludeSections, s.config.Processing.ExcludeSections)
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("no content left to analyze after section filtering")
+	}
+
+	chunks := s.chunkContent(content)
+	estimate := &models.CostEstimate{Model: s.aiService.Model(), Chunks: len(chunks), Requests: len(chunks)}
+	for i, chunk := range chunks {
+		inputTokens, maxOutputTokens, err := s.aiService.EstimateChunk(chunk, i+1, len(chunks))
+		if err != nil {
+			return nil, err
+		}
+		estimate.InputTokens += inputTokens
+		estimate.MaxOutputTokens += maxOutputTokens
+	}
+
+	// The synthesis prompt carries every chunk's answer
+	maxInputTokens := estimate.InputTokens
+	if s.config.Processing.Synthesis && len(chunks) > 1 {
+		estimate.Requests++
+		maxInputTokens += estimate.MaxOutputTokens
+		estimate.MaxOutputTokens += s.config.Anthropic.MaxTokens
+	}
+
+	pricing := s.aiService.Pricing()
+	estimate.MinCostUSD = cost(pricing, estimate.InputTokens, 0)
+	estimate.MaxCostUSD = cost(pricing, maxInputTokens, estimate.MaxOutputTokens)
+	return estimate, nil
+}
+
+// SaveReviewQueue saves items held back for human review as an analysis file that can be
+// passed to create-from-analysis once reviewed
+func (s *AnalysisService) SaveReviewQueue(breakdown *models.ProjectBreakdown, outputDir string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	result := &models.AnalysisResult{
+		ProjectBreakdown: *breakdown,
+		AnalysisTime:     time.Now(),
+		ProcessingMode:   s.config.Processing.Mode,
+	}
+
+	path := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("review-queue", "json"))
+	if err := helpers.SaveJSON(result, path); err != nil {
+		return "", fmt.Errorf("failed to save review queue: %w", err)
+	}
+	NewSigningService(s.config).SignIfEnabled(path)
+
+	return path, nil
+}
+
+// Ask answers a follow-up question about an analysis, continuing the conversation of the
+// analysis run and persisting the new exchange
+func (s *AnalysisService) Ask(ctx context.Context, analysisFile, question string) (string, error) {
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return "", fmt.Errorf("failed to load analysis file: %w", err)
+	}
+
+	var conversation *models.Conversation
+	var conversationPath string
+
+	if result.ConversationFile != "" {
+		conversationPath = filepath.Join(filepath.Dir(analysisFile), result.ConversationFile)
+		conversation = &models.Conversation{}
+		if err := helpers.LoadJSON(conversationPath, conversation); err != nil {
+			return "", fmt.Errorf("failed to load conversation: %w", err)
+		}
+		helpers.PrintInfo("Continuing conversation with %d previous messages", len(conversation.Messages))
+	} else {
+		// Older analyses have no saved conversation, so seed one from the breakdown itself
+		helpers.PrintWarning("No saved conversation for this analysis, starting from the breakdown only")
+
+		seeded, err := s.seedConversation(&result.ProjectBreakdown)
+		if err != nil {
+			return "", err
+		}
+		conversation = seeded
+		conversationPath = strings.TrimSuffix(analysisFile, filepath.Ext(analysisFile)) + "-conversation.json"
+	}
+
+	answer, err := s.aiService.Ask(ctx, conversation, question)
+	if err != nil {
+		return "", fmt.Errorf("failed to get answer: %w", err)
+	}
+
+	if err := helpers.SaveJSON(conversation, conversationPath); err != nil {
+		return "", fmt.Errorf("failed to save conversation: %w", err)
+	}
+
+	if result.ConversationFile == "" {
+		result.ConversationFile = filepath.Base(conversationPath)
+		if err := helpers.SaveJSON(&result, analysisFile); err != nil {
+			return "", fmt.Errorf("failed to update analysis file: %w", err)
+		}
+	}
+
+	return answer, nil
+}
+
+// seedConversation creates a conversation whose context is a previously generated breakdown
+func (s *AnalysisService) seedConversation(breakdown *models.ProjectBreakdown) (*models.Conversation, error) {
+	breakdownJSON, err := json.MarshalIndent(breakdown, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal breakdown: %w", err)
+	}
+
+	conversation := s.aiService.Conversation()
+	conversation.Messages = []models.ChatMessage{
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("You previously broke a project description down into the following epics and stories:\n\n%s\n\nI will ask follow-up questions about this breakdown.", breakdownJSON),
+		},
+		{
+			Role:    "assistant",
+			Content: "Understood. I have the breakdown and am ready to answer questions about it.",
+		},
+	}
+
+	return conversation, nil
 }
 
 // saveSummary saves a markdown summary of the analysis
 func (s *AnalysisService) saveSummary(breakdown *models.ProjectBreakdown, filepath string) error {
+	return helpers.WriteFile(filepath, SummaryMarkdown(breakdown))
+}
+
+// SummaryMarkdown renders a breakdown as the markdown summary saved with its analysis
+func SummaryMarkdown(breakdown *models.ProjectBreakdown) string {
 	var summary strings.Builder
 
 	summary.WriteString(fmt.Sprintf("# %s\n\n", breakdown.ProjectName))
 	summary.WriteString(fmt.Sprintf("**Overview:** %s\n\n", breakdown.Overview))
 	summary.WriteString(fmt.Sprintf("**Total Epics:** %d\n", breakdown.TotalEpics))
 	summary.WriteString(fmt.Sprintf("**Total Stories:** %d\n", breakdown.TotalStories))
-	summary.WriteString(fmt.Sprintf("**Total Story Points:** %d\n\n", breakdown.TotalStoryPoints))
+	if breakdown.Methodology != config.MethodologyKanban {
+		summary.WriteString(fmt.Sprintf("**Total Story Points:** %d\n", breakdown.TotalStoryPoints))
+	}
+	summary.WriteString("\n")
+
+	if len(breakdown.Releases) > 0 {
+		summary.WriteString("## Releases\n\n")
+		for _, release := range breakdown.Releases {
+			summary.WriteString(strings.TrimSuffix(fmt.Sprintf("- **%s**: %s", releaseLabel(release), release.Description), ": ") + "\n")
+		}
+		summary.WriteString("\n")
+	}
+
+	if len(breakdown.Initiatives) > 0 {
+		summary.WriteString("## Initiatives\n\n")
+		for i, initiative := range breakdown.Initiatives {
+			summary.WriteString(fmt.Sprintf("- **%s %s**: %s\n", itemLabel(initiative.ID, i+1, 0), initiative.Title, initiative.Description))
+		}
+		summary.WriteString("\n")
+	}
 
 	for i, epic := range breakdown.Epics {
-		summary.WriteString(fmt.Sprintf("## Epic %d: %s\n\n", i+1, epic.Title))
+		summary.WriteString(fmt.Sprintf("## Epic %s: %s\n\n", itemLabel(epic.ID, i+1, 0), epic.Title))
 		summary.WriteString(fmt.Sprintf("**Priority:** %s | **Chunk:** %d\n\n", epic.Priority, epic.Chunk))
+		if epic.Initiative != "" {
+			summary.WriteString(fmt.Sprintf("**Initiative:** %s\n\n", epic.Initiative))
+		}
+		if epic.Team != "" {
+			summary.WriteString(fmt.Sprintf("**Team:** %s\n\n", epic.Team))
+		}
+		if epic.Component != "" {
+			summary.WriteString(fmt.Sprintf("**Component:** %s\n\n", epic.Component))
+		}
+		if len(epic.Labels) > 0 {
+			summary.WriteString(fmt.Sprintf("**Labels:** %s\n\n", strings.Join(epic.Labels, ", ")))
+		}
 		summary.WriteString(fmt.Sprintf("%s\n\n", epic.Description))
 
+		if epic.Rationale != "" {
+			summary.WriteString(fmt.Sprintf("*Rationale: %s*\n\n", epic.Rationale))
+		}
+
 		for j, story := range epic.Stories {
-			summary.WriteString(fmt.Sprintf("### Story %d.%d: %s\n\n", i+1, j+1, story.Title))
-			summary.WriteString(fmt.Sprintf("**Points:** %d | **Priority:** %s\n\n", story.StoryPoints, story.Priority))
+			summary.WriteString(fmt.Sprintf("### %s %s: %s\n\n", storyKind(&story), itemLabel(story.ID, i+1, j+1), story.Title))
+			estimate := ""
+			if name, value := storyEstimate(breakdown, &story); name != "" {
+				estimate = fmt.Sprintf("**%s:** %s | ", name, value)
+			}
+			summary.WriteString(fmt.Sprintf("%s**Priority:** %s\n\n", estimate, story.Priority))
+			if story.Team != "" {
+				summary.WriteString(fmt.Sprintf("**Team:** %s\n\n", story.Team))
+			}
+			if len(story.Labels) > 0 {
+				summary.WriteString(fmt.Sprintf("**Labels:** %s\n\n", strings.Join(story.Labels, ", ")))
+			}
+			if story.Release != "" {
+				summary.WriteString(fmt.Sprintf("**Release:** %s\n\n", story.Release))
+			}
+			if story.Assignee != "" {
+				summary.WriteString(fmt.Sprintf("**Assignee:** %s\n\n", story.Assignee))
+			}
 			summary.WriteString(fmt.Sprintf("%s\n\n", story.Description))
 
 			if len(story.AcceptanceCriteria) > 0 {
@@ -127,14 +496,49 @@ func (s *AnalysisService) saveSummary(breakdown *models.ProjectBreakdown, filepa
 			if len(story.Dependencies) > 0 {
 				summary.WriteString(fmt.Sprintf("**Dependencies:** %s\n\n", strings.Join(story.Dependencies, ", ")))
 			}
+
+			if story.Requirement != "" {
+				summary.WriteString(fmt.Sprintf("**Requirement:** %s %s\n\n", story.Requirement, story.Section))
+			}
+
+			if len(story.DoneGaps) > 0 {
+				summary.WriteString(fmt.Sprintf("**Definition of done not met:** %s\n\n", strings.Join(story.DoneGaps, "; ")))
+			}
+
+			if len(story.Subtasks) > 0 {
+				summary.WriteString("**Subtasks:**\n")
+				for _, subtask := range story.Subtasks {
+					summary.WriteString(fmt.Sprintf("- %s\n", subtaskLabel(subtask)))
+				}
+				summary.WriteString("\n")
+			}
+
+			if story.Rationale != "" {
+				summary.WriteString(fmt.Sprintf("*Rationale: %s*\n\n", story.Rationale))
+			}
 		}
 	}
 
-	return helpers.SaveJSON(summary.String(), filepath)
+	if len(breakdown.Risks) > 0 {
+		summary.WriteString("## Risks\n\n")
+		for i, risk := range breakdown.Risks {
+			summary.WriteString(fmt.Sprintf("### Risk %s: %s\n\n", itemLabel(risk.ID, i+1, 0), risk.Title))
+			summary.WriteString(fmt.Sprintf("**Likelihood:** %s | **Impact:** %s\n\n", risk.Likelihood, risk.Impact))
+			summary.WriteString(fmt.Sprintf("%s\n\n", risk.Description))
+			summary.WriteString(fmt.Sprintf("**Mitigation:** %s\n\n", risk.Mitigation))
+		}
+	}
+
+	if graph := DependencyMermaid(breakdown); graph != "" {
+		summary.WriteString("## Dependencies\n\n")
+		summary.WriteString(fmt.Sprintf("```mermaid\n%s```\n", graph))
+	}
+
+	return summary.String()
 }
 
 // ProcessProject processes a project description file with AI analysis
-func (s *AnalysisService) ProcessProject(inputFile string) (*models.ProjectBreakdown, error) {
+func (s *AnalysisService) ProcessProject(ctx context.Context, inputFile string) (*models.ProjectBreakdown, error) {
 	// Read the input file
 	content, err := helpers.ReadFile(inputFile)
 	if err != nil {
@@ -143,40 +547,474 @@ func (s *AnalysisService) ProcessProject(inputFile string) (*models.ProjectBreak
 
 	helpers.PrintInfo("Read %d bytes from input file", len(content))
 
-	// Determine if we need to chunk the content
-	chunks := s.chunkContent(content)
-	helpers.PrintInfo("Processing with AI (%d chunks)...", len(chunks))
+	s.source = content
+	s.sourceFile = inputFile
+	return s.analyzeContent(ctx, content)
+}
 
-	var allEpics []models.Epic
-	var projectName string
-	var overview string
-	totalStories := 0
-	totalStoryPoints := 0
+// SetSourceFile records where the analyzed document came from when it is not the file that was
+// read, such as the URL a downloaded document was fetched from
+func (s *AnalysisService) SetSourceFile(source string) {
+	s.sourceFile = source
+}
 
-	// Process each chunk
-	for i, chunk := range chunks {
-		helpers.PrintProgress(i+1, len(chunks), fmt.Sprintf("Processing chunk %d", i+1))
+// ProcessIncremental analyzes only the sections added to a document since a previous analysis
+// and merges the resulting epics and stories into that analysis
+func (s *AnalysisService) ProcessIncremental(ctx context.Context, inputFile, previousFile string) (*models.ProjectBreakdown, error) {
+	var previous models.AnalysisResult
+	if err := helpers.LoadJSON(previousFile, &previous); err != nil {
+		return nil, fmt.Errorf("failed to load previous analysis: %w", err)
+	}
+
+	if previous.SourceSnapshot == "" {
+		return nil, fmt.Errorf("previous analysis has no source snapshot, run a full analysis first")
+	}
+
+	snapshot, err := helpers.ReadFile(filepath.Join(filepath.Dir(previousFile), previous.SourceSnapshot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source snapshot: %w", err)
+	}
+
+	content, err := helpers.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	s.source = content
+	s.sourceFile = inputFile
+	s.directives = previous.Directives
+	s.revisions = previous.Revisions
+	s.releases = previous.ProjectBreakdown.Releases
+	breakdown := previous.ProjectBreakdown
+
+	frontMatter, body := helpers.SplitFrontMatter(content)
+	_, previousBody := helpers.SplitFrontMatter(snapshot)
+
+	added, removed := helpers.DiffMarkdownSections(previousBody, body)
+	if len(removed) > 0 {
+		helpers.PrintWarning("%d sections changed or were removed since the previous analysis: %s", len(removed), strings.Join(removed, ", "))
+		helpers.PrintWarning("Changed sections are analyzed as new ones; run a full analysis if the existing breakdown no longer fits")
+	}
+
+	if len(added) == 0 {
+		helpers.PrintInfo("No new sections since the previous analysis")
+		return &breakdown, nil
+	}
+
+	// Keep the front matter so its directives still apply to the new sections
+	var update strings.Builder
+	if frontMatter != "" {
+		update.WriteString("---\n" + frontMatter + "---\n")
+	}
+
+	var headings []string
+	for _, section := range added {
+		update.WriteString(section.Content)
+		if section.Heading != "" {
+			headings = append(headings, section.Heading)
+		} else {
+			headings = append(headings, "(preamble)")
+		}
+	}
+
+	helpers.PrintInfo("Analyzing %d new sections: %s", len(added), strings.Join(headings, ", "))
+
+	// Steer new stories into existing epics rather than near-duplicates of them
+	var titles []string
+	for _, epic := range breakdown.Epics {
+		titles = append(titles, fmt.Sprintf("\"%s\"", epic.Title))
+	}
+	if len(titles) > 0 {
+		s.aiService.AddGuidance(fmt.Sprintf("This content extends a project that already has these epics: %s. Put stories that fit an existing epic under that epic, reusing its exact title, and only create new epics for new areas of work.",
+			strings.Join(titles, ", ")))
+	}
+
+	updateBreakdown, err := s.analyzeContent(ctx, update.String())
+	if err != nil {
+		return nil, err
+	}
+
+	revision := models.AnalysisRevision{
+		ID:           helpers.GenerateTimestamp(),
+		AnalyzedAt:   time.Now(),
+		BaseAnalysis: filepath.Base(previousFile),
+		Sections:     headings,
+	}
+	mergeIncremental(&breakdown, updateBreakdown, revision.ID)
+	s.revisions = append(s.revisions, revision)
+
+	return &breakdown, nil
+}
+
+// mergeIncremental adds the epics and stories of an incremental update to a breakdown. Existing
+// items, including their JIRA keys, are left untouched and new ones are marked with the revision.
+func mergeIncremental(breakdown, update *models.ProjectBreakdown, revisionID string) {
+	epicIndex := make(map[string]int)
+	for i, epic := range breakdown.Epics {
+		epicIndex[strings.ToLower(strings.TrimSpace(epic.Title))] = i
+	}
+
+	for _, epic := range update.Epics {
+		key := strings.ToLower(strings.TrimSpace(epic.Title))
+
+		i, exists := epicIndex[key]
+		if !exists {
+			epic.AddedIn = revisionID
+			for j := range epic.Stories {
+				epic.Stories[j].AddedIn = revisionID
+			}
+			breakdown.Epics = append(breakdown.Epics, epic)
+			epicIndex[key] = len(breakdown.Epics) - 1
+			continue
+		}
+
+		existing := &breakdown.Epics[i]
+		storyTitles := make(map[string]bool)
+		for _, story := range existing.Stories {
+			storyTitles[strings.ToLower(strings.TrimSpace(story.Title))] = true
+		}
+
+		for _, story := range epic.Stories {
+			storyKey := strings.ToLower(strings.TrimSpace(story.Title))
+			if storyTitles[storyKey] {
+				continue
+			}
+			story.AddedIn = revisionID
+			existing.Stories = append(existing.Stories, story)
+			storyTitles[storyKey] = true
+		}
+	}
+
+	for _, release := range update.Releases {
+		if findRelease(breakdown.Releases, release.Name) < 0 {
+			breakdown.Releases = append(breakdown.Releases, release)
+		}
+	}
+	breakdown.Risks = mergeRisks(breakdown.Risks, update.Risks)
+	breakdown.Initiatives = mergeInitiatives(breakdown.Initiatives, update.Initiatives)
 
-		// Process chunk with AI
-		breakdown, err := s.aiService.ProcessWithRetry(chunk, i+1, len(chunks))
+	// Requirements are numbered in the whole current document, so earlier stories are traced again
+	if len(update.Requirements) > 0 {
+		breakdown.Requirements = update.Requirements
+		traceStories(breakdown, nil)
+	}
+
+	breakdown.ProcessedChunks += update.ProcessedChunks
+	breakdown.SkippedChunks = update.SkippedChunks
+	recalculateTotals(breakdown)
+}
+
+// storyEstimate returns the name and value of the estimate of a story: its story points, its
+// size for Kanban teams that size stories, or nothing for those that do not
+func storyEstimate(breakdown *models.ProjectBreakdown, story *models.Story) (string, string) {
+	switch {
+	case story.Size != "":
+		return "Size", story.Size
+	case breakdown.Methodology != config.MethodologyKanban:
+		return "Points", strconv.Itoa(story.StoryPoints)
+	default:
+		return "", ""
+	}
+}
+
+// storyKind returns how a story is named in displays: as a spike or a story
+func storyKind(story *models.Story) string {
+	if story.Type == models.StoryTypeSpike {
+		return "Spike"
+	}
+	return "Story"
+}
+
+// nfrEpicLast moves the non-functional requirements epic behind the feature epics
+func nfrEpicLast(epics []models.Epic) []models.Epic {
+	for i, epic := range epics {
+		if strings.EqualFold(strings.TrimSpace(epic.Title), NFREpicTitle) {
+			return append(append(epics[:i:i], epics[i+1:]...), epic)
+		}
+	}
+	return epics
+}
+
+// mergeRisks adds the risks whose titles are not known yet to a list of risks
+func mergeRisks(risks, more []models.Risk) []models.Risk {
+	titles := make(map[string]bool)
+	for _, risk := range risks {
+		titles[strings.ToLower(strings.TrimSpace(risk.Title))] = true
+	}
+	for _, risk := range more {
+		key := strings.ToLower(strings.TrimSpace(risk.Title))
+		if !titles[key] {
+			risks = append(risks, risk)
+			titles[key] = true
+		}
+	}
+	return risks
+}
+
+// mergeInitiatives adds the initiatives whose titles are not known yet to a list of initiatives
+func mergeInitiatives(initiatives, more []models.Initiative) []models.Initiative {
+	titles := make(map[string]bool)
+	for _, initiative := range initiatives {
+		titles[strings.ToLower(strings.TrimSpace(initiative.Title))] = true
+	}
+	for _, initiative := range more {
+		key := strings.ToLower(strings.TrimSpace(initiative.Title))
+		if !titles[key] {
+			initiatives = append(initiatives, initiative)
+			titles[key] = true
+		}
+	}
+	return initiatives
+}
+
+// IsManifestFile reports whether an input file is a project manifest rather than a document
+func IsManifestFile(inputFile string) bool {
+	ext := strings.ToLower(filepath.Ext(inputFile))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// CheckInput classifies the input document, or the PRDs and technical designs of a project
+// manifest, and returns why each one that is clearly not a project description is not
+func CheckInput(inputFile string) ([]string, error) {
+	documents := []string{inputFile}
+
+	if IsManifestFile(inputFile) {
+		data, err := helpers.ReadFile(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		var manifest models.ProjectManifest
+		if err := yaml.Unmarshal([]byte(data), &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+
+		// Research and constraints are context, not descriptions
+		documents = nil
+		for _, document := range manifest.Documents {
+			if document.Role != models.RolePRD && document.Role != models.RoleTechDesign {
+				continue
+			}
+			path := document.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(inputFile), path)
+			}
+			documents = append(documents, path)
+		}
+	}
+
+	var problems []string
+	for _, document := range documents {
+		content, err := helpers.ReadFile(document)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+
+		if class := helpers.ClassifyDocument(content); !class.IsProjectDescription() {
+			problems = append(problems, fmt.Sprintf("%s looks like %s, not a project description: %s", document, withArticle(class.Kind), class.Reason))
+		}
+	}
+	return problems, nil
+}
+
+// withArticle puts "a" or "an" before a kind of document, unless it is uncountable
+func withArticle(kind string) string {
+	switch kind {
+	case helpers.DocumentCode, helpers.DocumentData, helpers.DocumentNotes, helpers.DocumentEmpty:
+		return kind
+	}
+	if strings.ContainsAny(kind[:1], "aeiou") {
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// ProcessManifest processes a multi-document project manifest. PRDs and technical designs are
+// analyzed, constraints are injected into every prompt and research is summarized as context.
+func (s *AnalysisService) ProcessManifest(ctx context.Context, manifestFile string) (*models.ProjectBreakdown, error) {
+	data, err := helpers.ReadFile(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest models.ProjectManifest
+	if err := yaml.Unmarshal([]byte(data), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if len(manifest.Documents) == 0 {
+		return nil, fmt.Errorf("manifest lists no documents")
+	}
+
+	helpers.PrintInfo("Project manifest: %s (%d documents)", manifest.Name, len(manifest.Documents))
+
+	baseDir := filepath.Dir(manifestFile)
+	var analyzed strings.Builder
+
+	for _, document := range manifest.Documents {
+		path := document.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+
+		content, err := helpers.ReadFile(path)
 		if err != nil {
-			return nil, fmt.Errorf("failed to process chunk %d: %w", i+1, err)
+			return nil, fmt.Errorf("failed to read %s document '%s': %w", document.Role, document.Path, err)
 		}
 
-		// Save intermediate results if enabled
-		if s.config.Processing.SaveIntermediate {
-			intermediateFilename := helpers.GenerateOutputFilename(fmt.Sprintf("chunk-%d", i+1), "json")
-			intermediatePath := helpers.GetOutputPath(s.config.Processing.OutputDir, intermediateFilename)
+		helpers.PrintInfo("  %s (%s, %d bytes)", document.Path, document.Role, len(content))
+
+		switch document.Role {
+		case models.RolePRD, models.RoleTechDesign:
+			body, err := s.applyDirectives(content)
+			if err != nil {
+				return nil, fmt.Errorf("document '%s': %w", document.Path, err)
+			}
+
+			label := "Product requirements"
+			if document.Role == models.RoleTechDesign {
+				label = "Technical design"
+			}
+			analyzed.WriteString(fmt.Sprintf("\n\n# Document: %s (%s)\n\n%s", filepath.Base(document.Path), label, body))
+
+		case models.RoleConstraints:
+			s.aiService.AddGuidance(fmt.Sprintf("Constraints every epic and story must respect (from %s):\n%s",
+				filepath.Base(document.Path), strings.TrimSpace(content)))
 
-			if err := helpers.SaveJSON(breakdown, intermediatePath); err != nil {
-				helpers.PrintWarning("Failed to save intermediate result: %v", err)
+		case models.RoleResearch:
+			helpers.PrintInfo("  Summarizing research document %s...", document.Path)
+			summary, err := s.summarizeResearch(ctx, content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to summarize research document '%s': %w", document.Path, err)
 			}
+			s.aiService.AddGuidance(fmt.Sprintf("Research findings to ground the breakdown (summarized from %s):\n%s",
+				filepath.Base(document.Path), summary))
+
+		default:
+			return nil, fmt.Errorf("document '%s' has unknown role '%s' (must be %s, %s, %s or %s)", document.Path, document.Role,
+				models.RolePRD, models.RoleTechDesign, models.RoleResearch, models.RoleConstraints)
+		}
+	}
+
+	if analyzed.Len() == 0 {
+		return nil, fmt.Errorf("manifest needs at least one %s or %s document to analyze", models.RolePRD, models.RoleTechDesign)
+	}
+
+	s.aiService.AddGuidance("The content combines several documents. Derive scope from product requirements; use technical design documents to shape technical stories and dependencies.")
+
+	breakdown, err := s.analyzeContent(ctx, analyzed.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Name != "" {
+		breakdown.ProjectName = manifest.Name
+	}
+	return breakdown, nil
+}
+
+// summarizeResearch condenses a research document chunk by chunk
+func (s *AnalysisService) summarizeResearch(ctx context.Context, content string) (string, error) {
+	var summaries []string
+	for _, chunk := range s.chunkContent(content) {
+		summary, err := s.aiService.Summarize(ctx, chunk, "research findings, user needs and risks relevant to planning the project")
+		if err != nil {
+			return "", err
+		}
+		summaries = append(summaries, summary)
+	}
+	return strings.Join(summaries, "\n"), nil
+}
+
+// analyzeContent runs the AI analysis pipeline on document content
+func (s *AnalysisService) analyzeContent(ctx context.Context, content string) (*models.ProjectBreakdown, error) {
+	// Apply front-matter directives before anything else reads the config
+	content, err := s.applyDirectives(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop irrelevant sections before chunking
+	content, skipped := helpers.FilterMarkdownSections(content, s.config.Processing.IncludeSections, s.config.Processing.ExcludeSections)
+	if len(skipped) > 0 {
+		helpers.PrintInfo("Skipped %d sections: %s", len(skipped), strings.Join(skipped, ", "))
+		helpers.PrintInfo("%d bytes remain for analysis", len(content))
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("no content left to analyze after section filtering")
+	}
+
+	// Calibrate with what humans changed in earlier breakdowns
+	feedbackService := NewFeedbackService(s.config)
+	calibration, err := feedbackService.CalibrationGuidance()
+	if err != nil {
+		helpers.PrintWarning("Failed to load edit feedback: %v", err)
+	} else if calibration != "" {
+		helpers.PrintInfo("Applying calibration from recorded human edits")
+		s.aiService.AddGuidance(calibration)
+	}
+
+	// Steer the style with items reviewers rated
+	examples, err := feedbackService.ExampleGuidance()
+	if err != nil {
+		helpers.PrintWarning("Failed to load item ratings: %v", err)
+	} else if examples != "" {
+		helpers.PrintInfo("Applying reviewer ratings as examples")
+		s.aiService.AddGuidance(examples)
+	}
+
+	// Let the AI split the work between the configured teams
+	if len(s.config.Jira.Teams) > 0 {
+		s.aiService.AddGuidance(fmt.Sprintf("Several teams deliver this project: %s. Add a \"team\" field to every epic naming the team that owns it, using one of these names exactly. Add a \"team\" field to a story only when a different team should deliver it.",
+			strings.Join(teamNames(s.config.Jira.Teams), ", ")))
+	}
+
+	// Blend the author's inline annotations with AI generation
+	s.applyAnnotations(content)
+
+	// Determine if we need to chunk the content
+	chunks := s.chunkContent(content)
+	helpers.PrintInfo("Processing with AI (%d chunks)...", len(chunks))
+
+	if err := s.aiService.checkBudget(chunks); err != nil {
+		return nil, err
+	}
+
+	results, err := s.processChunks(ctx, chunks)
+	var budgetErr *BudgetError
+	var skippedChunks []int
+	if errors.As(err, &budgetErr) {
+		// Keep what the budget paid for
+		var analyzed []*models.ProjectBreakdown
+		for i, result := range results {
+			if result == nil {
+				skippedChunks = append(skippedChunks, i+1)
+			} else {
+				analyzed = append(analyzed, result)
+			}
+		}
+		if len(analyzed) == 0 {
+			return nil, err
 		}
+		helpers.PrintWarning("%v", budgetErr)
+		helpers.PrintWarning("Stopped after %d of %d chunks; the breakdown leaves out the others", len(analyzed), len(chunks))
+		results = analyzed
+	} else if err != nil {
+		return nil, err
+	}
 
-		// Collect epics and update totals
+	var allEpics []models.Epic
+	var risks []models.Risk
+	var initiatives []models.Initiative
+	var projectName string
+	var overview string
+
+	for i, breakdown := range results {
+		// Collect epics, risks and initiatives
 		allEpics = append(allEpics, breakdown.Epics...)
-		totalStories += breakdown.TotalStories
-		totalStoryPoints += breakdown.TotalStoryPoints
+		risks = mergeRisks(risks, breakdown.Risks)
+		initiatives = mergeInitiatives(initiatives, breakdown.Initiatives)
 
 		// Use the first chunk's project name and overview, or merge if needed
 		if i == 0 {
@@ -189,7 +1027,44 @@ func (s *AnalysisService) ProcessProject(inputFile string) (*models.ProjectBreak
 	}
 
 	// Merge and deduplicate epics
-	mergedEpics := s.aiService.MergeEpics(allEpics)
+	var mergedEpics []models.Epic
+	if s.config.Processing.Synthesis && len(results) > 1 {
+		helpers.PrintInfo("Synthesizing %d chunk breakdowns into one...", len(results))
+
+		synthesized, err := s.aiService.SynthesizeBreakdown(ctx, results)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			helpers.PrintWarning("Synthesis pass failed, merging epics by title instead: %v", err)
+		} else {
+			projectName = synthesized.ProjectName
+			overview = synthesized.Overview
+			mergedEpics = synthesized.Epics
+			if len(synthesized.Risks) > 0 {
+				risks = synthesized.Risks
+			}
+			if len(synthesized.Initiatives) > 0 {
+				initiatives = synthesized.Initiatives
+			}
+		}
+	}
+	if mergedEpics == nil {
+		mergedEpics = s.aiService.MergeEpics(allEpics)
+		s.publishMerges(allEpics, mergedEpics)
+	}
+
+	if s.config.Processing.NFREpic {
+		mergedEpics = nfrEpicLast(mergedEpics)
+	}
+
+	// Enforce priorities pinned by annotations
+	for i := range mergedEpics {
+		key := strings.ToLower(strings.TrimSpace(mergedEpics[i].Title))
+		if priority := s.pinnedEpics[key]; priority != "" {
+			mergedEpics[i].Priority = priority
+		}
+	}
 
 	// Calculate final totals
 	finalTotalStories := 0
@@ -209,30 +1084,287 @@ func (s *AnalysisService) ProcessProject(inputFile string) (*models.ProjectBreak
 		TotalEpics:       len(mergedEpics),
 		TotalStories:     finalTotalStories,
 		TotalStoryPoints: finalTotalStoryPoints,
-		ProcessedChunks:  len(chunks),
+		ProcessedChunks:  len(results),
+		SkippedChunks:    skippedChunks,
+		CriteriaFormat:   s.config.Processing.CriteriaFormat,
+		Methodology:      s.config.Processing.Methodology,
+		EstimationScale:  s.config.Processing.EstimationScale.Points,
+		Language:         s.config.Processing.Language,
+		Risks:            risks,
+		Initiatives:      initiatives,
 	}
 
-	helpers.PrintSuccess("AI processing complete - %d chunks processed, %d epics found", len(chunks), len(mergedEpics))
+	if s.config.Processing.Traceability {
+		// Sections are numbered in the whole document, so incremental runs trace to the same IDs
+		document := content
+		if s.source != "" {
+			_, document = helpers.SplitFrontMatter(s.source)
+		}
+		finalBreakdown.Requirements = documentRequirements(document)
+		if untraced := traceStories(finalBreakdown, chunks); untraced > 0 {
+			helpers.PrintWarning("%d stories name no section of the document and are not traced to a requirement", untraced)
+		}
+	}
+
+	if err := s.planReleases(ctx, finalBreakdown); err != nil {
+		return nil, err
+	}
+	s.suggestAssignees(finalBreakdown)
+
+	helpers.PrintSuccess("AI processing complete - %d chunks processed, %d epics found", len(results), len(mergedEpics))
 	return finalBreakdown, nil
 }
 
-// chunkContent splits content into chunks if it's too large
-func (s *AnalysisService) chunkContent(content string) []string {
-	if len(content) <= s.config.Anthropic.ChunkSizeChars {
-		return []string{content}
+// applyAnnotations turns inline <!-- scrum-master: epic="..." priority=... --> annotations into
+// prompt guidance pinning sections to epics and priorities
+func (s *AnalysisService) applyAnnotations(content string) {
+	annotations := helpers.ParseAnnotations(content)
+	if len(annotations) == 0 {
+		return
 	}
 
-	var chunks []string
-	chunkSize := s.config.Anthropic.ChunkSizeChars
-	overlap := chunkSize / 4 // 25% overlap
+	s.pinnedEpics = make(map[string]string)
+	var lines []string
+
+	for _, annotation := range annotations {
+		scope := "the entire document"
+		if annotation.Section != "" {
+			scope = fmt.Sprintf("the section \"%s\"", annotation.Section)
+		}
+
+		epic := annotation.Attributes["epic"]
+		priority := normalizePriority(annotation.Attributes["priority"])
+
+		if annotation.Attributes["priority"] != "" && priority == "" {
+			helpers.PrintWarning("Ignoring invalid priority '%s' in annotation for %s", annotation.Attributes["priority"], scope)
+		}
+
+		switch {
+		case epic != "" && priority != "":
+			lines = append(lines, fmt.Sprintf("- All stories derived from %s MUST belong to an epic titled exactly \"%s\" with priority %s", scope, epic, priority))
+			s.pinnedEpics[strings.ToLower(epic)] = priority
+		case epic != "":
+			lines = append(lines, fmt.Sprintf("- All stories derived from %s MUST belong to an epic titled exactly \"%s\"", scope, epic))
+		case priority != "":
+			lines = append(lines, fmt.Sprintf("- All stories derived from %s MUST have priority %s", scope, priority))
+		default:
+			continue
+		}
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	helpers.PrintInfo("Applying %d inline annotations from the document", len(lines))
+	s.aiService.AddGuidance("The document author pinned parts of the breakdown. These directives override your own judgement:\n" + strings.Join(lines, "\n"))
+}
+
+// normalizePriority returns the canonical High/Medium/Low spelling of a priority, or "" if invalid
+func normalizePriority(priority string) string {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case "high":
+		return "High"
+	case "medium":
+		return "Medium"
+	case "low":
+		return "Low"
+	}
+	return ""
+}
+
+// publishMerges publishes an EpicMerged event for every merged epic that came from several chunks
+func (s *AnalysisService) publishMerges(chunkEpics, mergedEpics []models.Epic) {
+	sources := make(map[string]int)
+	for _, epic := range chunkEpics {
+		sources[strings.ToLower(strings.TrimSpace(epic.Title))]++
+	}
+
+	for _, epic := range mergedEpics {
+		if chunks := sources[strings.ToLower(strings.TrimSpace(epic.Title))]; chunks > 1 {
+			s.events.Publish(models.EpicMerged{Title: epic.Title, Chunks: chunks, Stories: len(epic.Stories)})
+		}
+	}
+}
+
+// processChunks analyzes chunks with a bounded pool of workers and returns the results in
+// chunk order. With prompt caching, the first chunk is analyzed before the others are started.
+// No new chunks are started once one has failed. When the AI budget runs out, the
+// results of the chunks analyzed so far are returned with the BudgetError, nil for the others.
+func (s *AnalysisService) processChunks(ctx context.Context, chunks []string) ([]*models.ProjectBreakdown, error) {
+	concurrency := s.config.Processing.MaxConcurrency
+	if concurrency > len(chunks) {
+		concurrency = len(chunks)
+	}
+	if concurrency > 1 {
+		helpers.PrintInfo("Processing up to %d chunks concurrently", concurrency)
+	}
+
+	results := make([]*models.ProjectBreakdown, len(chunks))
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	slots := make(chan struct{}, concurrency)
 
-	for i := 0; i < len(content); i += chunkSize - overlap {
-		end := i + chunkSize
-		if end > len(content) {
-			end = len(content)
+	for i, chunk := range chunks {
+		slots <- struct{}{}
+		if failed.Load() || ctx.Err() != nil {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			helpers.PrintProgress(i+1, len(chunks), fmt.Sprintf("Processing chunk %d", i+1))
+
+			// Process chunk with AI
+			breakdown, err := s.aiService.ProcessWithRetry(ctx, chunk, i+1, len(chunks))
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to process chunk %d: %w", i+1, err)
+				failed.Store(true)
+				return
+			}
+
+			// Save intermediate results if enabled
+			if s.config.Processing.SaveIntermediate {
+				intermediateFilename := helpers.GenerateOutputFilename(fmt.Sprintf("chunk-%d", i+1), "json")
+				intermediatePath := helpers.GetOutputPath(s.config.Processing.OutputDir, intermediateFilename)
+
+				if err := helpers.SaveJSON(breakdown, intermediatePath); err != nil {
+					helpers.PrintWarning("Failed to save intermediate result: %v", err)
+				}
+			}
+
+			if s.config.Processing.Traceability {
+				for j := range breakdown.Epics {
+					for k := range breakdown.Epics[j].Stories {
+						breakdown.Epics[j].Stories[k].Chunk = i + 1
+					}
+				}
+			}
+			results[i] = breakdown
+
+			stories := 0
+			for _, epic := range breakdown.Epics {
+				stories += len(epic.Stories)
+			}
+			s.events.Publish(models.ChunkAnalyzed{Chunk: i + 1, TotalChunks: len(chunks), Epics: len(breakdown.Epics), Stories: stories})
+		}(i, chunk)
+
+		// The first chunk writes the cached instructions alone, so the others read them instead
+		// of each writing them again
+		if i == 0 && concurrency > 1 && s.aiService.CachesPrompts() {
+			wg.Wait()
 		}
-		chunks = append(chunks, content[i:end])
 	}
 
-	return chunks
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var budgetErr error
+	for _, err := range errs {
+		var exceeded *BudgetError
+		switch {
+		case err == nil:
+		case errors.As(err, &exceeded):
+			budgetErr = err
+		default:
+			return nil, err
+		}
+	}
+
+	return results, budgetErr
+}
+
+// applyDirectives parses the document's front matter, applies it on top of the configuration
+// and returns the document body without the front matter
+func (s *AnalysisService) applyDirectives(content string) (string, error) {
+	frontMatter, body := helpers.SplitFrontMatter(content)
+	if frontMatter == "" {
+		return content, nil
+	}
+
+	var directives models.DocumentDirectives
+	if err := yaml.Unmarshal([]byte(frontMatter), &directives); err != nil {
+		return "", fmt.Errorf("failed to parse front matter: %w", err)
+	}
+
+	helpers.PrintInfo("Applying front-matter directives from the document")
+
+	if directives.ProjectKey != "" {
+		helpers.PrintInfo("  Project key: %s", directives.ProjectKey)
+		s.config.Jira.ProjectKey = directives.ProjectKey
+	}
+
+	if len(directives.Labels) > 0 {
+		helpers.PrintInfo("  Labels: %s", strings.Join(directives.Labels, ", "))
+		s.config.Jira.Labels = directives.Labels
+	}
+
+	if directives.Team != "" {
+		helpers.PrintInfo("  Team: %s", directives.Team)
+		s.config.Jira.Team = directives.Team
+	}
+
+	if len(directives.IncludeSections) > 0 {
+		s.config.Processing.IncludeSections = directives.IncludeSections
+	}
+	if len(directives.ExcludeSections) > 0 {
+		s.config.Processing.ExcludeSections = directives.ExcludeSections
+	}
+
+	switch strings.ToLower(directives.Granularity) {
+	case "":
+	case "coarse":
+		helpers.PrintInfo("  Granularity: coarse")
+		s.aiService.AddGuidance("Granularity: prefer fewer, broader epics and larger stories (3-8 points). Avoid splitting closely related work.")
+	case "fine":
+		helpers.PrintInfo("  Granularity: fine")
+		s.aiService.AddGuidance("Granularity: prefer small, independently deliverable stories (1-3 points), even if that means more stories per epic.")
+	case "normal":
+		helpers.PrintInfo("  Granularity: normal")
+	default:
+		return "", fmt.Errorf("invalid granularity '%s' in front matter (must be coarse, normal or fine)", directives.Granularity)
+	}
+
+	if len(directives.Phases) > 0 {
+		helpers.PrintInfo("  Phases: %s", strings.Join(directives.Phases, ", "))
+		s.aiService.AddGuidance(fmt.Sprintf("The project is delivered in these phases, in order: %s. Mention the phase in each epic description and prioritize earlier phases higher.",
+			strings.Join(directives.Phases, ", ")))
+	}
+
+	if directives.Template != "" {
+		if !filepath.IsLocal(directives.Template) {
+			return "", fmt.Errorf("invalid template '%s' in front matter (must name a folder in processing.templates_dir)", directives.Template)
+		}
+		dir := filepath.Join(s.config.Processing.TemplatesDir, directives.Template)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("template '%s' in front matter not found: %s is not a folder", directives.Template, dir)
+		}
+		helpers.PrintInfo("  Template: %s", dir)
+		if err := s.aiService.UseTemplates(dir, s.config.Processing.TemplatesDir); err != nil {
+			return "", err
+		}
+	}
+
+	s.directives = &directives
+	return body, nil
+}
+
+// chunkContent splits content into chunks if it's larger than the token budget
+func (s *AnalysisService) chunkContent(content string) []string {
+	chunkSize := s.config.Anthropic.ChunkSizeTokens
+	overlap := chunkSize / 4 // 25% overlap
+
+	if s.config.Processing.ChunkingStrategy == config.ChunkingTokens {
+		return helpers.ChunkByTokens(content, chunkSize, overlap)
+	}
+	return helpers.ChunkMarkdown(content, chunkSize, overlap)
 }
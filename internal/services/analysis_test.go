@@ -0,0 +1,96 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scrum-master/internal/config"
+)
+
+func TestApplyDirectivesTemplate(t *testing.T) {
+	// The templates folder overrides the chunk prompt, and its payments set the breakdown prompt
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"chunk.tmpl":              "Shared chunk {{.Content}}",
+		"payments/breakdown.tmpl": "Payments breakdown",
+		"notes.md":                "Not a template set",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		template      string
+		wantBreakdown string
+		wantErr       string
+	}{
+		{
+			name:          "template set of the document",
+			template:      "payments",
+			wantBreakdown: "Payments breakdown",
+		},
+		{
+			name:     "unknown template set",
+			template: "billing",
+			wantErr:  "template 'billing' in front matter not found",
+		},
+		{
+			name:     "file instead of a folder",
+			template: "notes.md",
+			wantErr:  "is not a folder",
+		},
+		{
+			name:     "folder outside the templates",
+			template: "../payments",
+			wantErr:  "invalid template '../payments'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Defaults()
+			cfg.Processing.TemplatesDir = dir
+			templates, err := loadTemplates(dir)
+			if err != nil {
+				t.Fatalf("loadTemplates() error = %v", err)
+			}
+			service := &AnalysisService{config: cfg, aiService: &AIService{templates: templates}}
+
+			body, err := service.applyDirectives("---\ntemplate: " + tt.template + "\n---\n# Payments\n")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyDirectives() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyDirectives() error = %v", err)
+			}
+			if body != "# Payments\n" {
+				t.Errorf("applyDirectives() body = %q, want %q", body, "# Payments\n")
+			}
+
+			if got, _ := service.aiService.render(TemplateBreakdown, promptData{}); got != tt.wantBreakdown {
+				t.Errorf("breakdown prompt = %q, want %q", got, tt.wantBreakdown)
+			}
+			if got, _ := service.aiService.render(TemplateChunk, promptData{Content: "text"}); got != "Shared chunk text" {
+				t.Errorf("chunk prompt = %q, want the override of the templates folder", got)
+			}
+			defaults, err := loadTemplates()
+			if err != nil {
+				t.Fatalf("loadTemplates() error = %v", err)
+			}
+			want, _ := (&AIService{templates: defaults}).render(TemplateSynthesis, promptData{})
+			if got, _ := service.aiService.render(TemplateSynthesis, promptData{}); got != want {
+				t.Errorf("synthesis prompt = %q, want the default", got)
+			}
+		})
+	}
+}
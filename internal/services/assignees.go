@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// SuggestAssignees suggests a team member for every story of a breakdown without an assignee by
+// matching the members' skills against the story's title, description, acceptance criteria,
+// labels and subtask components, and the component of its epic. A story goes to the member with
+// the most matching skills; ties go to the member with the fewest story points so far. Stories no
+// skill matches are left unassigned. It returns the number of stories assigned.
+func SuggestAssignees(breakdown *models.ProjectBreakdown, members []config.TeamMember) int {
+	if len(members) == 0 {
+		return 0
+	}
+
+	load := make(map[string]int)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			if story.Assignee != "" {
+				load[strings.ToLower(story.Assignee)] += story.StoryPoints
+			}
+		}
+	}
+
+	assigned := 0
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+			if story.Assignee != "" {
+				continue
+			}
+
+			text := " " + skillText(storyText(epic, story)) + " "
+			best, bestMatches := "", 0
+			for _, member := range members {
+				matches := 0
+				for _, skill := range member.Skills {
+					if skill := skillText(skill); skill != "" && strings.Contains(text, " "+skill+" ") {
+						matches++
+					}
+				}
+				if matches == 0 {
+					continue
+				}
+				if matches > bestMatches || (matches == bestMatches && load[strings.ToLower(member.Name)] < load[strings.ToLower(best)]) {
+					best, bestMatches = member.Name, matches
+				}
+			}
+
+			if best != "" {
+				story.Assignee = best
+				load[strings.ToLower(best)] += story.StoryPoints
+				assigned++
+			}
+		}
+	}
+	return assigned
+}
+
+// storyText returns the text of a story that skills are matched against
+func storyText(epic *models.Epic, story *models.Story) string {
+	parts := []string{story.Title, story.Description, epic.Component}
+	parts = append(parts, story.AcceptanceCriteria...)
+	parts = append(parts, story.Labels...)
+	for _, subtask := range story.Subtasks {
+		parts = append(parts, subtask.Component)
+	}
+	return strings.Join(parts, " ")
+}
+
+// skillText lower-cases text and turns everything but letters, digits and the characters of
+// skills such as "c++", "c#" and ".net" into single spaces, so skills match whole words only
+func skillText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("+#.", r)
+	}), " ")
+}
+
+// suggestAssignees suggests assignees for the stories of a breakdown when team.members is set
+func (s *AnalysisService) suggestAssignees(breakdown *models.ProjectBreakdown) {
+	if len(s.config.Team.Members) == 0 {
+		return
+	}
+
+	assigned := SuggestAssignees(breakdown, s.config.Team.Members)
+	helpers.PrintInfo("Suggested assignees for %d of %d stories", assigned, breakdown.TotalStories)
+}
+
+// ResolveAssignees looks up the JIRA user of the team member every story is assigned to, so a
+// story assigned to someone who is not in team.members, or who has neither an account_id nor a
+// username, fails the run before anything is created. Assignees are only set on created stories
+// once they are resolved.
+func (s *JiraService) ResolveAssignees(breakdown *models.ProjectBreakdown, members []config.TeamMember) error {
+	users := make(map[string]models.JiraUser)
+	for _, member := range members {
+		users[strings.ToLower(member.Name)] = models.JiraUser{AccountID: member.AccountID, Name: member.Username}
+	}
+
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			if story.Assignee == "" || story.Key != "" {
+				continue
+			}
+			user, ok := users[strings.ToLower(story.Assignee)]
+			if !ok {
+				return fmt.Errorf("story '%s' is assigned to '%s', who is not in team.members", story.Title, story.Assignee)
+			}
+			if user.AccountID == "" && user.Name == "" {
+				return fmt.Errorf("team member '%s' needs an account_id or a username to be assigned stories", story.Assignee)
+			}
+		}
+	}
+
+	s.assignees = users
+	return nil
+}
+
+// setAssignee sets the assignee of an issue to the JIRA user of a team member, once assignees are
+// resolved
+func (s *JiraService) setAssignee(custom map[string]interface{}, assignee string) {
+	if assignee == "" || s.assignees == nil {
+		return
+	}
+	if user, ok := s.assignees[strings.ToLower(assignee)]; ok {
+		custom["assignee"] = user
+	}
+}
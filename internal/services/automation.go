@@ -0,0 +1,273 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"scrum-master/internal/models"
+)
+
+// SplitByConfidence separates a breakdown into items confident enough to create automatically
+// and items that need human review. Stories of a low-confidence epic are always reviewed with it.
+// Items without a confidence score are treated as low confidence.
+func SplitByConfidence(breakdown *models.ProjectBreakdown, threshold int) (confident, review *models.ProjectBreakdown) {
+	confident = &models.ProjectBreakdown{
+		ProjectName:     breakdown.ProjectName,
+		Overview:        breakdown.Overview,
+		ProcessedChunks: breakdown.ProcessedChunks,
+		CriteriaFormat:  breakdown.CriteriaFormat,
+		Methodology:     breakdown.Methodology,
+		EstimationScale: breakdown.EstimationScale,
+		Language:        breakdown.Language,
+		Releases:        breakdown.Releases,
+		Risks:           breakdown.Risks,
+		Initiatives:     breakdown.Initiatives,
+		Requirements:    breakdown.Requirements,
+	}
+	review = &models.ProjectBreakdown{
+		ProjectName:     breakdown.ProjectName,
+		Overview:        breakdown.Overview,
+		ProcessedChunks: breakdown.ProcessedChunks,
+		CriteriaFormat:  breakdown.CriteriaFormat,
+		Methodology:     breakdown.Methodology,
+		EstimationScale: breakdown.EstimationScale,
+		Language:        breakdown.Language,
+		Releases:        breakdown.Releases,
+		Requirements:    breakdown.Requirements,
+	}
+
+	for _, epic := range breakdown.Epics {
+		if epic.Confidence < threshold {
+			review.Epics = append(review.Epics, epic)
+			continue
+		}
+
+		confidentEpic := epic
+		confidentEpic.Stories = nil
+		reviewEpic := epic
+		reviewEpic.Stories = nil
+
+		for _, story := range epic.Stories {
+			if story.Confidence >= threshold {
+				confidentEpic.Stories = append(confidentEpic.Stories, story)
+			} else {
+				reviewEpic.Stories = append(reviewEpic.Stories, story)
+			}
+		}
+
+		confident.Epics = append(confident.Epics, confidentEpic)
+		if len(reviewEpic.Stories) > 0 {
+			review.Epics = append(review.Epics, reviewEpic)
+		}
+	}
+
+	recalculateTotals(confident)
+	recalculateTotals(review)
+	return confident, review
+}
+
+// BreakdownFilter selects part of a breakdown to create. Zero values select everything.
+type BreakdownFilter struct {
+	// Epics lists epics by position (1, 3) or ID (E2)
+	Epics []string
+	// MinPriority skips stories below this priority; stories without one count as Low
+	MinPriority string
+	// MaxPoints skips stories estimated higher than this
+	MaxPoints int
+}
+
+// priorityRanks orders priorities from lowest to highest
+var priorityRanks = map[string]int{"Low": 1, "Medium": 2, "High": 3}
+
+// FilterBreakdown returns the epics and stories a filter selects. Epics left without stories are
+// dropped, unless they had none to begin with and were selected with Epics.
+func FilterBreakdown(breakdown *models.ProjectBreakdown, filter BreakdownFilter) (*models.ProjectBreakdown, error) {
+	minRank := 0
+	if filter.MinPriority != "" {
+		priority := normalizePriority(filter.MinPriority)
+		if priority == "" {
+			return nil, fmt.Errorf("invalid priority '%s' (must be High, Medium or Low)", filter.MinPriority)
+		}
+		minRank = priorityRanks[priority]
+	}
+
+	selected := make(map[int]bool)
+	for _, ref := range filter.Epics {
+		index := epicIndex(breakdown, strings.TrimSpace(ref))
+		if index < 0 {
+			return nil, fmt.Errorf("no epic '%s' in the analysis (use its number or ID)", ref)
+		}
+		selected[index] = true
+	}
+
+	filtered := &models.ProjectBreakdown{
+		ProjectName:     breakdown.ProjectName,
+		Overview:        breakdown.Overview,
+		ProcessedChunks: breakdown.ProcessedChunks,
+		CriteriaFormat:  breakdown.CriteriaFormat,
+		Methodology:     breakdown.Methodology,
+		EstimationScale: breakdown.EstimationScale,
+		Language:        breakdown.Language,
+		Releases:        breakdown.Releases,
+		Risks:           breakdown.Risks,
+		Initiatives:     breakdown.Initiatives,
+		Requirements:    breakdown.Requirements,
+	}
+
+	for i, epic := range breakdown.Epics {
+		if len(selected) > 0 && !selected[i] {
+			continue
+		}
+
+		filteredEpic := epic
+		filteredEpic.Stories = nil
+		for _, story := range epic.Stories {
+			rank := priorityRanks[normalizePriority(story.Priority)]
+			if rank == 0 {
+				rank = priorityRanks["Low"]
+			}
+			if rank < minRank || (filter.MaxPoints > 0 && story.StoryPoints > filter.MaxPoints) {
+				continue
+			}
+			filteredEpic.Stories = append(filteredEpic.Stories, story)
+		}
+
+		if len(filteredEpic.Stories) > 0 || (len(epic.Stories) == 0 && selected[i]) {
+			filtered.Epics = append(filtered.Epics, filteredEpic)
+		}
+	}
+
+	recalculateTotals(filtered)
+	return filtered, nil
+}
+
+// epicIndex finds an epic by its 1-based position or its ID, returning -1 if there is none
+func epicIndex(breakdown *models.ProjectBreakdown, ref string) int {
+	for i, epic := range breakdown.Epics {
+		if epic.ID != "" && strings.EqualFold(epic.ID, ref) {
+			return i
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(breakdown.Epics) {
+		return n - 1
+	}
+	return -1
+}
+
+// LinkCreatedEpics copies the JIRA keys of created epics onto matching epics in another breakdown
+func LinkCreatedEpics(created, target *models.ProjectBreakdown) {
+	keys := make(map[string]string)
+	for _, epic := range created.Epics {
+		if epic.Key != "" {
+			keys[epic.Title] = epic.Key
+		}
+	}
+
+	for i := range target.Epics {
+		if key, exists := keys[target.Epics[i].Title]; exists {
+			target.Epics[i].Key = key
+		}
+	}
+}
+
+// ApplyLedger copies the JIRA keys recorded in a run ledger onto the matching initiatives, epics,
+// stories, subtasks and risks of a breakdown, so creating it again skips them. Epics and stories
+// are matched by ID, or by title for entries recorded without one; subtasks, risks and
+// initiatives were always recorded with an ID and are matched by ID only. It returns the number
+// of items matched.
+func ApplyLedger(breakdown *models.ProjectBreakdown, ledger models.RunLedger) int {
+	idKeys := make(map[string]string)
+	epicKeys := make(map[string]string)
+	storyKeys := make(map[string]string)
+	for _, entry := range ledger.Entries {
+		if entry.RolledBack || entry.Deleted {
+			continue
+		}
+		if entry.ItemID != "" {
+			idKeys[entry.ItemID] = entry.Key
+		} else if entry.ItemType == models.ItemTypeEpic {
+			epicKeys[entry.Title] = entry.Key
+		} else {
+			storyKeys[entry.Epic+"\x00"+entry.Title] = entry.Key
+		}
+	}
+
+	matched := 0
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		if key, exists := ledgerKey(idKeys, epicKeys, epic.ID, epic.Title); exists {
+			epic.Key = key
+			matched++
+		}
+
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+			if key, exists := ledgerKey(idKeys, storyKeys, story.ID, epic.Title+"\x00"+story.Title); exists {
+				story.Key = key
+				matched++
+			}
+
+			// Subtasks were only ever recorded with IDs
+			for k := range story.Subtasks {
+				subtask := &story.Subtasks[k]
+				if key, exists := idKeys[subtask.ID]; exists && subtask.ID != "" {
+					subtask.Key = key
+					matched++
+				}
+			}
+		}
+	}
+
+	// So were risks and initiatives
+	for i := range breakdown.Risks {
+		risk := &breakdown.Risks[i]
+		if key, exists := idKeys[risk.ID]; exists && risk.ID != "" {
+			risk.Key = key
+			matched++
+		}
+	}
+	for i := range breakdown.Initiatives {
+		initiative := &breakdown.Initiatives[i]
+		if key, exists := idKeys[initiative.ID]; exists && initiative.ID != "" {
+			initiative.Key = key
+			matched++
+		}
+	}
+
+	return matched
+}
+
+// ledgerKey looks up the recorded key of an item by its ID, then by its title key
+func ledgerKey(idKeys, titleKeys map[string]string, id, titleKey string) (string, bool) {
+	if key, exists := idKeys[id]; exists && id != "" {
+		return key, true
+	}
+	key, exists := titleKeys[titleKey]
+	return key, exists
+}
+
+// recalculateTotals updates the epic, story and story point totals of a breakdown
+func recalculateTotals(breakdown *models.ProjectBreakdown) {
+	breakdown.TotalEpics = len(breakdown.Epics)
+	breakdown.TotalStories = 0
+	breakdown.TotalStoryPoints = 0
+
+	for _, epic := range breakdown.Epics {
+		breakdown.TotalStories += len(epic.Stories)
+		for _, story := range epic.Stories {
+			breakdown.TotalStoryPoints += story.StoryPoints
+		}
+	}
+}
+
+// CountSubtasks returns the number of subtasks of the stories of a breakdown
+func CountSubtasks(breakdown *models.ProjectBreakdown) int {
+	count := 0
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			count += len(story.Subtasks)
+		}
+	}
+	return count
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// boardColumns are the board columns in display order
+var boardColumns = []string{models.BoardToDo, models.BoardDoing, models.BoardDone}
+
+// boardColumnTitles are the headings of the board columns
+var boardColumnTitles = map[string]string{
+	models.BoardToDo:  "TO DO",
+	models.BoardDoing: "DOING",
+	models.BoardDone:  "DONE",
+}
+
+// boardCommands maps the commands of the interactive board to the column they move cards to
+var boardCommands = map[string]string{
+	"todo":  models.BoardToDo,
+	"start": models.BoardDoing,
+	"doing": models.BoardDoing,
+	"done":  models.BoardDone,
+}
+
+// BoardService tracks the stories of an analysis on a local kanban board
+type BoardService struct {
+	config    *config.Config
+	repo      *repositories.BoardRepository
+	breakdown *models.ProjectBreakdown
+	state     models.BoardState
+}
+
+// boardItem is a story on the board with its reference and column
+type boardItem struct {
+	ref    string
+	epic   *models.Epic
+	story  *models.Story
+	status string
+}
+
+// NewBoardService loads an analysis file and its saved board state
+func NewBoardService(config *config.Config, analysisFile string) (*BoardService, error) {
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return nil, fmt.Errorf("failed to load analysis file: %w", err)
+	}
+
+	AssignItemIDs(&result.ProjectBreakdown)
+
+	repo := repositories.NewBoardRepository(config.Processing.OutputDir, analysisFile)
+	state, err := repo.GetState()
+	if err != nil {
+		return nil, err
+	}
+	state.AnalysisFile = analysisFile
+
+	return &BoardService{
+		config:    config,
+		repo:      repo,
+		breakdown: &result.ProjectBreakdown,
+		state:     state,
+	}, nil
+}
+
+// StatePath returns the file the board state is saved to
+func (s *BoardService) StatePath() string {
+	return s.repo.Path()
+}
+
+// Display renders the board as three columns followed by the overall progress
+func (s *BoardService) Display() {
+	items := s.items()
+
+	columns := make(map[string][]boardItem)
+	for _, item := range items {
+		columns[item.status] = append(columns[item.status], item)
+	}
+
+	width := boardColumnWidth()
+
+	helpers.PrintTitle("Board: %s", s.breakdown.ProjectName)
+
+	var header []string
+	for _, column := range boardColumns {
+		header = append(header, padRight(fmt.Sprintf("%s (%d)", boardColumnTitles[column], len(columns[column])), width))
+	}
+	helpers.TitleColor.Println(strings.Join(header, " | "))
+	fmt.Println(strings.Repeat("-", width*len(boardColumns)+3*(len(boardColumns)-1)))
+
+	rows := 0
+	for _, column := range boardColumns {
+		if len(columns[column]) > rows {
+			rows = len(columns[column])
+		}
+	}
+
+	for row := 0; row < rows; row++ {
+		var cells []string
+		for _, column := range boardColumns {
+			cell := ""
+			if row < len(columns[column]) {
+				item := columns[column][row]
+				cell = fmt.Sprintf("%s %s [%d]", item.ref, item.story.Title, item.story.StoryPoints)
+			}
+			cells = append(cells, padRight(cell, width))
+		}
+		fmt.Println(strings.Join(cells, " | "))
+	}
+
+	doneStories, donePoints, totalPoints := 0, 0, 0
+	for _, item := range items {
+		totalPoints += item.story.StoryPoints
+		if item.status == models.BoardDone {
+			doneStories++
+			donePoints += item.story.StoryPoints
+		}
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("Done: %d/%d stories, %d/%d points", doneStories, len(items), donePoints, totalPoints)
+}
+
+// Move moves stories to a column and saves the board. A reference is either a story (E2-S3 or
+// 2.3) or an epic (E2 or 2), which moves all of its stories.
+func (s *BoardService) Move(refs []string, status string) ([]string, error) {
+	if boardColumnTitles[status] == "" {
+		return nil, fmt.Errorf("unknown column '%s' (must be todo, doing or done)", status)
+	}
+
+	var moved []string
+	for _, ref := range refs {
+		epic, story, err := FindItem(s.breakdown, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		stories := []*models.Story{story}
+		if story == nil {
+			stories = nil
+			for i := range epic.Stories {
+				stories = append(stories, &epic.Stories[i])
+			}
+		}
+
+		for _, story := range stories {
+			s.setStatus(epic, story, status)
+			moved = append(moved, story.ID)
+		}
+	}
+
+	s.state.UpdatedAt = time.Now()
+	if err := s.repo.SaveState(s.state); err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// Run shows the board and reads commands from in until quit, end of input or cancellation.
+// Every move is saved immediately.
+func (s *BoardService) Run(ctx context.Context, in io.Reader) error {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	s.Display()
+	s.printHelp()
+
+	for {
+		fmt.Print("board> ")
+
+		var line string
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case next, ok := <-lines:
+			if !ok {
+				fmt.Println()
+				return nil
+			}
+			line = next
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			s.Display()
+			continue
+		}
+
+		command := strings.ToLower(fields[0])
+		switch {
+		case command == "q" || command == "quit" || command == "exit":
+			return nil
+
+		case command == "?" || command == "help":
+			s.printHelp()
+
+		case command == "show":
+			for _, ref := range fields[1:] {
+				s.showItem(ref)
+			}
+
+		case boardCommands[command] != "":
+			if len(fields) < 2 {
+				helpers.PrintWarning("Usage: %s <ref>...", command)
+				continue
+			}
+
+			moved, err := s.Move(fields[1:], boardCommands[command])
+			if err != nil {
+				helpers.PrintError("%v", err)
+				continue
+			}
+			s.Display()
+			helpers.PrintSuccess("Moved %s to %s", strings.Join(moved, ", "), boardColumnTitles[boardCommands[command]])
+
+		default:
+			helpers.PrintWarning("Unknown command '%s'; type ? for help", command)
+		}
+	}
+}
+
+// printHelp lists the commands of the interactive board
+func (s *BoardService) printHelp() {
+	helpers.PrintInfo("Commands: start <ref>, done <ref>, todo <ref>, show <ref>, q to quit")
+	helpers.PrintInfo("A ref is a story (E2-S3) or an epic (E2) to move all of its stories; press Enter to redraw")
+}
+
+// showItem prints the details of a story
+func (s *BoardService) showItem(ref string) {
+	_, story, err := FindItem(s.breakdown, ref)
+	if err != nil || story == nil {
+		helpers.PrintWarning("No story '%s' on the board", ref)
+		return
+	}
+
+	for _, item := range s.items() {
+		if item.story != story {
+			continue
+		}
+
+		helpers.PrintInfo("Story %s: %s [%s]", item.ref, item.story.Title, boardColumnTitles[item.status])
+		helpers.PrintInfo("  Epic: %s", item.epic.Title)
+		helpers.PrintInfo("  Points: %d | Priority: %s", item.story.StoryPoints, item.story.Priority)
+		helpers.PrintInfo("  %s", item.story.Description)
+		for _, criteria := range item.story.AcceptanceCriteria {
+			helpers.PrintInfo("    %s %s", helpers.Symbol("•", "-"), criteria)
+		}
+		return
+	}
+}
+
+// items lists the stories of the breakdown with their board column
+func (s *BoardService) items() []boardItem {
+	status := make(map[string]string)
+	for _, card := range s.state.Cards {
+		if card.ID != "" {
+			status[card.ID] = card.Status
+		} else {
+			status[card.Epic+"\x00"+card.Story] = card.Status
+		}
+	}
+
+	var items []boardItem
+	for i := range s.breakdown.Epics {
+		epic := &s.breakdown.Epics[i]
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+
+			column := status[story.ID]
+			if column == "" {
+				column = status[epic.Title+"\x00"+story.Title]
+			}
+			if column == "" {
+				column = models.BoardToDo
+			}
+
+			items = append(items, boardItem{
+				ref:    story.ID,
+				epic:   epic,
+				story:  story,
+				status: column,
+			})
+		}
+	}
+	return items
+}
+
+// setStatus records the column of a story. Cards saved before stories had IDs take the ID on
+// their next move.
+func (s *BoardService) setStatus(epic *models.Epic, story *models.Story, status string) {
+	for i := range s.state.Cards {
+		card := &s.state.Cards[i]
+		if card.ID == story.ID || (card.ID == "" && card.Epic == epic.Title && card.Story == story.Title) {
+			card.ID = story.ID
+			card.Epic = epic.Title
+			card.Story = story.Title
+			if card.Status != status {
+				card.Status = status
+				card.MovedAt = time.Now()
+			}
+			return
+		}
+	}
+
+	s.state.Cards = append(s.state.Cards, models.BoardCard{
+		ID:      story.ID,
+		Epic:    epic.Title,
+		Story:   story.Title,
+		Status:  status,
+		MovedAt: time.Now(),
+	})
+}
+
+// boardColumnWidth fits three columns into the terminal width from $COLUMNS, or 120 characters
+func boardColumnWidth() int {
+	total := 120
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		total = columns
+	}
+
+	width := (total - 3*(len(boardColumns)-1)) / len(boardColumns)
+	if width < 20 {
+		width = 20
+	}
+	return width
+}
+
+// padRight truncates or pads text to exactly width characters
+func padRight(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		ellipsis := []rune(helpers.Symbol("…", "..."))
+		return string(runes[:width-len(ellipsis)]) + string(ellipsis)
+	}
+	return text + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+
+	"scrum-master/internal/helpers"
+)
+
+// BudgetError reports that prompts were not sent because they could take the AI spend of the
+// run over anthropic.max_cost_usd
+type BudgetError struct {
+	SpentUSD    float64 // Spent so far
+	RequiredUSD float64 // What the prompts not sent could cost
+	BudgetUSD   float64
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("AI budget of $%.2f (anthropic.max_cost_usd) reached: $%.4f spent, $%.4f more needed",
+		e.BudgetUSD, e.SpentUSD, e.RequiredUSD)
+}
+
+// reserve books the most a prompt can cost against the budget of the run, so concurrent prompts
+// cannot overspend it together. A prompt that only fits once the prompts in flight are counted
+// waits for them. It returns the amount to release once the answer is counted.
+func (s *AIService) reserve(prompt string) (float64, error) {
+	if s.budget == 0 {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	required := cost(s.pricing, helpers.EstimateTokens(prompt), s.config.MaxTokens)
+	for {
+		spent := usageCost(s.pricing, s.usage)
+		if spent+required > s.budget {
+			return 0, &BudgetError{SpentUSD: spent, RequiredUSD: required, BudgetUSD: s.budget}
+		}
+		if spent+s.reserved+required <= s.budget {
+			s.reserved += required
+			return required, nil
+		}
+		s.released.Wait()
+	}
+}
+
+// release returns a booking of reserve once its answer is counted or the prompt failed
+func (s *AIService) release(amount float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reserved -= amount
+	s.released.Broadcast()
+}
+
+// checkBudget fails before any prompt is sent when the input of the chunks alone costs more than
+// the budget of the run
+func (s *AIService) checkBudget(chunks []string) error {
+	if s.budget == 0 {
+		return nil
+	}
+
+	inputTokens := 0
+	for i, chunk := range chunks {
+		tokens, _, err := s.EstimateChunk(chunk, i+1, len(chunks))
+		if err != nil {
+			return err
+		}
+		inputTokens += tokens
+	}
+	if required := cost(s.pricing, inputTokens, 0); required > s.budget {
+		return &BudgetError{RequiredUSD: required, BudgetUSD: s.budget}
+	}
+	return nil
+}
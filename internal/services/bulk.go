@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+	"scrum-master/internal/transport"
+)
+
+// createStoriesInBulk creates the stories of an epic that do not exist yet through JIRA's bulk
+// create endpoint, in batches of up to repositories.MaxBulkIssues, instead of one request per
+// story. A story JIRA rejects is reported as failed, like one created on its own. It returns the
+// indexes of the stories it created or reported. When JIRA does not offer the endpoint, the
+// stories are left to be created one by one, as are all stories after them. Any other failure
+// of a whole request is returned, since JIRA may have created some of its stories.
+func (s *JiraService) createStoriesInBulk(ctx context.Context, breakdown *models.ProjectBreakdown, epic *models.Epic, epicKey string) (map[int]bool, error) {
+	handled := make(map[int]bool)
+
+	var pending []int
+	for j, story := range epic.Stories {
+		if story.Key == "" {
+			pending = append(pending, j)
+		}
+	}
+	if len(pending) < 2 || s.bulkUnavailable {
+		return handled, nil
+	}
+
+	for start := 0; start < len(pending); start += repositories.MaxBulkIssues {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("interrupted before creating the stories of epic '%s': %w", epic.Title, err)
+		}
+
+		var batch []int
+		var issues []*models.JiraIssue
+		for _, j := range pending[start:min(start+repositories.MaxBulkIssues, len(pending))] {
+			story := &epic.Stories[j]
+			custom, err := s.taskFields(s.storyTeam(epic, story), story.Release, story.Assignee, storyFields(story))
+			if err != nil {
+				s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeStory, ItemID: story.ID, Epic: epic.Title, Title: story.Title, Err: err})
+				handled[j] = true
+				continue
+			}
+
+			batch = append(batch, j)
+			issues = append(issues, s.newIssue(story.Title, s.storyDescription(breakdown, story), storyIssueType(s.issueTypes, story), story.Priority, epicKey,
+				mergeLabels(s.traceLabels(epic.Title, story.Title), s.idLabels(breakdown, story.ID), story.Labels), []string{s.epicComponent(epic)}, custom))
+		}
+		if len(issues) == 0 {
+			continue
+		}
+
+		helpers.PrintInfo("Creating %d stories of epic '%s' in one request", len(issues), epic.Title)
+		keys, errs, err := s.repo.CreateIssues(context.WithoutCancel(ctx), issues)
+		if err != nil {
+			var statusErr *transport.StatusError
+			if !errors.As(err, &statusErr) || (statusErr.StatusCode != http.StatusNotFound && statusErr.StatusCode != http.StatusMethodNotAllowed) {
+				return nil, fmt.Errorf("failed to create the stories of epic '%s': %w", epic.Title, err)
+			}
+
+			helpers.PrintWarning("Bulk creation is not available, creating stories one by one: %v", err)
+			s.bulkUnavailable = true
+			return handled, nil
+		}
+
+		for i, j := range batch {
+			story := &epic.Stories[j]
+			handled[j] = true
+			if errs[i] != nil {
+				s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeStory, ItemID: story.ID, Epic: epic.Title, Title: story.Title, Err: errs[i]})
+				continue
+			}
+
+			story.Key = keys[i]
+			s.events.Publish(models.IssueCreated{ItemType: models.ItemTypeStory, ItemID: story.ID, Epic: epic.Title, Title: story.Title, Key: story.Key})
+			s.markCreated(ctx, models.ItemTypeStory, story.ID, epic.Title, story.Title, story.Key)
+			s.linkSource(ctx, story.Key)
+		}
+	}
+	return handled, nil
+}
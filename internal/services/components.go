@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// ResolveComponents checks jira.components and the components jira.component_mapping maps to
+// against the components of the project, so a misspelled name fails the run before anything is
+// created. A component suggested for an epic that is neither mapped nor one of the project's is
+// left out with a warning. When the project cannot be read, only configured and mapped
+// components are set, as they are written.
+func (s *JiraService) ResolveComponents(ctx context.Context, breakdown *models.ProjectBreakdown) error {
+	suggested := false
+	for _, epic := range breakdown.Epics {
+		if epic.Component != "" {
+			suggested = true
+		}
+	}
+	if len(s.config.Components) == 0 && len(s.config.ComponentMapping) == 0 && !suggested {
+		return nil
+	}
+
+	project, err := s.repo.GetProjectInfo(ctx, s.config.ProjectKey)
+	if err != nil {
+		helpers.PrintWarning("Failed to read the components of project %s, setting configured components as they are: %v", s.config.ProjectKey, err)
+		return nil
+	}
+
+	components := make(map[string]string)
+	available := []string{}
+	for _, component := range project.Components {
+		components[strings.ToLower(component.Name)] = component.Name
+		available = append(available, component.Name)
+	}
+	if len(available) == 0 {
+		available = append(available, "none")
+	}
+
+	for _, name := range s.config.Components {
+		if _, ok := components[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("jira.components has '%s', which project %s does not have (available: %s)",
+				name, s.config.ProjectKey, strings.Join(available, ", "))
+		}
+	}
+	for from, name := range s.config.ComponentMapping {
+		if _, ok := components[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("jira.component_mapping maps '%s' to '%s', which project %s does not have (available: %s)",
+				from, name, s.config.ProjectKey, strings.Join(available, ", "))
+		}
+	}
+	s.components = components
+
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		component := s.epicComponent(epic)
+		switch {
+		case component != "":
+			helpers.PrintInfo("Epic '%s' -> component %s", epic.Title, component)
+		case epic.Component != "":
+			helpers.PrintWarning("Project %s has no component '%s' suggested for epic '%s'; map it in jira.component_mapping to set one",
+				s.config.ProjectKey, epic.Component, epic.Title)
+		}
+	}
+	return nil
+}
+
+// epicComponent returns the component of an epic and its stories and subtasks: the one
+// jira.component_mapping maps the epic's title or suggested component to, or else the suggested
+// component when the project has it
+func (s *JiraService) epicComponent(epic *models.Epic) string {
+	for _, key := range []string{epic.Title, epic.Component} {
+		if key == "" {
+			continue
+		}
+		for from, name := range s.config.ComponentMapping {
+			if strings.EqualFold(from, key) {
+				return s.componentName(name)
+			}
+		}
+	}
+
+	if epic.Component == "" || s.components == nil {
+		return ""
+	}
+	return s.components[strings.ToLower(epic.Component)]
+}
+
+// componentName returns the project's spelling of a component, once components are resolved
+func (s *JiraService) componentName(name string) string {
+	if spelled, ok := s.components[strings.ToLower(name)]; ok {
+		return spelled
+	}
+	return name
+}
+
+// issueComponents returns the components of a new issue: the configured components and those
+// of its item
+func (s *JiraService) issueComponents(components []string) []models.JiraComponent {
+	var issueComponents []models.JiraComponent
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string(nil), s.config.Components...), components...) {
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		issueComponents = append(issueComponents, models.JiraComponent{Name: s.componentName(name)})
+	}
+	return issueComponents
+}
+
+// issueLabels returns the labels of a new issue: the configured labels, with {run_id} replaced by
+// the ID of the run creating it, followed by the labels of its item
+func (s *JiraService) issueLabels(labels []string) []string {
+	runID := ""
+	if s.ledger != nil {
+		runID = s.ledger.Ledger().RunID
+	}
+	return mergeLabels(runLabels(s.config.Labels, runID), labels)
+}
+
+// runLabels replaces {run_id} in configured labels with the ID of a run. Labels using it are left
+// out when there is no run, as in an export.
+func runLabels(labels []string, runID string) []string {
+	var expanded []string
+	for _, label := range labels {
+		if strings.Contains(label, config.RunIDPlaceholder) {
+			if runID == "" {
+				continue
+			}
+			label = strings.ReplaceAll(label, config.RunIDPlaceholder, runID)
+		}
+		expanded = append(expanded, label)
+	}
+	return expanded
+}
+
+// mergeLabels joins lists of labels, dropping duplicates. JIRA labels cannot contain spaces, so
+// spaces in suggested labels become hyphens.
+func mergeLabels(lists ...[]string) []string {
+	var labels []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, label := range list {
+			label = strings.Join(strings.Fields(label), "-")
+			if label == "" || seen[label] {
+				continue
+			}
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+var (
+	// confluencePageID matches the page ID in the URL of a page: /pages/<id> on Confluence Cloud
+	// and viewpage.action?pageId=<id> on Data Center
+	confluencePageID = regexp.MustCompile(`(?:/pages/|[?&]pageId=)(\d+)`)
+
+	// confluenceDisplayPath matches the /display/<space>/<title> URL of a page on Data Center
+	confluenceDisplayPath = regexp.MustCompile(`/display/([^/?#]+)/([^/?#]+)`)
+)
+
+// ConfluenceService publishes breakdown summaries as Confluence pages and reads pages as input
+// documents
+type ConfluenceService struct {
+	config      *config.ConfluenceConfig
+	repo        *repositories.ConfluenceRepository
+	jiraService *JiraService
+}
+
+// NewConfluenceService creates a new Confluence service. Empty credentials are taken from the
+// JIRA configuration.
+func NewConfluenceService(cfg *config.Config) *ConfluenceService {
+	confluence := cfg.Confluence
+	if confluence.Username == "" && confluence.APIToken == "" {
+		confluence.Username = cfg.Jira.Username
+	}
+	if confluence.APIToken == "" {
+		confluence.APIToken = cfg.Jira.APIToken
+	}
+
+	return &ConfluenceService{
+		config:      &confluence,
+		repo:        repositories.NewConfluenceRepository(&confluence, &cfg.HTTP),
+		jiraService: NewJiraService(&cfg.Jira, &cfg.HTTP),
+	}
+}
+
+// PageTitle returns the title of the page a breakdown is published as
+func PageTitle(breakdown *models.ProjectBreakdown) string {
+	return fmt.Sprintf("%s - Project Breakdown", breakdown.ProjectName)
+}
+
+// Publish publishes the summary of a breakdown as a page of the configured space. The page is
+// found by its title, so publishing the breakdown again creates a new version of the same page.
+func (s *ConfluenceService) Publish(ctx context.Context, breakdown *models.ProjectBreakdown) (*models.ConfluencePublication, error) {
+	if s.config.SpaceKey == "" {
+		return nil, fmt.Errorf("confluence.space_key is required to publish to Confluence")
+	}
+	if err := s.checkConnection(); err != nil {
+		return nil, err
+	}
+
+	title := PageTitle(breakdown)
+	body := helpers.MarkdownToStorage(SummaryMarkdown(breakdown))
+
+	existing, err := s.repo.FindPage(ctx, s.config.SpaceKey, title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up page '%s': %w", title, err)
+	}
+
+	var page *models.ConfluencePage
+	if existing != nil {
+		page, err = s.repo.UpdatePage(ctx, existing, title, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update page '%s': %w", title, err)
+		}
+	} else {
+		page, err = s.repo.CreatePage(ctx, s.config.SpaceKey, s.config.ParentID, title, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create page '%s': %w", title, err)
+		}
+	}
+
+	return &models.ConfluencePublication{Page: *page, URL: s.repo.PageURL(page), Created: existing == nil}, nil
+}
+
+// LinkEpics adds a link to a published page to the JIRA issue of every epic of a breakdown that
+// has one. A failed link only warns. It returns the number of linked epics.
+func (s *ConfluenceService) LinkEpics(ctx context.Context, breakdown *models.ProjectBreakdown, publication *models.ConfluencePublication) int {
+	linked := 0
+	for _, epic := range breakdown.Epics {
+		if epic.Key == "" {
+			continue
+		}
+		if err := s.jiraService.repo.AddRemoteLink(ctx, epic.Key, publication.URL, "Confluence: "+publication.Page.Title); err != nil {
+			helpers.PrintWarning("Failed to link %s to the Confluence page: %v", epic.Key, err)
+			continue
+		}
+		linked++
+	}
+	return linked
+}
+
+// FetchPage reads a page, given by ID or URL, and saves it as markdown in the output directory for
+// analysis. It returns the path of the markdown file and the web address of the page.
+func (s *ConfluenceService) FetchPage(ctx context.Context, ref, outputDir string) (string, string, error) {
+	if err := s.checkConnection(); err != nil {
+		return "", "", err
+	}
+
+	page, err := s.findPage(ctx, strings.TrimSpace(ref))
+	if err != nil {
+		return "", "", err
+	}
+
+	content, err := helpers.StorageToMarkdown(page.Body.Storage.Value)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to convert page '%s': %w", page.Title, err)
+	}
+	// The page title names the document, which the content of a page does not repeat
+	content = fmt.Sprintf("# %s\n\n%s", page.Title, content)
+
+	saved, err := saveInput(outputDir, "md", content)
+	if err != nil {
+		return "", "", err
+	}
+
+	helpers.PrintSuccess("Fetched Confluence page '%s' (version %d) to: %s", page.Title, page.Version.Number, saved)
+	return saved, s.repo.PageURL(page), nil
+}
+
+// findPage returns the page a page ID or URL refers to, with its content
+func (s *ConfluenceService) findPage(ctx context.Context, ref string) (*models.ConfluencePage, error) {
+	pageID := ""
+	if _, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		pageID = ref
+	} else if match := confluencePageID.FindStringSubmatch(ref); match != nil {
+		pageID = match[1]
+	}
+
+	if pageID != "" {
+		page, err := s.repo.GetPage(ctx, pageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Confluence page %s: %w", pageID, err)
+		}
+		return page, nil
+	}
+
+	match := confluenceDisplayPath.FindStringSubmatch(ref)
+	if match == nil {
+		return nil, fmt.Errorf("'%s' is not a Confluence page ID or page URL", ref)
+	}
+	space, _ := url.PathUnescape(match[1])
+	title, _ := url.QueryUnescape(match[2])
+	page, err := s.repo.FindPage(ctx, space, title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up page '%s': %w", title, err)
+	}
+	if page == nil {
+		return nil, fmt.Errorf("space %s has no page '%s'", space, title)
+	}
+	return page, nil
+}
+
+// checkConnection checks that the Confluence site and credentials are configured
+func (s *ConfluenceService) checkConnection() error {
+	if s.config.BaseURL == "" {
+		return fmt.Errorf("confluence.base_url is required to use Confluence")
+	}
+	if s.config.APIToken == "" {
+		return fmt.Errorf("confluence API token is required (confluence.api_token or jira.api_token, or 'scrum-master auth login confluence.api_token')")
+	}
+	return nil
+}
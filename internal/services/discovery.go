@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+)
+
+// customFieldNames maps jira.custom_fields entries to the field names JIRA uses for them
+var customFieldNames = map[string][]string{
+	config.CustomFieldEpicName: {"epic name"},
+	config.CustomFieldEpicLink: {"epic link"},
+	"sprint":                   {"sprint"},
+	config.CustomFieldTeam:     {"team"},
+}
+
+// storyPointsFieldNames are the names JIRA Server and Cloud use for the story points field
+var storyPointsFieldNames = []string{"story points", "story point estimate"}
+
+// standardFieldIDs are fields scrum-master fills itself, so they are never reported as missing
+var standardFieldIDs = map[string]bool{
+	"project":     true,
+	"summary":     true,
+	"issuetype":   true,
+	"description": true,
+	"reporter":    true,
+	"parent":      true,
+	"labels":      true,
+	"priority":    true,
+}
+
+// DiscoverFields reads the project's issue types and the instance's fields and suggests the
+// issue types, story points field and custom fields to configure
+func (s *JiraService) DiscoverFields(ctx context.Context) (*models.JiraFieldMapping, error) {
+	fields, err := s.repo.GetFields(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fields: %w", err)
+	}
+
+	issueTypes, err := s.repo.GetCreateMeta(ctx, s.config.ProjectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read create metadata for project '%s': %w", s.config.ProjectKey, err)
+	}
+
+	mapping := &models.JiraFieldMapping{
+		ProjectKey:     s.config.ProjectKey,
+		CustomFields:   make(map[string]models.JiraFieldInfo),
+		RequiredFields: make(map[string][]models.JiraCreateMetaField),
+	}
+
+	mapping.AvailableTypes = availableIssueTypes(issueTypes)
+	epicType, storyType := detectIssueTypes(issueTypes)
+	if epicType != nil {
+		mapping.EpicType = epicType.Name
+	}
+	if storyType != nil {
+		mapping.StoryType = storyType.Name
+	}
+	mapping.SubtaskType, _ = detectSubtaskType(issueTypes)
+
+	// Prefer the story points field that is actually on the story create screen
+	onStoryScreen := make(map[string]bool)
+	if storyType != nil {
+		for _, field := range storyType.Fields {
+			onStoryScreen[field.FieldID] = true
+		}
+	}
+
+	for _, field := range fields {
+		if !field.Custom {
+			continue
+		}
+		name := strings.ToLower(field.Name)
+
+		for _, candidate := range storyPointsFieldNames {
+			if name == candidate && (mapping.StoryPointsField == "" || onStoryScreen[field.ID]) {
+				mapping.StoryPointsField = field.ID
+			}
+		}
+
+		for key, names := range customFieldNames {
+			for _, candidate := range names {
+				if name == candidate {
+					if _, exists := mapping.CustomFields[key]; !exists {
+						mapping.CustomFields[key] = field
+					}
+				}
+			}
+		}
+	}
+
+	// Report required fields scrum-master would not fill
+	mapped := map[string]bool{mapping.StoryPointsField: true}
+	for _, field := range mapping.CustomFields {
+		mapped[field.ID] = true
+	}
+
+	for _, issueType := range []*models.JiraCreateMetaIssueType{epicType, storyType} {
+		if issueType == nil {
+			continue
+		}
+		for _, field := range issueType.Fields {
+			if field.Required && !standardFieldIDs[field.FieldID] && !mapped[field.FieldID] {
+				mapping.RequiredFields[issueType.Name] = append(mapping.RequiredFields[issueType.Name], field)
+			}
+		}
+	}
+
+	return mapping, nil
+}
+
+// RenderFieldMapping renders a discovered field mapping as a jira config section ready to paste
+// into config.yaml
+func RenderFieldMapping(mapping *models.JiraFieldMapping) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("# Field mapping discovered for project %s\n", mapping.ProjectKey))
+	out.WriteString("jira:\n")
+
+	out.WriteString(fmt.Sprintf("  issue_type_mapping:           # available: %s\n", strings.Join(mapping.AvailableTypes, ", ")))
+	writeYAMLValue(&out, "    epic", mapping.EpicType, "no epic issue type found")
+	writeYAMLValue(&out, "    story", mapping.StoryType, "no Story or Task issue type found")
+	writeYAMLValue(&out, "    subtask", mapping.SubtaskType, "no subtask issue type found")
+
+	out.WriteString("  field_mapping:\n")
+	writeYAMLValue(&out, "    story_points", mapping.StoryPointsField, "no story points field found")
+
+	out.WriteString("  custom_fields:\n")
+	keys := make([]string, 0, len(customFieldNames))
+	for key := range customFieldNames {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if field, exists := mapping.CustomFields[key]; exists {
+			out.WriteString(fmt.Sprintf("    %s: %q  # %s\n", key, field.ID, field.Name))
+		}
+	}
+
+	if len(mapping.RequiredFields) > 0 {
+		out.WriteString("\n# Required fields scrum-master does not fill; issue creation fails until they get defaults in JIRA:\n")
+
+		issueTypes := make([]string, 0, len(mapping.RequiredFields))
+		for issueType := range mapping.RequiredFields {
+			issueTypes = append(issueTypes, issueType)
+		}
+		sort.Strings(issueTypes)
+
+		for _, issueType := range issueTypes {
+			for _, field := range mapping.RequiredFields[issueType] {
+				out.WriteString(fmt.Sprintf("#   %s: %s (%s)\n", issueType, field.FieldID, field.Name))
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// writeYAMLValue writes a quoted YAML value, or a commented-out placeholder when it is unknown
+func writeYAMLValue(out *strings.Builder, key, value, missing string) {
+	if value == "" {
+		out.WriteString(fmt.Sprintf("#%s: \"\"  # %s\n", key[1:], missing))
+		return
+	}
+	out.WriteString(fmt.Sprintf("%s: %q\n", key, value))
+}
+
+// detectIssueTypes picks the issue types for epics and stories: Epic, or a type whose name
+// contains "epic", and Story, or Task when there is no Story. Either is nil when none matches.
+func detectIssueTypes(issueTypes []models.JiraCreateMetaIssueType) (epicType, storyType *models.JiraCreateMetaIssueType) {
+	for i := range issueTypes {
+		issueType := &issueTypes[i]
+		if issueType.Subtask {
+			continue
+		}
+
+		name := strings.ToLower(issueType.Name)
+		switch {
+		case name == "epic" || (epicType == nil && strings.Contains(name, "epic")):
+			epicType = issueType
+		case name == "story":
+			storyType = issueType
+		case name == "task" && (storyType == nil || strings.ToLower(storyType.Name) != "story"):
+			storyType = issueType
+		}
+	}
+	return epicType, storyType
+}
+
+// detectSubtaskType finds the issue type of subtasks: Sub-task, or else the first subtask type. It
+// also lists the names of all subtask types.
+func detectSubtaskType(issueTypes []models.JiraCreateMetaIssueType) (string, []string) {
+	detected := ""
+	var available []string
+	for _, issueType := range issueTypes {
+		if !issueType.Subtask {
+			continue
+		}
+		available = append(available, issueType.Name)
+
+		name := strings.ToLower(issueType.Name)
+		if name == "sub-task" || name == "subtask" || detected == "" {
+			detected = issueType.Name
+		}
+	}
+	return detected, available
+}
+
+// availableIssueTypes lists the names of the issue types that are not subtasks
+func availableIssueTypes(issueTypes []models.JiraCreateMetaIssueType) []string {
+	var names []string
+	for _, issueType := range issueTypes {
+		if !issueType.Subtask {
+			names = append(names, issueType.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/providers"
+)
+
+// Names of the checks of 'scrum-master doctor'
+const (
+	CheckConfig      = "Configuration"
+	CheckAI          = "AI provider"
+	CheckJiraAuth    = "JIRA authentication"
+	CheckProject     = "Project access"
+	CheckIssueTypes  = "Issue types"
+	CheckEpicLinking = "Epic linking"
+	CheckRequired    = "Required fields"
+	CheckPermission  = "Create permission"
+)
+
+// passedCheck, failedCheck and skippedCheck create the outcome of a diagnostic check
+func passedCheck(name, detail string, args ...interface{}) models.DiagnosticCheck {
+	return models.DiagnosticCheck{Name: name, Status: models.CheckPassed, Detail: fmt.Sprintf(detail, args...)}
+}
+
+func failedCheck(name string, err error) models.DiagnosticCheck {
+	return models.DiagnosticCheck{Name: name, Status: models.CheckFailed, Detail: err.Error()}
+}
+
+func skippedCheck(name, reason string) models.DiagnosticCheck {
+	return models.DiagnosticCheck{Name: name, Status: models.CheckSkipped, Detail: reason}
+}
+
+// DiagnoseAI checks that the configured AI provider accepts its credentials. Providers that
+// cannot be checked without sending a prompt are skipped.
+func DiagnoseAI(ctx context.Context, cfg *config.Config) models.DiagnosticCheck {
+	provider, err := providers.New(cfg)
+	if err != nil {
+		return failedCheck(CheckAI, err)
+	}
+	tester, ok := provider.(providers.ConnectionTester)
+	if !ok {
+		return skippedCheck(CheckAI, fmt.Sprintf("%s cannot be checked without sending a prompt", provider.Name()))
+	}
+	if err := tester.TestConnection(ctx); err != nil {
+		return failedCheck(CheckAI, err)
+	}
+	return passedCheck(CheckAI, "%s accepts the API key for model %s", provider.Name(), provider.Model())
+}
+
+// Diagnose checks what creating issues needs: that JIRA accepts the credentials, the project is
+// accessible, its issue types match jira.issue_type_mapping, stories can be linked to epics, it
+// requires no fields scrum-master leaves empty, and the user may create issues in it. Checks
+// that depend on a failed one are skipped.
+func (s *JiraService) Diagnose(ctx context.Context) []models.DiagnosticCheck {
+	projects, err := s.repo.TestConnection(ctx)
+	if err != nil {
+		return append([]models.DiagnosticCheck{failedCheck(CheckJiraAuth, err)},
+			skipRest("needs JIRA authentication", CheckProject, CheckIssueTypes, CheckEpicLinking, CheckRequired, CheckPermission)...)
+	}
+	checks := []models.DiagnosticCheck{passedCheck(CheckJiraAuth, "%s as %s, %d projects accessible", s.config.BaseURL, s.user(), len(projects))}
+
+	project, err := s.repo.GetProjectInfo(ctx, s.config.ProjectKey)
+	if err != nil {
+		return append(append(checks, failedCheck(CheckProject, err)),
+			skipRest("needs project access", CheckIssueTypes, CheckEpicLinking, CheckRequired, CheckPermission)...)
+	}
+	checks = append(checks, passedCheck(CheckProject, "%s (%s)", project.Key, project.Name))
+
+	if _, err := s.getCreateMeta(ctx); err != nil {
+		checks = append(checks, failedCheck(CheckIssueTypes, fmt.Errorf("failed to read the create metadata: %w", err)))
+		checks = append(checks, skipRest("needs the create metadata", CheckEpicLinking, CheckRequired)...)
+	} else if err := s.ResolveIssueTypes(ctx); err != nil {
+		checks = append(checks, failedCheck(CheckIssueTypes, err))
+		checks = append(checks, skipRest("needs the issue types", CheckEpicLinking, CheckRequired)...)
+	} else {
+		checks = append(checks, passedCheck(CheckIssueTypes, "epics as %s, stories as %s", s.issueTypes.EpicType(), s.issueTypes.StoryType()))
+		checks = append(checks, s.diagnoseEpicLinking(ctx), s.diagnoseRequiredFields())
+	}
+
+	allowed, err := s.repo.HasPermission(ctx, s.config.ProjectKey, "CREATE_ISSUES")
+	switch {
+	case err != nil:
+		checks = append(checks, failedCheck(CheckPermission, err))
+	case !allowed:
+		checks = append(checks, failedCheck(CheckPermission, fmt.Errorf("%s may not create issues in project %s", s.user(), s.config.ProjectKey)))
+	default:
+		checks = append(checks, passedCheck(CheckPermission, "%s may create issues in project %s", s.user(), s.config.ProjectKey))
+	}
+	return checks
+}
+
+// diagnoseEpicLinking checks that stories can be linked to their epics
+func (s *JiraService) diagnoseEpicLinking(ctx context.Context) models.DiagnosticCheck {
+	if err := s.ResolveEpicLinking(ctx); err != nil {
+		return failedCheck(CheckEpicLinking, err)
+	}
+	if s.epicLinkField != "" {
+		return passedCheck(CheckEpicLinking, "with the Epic Link field %s", s.epicLinkField)
+	}
+	return passedCheck(CheckEpicLinking, "with the epic as parent")
+}
+
+// diagnoseRequiredFields checks that epics and stories require no fields that created issues
+// leave empty
+func (s *JiraService) diagnoseRequiredFields() models.DiagnosticCheck {
+	filled := s.filledFields()
+	var missing []string
+	for _, issueType := range s.createMeta {
+		if issueType.Name != s.issueTypes.EpicType() && issueType.Name != s.issueTypes.StoryType() {
+			continue
+		}
+		for _, field := range issueType.Fields {
+			if field.Required && !filled[field.FieldID] {
+				missing = append(missing, fmt.Sprintf("%s needs %s (%s)", issueType.Name, field.Name, field.FieldID))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return failedCheck(CheckRequired, fmt.Errorf("%s; give them defaults in JIRA",
+			strings.Join(missing, ", ")))
+	}
+	return passedCheck(CheckRequired, "every required field of %s and %s is filled", s.issueTypes.EpicType(), s.issueTypes.StoryType())
+}
+
+// filledFields returns the IDs of the fields created issues are given with the configuration
+func (s *JiraService) filledFields() map[string]bool {
+	filled := make(map[string]bool)
+	for id := range standardFieldIDs {
+		filled[id] = true
+	}
+	for _, id := range s.config.FieldMapping {
+		filled[id] = true
+	}
+	for _, id := range s.config.CustomFields {
+		filled[id] = true
+	}
+	for _, id := range []string{s.epicNameField, s.epicLinkField} {
+		if id != "" {
+			filled[id] = true
+		}
+	}
+	if len(s.config.Components) > 0 || len(s.config.ComponentMapping) > 0 {
+		filled["components"] = true
+	}
+	if len(s.config.Releases) > 0 {
+		filled["fixVersions"] = true
+	}
+	if s.config.HoursPerPoint > 0 {
+		filled["timetracking"] = true
+	}
+	return filled
+}
+
+// user returns the name the JIRA API is used as
+func (s *JiraService) user() string {
+	if s.config.AuthType == config.JiraAuthPAT {
+		return "the personal access token"
+	}
+	return s.config.Username
+}
+
+// skipRest skips checks that depend on a failed one
+func skipRest(reason string, names ...string) []models.DiagnosticCheck {
+	checks := make([]models.DiagnosticCheck, len(names))
+	for i, name := range names {
+		checks[i] = skippedCheck(name, reason)
+	}
+	return checks
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// Formats a breakdown can be edited in
+const (
+	EditFormatYAML = "yaml"
+	EditFormatJSON = "json"
+)
+
+// defaultEditor is used when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// ErrEditAborted is returned when the user gives up on an edit that does not validate
+var ErrEditAborted = errors.New("edit aborted")
+
+// EditBreakdown writes a breakdown to a temporary file, opens it in the user's editor and returns
+// the saved version once it parses and validates. When it does not, retry is asked whether to
+// open the editor again with the changes kept; otherwise ErrEditAborted is returned. Totals are
+// recalculated and new items numbered, so they can be left alone while editing.
+func EditBreakdown(breakdown *models.ProjectBreakdown, format string, retry func(err error) bool) (*models.ProjectBreakdown, error) {
+	if format != EditFormatYAML && format != EditFormatJSON {
+		return nil, fmt.Errorf("invalid edit format '%s' (must be yaml or json)", format)
+	}
+
+	original, err := encodeBreakdown(breakdown, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode breakdown: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "scrum-master-breakdown-*."+format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	for {
+		if err := runEditor(path); err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read edited breakdown: %w", err)
+		}
+		if bytes.Equal(data, original) {
+			helpers.PrintInfo("Breakdown was not changed")
+			return breakdown, nil
+		}
+
+		edited, err := decodeBreakdown(data, format)
+		if err == nil {
+			err = ValidateBreakdown(edited)
+		}
+		if err == nil {
+			// Dependencies are only resolved to story IDs once every story has one
+			AssignItemIDs(edited)
+			err = dependencyCycleError(edited)
+		}
+		if err == nil {
+			recalculateTotals(edited)
+			return edited, nil
+		}
+
+		if !retry(err) {
+			return nil, ErrEditAborted
+		}
+	}
+}
+
+// ValidateBreakdown checks that a breakdown can be created in JIRA: every epic and story has a
+// title, priorities are empty or High, Medium or Low, story points are not negative and IDs are
+// unique. Priorities are normalized to their canonical spelling.
+func ValidateBreakdown(breakdown *models.ProjectBreakdown) error {
+	var problems []string
+	if len(breakdown.Epics) == 0 {
+		problems = append(problems, "the breakdown has no epics")
+	}
+
+	ids := make(map[string]bool)
+	checkID := func(id string) {
+		if id == "" {
+			return
+		}
+		if ids[id] {
+			problems = append(problems, fmt.Sprintf("ID %s is used more than once", id))
+		}
+		ids[id] = true
+	}
+
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		name := fmt.Sprintf("epic %d", i+1)
+		if strings.TrimSpace(epic.Title) == "" {
+			problems = append(problems, name+" has no title")
+		} else {
+			name = fmt.Sprintf("epic '%s'", epic.Title)
+		}
+		if priority := normalizePriority(epic.Priority); priority != "" {
+			epic.Priority = priority
+		} else if epic.Priority != "" {
+			problems = append(problems, fmt.Sprintf("%s has priority '%s' (must be High, Medium or Low)", name, epic.Priority))
+		}
+		checkID(epic.ID)
+
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+			storyName := fmt.Sprintf("story %d of %s", j+1, name)
+			if strings.TrimSpace(story.Title) == "" {
+				problems = append(problems, storyName+" has no title")
+			} else {
+				storyName = fmt.Sprintf("story '%s'", story.Title)
+			}
+			if priority := normalizePriority(story.Priority); priority != "" {
+				story.Priority = priority
+			} else if story.Priority != "" {
+				problems = append(problems, fmt.Sprintf("%s has priority '%s' (must be High, Medium or Low)", storyName, story.Priority))
+			}
+			if story.StoryPoints < 0 {
+				problems = append(problems, storyName+" has negative story points")
+			}
+			checkID(story.ID)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid breakdown:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// encodeBreakdown encodes a breakdown for editing
+func encodeBreakdown(breakdown *models.ProjectBreakdown, format string) ([]byte, error) {
+	if format == EditFormatJSON {
+		return json.MarshalIndent(breakdown, "", "  ")
+	}
+	return yaml.Marshal(breakdown)
+}
+
+// decodeBreakdown decodes an edited breakdown, rejecting unknown fields so typos are not
+// silently dropped
+func decodeBreakdown(data []byte, format string) (*models.ProjectBreakdown, error) {
+	var breakdown models.ProjectBreakdown
+	if format == EditFormatJSON {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&breakdown); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return &breakdown, nil
+	}
+
+	if err := yaml.UnmarshalStrict(data, &breakdown); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	return &breakdown, nil
+}
+
+// runEditor opens a file in $VISUAL or $EDITOR, which may include arguments such as
+// "code --wait", and waits for it to exit
+func runEditor(path string) error {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", args[0], err)
+	}
+	return nil
+}
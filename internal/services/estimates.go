@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// ResolveTimeTracking checks, when jira.hours_per_point is set, that stories can be created with
+// an original estimate, so a project without time tracking fails the run before anything is
+// created. When the project's create metadata cannot be read, estimates are set anyway.
+func (s *JiraService) ResolveTimeTracking(ctx context.Context) error {
+	if s.config.HoursPerPoint == 0 {
+		return nil
+	}
+
+	issueTypes, err := s.getCreateMeta(ctx)
+	if err != nil {
+		helpers.PrintWarning("Failed to read the create screen of project %s, setting original estimates anyway: %v", s.config.ProjectKey, err)
+		return nil
+	}
+
+	storyType := s.issueTypes.StoryType()
+	for _, issueType := range issueTypes {
+		if issueType.Name != storyType {
+			continue
+		}
+		for _, field := range issueType.Fields {
+			if field.FieldID == "timetracking" {
+				helpers.PrintInfo("Stories get an original estimate of %s per story point", originalEstimate(s.config.HoursPerPoint))
+				return nil
+			}
+		}
+		return fmt.Errorf("jira.hours_per_point is set, but %s issues of project %s have no time tracking field; enable time tracking or unset it",
+			storyType, s.config.ProjectKey)
+	}
+
+	// The story type was not found in the metadata; creation reports that
+	return nil
+}
+
+// setEstimate sets the original estimate of a story from its story points
+func (s *JiraService) setEstimate(custom map[string]interface{}, points int) {
+	if s.config.HoursPerPoint == 0 || points <= 0 {
+		return
+	}
+	custom["timetracking"] = models.JiraTimeTracking{OriginalEstimate: originalEstimate(float64(points) * s.config.HoursPerPoint)}
+}
+
+// originalEstimate formats hours as a JIRA duration in hours and minutes, such as "2h 30m".
+// Days and weeks are avoided, as their length depends on the instance's working hours.
+func originalEstimate(hours float64) string {
+	minutes := int(math.Round(hours * 60))
+	switch {
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+	}
+}
+
+// estimateSeconds returns the original estimate of a story in seconds, as the CSV importer takes
+// it, or "" without one
+func estimateSeconds(points int, hoursPerPoint float64) string {
+	if hoursPerPoint == 0 || points <= 0 {
+		return ""
+	}
+	return fmt.Sprint(int(math.Round(float64(points) * hoursPerPoint * 60 * 60)))
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// EventHandler receives pipeline events. Handlers run synchronously on the goroutine that
+// published the event, which for chunk events is one of several workers, so they must be safe
+// for concurrent use and should return quickly.
+type EventHandler func(event models.Event)
+
+// EventBus delivers the events of the analysis and creation pipeline to every subscribed
+// handler, in subscription order. Progress output, the run ledger and command results are all
+// subscribers, so new integrations can be added without touching the pipeline.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers []EventHandler
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe adds a handler for every event published from now on
+func (b *EventBus) Subscribe(handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish delivers an event to all handlers
+func (b *EventBus) Publish(event models.Event) {
+	b.mu.RLock()
+	handlers := append([]EventHandler(nil), b.handlers...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// newConsoleEventBus creates an event bus that prints progress to the terminal
+func newConsoleEventBus() *EventBus {
+	bus := NewEventBus()
+	bus.Subscribe(printEvent)
+	return bus
+}
+
+// printEvent prints the progress an event reports
+func printEvent(event models.Event) {
+	switch e := event.(type) {
+	case models.ChunkAnalyzed:
+		helpers.PrintInfo("Chunk %d/%d analyzed: %d epics, %d stories", e.Chunk, e.TotalChunks, e.Epics, e.Stories)
+	case models.EpicMerged:
+		helpers.PrintInfo("Merged epic '%s' from %d chunks (%d stories)", e.Title, e.Chunks, e.Stories)
+	case models.IssueCreated:
+		helpers.PrintSuccess("Created %s: %s", e.ItemType, e.Key)
+	case models.IssueFailed:
+		helpers.PrintWarning("Failed to create %s '%s': %v", e.ItemType, e.Title, e.Err)
+	}
+}
+
+// ledgerRecorder records created issues in a run ledger
+func ledgerRecorder(ledger *repositories.LedgerRepository) EventHandler {
+	return func(event models.Event) {
+		created, ok := event.(models.IssueCreated)
+		if !ok {
+			return
+		}
+
+		err := ledger.Record(models.LedgerEntry{
+			ItemID:    created.ItemID,
+			ItemType:  created.ItemType,
+			Epic:      created.Epic,
+			Title:     created.Title,
+			Key:       created.Key,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			helpers.PrintWarning("Failed to record %s in run ledger: %v", created.Key, err)
+		}
+	}
+}
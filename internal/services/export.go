@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// Export formats
+const (
+	ExportJiraCSV = "jira-csv"
+	ExportHTML    = "html"
+	ExportMermaid = "mermaid"
+	ExportDOT     = "dot"
+)
+
+// ExportService converts breakdowns into formats other tools can import
+type ExportService struct {
+	config *config.Config
+	jira   *JiraService
+}
+
+// NewExportService creates a new export service
+func NewExportService(config *config.Config) *ExportService {
+	return &ExportService{
+		config: config,
+		jira:   NewJiraService(&config.Jira, &config.HTTP),
+	}
+}
+
+// Export renders a breakdown in the given format
+func (s *ExportService) Export(breakdown *models.ProjectBreakdown, format string) ([]byte, error) {
+	switch format {
+	case ExportJiraCSV:
+		return s.jiraCSV(breakdown)
+	case ExportHTML:
+		return s.htmlReport(breakdown)
+	case ExportMermaid, ExportDOT:
+		graph := DependencyMermaid(breakdown)
+		if format == ExportDOT {
+			graph = DependencyDOT(breakdown)
+		}
+		if graph == "" {
+			return nil, fmt.Errorf("the analysis has no story dependencies to export")
+		}
+		return []byte(graph), nil
+	default:
+		return nil, fmt.Errorf("unknown export format '%s' (must be %s, %s, %s or %s)", format, ExportJiraCSV, ExportHTML, ExportMermaid, ExportDOT)
+	}
+}
+
+// ExportFilename returns a timestamped file name for an export in the given format
+func ExportFilename(format string) string {
+	switch format {
+	case ExportHTML:
+		return helpers.GenerateOutputFilename("project-desc-report", "html")
+	case ExportMermaid:
+		return helpers.GenerateOutputFilename("project-desc-dependencies", "mmd")
+	case ExportDOT:
+		return helpers.GenerateOutputFilename("project-desc-dependencies", "dot")
+	}
+	return helpers.GenerateOutputFilename("project-desc-jira-import", "csv")
+}
+
+// jiraCSV renders a breakdown for JIRA's external CSV importer. Stories reference their epic
+// both by Parent Id, for team-managed and newer company-managed projects, and by Epic Link to
+// the epic's Epic Name, for older ones. Subtasks reference their story by Parent Id. Stories name
+// their release as fix version, which the importer creates when missing, and with
+// jira.hours_per_point an original estimate in seconds. Descriptions,
+// labels and components match those of issues created via the API, except for labels using
+// {run_id}, as an import is not a run.
+func (s *ExportService) jiraCSV(breakdown *models.ProjectBreakdown) ([]byte, error) {
+	var rows []csvRow
+
+	id := 0
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		components := s.csvComponents(epic)
+
+		id++
+		epicID := strconv.Itoa(id)
+		rows = append(rows, csvRow{
+			fields:     []string{epicID, "", s.config.Jira.IssueTypeMapping.EpicType(), epic.Title, helpers.MarkdownToWiki(s.jira.epicDescription(epic)), epic.Priority, "", "", epic.Title, "", ""},
+			labels:     s.csvLabels(epic.Title, "", append(s.jira.idLabels(breakdown, epic.ID), epic.Labels...)),
+			components: components,
+		})
+
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+
+			points := ""
+			if story.StoryPoints > 0 {
+				points = strconv.Itoa(story.StoryPoints)
+			}
+
+			id++
+			storyID := strconv.Itoa(id)
+			rows = append(rows, csvRow{
+				fields:     []string{storyID, epicID, storyIssueType(s.config.Jira.IssueTypeMapping, story), story.Title, helpers.MarkdownToWiki(s.jira.storyDescription(breakdown, story)), story.Priority, points, estimateSeconds(story.StoryPoints, s.config.Jira.HoursPerPoint), "", epic.Title, story.Release},
+				labels:     s.csvLabels(epic.Title, story.Title, append(s.jira.idLabels(breakdown, story.ID), story.Labels...)),
+				components: components,
+			})
+
+			for k := range story.Subtasks {
+				subtask := &story.Subtasks[k]
+
+				id++
+				rows = append(rows, csvRow{
+					fields:     []string{strconv.Itoa(id), storyID, s.config.Jira.IssueTypeMapping.SubtaskType(), subtask.Title, helpers.MarkdownToWiki(subtaskDescription(subtask)), story.Priority, "", "", "", "", ""},
+					labels:     s.csvLabels(epic.Title, story.Title, s.jira.idLabels(breakdown, subtask.ID)),
+					components: components,
+				})
+			}
+		}
+	}
+
+	// The importer takes one label or component per column, repeating the column header
+	labelColumns, componentColumns := 0, 0
+	for _, row := range rows {
+		labelColumns = max(labelColumns, len(row.labels))
+		componentColumns = max(componentColumns, len(row.components))
+	}
+
+	header := []string{"Issue Id", "Parent Id", "Issue Type", "Summary", "Description", "Priority", "Story Points", "Original Estimate", "Epic Name", "Epic Link", "Fix Version/s"}
+	header = append(header, repeatColumn("Labels", labelColumns)...)
+	header = append(header, repeatColumn("Component/s", componentColumns)...)
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	for _, row := range rows {
+		record := append(row.fields, padColumns(row.labels, labelColumns)...)
+		record = append(record, padColumns(row.components, componentColumns)...)
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// csvRow is an issue of the CSV export with its labels and components, which take a variable
+// number of columns
+type csvRow struct {
+	fields     []string
+	labels     []string
+	components []string
+}
+
+// csvLabels returns the labels of an exported issue: the configured labels, the traceability
+// label when trace labels are enabled and the labels of its item
+func (s *ExportService) csvLabels(epic, story string, labels []string) []string {
+	var trace []string
+	if s.config.Jira.TraceLabels {
+		trace = []string{TraceLabel(epic, story)}
+	}
+	return mergeLabels(runLabels(s.config.Jira.Labels, ""), trace, labels)
+}
+
+// csvComponents returns the components of an epic's exported issues
+func (s *ExportService) csvComponents(epic *models.Epic) []string {
+	var names []string
+	for _, component := range s.jira.issueComponents([]string{s.jira.epicComponent(epic)}) {
+		names = append(names, component.Name)
+	}
+	return names
+}
+
+// repeatColumn returns a column header repeated n times
+func repeatColumn(name string, n int) []string {
+	columns := make([]string, n)
+	for i := range columns {
+		columns[i] = name
+	}
+	return columns
+}
+
+// padColumns fills values up to n columns
+func padColumns(values []string, n int) []string {
+	return append(append([]string(nil), values...), make([]string, n-len(values))...)
+}
+
+// htmlReport renders a breakdown as a self-contained HTML page for sharing with people who do
+// not use JIRA. Created issues link to JIRA.
+func (s *ExportService) htmlReport(breakdown *models.ProjectBreakdown) ([]byte, error) {
+	baseURL := strings.TrimSuffix(s.config.Jira.BaseURL, "/")
+	funcs := template.FuncMap{
+		"issueURL": func(key string) string {
+			if baseURL == "" {
+				return ""
+			}
+			return baseURL + "/browse/" + key
+		},
+	}
+
+	tmpl, err := template.New("report").Funcs(funcs).Parse(htmlReportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Breakdown": breakdown,
+		"Generated": time.Now().Format("2006-01-02 15:04"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Breakdown.ProjectName}} - Project Breakdown</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+h1 { margin-bottom: 0.25rem; }
+.meta { color: #59636e; }
+.epic { border: 1px solid #d1d9e0; border-radius: 6px; padding: 1rem; margin: 1.5rem 0; }
+table { border-collapse: collapse; width: 100%; margin-top: 1rem; }
+th, td { text-align: left; vertical-align: top; padding: 0.4rem; border-top: 1px solid #d1d9e0; }
+.rationale { color: #59636e; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Breakdown.ProjectName}}</h1>
+<p class="meta">{{len .Breakdown.Epics}} epics, {{.Breakdown.TotalStories}} stories, {{.Breakdown.TotalStoryPoints}} story points. Generated {{.Generated}}.</p>
+<p>{{.Breakdown.Overview}}</p>
+{{range .Breakdown.Epics}}
+<section class="epic">
+<h2>{{.ID}}: {{.Title}}{{if .Key}} ({{with issueURL .Key}}<a href="{{.}}">{{end}}{{.Key}}{{if issueURL .Key}}</a>{{end}}){{end}}</h2>
+<p class="meta">Priority: {{or .Priority "-"}} | Confidence: {{.Confidence}}%{{if .Team}} | Team: {{.Team}}{{end}}</p>
+<p>{{.Description}}</p>
+{{if .Rationale}}<p class="rationale">{{.Rationale}}</p>{{end}}
+<table>
+<tr><th>Story</th><th>Points</th><th>Priority</th><th>Details</th></tr>
+{{range .Stories}}
+<tr>
+<td>{{.ID}}{{if .Key}}<br>{{with issueURL .Key}}<a href="{{.}}">{{end}}{{.Key}}{{if issueURL .Key}}</a>{{end}}{{end}}</td>
+<td>{{.StoryPoints}}</td>
+<td>{{or .Priority "-"}}</td>
+<td><strong>{{.Title}}</strong><br>{{.Description}}
+{{if .AcceptanceCriteria}}<ul>{{range .AcceptanceCriteria}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Dependencies}}<p>Depends on: {{range $i, $d := .Dependencies}}{{if $i}}, {{end}}{{$d}}{{end}}</p>{{end}}
+{{if .Rationale}}<p class="rationale">{{.Rationale}}</p>{{end}}</td>
+</tr>
+{{end}}
+</table>
+</section>
+{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// minPatternSamples is the number of estimate edits needed before a pattern is reported
+const minPatternSamples = 3
+
+// maxPromptExamples is the number of most recent ratings of each kind injected into prompts
+const maxPromptExamples = 5
+
+// FeedbackService learns from how humans edit the issues scrum-master created
+type FeedbackService struct {
+	repo *repositories.FeedbackRepository
+}
+
+// NewFeedbackService creates a new feedback service
+func NewFeedbackService(cfg *config.Config) *FeedbackService {
+	return &FeedbackService{
+		repo: repositories.NewFeedbackRepository(cfg.Processing.OutputDir),
+	}
+}
+
+// TrackCreatedIssues records every epic and story of a breakdown that has a JIRA key
+func (s *FeedbackService) TrackCreatedIssues(breakdown *models.ProjectBreakdown) error {
+	var issues []models.TrackedIssue
+	now := time.Now()
+
+	for _, epic := range breakdown.Epics {
+		if epic.Key != "" {
+			issues = append(issues, models.TrackedIssue{
+				Key:       epic.Key,
+				IssueType: "epic",
+				Epic:      epic.Title,
+				Summary:   epic.Title,
+				CreatedAt: now,
+			})
+		}
+
+		for _, story := range epic.Stories {
+			if story.Key == "" {
+				continue
+			}
+			issues = append(issues, models.TrackedIssue{
+				Key:         story.Key,
+				IssueType:   "story",
+				Epic:        epic.Title,
+				Summary:     story.Title,
+				StoryPoints: story.StoryPoints,
+				CreatedAt:   now,
+			})
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	return s.repo.TrackIssues(issues)
+}
+
+// RecordWebhookEvent stores the field changes of a webhook event if the issue is tracked.
+// It returns the number of edits recorded.
+func (s *FeedbackService) RecordWebhookEvent(event *models.JiraWebhookEvent) (int, error) {
+	if event.WebhookEvent != "jira:issue_updated" || len(event.Changelog.Items) == 0 {
+		return 0, nil
+	}
+
+	tracked, err := s.repo.GetTrackedIssues()
+	if err != nil {
+		return 0, err
+	}
+
+	issue, exists := tracked[event.Issue.Key]
+	if !exists {
+		return 0, nil
+	}
+
+	editedAt := time.Now()
+	if event.Timestamp > 0 {
+		editedAt = time.UnixMilli(event.Timestamp)
+	}
+
+	var edits []models.IssueEdit
+	for _, item := range event.Changelog.Items {
+		edits = append(edits, models.IssueEdit{
+			IssueKey:  issue.Key,
+			IssueType: issue.IssueType,
+			Epic:      issue.Epic,
+			Field:     item.Field,
+			From:      item.FromString,
+			To:        item.ToString,
+			Author:    event.User.DisplayName,
+			EditedAt:  editedAt,
+		})
+	}
+
+	if err := s.repo.AddEdits(edits); err != nil {
+		return 0, err
+	}
+
+	return len(edits), nil
+}
+
+// Patterns aggregates recorded edits per epic and across all stories
+func (s *FeedbackService) Patterns() ([]models.EditPattern, error) {
+	tracked, err := s.repo.GetTrackedIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	edits, err := s.repo.GetEdits()
+	if err != nil {
+		return nil, err
+	}
+
+	const allGroup = "All stories"
+	patterns := map[string]*models.EditPattern{allGroup: {Group: allGroup}}
+	changeTotals := make(map[string]float64)
+
+	groupsFor := func(epic string) []*models.EditPattern {
+		key := fmt.Sprintf("Epic: %s", epic)
+		if _, exists := patterns[key]; !exists {
+			patterns[key] = &models.EditPattern{Group: key}
+		}
+		return []*models.EditPattern{patterns[allGroup], patterns[key]}
+	}
+
+	for _, issue := range tracked {
+		if issue.IssueType != "story" {
+			continue
+		}
+		for _, pattern := range groupsFor(issue.Epic) {
+			pattern.TrackedIssues++
+		}
+	}
+
+	for _, edit := range edits {
+		if edit.IssueType != "story" {
+			continue
+		}
+
+		switch {
+		case isStoryPointsField(edit.Field):
+			from, fromErr := strconv.ParseFloat(edit.From, 64)
+			to, toErr := strconv.ParseFloat(edit.To, 64)
+			if fromErr != nil || toErr != nil || from == 0 {
+				continue
+			}
+			for _, pattern := range groupsFor(edit.Epic) {
+				pattern.EstimateEdits++
+				changeTotals[pattern.Group] += (to - from) / from * 100
+			}
+		case strings.EqualFold(edit.Field, "summary"):
+			for _, pattern := range groupsFor(edit.Epic) {
+				pattern.SummaryRewrites++
+			}
+		}
+	}
+
+	var result []models.EditPattern
+	for group, pattern := range patterns {
+		if pattern.EstimateEdits > 0 {
+			pattern.AverageEstimateChange = changeTotals[group] / float64(pattern.EstimateEdits)
+		}
+		if pattern.EstimateEdits > 0 || pattern.SummaryRewrites > 0 {
+			result = append(result, *pattern)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Group == allGroup {
+			return true
+		}
+		if result[j].Group == allGroup {
+			return false
+		}
+		return result[i].Group < result[j].Group
+	})
+
+	return result, nil
+}
+
+// DisplayPatterns displays aggregate edit patterns
+func (s *FeedbackService) DisplayPatterns(patterns []models.EditPattern) {
+	if len(patterns) == 0 {
+		helpers.PrintInfo("No human edits recorded yet")
+		return
+	}
+
+	for _, pattern := range patterns {
+		helpers.PrintInfo("%s (%d tracked stories)", pattern.Group, pattern.TrackedIssues)
+		if pattern.EstimateEdits > 0 {
+			helpers.PrintInfo("  Estimates changed %d times, by %+.0f%% on average", pattern.EstimateEdits, pattern.AverageEstimateChange)
+		}
+		if pattern.SummaryRewrites > 0 {
+			helpers.PrintInfo("  Summaries rewritten %d times", pattern.SummaryRewrites)
+		}
+	}
+}
+
+// CalibrationGuidance turns significant edit patterns into prompt guidance
+func (s *FeedbackService) CalibrationGuidance() (string, error) {
+	patterns, err := s.Patterns()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, pattern := range patterns {
+		if pattern.EstimateEdits < minPatternSamples {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- Humans changed story point estimates by %+.0f%% on average for %s (%d edits)",
+			pattern.AverageEstimateChange, strings.ToLower(pattern.Group), pattern.EstimateEdits))
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	return "Calibrate estimates using how the team edited previous breakdowns:\n" + strings.Join(lines, "\n"), nil
+}
+
+// RateItem stores a reviewer rating for an epic ("E2" or "2") or story ("E3-S2" or "3.2") of an
+// analysis
+func (s *FeedbackService) RateItem(analysisFile, itemRef, rating, note string) (*models.ItemFeedback, error) {
+	if rating != models.RatingGood && rating != models.RatingBad {
+		return nil, fmt.Errorf("invalid rating '%s' (must be %s or %s)", rating, models.RatingGood, models.RatingBad)
+	}
+
+	var result models.AnalysisResult
+	if err := helpers.LoadJSON(analysisFile, &result); err != nil {
+		return nil, fmt.Errorf("failed to load analysis file: %w", err)
+	}
+
+	feedback := models.ItemFeedback{
+		AnalysisFile: analysisFile,
+		ItemRef:      itemRef,
+		Rating:       rating,
+		Note:         note,
+		CreatedAt:    time.Now(),
+	}
+
+	AssignItemIDs(&result.ProjectBreakdown)
+
+	epic, story, err := FindItem(&result.ProjectBreakdown, itemRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if story == nil {
+		feedback.ItemType = "epic"
+		feedback.ItemID = epic.ID
+		feedback.Title = epic.Title
+		feedback.Description = epic.Description
+	} else {
+		feedback.ItemType = "story"
+		feedback.ItemID = story.ID
+		feedback.Title = story.Title
+		feedback.Description = story.Description
+	}
+
+	if err := s.repo.AddRating(feedback); err != nil {
+		return nil, err
+	}
+
+	return &feedback, nil
+}
+
+// ExampleGuidance turns recent ratings into few-shot examples and counter-examples for prompts
+func (s *FeedbackService) ExampleGuidance() (string, error) {
+	ratings, err := s.repo.GetRatings()
+	if err != nil {
+		return "", err
+	}
+
+	var good, bad []string
+	for i := len(ratings) - 1; i >= 0; i-- {
+		rating := ratings[i]
+		example := fmt.Sprintf("- %s: %q - %s", rating.ItemType, rating.Title, rating.Description)
+		if rating.Note != "" {
+			example += fmt.Sprintf(" (reviewer note: %s)", rating.Note)
+		}
+
+		if rating.Rating == models.RatingBad && len(bad) < maxPromptExamples {
+			bad = append(bad, example)
+		} else if rating.Rating == models.RatingGood && len(good) < maxPromptExamples {
+			good = append(good, example)
+		}
+	}
+
+	var sections []string
+	if len(good) > 0 {
+		sections = append(sections, "Reviewers rated these previously generated items as good examples to follow:\n"+strings.Join(good, "\n"))
+	}
+	if len(bad) > 0 {
+		sections = append(sections, "Reviewers rated these previously generated items as bad - avoid repeating their problems:\n"+strings.Join(bad, "\n"))
+	}
+
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// parseItemRef parses an item reference like "3" (epic 3) or "3.2" (story 2 of epic 3)
+func parseItemRef(itemRef string) (epicIndex, storyIndex int, err error) {
+	parts := strings.Split(itemRef, ".")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("invalid item reference '%s' (expected an ID such as E3-S2, or EPIC.STORY such as 3.2)", itemRef)
+	}
+
+	epicIndex, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid item reference '%s' (expected an ID such as E3-S2, or EPIC.STORY such as 3.2)", itemRef)
+	}
+
+	if len(parts) == 2 {
+		storyIndex, err = strconv.Atoi(parts[1])
+		if err != nil || storyIndex < 1 {
+			return 0, 0, fmt.Errorf("invalid item reference '%s' (expected an ID such as E3-S2, or EPIC.STORY such as 3.2)", itemRef)
+		}
+	}
+
+	return epicIndex, storyIndex, nil
+}
+
+// isStoryPointsField reports whether a changelog field holds story points
+func isStoryPointsField(field string) bool {
+	switch strings.ToLower(field) {
+	case "story points", "story point estimate":
+		return true
+	}
+	return false
+}
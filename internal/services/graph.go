@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// dependencyGraph is the stories of a breakdown that depend on or are depended on by others,
+// grouped by epic, with dependencies that name no story as external nodes
+type dependencyGraph struct {
+	epics    []graphEpic
+	external []string
+	edges    [][2]string // dependency, dependent story
+}
+
+// graphEpic is an epic with the stories of a dependency graph
+type graphEpic struct {
+	id, title string
+	stories   [][2]string // ID and title
+}
+
+// buildDependencyGraph collects the dependency graph of a breakdown. Dependencies are story IDs
+// once AssignItemIDs has resolved them from titles; any other text is an external dependency.
+func buildDependencyGraph(breakdown *models.ProjectBreakdown) *dependencyGraph {
+	isStory := make(map[string]bool)
+	linked := make(map[string]bool)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			isStory[story.ID] = true
+		}
+	}
+
+	graph := &dependencyGraph{}
+	external := make(map[string]bool)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			for _, dependency := range story.Dependencies {
+				dependency = strings.TrimSpace(dependency)
+				if dependency == "" || dependency == story.ID {
+					continue
+				}
+				if !isStory[dependency] && !external[dependency] {
+					external[dependency] = true
+					graph.external = append(graph.external, dependency)
+				}
+				graph.edges = append(graph.edges, [2]string{dependency, story.ID})
+				linked[dependency], linked[story.ID] = true, true
+			}
+		}
+	}
+
+	for _, epic := range breakdown.Epics {
+		node := graphEpic{id: epic.ID, title: epic.Title}
+		for _, story := range epic.Stories {
+			if linked[story.ID] {
+				node.stories = append(node.stories, [2]string{story.ID, story.Title})
+			}
+		}
+		if len(node.stories) > 0 {
+			graph.epics = append(graph.epics, node)
+		}
+	}
+	return graph
+}
+
+// DependencyMermaid renders the story dependencies of a breakdown as a Mermaid flowchart, with
+// an arrow from every story to the stories that depend on it and a subgraph per epic. It is
+// empty when no story has dependencies.
+func DependencyMermaid(breakdown *models.ProjectBreakdown) string {
+	graph := buildDependencyGraph(breakdown)
+	if len(graph.edges) == 0 {
+		return ""
+	}
+
+	// Mermaid node IDs may not contain hyphens, and labels are quoted
+	nodes := make(map[string]string)
+	label := strings.NewReplacer(`"`, "#quot;")
+	var flowchart strings.Builder
+	flowchart.WriteString("flowchart LR\n")
+	for i, epic := range graph.epics {
+		flowchart.WriteString(fmt.Sprintf("    subgraph epic%d[\"%s %s\"]\n", i+1, epic.id, label.Replace(epic.title)))
+		for _, story := range epic.stories {
+			nodes[story[0]] = strings.ReplaceAll(story[0], "-", "_")
+			flowchart.WriteString(fmt.Sprintf("        %s[\"%s %s\"]\n", nodes[story[0]], story[0], label.Replace(story[1])))
+		}
+		flowchart.WriteString("    end\n")
+	}
+	for i, dependency := range graph.external {
+		nodes[dependency] = fmt.Sprintf("external%d", i+1)
+		flowchart.WriteString(fmt.Sprintf("    %s([\"%s\"])\n", nodes[dependency], label.Replace(dependency)))
+	}
+	for _, edge := range graph.edges {
+		flowchart.WriteString(fmt.Sprintf("    %s --> %s\n", nodes[edge[0]], nodes[edge[1]]))
+	}
+	return flowchart.String()
+}
+
+// DependencyDOT renders the story dependencies of a breakdown as a Graphviz DOT digraph, with a
+// cluster per epic. It is empty when no story has dependencies.
+func DependencyDOT(breakdown *models.ProjectBreakdown) string {
+	graph := buildDependencyGraph(breakdown)
+	if len(graph.edges) == 0 {
+		return ""
+	}
+
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var dot strings.Builder
+	dot.WriteString("digraph dependencies {\n    rankdir=LR;\n    node [shape=box];\n")
+	for i, epic := range graph.epics {
+		dot.WriteString(fmt.Sprintf("    subgraph cluster_%d {\n        label=\"%s %s\";\n", i+1, epic.id, quote.Replace(epic.title)))
+		for _, story := range epic.stories {
+			dot.WriteString(fmt.Sprintf("        \"%s\" [label=\"%s\\n%s\"];\n", story[0], story[0], quote.Replace(story[1])))
+		}
+		dot.WriteString("    }\n")
+	}
+	for _, dependency := range graph.external {
+		dot.WriteString(fmt.Sprintf("    \"%s\" [shape=ellipse, style=dashed];\n", quote.Replace(dependency)))
+	}
+	for _, edge := range graph.edges {
+		dot.WriteString(fmt.Sprintf("    \"%s\" -> \"%s\";\n", quote.Replace(edge[0]), edge[1]))
+	}
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+// DependencyCycles returns the cycles among the story dependencies of a breakdown, each as the
+// IDs of its stories in dependency order with the first repeated at the end. Every cycle is
+// returned once, starting from the story that comes first in the breakdown.
+func DependencyCycles(breakdown *models.ProjectBreakdown) [][]string {
+	var order []string
+	dependsOn := make(map[string][]string)
+	position := make(map[string]int)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			position[story.ID] = len(order)
+			order = append(order, story.ID)
+			dependsOn[story.ID] = story.Dependencies
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	seen := make(map[string]bool)
+	var cycles [][]string
+	var path []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		path = append(path, id)
+		for _, dependency := range dependsOn[id] {
+			if _, isStory := dependsOn[dependency]; !isStory {
+				continue
+			}
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case visiting:
+				members := slices.Clone(path[slices.Index(path, dependency):])
+				// The search may enter a cycle at any of its stories; rotate it to start at the first
+				first := 0
+				for i, member := range members {
+					if position[member] < position[members[first]] {
+						first = i
+					}
+				}
+				cycle := append(append(slices.Clone(members[first:]), members[:first]...), members[first])
+
+				slices.Sort(members)
+				if key := strings.Join(members, " "); !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = visited
+	}
+	for _, id := range order {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// UnknownDependencies returns the dependencies that name a story ID no story of the breakdown
+// has, as "<story ID> depends on <ID>". Other dependencies that name no story are external.
+func UnknownDependencies(breakdown *models.ProjectBreakdown) []string {
+	isStory := make(map[string]bool)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			isStory[story.ID] = true
+		}
+	}
+
+	var unknown []string
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			for _, dependency := range story.Dependencies {
+				dependency = strings.TrimSpace(dependency)
+				if _, _, ok := splitStoryID(dependency); ok && !isStory[dependency] {
+					unknown = append(unknown, fmt.Sprintf("%s depends on %s", story.ID, dependency))
+				}
+			}
+		}
+	}
+	return unknown
+}
+
+// CheckDependencies checks the story dependencies of a breakdown before issues are created, so
+// that no contradictory links end up in JIRA. Cycles are an error, as none of their stories
+// could be started first; dependencies on stories that do not exist are warned about.
+func CheckDependencies(breakdown *models.ProjectBreakdown) error {
+	for _, unknown := range UnknownDependencies(breakdown) {
+		helpers.PrintWarning("%s, which is not a story of the analysis", unknown)
+	}
+	return dependencyCycleError(breakdown)
+}
+
+// dependencyCycleError returns an error listing the dependency cycles of a breakdown, if any
+func dependencyCycleError(breakdown *models.ProjectBreakdown) error {
+	cycles := DependencyCycles(breakdown)
+	if len(cycles) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(cycles))
+	for i, cycle := range cycles {
+		descriptions[i] = strings.Join(cycle, " -> ")
+	}
+	return fmt.Errorf("story dependencies form cycles, each story depending on the next: %s; remove a dependency of each cycle",
+		strings.Join(descriptions, "; "))
+}
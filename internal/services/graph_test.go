@@ -0,0 +1,72 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"scrum-master/internal/models"
+)
+
+// dependencyBreakdown returns a breakdown of stories with the given dependencies by story ID, in
+// the order given
+func dependencyBreakdown(stories ...[]string) *models.ProjectBreakdown {
+	breakdown := &models.ProjectBreakdown{Epics: []models.Epic{{ID: "E1"}}}
+	for _, story := range stories {
+		breakdown.Epics[0].Stories = append(breakdown.Epics[0].Stories, models.Story{ID: story[0], Dependencies: story[1:]})
+	}
+	return breakdown
+}
+
+func TestDependencyCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		breakdown *models.ProjectBreakdown
+		want      [][]string
+	}{
+		{
+			name:      "no cycles",
+			breakdown: dependencyBreakdown([]string{"S1"}, []string{"S2", "S1"}, []string{"S3", "S1", "S2"}),
+		},
+		{
+			name:      "story depending on itself",
+			breakdown: dependencyBreakdown([]string{"S1", "S1"}),
+			want:      [][]string{{"S1", "S1"}},
+		},
+		{
+			name:      "cycle returned once",
+			breakdown: dependencyBreakdown([]string{"S1", "S2"}, []string{"S2", "S3"}, []string{"S3", "S1"}),
+			want:      [][]string{{"S1", "S2", "S3", "S1"}},
+		},
+		{
+			name:      "cycle starts at its first story",
+			breakdown: dependencyBreakdown([]string{"S1", "S3"}, []string{"S2", "S3"}, []string{"S3", "S2"}),
+			want:      [][]string{{"S2", "S3", "S2"}},
+		},
+		{
+			name: "separate cycles",
+			breakdown: dependencyBreakdown(
+				[]string{"S1", "S2"}, []string{"S2", "S1"},
+				[]string{"S3"},
+				[]string{"S4", "S5"}, []string{"S5", "S4"},
+			),
+			want: [][]string{{"S1", "S2", "S1"}, {"S4", "S5", "S4"}},
+		},
+		{
+			name:      "cycles sharing a story",
+			breakdown: dependencyBreakdown([]string{"S1", "S2", "S3"}, []string{"S2", "S1"}, []string{"S3", "S1"}),
+			want:      [][]string{{"S1", "S2", "S1"}, {"S1", "S3", "S1"}},
+		},
+		{
+			name:      "external dependencies ignored",
+			breakdown: dependencyBreakdown([]string{"S1", "Payment provider", "S9"}, []string{"S2", "S1"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DependencyCycles(tt.breakdown); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DependencyCycles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
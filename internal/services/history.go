@@ -0,0 +1,210 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// HistoryService lists, archives and cleans up the runs stored in the output directory
+type HistoryService struct {
+	config *config.Config
+	repo   *repositories.ArchiveRepository
+}
+
+// NewHistoryService creates a new history service
+func NewHistoryService(config *config.Config) *HistoryService {
+	return &HistoryService{
+		config: config,
+		repo:   repositories.NewArchiveRepository(config.Processing.OutputDir),
+	}
+}
+
+// ListRuns returns the runs in the output directory and those archived out of it, newest first
+func (s *HistoryService) ListRuns() ([]models.OutputRun, error) {
+	runs, err := s.localRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	archived, err := s.repo.GetRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	runs = append(runs, archived...)
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Timestamp > runs[j].Timestamp
+	})
+	return runs, nil
+}
+
+// DisplayRuns displays runs with their files and archive location
+func (s *HistoryService) DisplayRuns(runs []models.OutputRun) {
+	if len(runs) == 0 {
+		helpers.PrintInfo("No runs in %s", s.config.Processing.OutputDir)
+		return
+	}
+
+	for _, run := range runs {
+		when := run.Timestamp
+		if t, err := helpers.ParseTimestamp(run.Timestamp); err == nil {
+			when = t.Format("2006-01-02 15:04:05")
+		}
+
+		if run.Archive != "" {
+			helpers.PrintInfo("%s (archived in %s)", when, run.Archive)
+		} else {
+			helpers.PrintInfo("%s", when)
+		}
+		for _, file := range run.Files {
+			helpers.PrintInfo("  %s", file)
+		}
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("%d runs", len(runs))
+}
+
+// Clean removes runs beyond the newest keepLast or older than maxAgeDays from the output
+// directory. When archivePath is set the runs are written to that archive first and recorded in
+// the archive index; otherwise they are deleted. With dryRun nothing is changed.
+func (s *HistoryService) Clean(keepLast, maxAgeDays int, archivePath string, dryRun bool) ([]models.OutputRun, error) {
+	if keepLast == 0 && maxAgeDays == 0 {
+		return nil, fmt.Errorf("no retention policy: set --keep-last or --max-age-days, or processing.retention in the config")
+	}
+
+	runs, err := s.localRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -maxAgeDays)
+
+	var expired []models.OutputRun
+	for i, run := range runs {
+		tooMany := keepLast > 0 && i >= keepLast
+
+		tooOld := false
+		if maxAgeDays > 0 {
+			if t, err := helpers.ParseTimestamp(run.Timestamp); err == nil && t.Before(cutoff) {
+				tooOld = true
+			}
+		}
+
+		if tooMany || tooOld {
+			expired = append(expired, run)
+		}
+	}
+
+	if len(expired) == 0 || dryRun {
+		return expired, nil
+	}
+
+	var files []string
+	for _, run := range expired {
+		files = append(files, run.Files...)
+	}
+
+	if archivePath != "" {
+		if err := helpers.EnsureDir(filepath.Dir(archivePath)); err != nil {
+			return nil, fmt.Errorf("failed to create archive directory: %w", err)
+		}
+
+		if err := helpers.CreateTarGz(archivePath, s.config.Processing.OutputDir, files); err != nil {
+			return nil, err
+		}
+
+		archivedAt := time.Now()
+		for i := range expired {
+			expired[i].Archive = archivePath
+			expired[i].ArchivedAt = archivedAt
+		}
+
+		// Record the archive before deleting so no run is ever unaccounted for
+		if err := s.repo.AddRuns(expired); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		if err := os.Remove(filepath.Join(s.config.Processing.OutputDir, file)); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", file, err)
+		}
+	}
+
+	return expired, nil
+}
+
+// CheckArchived returns an error naming the archive if a requested output file was archived
+// by the clean command. Files that exist, or were never archived, pass.
+func (s *HistoryService) CheckArchived(path string) error {
+	if helpers.FileExists(path) {
+		return nil
+	}
+
+	archived, err := s.repo.GetRuns()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+	for _, run := range archived {
+		for _, file := range run.Files {
+			if file == name {
+				return fmt.Errorf("'%s' was archived to %s on %s; extract it to use it again",
+					name, run.Archive, run.ArchivedAt.Format("2006-01-02"))
+			}
+		}
+	}
+
+	return nil
+}
+
+// localRuns groups the timestamped files at the top of the output directory into runs,
+// newest first
+func (s *HistoryService) localRuns() ([]models.OutputRun, error) {
+	entries, err := os.ReadDir(s.config.Processing.OutputDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+
+	byTimestamp := make(map[string]*models.OutputRun)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		timestamp, ok := helpers.ParseOutputTimestamp(entry.Name())
+		if !ok {
+			continue
+		}
+
+		run, exists := byTimestamp[timestamp]
+		if !exists {
+			run = &models.OutputRun{Timestamp: timestamp}
+			byTimestamp[timestamp] = run
+		}
+		run.Files = append(run.Files, entry.Name())
+	}
+
+	var runs []models.OutputRun
+	for _, run := range byTimestamp {
+		runs = append(runs, *run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Timestamp > runs[j].Timestamp
+	})
+	return runs, nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"scrum-master/internal/models"
+)
+
+// AssignItemIDs gives every initiative (I1), epic (E1), story (E1-S3), subtask (E1-S3-T2) and risk (R1) without an ID the
+// next free number and rewrites story dependencies that name another story by title to its ID. IDs that are already
+// assigned never change, so they stay stable through merges, edits and incremental updates.
+func AssignItemIDs(breakdown *models.ProjectBreakdown) {
+	nextEpic := 1
+	for _, epic := range breakdown.Epics {
+		if n, ok := idNumber(epic.ID, "E"); ok && n >= nextEpic {
+			nextEpic = n + 1
+		}
+	}
+
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		if epic.ID == "" {
+			epic.ID = fmt.Sprintf("E%d", nextEpic)
+			nextEpic++
+		}
+	}
+
+	// Story numbers are unique per epic ID across the whole breakdown, so a story moved to another
+	// epic by hand never collides with a later one
+	nextStory := make(map[string]int)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			prefix, n, ok := splitStoryID(story.ID)
+			if ok && n >= nextStory[prefix] {
+				nextStory[prefix] = n + 1
+			}
+		}
+	}
+
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+			if story.ID != "" {
+				continue
+			}
+
+			if nextStory[epic.ID] == 0 {
+				nextStory[epic.ID] = 1
+			}
+			story.ID = fmt.Sprintf("%s-S%d", epic.ID, nextStory[epic.ID])
+			nextStory[epic.ID]++
+		}
+	}
+
+	// Subtasks are numbered within their story and move with it
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			story := &breakdown.Epics[i].Stories[j]
+
+			nextSubtask := 1
+			for _, subtask := range story.Subtasks {
+				if n, ok := idNumber(strings.TrimPrefix(subtask.ID, story.ID+"-"), "T"); ok && n >= nextSubtask {
+					nextSubtask = n + 1
+				}
+			}
+
+			for k := range story.Subtasks {
+				if story.Subtasks[k].ID == "" {
+					story.Subtasks[k].ID = fmt.Sprintf("%s-T%d", story.ID, nextSubtask)
+					nextSubtask++
+				}
+			}
+		}
+	}
+
+	nextInitiative := 1
+	for _, initiative := range breakdown.Initiatives {
+		if n, ok := idNumber(initiative.ID, "I"); ok && n >= nextInitiative {
+			nextInitiative = n + 1
+		}
+	}
+	for i := range breakdown.Initiatives {
+		if breakdown.Initiatives[i].ID == "" {
+			breakdown.Initiatives[i].ID = fmt.Sprintf("I%d", nextInitiative)
+			nextInitiative++
+		}
+	}
+
+	nextRisk := 1
+	for _, risk := range breakdown.Risks {
+		if n, ok := idNumber(risk.ID, "R"); ok && n >= nextRisk {
+			nextRisk = n + 1
+		}
+	}
+	for i := range breakdown.Risks {
+		if breakdown.Risks[i].ID == "" {
+			breakdown.Risks[i].ID = fmt.Sprintf("R%d", nextRisk)
+			nextRisk++
+		}
+	}
+
+	storyIDs := make(map[string]string)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			storyIDs[strings.ToLower(strings.TrimSpace(story.Title))] = story.ID
+		}
+	}
+
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			story := &breakdown.Epics[i].Stories[j]
+			for k, dependency := range story.Dependencies {
+				if id, exists := storyIDs[strings.ToLower(strings.TrimSpace(dependency))]; exists && id != story.ID {
+					story.Dependencies[k] = id
+				}
+			}
+		}
+	}
+}
+
+// FindItem returns the epic and, for a story, the story a reference points to. A reference is an
+// item ID ("E2", "E2-S3") or a position in the breakdown ("2", "2.3").
+func FindItem(breakdown *models.ProjectBreakdown, ref string) (*models.Epic, *models.Story, error) {
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		if epic.ID != "" && strings.EqualFold(epic.ID, ref) {
+			return epic, nil, nil
+		}
+		for j := range epic.Stories {
+			if epic.Stories[j].ID != "" && strings.EqualFold(epic.Stories[j].ID, ref) {
+				return epic, &epic.Stories[j], nil
+			}
+		}
+	}
+
+	epicIndex, storyIndex, err := parseItemRef(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no item '%s' in the analysis (expected an ID such as E3-S2, or EPIC.STORY such as 3.2)", ref)
+	}
+
+	if epicIndex < 1 || epicIndex > len(breakdown.Epics) {
+		return nil, nil, fmt.Errorf("epic %d does not exist (analysis has %d epics)", epicIndex, len(breakdown.Epics))
+	}
+	epic := &breakdown.Epics[epicIndex-1]
+
+	if storyIndex == 0 {
+		return epic, nil, nil
+	}
+	if storyIndex > len(epic.Stories) {
+		return nil, nil, fmt.Errorf("story %s does not exist (epic %d has %d stories)", ref, epicIndex, len(epic.Stories))
+	}
+	return epic, &epic.Stories[storyIndex-1], nil
+}
+
+// itemLabel returns the ID of an item, or its position when the analysis predates IDs
+func itemLabel(id string, epicIndex, storyIndex int) string {
+	if id != "" {
+		return id
+	}
+	if storyIndex == 0 {
+		return fmt.Sprintf("%d", epicIndex)
+	}
+	return fmt.Sprintf("%d.%d", epicIndex, storyIndex)
+}
+
+// subtaskLabel returns the title of a subtask with the component it belongs to
+func subtaskLabel(subtask models.Subtask) string {
+	if subtask.Component == "" {
+		return subtask.Title
+	}
+	return fmt.Sprintf("[%s] %s", subtask.Component, subtask.Title)
+}
+
+// dependencyLabel expands a dependency that is a story ID into "ID Title"
+func dependencyLabel(breakdown *models.ProjectBreakdown, dependency string) string {
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			if story.ID != "" && story.ID == dependency {
+				return fmt.Sprintf("%s %s", story.ID, story.Title)
+			}
+		}
+	}
+	return dependency
+}
+
+// idNumber parses the number of an ID made of prefix and a positive number
+func idNumber(id, prefix string) (int, bool) {
+	if !strings.HasPrefix(id, prefix) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(id[len(prefix):])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// splitStoryID splits a story ID such as "E2-S3" into its epic ID and story number
+func splitStoryID(id string) (string, int, bool) {
+	i := strings.LastIndex(id, "-S")
+	if i < 0 {
+		return "", 0, false
+	}
+	n, ok := idNumber(id[i+1:], "S")
+	return id[:i], n, ok
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"scrum-master/internal/models"
+)
+
+// itemIDs lists the IDs of the items of a breakdown in order, with the dependencies of stories
+// that have any
+func itemIDs(breakdown models.ProjectBreakdown) []string {
+	var ids []string
+	for _, initiative := range breakdown.Initiatives {
+		ids = append(ids, initiative.ID)
+	}
+	for _, epic := range breakdown.Epics {
+		ids = append(ids, epic.ID)
+		for _, story := range epic.Stories {
+			if len(story.Dependencies) > 0 {
+				ids = append(ids, fmt.Sprintf("%s %v", story.ID, story.Dependencies))
+			} else {
+				ids = append(ids, story.ID)
+			}
+			for _, subtask := range story.Subtasks {
+				ids = append(ids, subtask.ID)
+			}
+		}
+	}
+	for _, risk := range breakdown.Risks {
+		ids = append(ids, risk.ID)
+	}
+	return ids
+}
+
+func TestAssignItemIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		breakdown models.ProjectBreakdown
+		want      []string
+	}{
+		{
+			name: "new breakdown",
+			breakdown: models.ProjectBreakdown{
+				Initiatives: []models.Initiative{{Title: "Growth"}},
+				Epics: []models.Epic{
+					{Title: "Checkout", Stories: []models.Story{
+						{Title: "Pay", Subtasks: []models.Subtask{{Title: "API"}, {Title: "UI"}}},
+						{Title: "Refund"},
+					}},
+					{Title: "Search", Stories: []models.Story{{Title: "Filter"}}},
+				},
+				Risks: []models.Risk{{Title: "Fraud"}, {Title: "Load"}},
+			},
+			want: []string{"I1", "E1", "E1-S1", "E1-S1-T1", "E1-S1-T2", "E1-S2", "E2", "E2-S1", "R1", "R2"},
+		},
+		{
+			name: "existing IDs kept and numbering continued",
+			breakdown: models.ProjectBreakdown{
+				Epics: []models.Epic{
+					{ID: "E3", Stories: []models.Story{
+						{ID: "E3-S2", Subtasks: []models.Subtask{{ID: "E3-S2-T4"}, {}}},
+						{},
+					}},
+					{},
+				},
+				Risks: []models.Risk{{}, {ID: "R5"}},
+			},
+			want: []string{"E3", "E3-S2", "E3-S2-T4", "E3-S2-T5", "E3-S3", "E4", "R6", "R5"},
+		},
+		{
+			name: "story moved to another epic keeps its ID",
+			breakdown: models.ProjectBreakdown{
+				Epics: []models.Epic{
+					{ID: "E1", Stories: []models.Story{{}}},
+					{ID: "E2", Stories: []models.Story{{ID: "E1-S1"}, {}}},
+				},
+			},
+			want: []string{"E1", "E1-S2", "E2", "E1-S1", "E2-S1"},
+		},
+		{
+			name: "IDs that do not parse are kept and not counted",
+			breakdown: models.ProjectBreakdown{
+				Epics: []models.Epic{{ID: "PAY"}, {ID: "E0"}, {}},
+			},
+			want: []string{"PAY", "E0", "E1"},
+		},
+		{
+			name: "dependencies on story titles resolved",
+			breakdown: models.ProjectBreakdown{
+				Epics: []models.Epic{{Stories: []models.Story{
+					{Title: "Pay by card"},
+					{Title: "Refund", Dependencies: []string{" pay BY card ", "Payment provider contract"}},
+					{Title: "Refund twice", Dependencies: []string{"Refund twice"}},
+				}}},
+			},
+			want: []string{"E1", "E1-S1", "E1-S2 [E1-S1 Payment provider contract]", "E1-S3 [Refund twice]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AssignItemIDs(&tt.breakdown)
+			if got := itemIDs(tt.breakdown); !slices.Equal(got, tt.want) {
+				t.Errorf("AssignItemIDs() IDs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
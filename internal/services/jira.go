@@ -1,35 +1,89 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
-	"time"
 
 	"scrum-master/internal/config"
 	"scrum-master/internal/helpers"
 	"scrum-master/internal/models"
 	"scrum-master/internal/repositories"
+	"scrum-master/internal/transport"
 )
 
 // JiraService handles JIRA business logic
 type JiraService struct {
 	repo   *repositories.JiraRepository
 	config *config.JiraConfig
+	ledger *repositories.LedgerRepository
+	teams  map[string]string // lower-cased team name -> JIRA team ID
+	events *EventBus
+
+	// issueTypes are the issue types of created epics and stories, detected from the project
+	// where not configured
+	issueTypes config.IssueTypesConfig
+	createMeta []models.JiraCreateMetaIssueType
+
+	// epicNameField is set to the title on created epics; with epicLinkField set, stories are
+	// linked to their epic through it instead of as its children
+	epicNameField string
+	epicLinkField string
+
+	// priorities lists the priorities each issue type's create screen accepts; issue types
+	// without a priority field are missing. Priorities are only set once it is resolved.
+	priorities map[string][]string
+
+	// selectFields holds the schema of the select fields on the project's create screens by field
+	// ID, so mapped values are sent as the options JIRA expects. It is nil until issue types
+	// are resolved.
+	selectFields map[string]models.JiraFieldSchema
+
+	// components maps the lower-cased names of the project's components to their spelling; it is
+	// nil until components are resolved
+	components map[string]string
+
+	// bulkUnavailable is set once the bulk create endpoint failed; stories are then created one
+	// by one
+	bulkUnavailable bool
+
+	// versions maps lower-cased release names to the IDs of their JIRA versions; it is nil until
+	// releases are resolved
+	versions map[string]string
+
+	// assignees maps the lower-cased names of team members to their JIRA users; it is nil until
+	// assignees are resolved
+	assignees map[string]models.JiraUser
+
+	// source are the files created issues point back to, and sourceLink the URL they link to
+	source     SourceFiles
+	sourceLink string
 }
 
 // NewJiraService creates a new JIRA service
-func NewJiraService(jiraConfig *config.JiraConfig) *JiraService {
+func NewJiraService(jiraConfig *config.JiraConfig, httpConfig *config.HTTPConfig) *JiraService {
 	return &JiraService{
-		repo:   repositories.NewJiraRepository(jiraConfig),
-		config: jiraConfig,
+		repo:       repositories.NewJiraRepository(jiraConfig, httpConfig),
+		config:     jiraConfig,
+		events:     newConsoleEventBus(),
+		issueTypes: jiraConfig.IssueTypeMapping,
+
+		epicNameField: jiraConfig.CustomFields[config.CustomFieldEpicName],
 	}
 }
 
+// Events returns the bus issue creation is published on
+func (s *JiraService) Events() *EventBus {
+	return s.events
+}
+
 // TestConnection tests the JIRA connection and validates project access
-func (s *JiraService) TestConnection() error {
+func (s *JiraService) TestConnection(ctx context.Context) error {
 	helpers.PrintInfo("Testing JIRA authentication and listing accessible projects...")
 
-	projects, err := s.repo.TestConnection()
+	projects, err := s.repo.TestConnection(ctx)
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
@@ -38,9 +92,9 @@ func (s *JiraService) TestConnection() error {
 
 	projectFound := false
 	for _, project := range projects {
-		marker := "📋"
+		marker := helpers.Symbol("📋", "-")
 		if project.Key == s.config.ProjectKey {
-			marker = "✅"
+			marker = helpers.Symbol("✅", "[configured]")
 			projectFound = true
 		}
 		helpers.PrintInfo("  %s %s (%s)", marker, project.Key, project.Name)
@@ -53,7 +107,7 @@ func (s *JiraService) TestConnection() error {
 	}
 
 	helpers.PrintInfo("Testing access to project '%s'...", s.config.ProjectKey)
-	if _, err := s.repo.GetProjectInfo(s.config.ProjectKey); err != nil {
+	if _, err := s.repo.GetProjectInfo(ctx, s.config.ProjectKey); err != nil {
 		return fmt.Errorf("failed to access project: %w", err)
 	}
 
@@ -62,32 +116,94 @@ func (s *JiraService) TestConnection() error {
 	return nil
 }
 
-// CreateIssueWithRetry creates a JIRA issue with retry logic
-func (s *JiraService) CreateIssueWithRetry(title, description, issueType, priority, epicLink string) (string, error) {
-	var lastErr error
+// SetLedger records every issue created from a breakdown in a run ledger
+func (s *JiraService) SetLedger(ledger *repositories.LedgerRepository) {
+	s.ledger = ledger
+	s.events.Subscribe(ledgerRecorder(ledger))
+}
 
-	for attempt := 1; attempt <= 3; attempt++ {
-		key, err := s.CreateIssue(title, description, issueType, priority, epicLink)
-		if err == nil {
-			return key, nil
-		}
+// GetIssue fetches an existing JIRA issue by key
+func (s *JiraService) GetIssue(ctx context.Context, issueKey string) (*models.JiraIssueDetails, error) {
+	issue, err := s.repo.GetIssue(ctx, issueKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issue '%s': %w", issueKey, err)
+	}
+	return issue, nil
+}
 
-		lastErr = err
-		helpers.PrintWarning("Attempt %d failed: %v", attempt, err)
+// CreateIssueOnce creates a JIRA issue without ever creating it twice. Requests JIRA did not act
+// on, such as throttled ones, are retried by the HTTP client. When a request fails after JIRA may
+// have acted on it, such as on a server error or a lost response, the issue is looked up by its
+// trace label, or by its summary among recently created issues, before the failure is reported.
+// A request in flight when the context is cancelled is allowed to finish so the created issue is
+// not lost.
+func (s *JiraService) CreateIssueOnce(ctx context.Context, title, description, issueType, priority, epicLink string, labels, components []string, custom map[string]interface{}) (string, error) {
+	key, err := s.CreateIssue(context.WithoutCancel(ctx), title, description, issueType, priority, epicLink, labels, components, custom)
+	if err == nil || !transport.Retryable(err) {
+		return key, err
+	}
 
-		if attempt < 3 {
-			time.Sleep(2 * time.Second)
+	helpers.PrintWarning("Creating '%s' failed, checking whether JIRA created it anyway: %v", title, err)
+	found, lookupErr := s.findCreatedIssue(context.WithoutCancel(ctx), title, issueType, labels)
+	if lookupErr != nil {
+		return "", fmt.Errorf("%w (and looking it up failed: %v)", err, lookupErr)
+	}
+	if found == "" {
+		return "", err
+	}
+
+	helpers.PrintInfo("JIRA created '%s' as %s despite the error", title, found)
+	return found, nil
+}
+
+// findCreatedIssue returns the key of the issue of a type with a summary that was created with
+// the given labels, or nothing. Without a trace label, only issues of the last hour count.
+func (s *JiraService) findCreatedIssue(ctx context.Context, title, issueType string, labels []string) (string, error) {
+	jql := fmt.Sprintf("project = %s AND summary ~ %s AND created >= -1h", jqlString(s.config.ProjectKey), jqlString(jqlString(title)))
+	for _, label := range labels {
+		if strings.HasPrefix(label, traceLabelPrefix+"id-") {
+			jql = fmt.Sprintf("project = %s AND labels = %s", jqlString(s.config.ProjectKey), jqlString(label))
+			break
+		}
+		if strings.HasPrefix(label, traceLabelPrefix) {
+			jql = fmt.Sprintf("project = %s AND labels = %s", jqlString(s.config.ProjectKey), jqlString(label))
+		}
+	}
+
+	issues, err := s.repo.SearchIssues(ctx, jql, syncFields)
+	if err != nil {
+		return "", err
+	}
+	for _, issue := range issues {
+		if strings.EqualFold(strings.TrimSpace(issue.Fields.Summary), strings.TrimSpace(title)) && strings.EqualFold(issue.Fields.IssueType.Name, issueType) {
+			return issue.Key, nil
 		}
 	}
+	return "", nil
+}
 
-	return "", fmt.Errorf("failed after 3 attempts: %w", lastErr)
+// jqlString quotes a value for a JQL query
+func jqlString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
 }
 
-// CreateIssue creates a single JIRA issue
-func (s *JiraService) CreateIssue(title, description, issueType, priority, epicLink string) (string, error) {
+// CreateIssue creates a single JIRA issue. labels and components are added to the configured
+// ones and custom sets instance-specific fields by field ID.
+func (s *JiraService) CreateIssue(ctx context.Context, title, description, issueType, priority, epicLink string, labels, components []string, custom map[string]interface{}) (string, error) {
 	helpers.PrintInfo("Making JIRA API request to: %s/rest/api/2/issue", s.config.BaseURL)
 	helpers.PrintInfo("Project Key: %s, Issue Type: %s", s.config.ProjectKey, issueType)
 
+	resp, err := s.repo.CreateIssue(ctx, s.newIssue(title, description, issueType, priority, epicLink, labels, components, custom))
+	if err != nil {
+		helpers.PrintError("JIRA API Error - Status: %v", err)
+		return "", err
+	}
+
+	return resp.Key, nil
+}
+
+// newIssue builds a new issue the way CreateIssue creates it
+func (s *JiraService) newIssue(title, description, issueType, priority, epicLink string, labels, components []string, custom map[string]interface{}) *models.JiraIssue {
 	issue := &models.JiraIssue{
 		Fields: models.JiraFields{
 			Project: models.JiraProject{
@@ -98,73 +214,851 @@ func (s *JiraService) CreateIssue(title, description, issueType, priority, epicL
 			IssueType: models.JiraIssueType{
 				Name: issueType,
 			},
+			Priority:   s.issuePriority(issueType, priority),
+			Labels:     s.issueLabels(labels),
+			Components: s.issueComponents(components),
+			Custom:     custom,
 		},
 	}
 
-	// Set parent (epic) if provided and issue type is not Epic
-	if epicLink != "" && issueType != "Epic" {
-		issue.Fields.Parent = &models.JiraParent{Key: epicLink}
+	// Epics link to their initiative, with the Advanced Roadmaps Parent Link field when it is
+	// configured and as parent otherwise
+	if epicLink != "" && issueType == s.issueTypes.EpicType() {
+		if field := s.config.CustomFields[config.CustomFieldParentLink]; field != "" {
+			if issue.Fields.Custom == nil {
+				issue.Fields.Custom = make(map[string]interface{})
+			}
+			issue.Fields.Custom[field] = epicLink
+		} else {
+			issue.Fields.Parent = &models.JiraParent{Key: epicLink}
+		}
+	}
+
+	// Link to the epic if provided and issue type is not the epic type. Subtasks always take their
+	// story as parent.
+	if epicLink != "" && issueType != s.issueTypes.EpicType() {
+		if s.epicLinkField != "" && issueType != s.issueTypes.SubtaskType() {
+			if issue.Fields.Custom == nil {
+				issue.Fields.Custom = make(map[string]interface{})
+			}
+			issue.Fields.Custom[s.epicLinkField] = epicLink
+		} else {
+			issue.Fields.Parent = &models.JiraParent{Key: epicLink}
+		}
+	}
+	return issue
+}
+
+// CreateEpic creates an epic in JIRA, under the initiative with initiativeKey and assigned to
+// team and component if set. fields holds breakdown values by field name, written to the custom
+// fields jira.field_mapping assigns them.
+func (s *JiraService) CreateEpic(ctx context.Context, title, description, priority, initiativeKey, team, component string, labels []string, fields map[string]interface{}) (string, error) {
+	custom := s.mapFields(fields)
+	if s.epicNameField != "" {
+		custom[s.epicNameField] = title
+	}
+	if err := s.setTeam(custom, team); err != nil {
+		return "", err
 	}
+	return s.CreateIssueOnce(ctx, title, description, s.issueTypes.EpicType(), priority, initiativeKey, append(s.traceLabels(title, ""), labels...), []string{component}, custom)
+}
 
-	resp, err := s.repo.CreateIssue(issue)
+// CreateTask creates a story in JIRA as an issue of issueType, assigned to team, release,
+// assignee and component if set. epicTitle is only used for the traceability label.
+// fields holds breakdown values by field name, written to the custom fields jira.field_mapping
+// assigns them; with jira.hours_per_point, the story points also give the original estimate.
+func (s *JiraService) CreateTask(ctx context.Context, title, description, issueType, priority, epicLink, epicTitle, team, release, assignee, component string, labels []string, fields map[string]interface{}) (string, error) {
+	custom, err := s.taskFields(team, release, assignee, fields)
 	if err != nil {
-		helpers.PrintError("JIRA API Error - Status: %v", err)
 		return "", err
 	}
+	return s.CreateIssueOnce(ctx, title, description, issueType, priority, epicLink, append(s.traceLabels(epicTitle, title), labels...), []string{component}, custom)
+}
 
-	return resp.Key, nil
+// storyIssueType returns the issue type a story is created as: the spike type for research
+// spikes and the story type for everything else
+func storyIssueType(issueTypes config.IssueTypesConfig, story *models.Story) string {
+	if story.Type == models.StoryTypeSpike {
+		return issueTypes.SpikeType()
+	}
+	return issueTypes.StoryType()
+}
+
+// taskFields returns the custom fields of a new story
+func (s *JiraService) taskFields(team, release, assignee string, fields map[string]interface{}) (map[string]interface{}, error) {
+	custom := s.mapFields(fields)
+	if err := s.setTeam(custom, team); err != nil {
+		return nil, err
+	}
+	if err := s.setRelease(custom, release); err != nil {
+		return nil, err
+	}
+	s.setAssignee(custom, assignee)
+	if points, ok := fields[config.FieldStoryPoints].(int); ok {
+		s.setEstimate(custom, points)
+	}
+	return custom, nil
 }
 
-// CreateEpic creates an epic in JIRA
-func (s *JiraService) CreateEpic(title, description, priority string) (string, error) {
-	return s.CreateIssueWithRetry(title, description, "Epic", priority, "")
+// CreateSubtask creates a subtask of a story in JIRA using the configured subtask issue type.
+// The subtask takes the story's priority and component, and the given traceability labels.
+func (s *JiraService) CreateSubtask(ctx context.Context, subtask *models.Subtask, storyKey, priority, component string, labels []string) (string, error) {
+	return s.CreateIssueOnce(ctx, subtask.Title, subtaskDescription(subtask), s.issueTypes.SubtaskType(), priority, storyKey, labels, []string{component}, nil)
 }
 
-// CreateTask creates a task in JIRA
-func (s *JiraService) CreateTask(title, description, priority, epicLink string) (string, error) {
-	return s.CreateIssueWithRetry(title, description, "Task", priority, epicLink)
+// mapFields returns the custom field values of the breakdown values jira.field_mapping maps.
+// Empty values and zero numbers are left out so JIRA keeps its defaults. Text mapped to a select
+// field is sent as the option of that name.
+func (s *JiraService) mapFields(fields map[string]interface{}) map[string]interface{} {
+	custom := make(map[string]interface{})
+	for name, value := range fields {
+		fieldID := s.config.FieldMapping[name]
+		if fieldID == "" || value == "" || value == 0 {
+			continue
+		}
+		custom[fieldID] = value
+		if text, ok := value.(string); ok {
+			switch schema, isSelect := s.selectFields[fieldID]; {
+			case !isSelect:
+			case schema.Type == "array":
+				custom[fieldID] = []map[string]string{{"value": text}}
+			default:
+				custom[fieldID] = map[string]string{"value": text}
+			}
+		}
+	}
+	return custom
+}
+
+// epicFields returns the values of an epic that can be mapped to custom fields
+func epicFields(epic *models.Epic) map[string]interface{} {
+	return map[string]interface{}{
+		config.FieldItemID:     epic.ID,
+		config.FieldConfidence: epic.Confidence,
+		config.FieldRationale:  epic.Rationale,
+	}
+}
+
+// storyFields returns the values of a story that can be mapped to custom fields. Lists become
+// text with one entry per line.
+func storyFields(story *models.Story) map[string]interface{} {
+	return map[string]interface{}{
+		config.FieldItemID:             story.ID,
+		config.FieldSize:               story.Size,
+		config.FieldStoryPoints:        story.StoryPoints,
+		config.FieldConfidence:         story.Confidence,
+		config.FieldRationale:          story.Rationale,
+		config.FieldAcceptanceCriteria: strings.Join(story.AcceptanceCriteria, "\n"),
+		config.FieldDependencies:       strings.Join(story.Dependencies, "\n"),
+	}
+}
+
+// getCreateMeta reads the project's create metadata once per run
+func (s *JiraService) getCreateMeta(ctx context.Context) ([]models.JiraCreateMetaIssueType, error) {
+	if s.createMeta != nil {
+		return s.createMeta, nil
+	}
+
+	issueTypes, err := s.repo.GetCreateMeta(ctx, s.config.ProjectKey)
+	if err != nil {
+		return nil, err
+	}
+	s.createMeta = issueTypes
+	return issueTypes, nil
+}
+
+// ResolveIssueTypes checks the issue types of jira.issue_type_mapping against the types the
+// project can create, so a misspelled or localized name fails the run before anything is
+// created. Types that are not configured are detected: Epic, and Story or Task. When the
+// project's create metadata cannot be read, the configured or default types are used as is.
+func (s *JiraService) ResolveIssueTypes(ctx context.Context) error {
+	issueTypes, err := s.getCreateMeta(ctx)
+	if err != nil {
+		helpers.PrintWarning("Failed to read the issue types of project %s, using %s for epics and %s for stories: %v",
+			s.config.ProjectKey, s.issueTypes.EpicType(), s.issueTypes.StoryType(), err)
+		return nil
+	}
+
+	available := availableIssueTypes(issueTypes)
+	epicType, storyType := detectIssueTypes(issueTypes)
+
+	resolve := func(key, configured string, detected *models.JiraCreateMetaIssueType) (string, error) {
+		if configured != "" {
+			for _, name := range available {
+				if strings.EqualFold(name, configured) {
+					return name, nil
+				}
+			}
+			return "", fmt.Errorf("jira.issue_type_mapping.%s is '%s', which project %s does not have (available: %s)",
+				key, configured, s.config.ProjectKey, strings.Join(available, ", "))
+		}
+		if detected == nil {
+			return "", fmt.Errorf("no %s issue type found in project %s; set jira.issue_type_mapping.%s to one of: %s",
+				key, s.config.ProjectKey, key, strings.Join(available, ", "))
+		}
+		return detected.Name, nil
+	}
+
+	if s.issueTypes.Epic, err = resolve("epic", s.config.IssueTypeMapping.Epic, epicType); err != nil {
+		return err
+	}
+	if s.issueTypes.Story, err = resolve("story", s.config.IssueTypeMapping.Story, storyType); err != nil {
+		return err
+	}
+
+	s.selectFields = selectFields(issueTypes)
+	helpers.PrintInfo("Creating epics as '%s' and stories as '%s'", s.issueTypes.Epic, s.issueTypes.Story)
+	return nil
+}
+
+// selectFields returns the schema of the single and multi select fields of any issue type
+func selectFields(issueTypes []models.JiraCreateMetaIssueType) map[string]models.JiraFieldSchema {
+	fields := make(map[string]models.JiraFieldSchema)
+	for _, issueType := range issueTypes {
+		for _, field := range issueType.Fields {
+			if field.Schema.Type == "option" || (field.Schema.Type == "array" && field.Schema.Items == "option") {
+				fields[field.FieldID] = field.Schema
+			}
+		}
+	}
+	return fields
+}
+
+// ResolveSubtaskType checks the subtask issue type of jira.issue_type_mapping against the
+// subtask types of the project, or detects it: Sub-task, or the project's only subtask type.
+// It is only needed when the breakdown has subtasks.
+func (s *JiraService) ResolveSubtaskType(ctx context.Context) error {
+	issueTypes, err := s.getCreateMeta(ctx)
+	if err != nil {
+		helpers.PrintWarning("Failed to read the issue types of project %s, using %s for subtasks: %v",
+			s.config.ProjectKey, s.issueTypes.SubtaskType(), err)
+		return nil
+	}
+
+	detected, available := detectSubtaskType(issueTypes)
+	if len(available) == 0 {
+		return fmt.Errorf("project %s has no subtask issue type; enable subtasks in JIRA or create the analysis without --with-subtasks", s.config.ProjectKey)
+	}
+
+	if configured := s.config.IssueTypeMapping.Subtask; configured != "" {
+		detected = ""
+		for _, name := range available {
+			if strings.EqualFold(name, configured) {
+				detected = name
+			}
+		}
+		if detected == "" {
+			return fmt.Errorf("jira.issue_type_mapping.subtask is '%s', which is not a subtask type of project %s (available: %s)",
+				configured, s.config.ProjectKey, strings.Join(available, ", "))
+		}
+	}
+
+	s.issueTypes.Subtask = detected
+	helpers.PrintInfo("Creating subtasks as '%s'", detected)
+	return nil
+}
+
+// ResolveEpicLinking works out how stories are linked to their epics. Team-managed projects take
+// the epic as parent. Company-managed projects need the Epic Link field on stories and the Epic
+// Name field on epics, whose IDs are taken from jira.custom_fields or found on the project's
+// create screens. The style is read from the project unless jira.project_style sets it.
+func (s *JiraService) ResolveEpicLinking(ctx context.Context) error {
+	style := s.config.ProjectStyle
+	if style == "" {
+		project, err := s.repo.GetProjectInfo(ctx, s.config.ProjectKey)
+		if err != nil {
+			helpers.PrintWarning("Failed to read the style of project %s, linking stories to epics as parent: %v", s.config.ProjectKey, err)
+			return nil
+		}
+
+		// JIRA Server and Data Center only have company-managed projects and report no style
+		style = config.ProjectStyleCompanyManaged
+		if project.Style == "next-gen" || project.Simplified {
+			style = config.ProjectStyleTeamManaged
+		}
+	}
+
+	if style == config.ProjectStyleTeamManaged {
+		helpers.PrintInfo("Project %s is team-managed; stories are linked to their epic as parent", s.config.ProjectKey)
+		return nil
+	}
+
+	epicLinkField := s.config.CustomFields[config.CustomFieldEpicLink]
+	if issueTypes, err := s.getCreateMeta(ctx); err == nil {
+		for _, issueType := range issueTypes {
+			for _, field := range issueType.Fields {
+				switch {
+				case epicLinkField == "" && strings.EqualFold(issueType.Name, s.issueTypes.StoryType()) && strings.EqualFold(field.Name, "Epic Link"):
+					epicLinkField = field.FieldID
+				case s.epicNameField == "" && strings.EqualFold(issueType.Name, s.issueTypes.EpicType()) && strings.EqualFold(field.Name, "Epic Name"):
+					s.epicNameField = field.FieldID
+				}
+			}
+		}
+	}
+
+	if epicLinkField == "" {
+		if s.config.ProjectStyle == config.ProjectStyleCompanyManaged {
+			return fmt.Errorf("no Epic Link field found for %s in project %s (set jira.custom_fields.epic_link)", s.issueTypes.StoryType(), s.config.ProjectKey)
+		}
+		helpers.PrintWarning("Project %s is company-managed but %s has no Epic Link field; linking stories to their epic as parent", s.config.ProjectKey, s.issueTypes.StoryType())
+		return nil
+	}
+
+	s.epicLinkField = epicLinkField
+	helpers.PrintInfo("Project %s is company-managed; stories are linked to their epic with %s", s.config.ProjectKey, epicLinkField)
+	return nil
+}
+
+// ResolvePriorities reads the priorities the project accepts for epics and stories, so created
+// issues get the priority of their breakdown item, translated by jira.priority_mapping. Issue
+// types without a priority field are created without one. A mapping to a priority the project
+// does not offer fails the run before anything is created.
+func (s *JiraService) ResolvePriorities(ctx context.Context) error {
+	issueTypes, err := s.getCreateMeta(ctx)
+	if err != nil {
+		helpers.PrintWarning("Failed to read the priorities of project %s, creating issues without priority: %v", s.config.ProjectKey, err)
+		return nil
+	}
+
+	s.priorities = make(map[string][]string)
+	for _, issueType := range issueTypes {
+		for _, field := range issueType.Fields {
+			if field.FieldID != "priority" {
+				continue
+			}
+			names := []string{}
+			for _, value := range field.AllowedValues {
+				names = append(names, value.Name)
+			}
+			s.priorities[issueType.Name] = names
+		}
+	}
+
+	for _, issueType := range []string{s.issueTypes.EpicType(), s.issueTypes.StoryType()} {
+		allowed, exists := s.priorities[issueType]
+		if !exists {
+			helpers.PrintInfo("Issue type '%s' has no priority field in project %s; its issues are created without priority", issueType, s.config.ProjectKey)
+			continue
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+
+		for _, priority := range []string{"High", "Medium", "Low"} {
+			name := s.jiraPriority(priority)
+			if allowedPriority(allowed, name) != "" {
+				continue
+			}
+			if _, mapped := s.config.PriorityMapping[priority]; mapped {
+				return fmt.Errorf("jira.priority_mapping maps %s to '%s', which project %s does not offer for %s (available: %s)",
+					priority, name, s.config.ProjectKey, issueType, strings.Join(allowed, ", "))
+			}
+			helpers.PrintWarning("Project %s has no '%s' priority for %s; these issues are created without priority unless %s is mapped in jira.priority_mapping (available: %s)",
+				s.config.ProjectKey, name, issueType, priority, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// issuePriority returns the JIRA priority of a new issue of the given type, or nil when it should
+// be left to the project's default
+func (s *JiraService) issuePriority(issueType, priority string) *models.JiraPriority {
+	allowed, exists := s.priorities[issueType]
+	name := s.jiraPriority(priority)
+	if !exists || name == "" {
+		return nil
+	}
+
+	// Allowed values are unknown on some JIRA versions; send the mapped name as is
+	if len(allowed) > 0 {
+		if name = allowedPriority(allowed, name); name == "" {
+			return nil
+		}
+	}
+	return &models.JiraPriority{Name: name}
+}
+
+// jiraPriority translates a breakdown priority to the JIRA priority name it maps to
+func (s *JiraService) jiraPriority(priority string) string {
+	priority = normalizePriority(priority)
+	if mapped := s.config.PriorityMapping[priority]; mapped != "" {
+		return mapped
+	}
+	return priority
+}
+
+// allowedPriority returns the spelling of a priority among the allowed ones, or "" if it is not
+// allowed
+func allowedPriority(allowed []string, name string) string {
+	for _, candidate := range allowed {
+		if strings.EqualFold(candidate, name) {
+			return candidate
+		}
+	}
+	return ""
 }
 
-// CreateTicketsFromBreakdown creates JIRA tickets from a project breakdown
-func (s *JiraService) CreateTicketsFromBreakdown(breakdown *models.ProjectBreakdown) error {
+// ResolveTeams maps the default team and every team named in the breakdown to its JIRA team
+// ID. Names are looked up in jira.teams first, then among the Advanced Roadmaps teams, so an
+// unknown team fails the run before anything is created.
+func (s *JiraService) ResolveTeams(ctx context.Context, breakdown *models.ProjectBreakdown) error {
+	var names []string
+	seen := make(map[string]bool)
+	addName := func(name string) {
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+
+	addName(s.config.Team)
+	for _, epic := range breakdown.Epics {
+		addName(epic.Team)
+		for _, story := range epic.Stories {
+			addName(story.Team)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	if s.config.CustomFields[config.CustomFieldTeam] == "" {
+		return fmt.Errorf("teams are assigned but jira.custom_fields.team is not set; run 'jira discover-fields' to find the Team field")
+	}
+
+	s.teams = make(map[string]string)
+	for name, id := range s.config.Teams {
+		if id != "" {
+			s.teams[strings.ToLower(name)] = id
+		}
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if _, ok := s.teams[strings.ToLower(name)]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		teams, err := s.repo.FindTeams(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to look up teams %s (map them in jira.teams instead): %w", strings.Join(unknown, ", "), err)
+		}
+
+		for _, team := range teams {
+			if _, exists := s.teams[strings.ToLower(team.Title)]; !exists {
+				s.teams[strings.ToLower(team.Title)] = strconv.FormatInt(team.ID, 10)
+			}
+		}
+
+		var missing []string
+		for _, name := range unknown {
+			if _, ok := s.teams[strings.ToLower(name)]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("unknown teams: %s; add their IDs to jira.teams", strings.Join(missing, ", "))
+		}
+	}
+
+	for _, name := range names {
+		helpers.PrintInfo("Team %s -> %s", name, s.teams[strings.ToLower(name)])
+	}
+	return nil
+}
+
+// setTeam sets the team field to the resolved ID of team
+func (s *JiraService) setTeam(custom map[string]interface{}, team string) error {
+	if team == "" {
+		return nil
+	}
+
+	id, ok := s.teams[strings.ToLower(team)]
+	if !ok {
+		return fmt.Errorf("team '%s' has not been resolved", team)
+	}
+
+	custom[s.config.CustomFields[config.CustomFieldTeam]] = id
+	return nil
+}
+
+// CreateTicketsFromBreakdown creates JIRA tickets from a project breakdown, recording the keys
+// of created issues on it, and ranks the created epics and stories in the backlog. When the
+// context is cancelled it stops before the next issue.
+func (s *JiraService) CreateTicketsFromBreakdown(ctx context.Context, breakdown *models.ProjectBreakdown) error {
 	createdEpics := make(map[string]string) // epic title -> JIRA key
+	existing := issueKeys(breakdown)
+
+	initiativeKeys, err := s.createInitiatives(ctx, breakdown)
+	if err != nil {
+		return err
+	}
 
 	// Create epics first
-	for i, epic := range breakdown.Epics {
-		helpers.PrintProgress(i+1, len(breakdown.Epics), fmt.Sprintf("Creating epic: %s", epic.Title))
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
 
-		epicKey, err := s.CreateEpic(epic.Title, epic.Description, epic.Priority)
-		if err != nil {
-			return fmt.Errorf("failed to create epic '%s': %w", epic.Title, err)
+		// Epics created by an earlier run only need their remaining stories
+		epicKey := epic.Key
+		if epicKey != "" {
+			helpers.PrintInfo("Using existing epic %s: %s", epicKey, epic.Title)
+		} else {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("interrupted before creating epic '%s': %w", epic.Title, err)
+			}
+
+			helpers.PrintProgress(i+1, len(breakdown.Epics), fmt.Sprintf("Creating epic: %s", epic.Title))
+
+			key, err := s.CreateEpic(ctx, epic.Title, s.epicDescription(epic), epic.Priority, initiativeKeys[strings.ToLower(strings.TrimSpace(epic.Initiative))], s.epicTeam(epic), s.epicComponent(epic), append(s.idLabels(breakdown, epic.ID), epic.Labels...), epicFields(epic))
+			if err != nil {
+				s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeEpic, ItemID: epic.ID, Epic: epic.Title, Title: epic.Title, Err: err})
+				return fmt.Errorf("failed to create epic '%s': %w", epic.Title, err)
+			}
+
+			epicKey = key
+			epic.Key = key
+			s.events.Publish(models.IssueCreated{ItemType: models.ItemTypeEpic, ItemID: epic.ID, Epic: epic.Title, Title: epic.Title, Key: key})
+			s.markCreated(ctx, models.ItemTypeEpic, epic.ID, epic.Title, epic.Title, key)
+			s.attachSource(ctx, key)
+			s.linkSource(ctx, key)
 		}
 
 		createdEpics[epic.Title] = epicKey
-		helpers.PrintSuccess("Created epic: %s", epicKey)
 
-		// Create stories for this epic
-		for j, story := range epic.Stories {
-			helpers.PrintProgress(j+1, len(epic.Stories), fmt.Sprintf("Creating story: %s", story.Title))
+		// Create stories for this epic, in bulk where JIRA supports it
+		handled, err := s.createStoriesInBulk(ctx, breakdown, epic, epicKey)
+		if err != nil {
+			return err
+		}
+
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+
+			if story.Key == "" && !handled[j] {
+				if err := ctx.Err(); err != nil {
+					return fmt.Errorf("interrupted before creating story '%s': %w", story.Title, err)
+				}
+
+				helpers.PrintProgress(j+1, len(epic.Stories), fmt.Sprintf("Creating story: %s", story.Title))
+
+				storyKey, err := s.CreateTask(ctx, story.Title, s.storyDescription(breakdown, story), storyIssueType(s.issueTypes, story), story.Priority, epicKey, epic.Title, s.storyTeam(epic, story), story.Release, story.Assignee, s.epicComponent(epic), append(s.idLabels(breakdown, story.ID), story.Labels...), storyFields(story))
+				if err != nil {
+					s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeStory, ItemID: story.ID, Epic: epic.Title, Title: story.Title, Err: err})
+					continue
+				}
+
+				story.Key = storyKey
+				s.events.Publish(models.IssueCreated{ItemType: models.ItemTypeStory, ItemID: story.ID, Epic: epic.Title, Title: story.Title, Key: storyKey})
+				s.markCreated(ctx, models.ItemTypeStory, story.ID, epic.Title, story.Title, storyKey)
+				s.linkSource(ctx, storyKey)
+			}
 
-			// Format story description with acceptance criteria
-			fullDescription := story.Description + "\n\n*Acceptance Criteria:*\n"
-			for _, criteria := range story.AcceptanceCriteria {
-				fullDescription += "• " + criteria + "\n"
+			// Failed stories have no subtasks; a story created by an earlier run may still miss some
+			if story.Key == "" {
+				continue
+			}
+			if err := s.createSubtasks(ctx, breakdown, epic, story); err != nil {
+				return err
 			}
+		}
+	}
+
+	if err := s.createRisks(ctx, breakdown); err != nil {
+		return err
+	}
+
+	// The issues exist either way, so a backlog that cannot be ranked only warns
+	if err := s.RankBacklog(ctx, breakdown, existing); err != nil {
+		helpers.PrintWarning("%v", err)
+	}
+
+	helpers.PrintSuccess("JIRA tickets created successfully!")
+	return nil
+}
+
+// createSubtasks creates the subtasks of a created story that do not exist yet. A subtask that
+// fails is reported and skipped, like a story.
+func (s *JiraService) createSubtasks(ctx context.Context, breakdown *models.ProjectBreakdown, epic *models.Epic, story *models.Story) error {
+	for k := range story.Subtasks {
+		subtask := &story.Subtasks[k]
+		if subtask.Key != "" {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("interrupted before creating subtask '%s': %w", subtask.Title, err)
+		}
+
+		labels := mergeLabels(s.traceLabels(epic.Title, story.Title), s.idLabels(breakdown, subtask.ID))
+		key, err := s.CreateSubtask(ctx, subtask, story.Key, story.Priority, s.epicComponent(epic), labels)
+		if err != nil {
+			s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeSubtask, ItemID: subtask.ID, Epic: epic.Title, Title: subtask.Title, Err: err})
+			continue
+		}
+
+		subtask.Key = key
+		s.events.Publish(models.IssueCreated{ItemType: models.ItemTypeSubtask, ItemID: subtask.ID, Epic: epic.Title, Title: subtask.Title, Key: key})
+		s.markCreated(ctx, models.ItemTypeSubtask, subtask.ID, epic.Title, subtask.Title, key)
+	}
+	return nil
+}
+
+// createInitiatives creates the initiatives of a breakdown that do not exist yet as issues of the
+// jira.issue_type_mapping.initiative type, so their epics can link to them. Without that type
+// initiatives are only kept in the analysis. It returns the keys of all initiatives with an
+// issue by lowercase title.
+func (s *JiraService) createInitiatives(ctx context.Context, breakdown *models.ProjectBreakdown) (map[string]string, error) {
+	keys := make(map[string]string)
+	issueType := s.config.IssueTypeMapping.Initiative
+	if issueType == "" {
+		return keys, nil
+	}
 
-			if len(story.Dependencies) > 0 {
-				fullDescription += "\n*Dependencies:* " + strings.Join(story.Dependencies, ", ")
+	for i := range breakdown.Initiatives {
+		initiative := &breakdown.Initiatives[i]
+		if initiative.Key == "" {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("interrupted before creating initiative '%s': %w", initiative.Title, err)
 			}
 
-			storyKey, err := s.CreateTask(story.Title, fullDescription, story.Priority, epicKey)
+			helpers.PrintProgress(i+1, len(breakdown.Initiatives), fmt.Sprintf("Creating initiative: %s", initiative.Title))
+			key, err := s.CreateIssueOnce(ctx, initiative.Title, initiative.Description, issueType, "", "", nil, nil, nil)
 			if err != nil {
-				helpers.PrintWarning("Failed to create story '%s': %v", story.Title, err)
-				continue
+				s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeInitiative, ItemID: initiative.ID, Title: initiative.Title, Err: err})
+				return nil, fmt.Errorf("failed to create initiative '%s': %w", initiative.Title, err)
 			}
 
-			helpers.PrintSuccess("Created story: %s", storyKey)
+			initiative.Key = key
+			s.events.Publish(models.IssueCreated{ItemType: models.ItemTypeInitiative, ItemID: initiative.ID, Title: initiative.Title, Key: key})
+			s.markCreated(ctx, models.ItemTypeInitiative, initiative.ID, "", initiative.Title, key)
 		}
+		keys[strings.ToLower(strings.TrimSpace(initiative.Title))] = initiative.Key
 	}
+	return keys, nil
+}
 
-	helpers.PrintSuccess("JIRA tickets created successfully!")
+// createRisks creates the risks of a breakdown that do not exist yet as issues of the
+// jira.issue_type_mapping.risk type, with their impact as priority. Without that type risks are
+// only kept in the analysis. A risk that fails is reported and skipped, like a story.
+func (s *JiraService) createRisks(ctx context.Context, breakdown *models.ProjectBreakdown) error {
+	issueType := s.config.IssueTypeMapping.Risk
+	if issueType == "" {
+		return nil
+	}
+
+	for i := range breakdown.Risks {
+		risk := &breakdown.Risks[i]
+		if risk.Key != "" {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("interrupted before creating risk '%s': %w", risk.Title, err)
+		}
+
+		helpers.PrintProgress(i+1, len(breakdown.Risks), fmt.Sprintf("Creating risk: %s", risk.Title))
+		key, err := s.CreateIssueOnce(ctx, risk.Title, riskDescription(risk), issueType, risk.Impact, "", nil, nil, nil)
+		if err != nil {
+			s.events.Publish(models.IssueFailed{ItemType: models.ItemTypeRisk, ItemID: risk.ID, Title: risk.Title, Err: err})
+			continue
+		}
+
+		risk.Key = key
+		s.events.Publish(models.IssueCreated{ItemType: models.ItemTypeRisk, ItemID: risk.ID, Title: risk.Title, Key: key})
+		s.markCreated(ctx, models.ItemTypeRisk, risk.ID, "", risk.Title, key)
+	}
 	return nil
 }
+
+// Rollback removes every issue a run ledger recorded: risks first, then subtasks before their
+// stories, stories before their epics and epics before their initiatives. With status set, issues
+// are moved to that workflow status instead of deleted. Rolled back issues are marked in the
+// ledger so the run can be rolled back again after a failure. With dryRun the issues are only
+// listed. It returns the number of issues rolled back.
+func (s *JiraService) Rollback(ctx context.Context, ledger *repositories.LedgerRepository, status string, dryRun bool) (int, error) {
+	entries := ledger.Ledger().Entries
+
+	// Risks stand alone; then every level goes before the one above it, so subtasks, stories and
+	// epics are removed before the stories, epics and initiatives they belong to, each level
+	// newest first
+	var ordered []models.LedgerEntry
+	for _, itemType := range []string{models.ItemTypeRisk, models.ItemTypeSubtask, models.ItemTypeStory, models.ItemTypeEpic, models.ItemTypeInitiative} {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].ItemType == itemType && !entries[i].RolledBack {
+				ordered = append(ordered, entries[i])
+			}
+		}
+	}
+
+	action := "Delete"
+	if status != "" {
+		action = fmt.Sprintf("Move to '%s'", status)
+	}
+
+	rolledBack := 0
+	for _, entry := range ordered {
+		if dryRun {
+			helpers.PrintInfo("[DRY RUN] %s %s %s: %s", action, entry.ItemType, entry.Key, entry.Title)
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return rolledBack, fmt.Errorf("rollback interrupted: %w", err)
+		}
+
+		var err error
+		if status != "" {
+			err = s.transitionTo(context.WithoutCancel(ctx), entry.Key, status)
+		} else {
+			err = s.repo.DeleteIssue(context.WithoutCancel(ctx), entry.Key)
+		}
+
+		switch {
+		case errors.Is(err, repositories.ErrIssueNotFound):
+			helpers.PrintWarning("%s no longer exists, skipping", entry.Key)
+		case err != nil:
+			return rolledBack, fmt.Errorf("failed to roll back %s: %w", entry.Key, err)
+		default:
+			helpers.PrintSuccess("Rolled back %s %s: %s", entry.ItemType, entry.Key, entry.Title)
+		}
+
+		rolledBack++
+		if err := ledger.MarkRolledBack(entry.Key); err != nil {
+			helpers.PrintWarning("Failed to update run ledger: %v", err)
+		}
+	}
+
+	if dryRun {
+		return len(ordered), nil
+	}
+	return rolledBack, nil
+}
+
+// transitionTo moves an issue to the named status using one of its available transitions
+func (s *JiraService) transitionTo(ctx context.Context, issueKey, status string) error {
+	transitions, err := s.repo.GetTransitions(ctx, issueKey)
+	if err != nil {
+		return err
+	}
+
+	for _, transition := range transitions {
+		if strings.EqualFold(transition.To.Name, status) || strings.EqualFold(transition.Name, status) {
+			return s.repo.TransitionIssue(ctx, issueKey, transition.ID)
+		}
+	}
+
+	return fmt.Errorf("no transition to status '%s' is available", status)
+}
+
+// epicDescription formats the JIRA description of an epic
+func (s *JiraService) epicDescription(epic *models.Epic) string {
+	return epic.Description + formatDone(s.config.DefinitionOfDone.Epic) + s.formatRationale(epic.Rationale)
+}
+
+// storyDescription formats the JIRA description of a story with its acceptance criteria, or of a
+// spike with its timebox and questions, dependencies on other stories of the breakdown and the
+// requirement it is traced to
+func (s *JiraService) storyDescription(breakdown *models.ProjectBreakdown, story *models.Story) string {
+	description := story.Description + "\n\n*Acceptance Criteria:*\n"
+	if story.Type == models.StoryTypeSpike {
+		// A spike answers questions within its timebox rather than delivering behaviour
+		description = fmt.Sprintf("%s\n\n*Timebox:* %s\n\n*Questions to Answer:*\n", story.Description, s.config.SpikeTimebox)
+		for _, question := range story.AcceptanceCriteria {
+			description += "• " + question + "\n"
+		}
+	} else if breakdown.CriteriaFormat == config.CriteriaGherkin {
+		// A code block QA can copy into a Cucumber feature file
+		description += "```gherkin\nFeature: " + story.Title + "\n\n" + strings.Join(story.AcceptanceCriteria, "\n\n") + "\n```\n"
+	} else {
+		for _, criteria := range story.AcceptanceCriteria {
+			description += "• " + criteria + "\n"
+		}
+	}
+
+	if len(story.Dependencies) > 0 {
+		var dependencies []string
+		for _, dependency := range story.Dependencies {
+			dependencies = append(dependencies, dependencyLabel(breakdown, dependency))
+		}
+		description += "\n*Dependencies:* " + strings.Join(dependencies, ", ")
+	}
+
+	if story.Requirement != "" {
+		description += "\n*Requirement:* " + story.Requirement + " " + story.Section
+	}
+
+	if done := formatDone(s.config.DefinitionOfDone.Story); done != "" {
+		description = strings.TrimRight(description, "\n") + done
+	}
+	return description + s.formatRationale(story.Rationale)
+}
+
+// formatDone formats a definition of done checklist for a JIRA description
+func formatDone(checklist []string) string {
+	if len(checklist) == 0 {
+		return ""
+	}
+	return "\n\n*Definition of Done:*\n• " + strings.Join(checklist, "\n• ")
+}
+
+// riskDescription formats the JIRA description of a risk with its rating and mitigation
+func riskDescription(risk *models.Risk) string {
+	return fmt.Sprintf("%s\n\n*Likelihood:* %s | *Impact:* %s\n\n*Mitigation:* %s", risk.Description, risk.Likelihood, risk.Impact, risk.Mitigation)
+}
+
+// subtaskDescription formats the JIRA description of a subtask with its component
+func subtaskDescription(subtask *models.Subtask) string {
+	if subtask.Component == "" {
+		return subtask.Description
+	}
+	return strings.TrimSpace(fmt.Sprintf("*Component:* %s\n\n%s", subtask.Component, subtask.Description))
+}
+
+// storyTeam returns the team of a story, falling back to the team of its epic
+func (s *JiraService) storyTeam(epic *models.Epic, story *models.Story) string {
+	if story.Team != "" {
+		return story.Team
+	}
+	return s.epicTeam(epic)
+}
+
+// epicTeam returns the team of an epic, falling back to the default team
+func (s *JiraService) epicTeam(epic *models.Epic) string {
+	if epic.Team != "" {
+		return epic.Team
+	}
+	return s.config.Team
+}
+
+// markCreated marks an issue created in a run with a ledger with an entity property naming its
+// run and item so later runs can verify it
+func (s *JiraService) markCreated(ctx context.Context, itemType, itemID, epic, title, key string) {
+	if s.ledger == nil {
+		return
+	}
+
+	ledger := s.ledger.Ledger()
+	err := s.repo.SetIssueProperty(context.WithoutCancel(ctx), key, models.IssuePropertyKey, models.IssueProperty{
+		RunID:        ledger.RunID,
+		AnalysisFile: ledger.AnalysisFile,
+		ItemID:       itemID,
+		ItemType:     itemType,
+		Epic:         epic,
+		Title:        title,
+	})
+	if err != nil {
+		helpers.PrintWarning("Failed to set the %s property on %s: %v", models.IssuePropertyKey, key, err)
+	}
+}
+
+// formatRationale renders the AI rationale as a collapsed section when enabled in config
+func (s *JiraService) formatRationale(rationale string) string {
+	if !s.config.IncludeRationale || rationale == "" {
+		return ""
+	}
+	return "\n\n{expand:Why this breakdown?}\n" + rationale + "\n{expand}"
+}
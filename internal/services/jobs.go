@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// ErrJobNotFound is returned for an unknown job ID
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrTooManyJobs is returned when server.max_jobs analyses are already running
+var ErrTooManyJobs = errors.New("too many analyses are running, try again later")
+
+// jobPruneInterval is how often finished jobs and old uploads are looked for
+const jobPruneInterval = 10 * time.Minute
+
+// JobService runs analyses of uploaded documents in the background for the server's analysis
+// API. Jobs keep their progress events so clients can reconnect and resume streaming them. At
+// most server.max_jobs run at a time, and jobs and uploads are removed server.retention_hours
+// after they finished or were created.
+type JobService struct {
+	ctx     context.Context
+	config  *config.Config
+	uploads *repositories.UploadRepository
+
+	mu      sync.Mutex
+	jobs    map[string]*jobState
+	running int
+}
+
+// jobState is a job with its events. changed is closed, and replaced, whenever an event is added.
+type jobState struct {
+	mu      sync.Mutex
+	job     models.Job
+	events  []models.JobEvent
+	changed chan struct{}
+}
+
+// NewJobService creates a job service. Running jobs are cancelled, and pruning stops, when ctx
+// is done.
+func NewJobService(ctx context.Context, config *config.Config, uploads *repositories.UploadRepository) *JobService {
+	service := &JobService{
+		ctx:     ctx,
+		config:  config,
+		uploads: uploads,
+		jobs:    make(map[string]*jobState),
+	}
+
+	go func() {
+		ticker := time.NewTicker(jobPruneInterval)
+		defer ticker.Stop()
+
+		for {
+			service.prune()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return service
+}
+
+// Start analyzes a completely uploaded document in the background
+func (s *JobService) Start(uploadID string) (*models.Job, error) {
+	upload, err := s.uploads.Get(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if !upload.Complete() {
+		return nil, fmt.Errorf("upload %s is incomplete: %d of %d bytes received", upload.ID, upload.Offset, upload.Size)
+	}
+
+	id, err := helpers.RandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	state := &jobState{
+		job: models.Job{
+			ID:               id,
+			UploadID:         upload.ID,
+			Name:             upload.Name,
+			Status:           models.JobRunning,
+			CreatedAt:        now,
+			HeartbeatAt:      now,
+			HeartbeatSeconds: s.config.Server.HeartbeatSeconds,
+		},
+		changed: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	if s.running >= s.config.Server.MaxJobs {
+		s.mu.Unlock()
+		return nil, ErrTooManyJobs
+	}
+	s.running++
+	s.jobs[id] = state
+	s.mu.Unlock()
+
+	state.publish(models.EventJobStarted, map[string]interface{}{"name": upload.Name, "size": upload.Size})
+	go s.run(state, s.uploads.Path(upload.ID))
+
+	job := state.snapshot()
+	return &job, nil
+}
+
+// Job returns the current state of a job
+func (s *JobService) Job(id string) (*models.Job, error) {
+	state, err := s.state(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job := state.snapshot()
+	return &job, nil
+}
+
+// Events returns the events of a job after the one numbered after, and a channel that is closed
+// when the next event is added. Once the job has finished no further events follow.
+func (s *JobService) Events(id string, after int) ([]models.JobEvent, <-chan struct{}, *models.Job, error) {
+	state, err := s.state(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	var events []models.JobEvent
+	if after < len(state.events) {
+		events = append(events, state.events[max(after, 0):]...)
+	}
+
+	job := state.job
+	return events, state.changed, &job, nil
+}
+
+// state looks up a job
+func (s *JobService) state(id string) (*jobState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	return state, nil
+}
+
+// prune removes the jobs that finished, and the uploads created, longer than the retention ago.
+// Uploads of running jobs are kept.
+func (s *JobService) prune() {
+	cutoff := time.Now().Add(-time.Duration(s.config.Server.RetentionHours) * time.Hour)
+
+	s.mu.Lock()
+	active := make(map[string]bool)
+	for id, state := range s.jobs {
+		job := state.snapshot()
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			continue
+		}
+		if job.FinishedAt == nil {
+			active[job.UploadID] = true
+		}
+	}
+	s.mu.Unlock()
+
+	removed, err := s.uploads.Prune(cutoff, active)
+	if err != nil {
+		helpers.PrintWarning("Failed to remove old uploads: %v", err)
+	}
+	if removed > 0 {
+		helpers.PrintInfo("Removed %d uploads older than %d hours", removed, s.config.Server.RetentionHours)
+	}
+}
+
+// run analyzes a document, renewing the job's heartbeat until it is done
+func (s *JobService) run(state *jobState, inputFile string) {
+	done := make(chan struct{})
+	defer close(done)
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.config.Server.HeartbeatSeconds) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				state.heartbeat()
+			}
+		}
+	}()
+
+	analysisFile, breakdown, err := s.analyze(state, inputFile)
+	if err != nil {
+		helpers.PrintError("Job %s failed: %v", state.job.ID, err)
+		state.end(models.JobFailed, func(job *models.Job) {
+			job.Error = err.Error()
+		}, models.EventJobFailed, map[string]string{"error": err.Error()})
+		return
+	}
+
+	helpers.PrintSuccess("Job %s finished: %s", state.job.ID, analysisFile)
+	state.end(models.JobSucceeded, func(job *models.Job) {
+		job.AnalysisFile = analysisFile
+	}, models.EventJobSucceeded, map[string]interface{}{
+		"analysis_file": analysisFile,
+		"epics":         len(breakdown.Epics),
+		"stories":       breakdown.TotalStories,
+		"story_points":  breakdown.TotalStoryPoints,
+	})
+}
+
+// analyze runs the analysis of a job, passing its progress events on to the job. Every job
+// analyzes with its own copy of the configuration, so the front matter of one document does not
+// apply to the others.
+func (s *JobService) analyze(state *jobState, inputFile string) (string, *models.ProjectBreakdown, error) {
+	cfg := s.config.Clone()
+	analysisService, err := NewAnalysisService(cfg)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create analysis service: %w", err)
+	}
+	analysisService.Events().Subscribe(func(event models.Event) {
+		state.publish(event.EventType(), event)
+	})
+
+	breakdown, err := analysisService.ProcessProject(s.ctx, inputFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to process project: %w", err)
+	}
+
+	analysisFile, err := analysisService.SaveAnalysisResult(breakdown, cfg.Processing.OutputDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to save analysis result: %w", err)
+	}
+	return analysisFile, breakdown, nil
+}
+
+// publish adds an event to a job and wakes up its progress streams
+func (state *jobState) publish(eventType string, data interface{}) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.add(eventType, data)
+}
+
+// end records the outcome of a job together with its last event, so a progress stream never
+// sees a finished job without that event
+func (state *jobState) end(status string, update func(job *models.Job), eventType string, data interface{}) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	now := time.Now()
+	state.job.Status = status
+	state.job.FinishedAt = &now
+	update(&state.job)
+	state.add(eventType, data)
+}
+
+// add appends an event and wakes up everyone waiting for it. The caller holds state.mu.
+func (state *jobState) add(eventType string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		helpers.PrintWarning("Failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	state.events = append(state.events, models.JobEvent{
+		ID:   len(state.events) + 1,
+		Type: eventType,
+		Time: time.Now(),
+		Data: encoded,
+	})
+	state.job.Events = len(state.events)
+
+	close(state.changed)
+	state.changed = make(chan struct{})
+}
+
+// heartbeat renews the heartbeat of a running job
+func (state *jobState) heartbeat() {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.job.HeartbeatAt = time.Now()
+}
+
+// snapshot returns a copy of the job
+func (state *jobState) snapshot() models.Job {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.job
+}
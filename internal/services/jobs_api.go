@@ -0,0 +1,284 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// UploadOffsetHeader carries the offset a chunk starts at, and the offset reached after it
+const UploadOffsetHeader = "Upload-Offset"
+
+// uploadIdleTimeout is how long a chunk may send nothing before it is cut off, so a stalled
+// client does not keep its upload locked
+const uploadIdleTimeout = 30 * time.Second
+
+// JobHandler serves the analysis API of server mode:
+//
+//	POST  /api/uploads             start an upload: {"name": ..., "size": ...}
+//	GET   /api/uploads/{id}        the upload with the offset to continue from
+//	PATCH /api/uploads/{id}        append the request body at the Upload-Offset header
+//	POST  /api/jobs                analyze a complete upload: {"upload_id": ...}
+//	GET   /api/jobs/{id}           the job with its status and heartbeat
+//	GET   /api/jobs/{id}/events    progress as server-sent events, resumed after Last-Event-ID
+//	GET   /api/jobs/{id}/result    the analysis file of a finished job
+//
+// Every request must carry the configured API token as a bearer token.
+type JobHandler struct {
+	jobs    *JobService
+	uploads *repositories.UploadRepository
+	config  *config.ServerConfig
+	mux     *http.ServeMux
+}
+
+// NewJobHandler creates the handler of the analysis API
+func NewJobHandler(jobs *JobService, uploads *repositories.UploadRepository, config *config.ServerConfig) *JobHandler {
+	h := &JobHandler{jobs: jobs, uploads: uploads, config: config, mux: http.NewServeMux()}
+
+	h.mux.HandleFunc("POST /api/uploads", h.createUpload)
+	h.mux.HandleFunc("GET /api/uploads/{id}", h.getUpload)
+	h.mux.HandleFunc("PATCH /api/uploads/{id}", h.appendUpload)
+	h.mux.HandleFunc("POST /api/jobs", h.startJob)
+	h.mux.HandleFunc("GET /api/jobs/{id}", h.getJob)
+	h.mux.HandleFunc("GET /api/jobs/{id}/events", h.streamEvents)
+	h.mux.HandleFunc("GET /api/jobs/{id}/result", h.getResult)
+	return h
+}
+
+// ServeHTTP checks the API token and routes the request
+func (h *JobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	expected := "Bearer " + h.config.APIToken
+	if h.config.APIToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+		writeAPIError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *JobHandler) createUpload(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid upload request")
+		return
+	}
+
+	maxSize := int64(h.config.MaxUploadMB) << 20
+	if request.Size <= 0 || request.Size > maxSize {
+		writeAPIError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("size must be between 1 byte and %d MB", h.config.MaxUploadMB))
+		return
+	}
+
+	upload, err := h.uploads.Create(request.Name, request.Size)
+	if err != nil {
+		helpers.PrintError("Failed to create upload: %v", err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to create upload")
+		return
+	}
+
+	helpers.PrintInfo("Upload %s started: %s (%d bytes)", upload.ID, upload.Name, upload.Size)
+	w.Header().Set("Location", "/api/uploads/"+upload.ID)
+	writeAPIJSON(w, http.StatusCreated, upload)
+}
+
+func (h *JobHandler) getUpload(w http.ResponseWriter, r *http.Request) {
+	upload, err := h.uploads.Get(r.PathValue("id"))
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set(UploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	writeAPIJSON(w, http.StatusOK, upload)
+}
+
+// appendUpload writes a chunk. A chunk that does not start at the current offset is rejected
+// with 409 and the current offset, so the client can continue from there. A chunk that stalls for
+// uploadIdleTimeout is cut off, keeping the bytes received until then.
+func (h *JobHandler) appendUpload(w http.ResponseWriter, r *http.Request) {
+	offset, err := strconv.ParseInt(r.Header.Get(UploadOffsetHeader), 10, 64)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "missing or invalid "+UploadOffsetHeader+" header")
+		return
+	}
+
+	body := &idleReader{reader: r.Body, controller: http.NewResponseController(w), timeout: uploadIdleTimeout}
+	upload, err := h.uploads.Append(r.PathValue("id"), offset, body)
+	if upload != nil {
+		w.Header().Set(UploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	}
+
+	switch {
+	case errors.Is(err, repositories.ErrUploadOffset):
+		writeAPIJSON(w, http.StatusConflict, upload)
+	case err != nil:
+		writeUploadError(w, err)
+	default:
+		writeAPIJSON(w, http.StatusOK, upload)
+	}
+}
+
+func (h *JobHandler) startJob(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		UploadID string `json:"upload_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid job request")
+		return
+	}
+
+	job, err := h.jobs.Start(request.UploadID)
+	if errors.Is(err, repositories.ErrUploadNotFound) {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, ErrTooManyJobs) {
+		w.Header().Set("Retry-After", "60")
+		writeAPIError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	helpers.PrintInfo("Job %s started for upload %s", job.ID, job.UploadID)
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	writeAPIJSON(w, http.StatusAccepted, job)
+}
+
+func (h *JobHandler) getJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Job(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, job)
+}
+
+// streamEvents sends a job's events as server-sent events, starting after the Last-Event-ID
+// header or the "after" query parameter, until the job has finished. Heartbeats keep the
+// connection open and tell the client the job is alive while the AI is working.
+func (h *JobHandler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	after := r.Header.Get("Last-Event-ID")
+	if after == "" {
+		after = r.URL.Query().Get("after")
+	}
+	last, _ := strconv.Atoi(after)
+
+	events, changed, job, err := h.jobs.Events(r.PathValue("id"), last)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(time.Duration(h.config.HeartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		for _, event := range events {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			last = event.ID
+		}
+		flusher.Flush()
+
+		if job.Finished() {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			current, err := h.jobs.Job(job.ID)
+			if err != nil {
+				return
+			}
+			data, _ := json.Marshal(map[string]interface{}{"status": current.Status, "heartbeat_at": current.HeartbeatAt})
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", models.EventHeartbeat, data)
+			events = nil
+		case <-changed:
+			if events, changed, job, err = h.jobs.Events(job.ID, last); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *JobHandler) getResult(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Job(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if job.Status != models.JobSucceeded {
+		writeAPIError(w, http.StatusConflict, fmt.Sprintf("job is %s", job.Status))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	http.ServeFile(w, r, job.AnalysisFile)
+}
+
+// writeUploadError answers a failed upload request
+func writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrUploadNotFound):
+		writeAPIError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrUploadTooLarge):
+		writeAPIError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, repositories.ErrUploadBusy):
+		writeAPIError(w, http.StatusLocked, err.Error())
+	default:
+		helpers.PrintError("Upload failed: %v", err)
+		writeAPIError(w, http.StatusInternalServerError, "upload failed")
+	}
+}
+
+// idleReader reads a request body, moving the connection's read deadline timeout ahead before
+// every read
+type idleReader struct {
+	reader     io.Reader
+	controller *http.ResponseController
+	timeout    time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	// Connections that cannot have deadlines, such as in tests, are read without one
+	r.controller.SetReadDeadline(time.Now().Add(r.timeout))
+	return r.reader.Read(p)
+}
+
+// writeAPIJSON writes a JSON response
+func writeAPIJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeAPIError writes a JSON error response
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/providers"
+	"scrum-master/internal/repositories"
+)
+
+// sectionProvider answers every prompt with an epic for each of its sections the prompt contains
+type sectionProvider struct {
+	sections []string
+}
+
+func (p *sectionProvider) Name() string  { return "jobs-test" }
+func (p *sectionProvider) Model() string { return "jobs-test" }
+
+func (p *sectionProvider) Analyze(ctx context.Context, prompt string) (string, error) {
+	var epics []string
+	for i, section := range p.sections {
+		if strings.Contains(prompt, section) {
+			epics = append(epics, fmt.Sprintf(`{"title": %q, "description": %q, "priority": "High", "stories": [{"title": "Story %d", "description": "As a buyer I want it", "story_points": 3, "priority": "High", "acceptance_criteria": ["It works"]}]}`, section, section, i+1))
+		}
+	}
+	return fmt.Sprintf(`{"project_name": "Shop", "overview": "Shop", "epics": [%s]}`, strings.Join(epics, ", ")), nil
+}
+
+func (p *sectionProvider) Chat(ctx context.Context, messages []models.ChatMessage) (string, error) {
+	return p.Analyze(ctx, messages[len(messages)-1].Content)
+}
+
+func TestJobServiceFrontMatter(t *testing.T) {
+	provider := &sectionProvider{sections: []string{"Card payments", "Parcel tracking"}}
+	providers.Register("jobs-test", func(cfg *config.Config) (providers.AIProvider, error) {
+		return provider, nil
+	})
+
+	cfg := config.Defaults()
+	cfg.Provider = "jobs-test"
+	cfg.Jira.ProjectKey = "SHOP"
+	cfg.Processing.OutputDir = t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	uploads := repositories.NewUploadRepository(cfg.Processing.OutputDir)
+	service := NewJobService(ctx, cfg, uploads)
+
+	body := "# Payments\n\nCard payments\n\n# Shipping\n\nParcel tracking\n"
+	tests := []struct {
+		name        string
+		frontMatter string
+		wantKey     string
+		wantEpics   []string
+	}{
+		{
+			name:        "sections chosen by the document",
+			frontMatter: "project_key: PAY\ninclude_sections: [Payments]",
+			wantKey:     "PAY",
+			wantEpics:   []string{"Card payments"},
+		},
+		{
+			name:        "other project of the same server",
+			frontMatter: "project_key: SHIP",
+			wantKey:     "SHIP",
+			wantEpics:   []string{"Card payments", "Parcel tracking"},
+		},
+	}
+
+	// The second job runs after the first, on the configuration the first one left behind
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := "---\n" + tt.frontMatter + "\n---\n" + body
+			upload, err := uploads.Create("doc.md", int64(len(document)))
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := uploads.Append(upload.ID, 0, strings.NewReader(document)); err != nil {
+				t.Fatalf("Append() error = %v", err)
+			}
+			started, err := service.Start(upload.ID)
+			if err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			job := waitForJob(t, service, started.ID)
+			if job.Status != models.JobSucceeded {
+				t.Fatalf("job status = %s (%s), want %s", job.Status, job.Error, models.JobSucceeded)
+			}
+
+			var result models.AnalysisResult
+			if err := helpers.LoadJSON(job.AnalysisFile, &result); err != nil {
+				t.Fatalf("LoadJSON() error = %v", err)
+			}
+			var epics []string
+			for _, epic := range result.ProjectBreakdown.Epics {
+				epics = append(epics, epic.Title)
+			}
+			slices.Sort(epics)
+			if !slices.Equal(epics, tt.wantEpics) {
+				t.Errorf("job epics = %q, want %q", epics, tt.wantEpics)
+			}
+			if result.Directives == nil || result.Directives.ProjectKey != tt.wantKey {
+				t.Errorf("job directives = %+v, want project key %s", result.Directives, tt.wantKey)
+			}
+		})
+	}
+
+	if cfg.Jira.ProjectKey != "SHOP" || cfg.Processing.IncludeSections != nil {
+		t.Errorf("server config changed to project key %s and sections %q", cfg.Jira.ProjectKey, cfg.Processing.IncludeSections)
+	}
+}
+
+// waitForJob returns a job once it has finished
+func waitForJob(t *testing.T, service *JobService, id string) *models.Job {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job, err := service.Job(id)
+		if err != nil {
+			t.Fatalf("Job() error = %v", err)
+		}
+		if job.Status != models.JobRunning {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still running", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
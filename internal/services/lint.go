@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// investCriteria are the INVEST criteria in the order they are scored
+var investCriteria = []string{
+	models.InvestIndependent, models.InvestNegotiable, models.InvestValuable,
+	models.InvestEstimable, models.InvestSmall, models.InvestTestable,
+}
+
+// Limits of the INVEST checks
+const (
+	lintMaxDependencies = 2 // more dependencies make a story hard to schedule on its own
+	lintMaxCriteria     = 8 // more acceptance criteria leave little to negotiate
+)
+
+// fibonacciPoints are the story point values of analyses that do not record their estimation scale
+var fibonacciPoints = []int{1, 2, 3, 5, 8, 13, 21}
+
+// vagueCriterion matches words that make an acceptance criterion impossible to test. Like the
+// "so that" of a benefit, they are only looked for in English stories.
+var vagueCriterion = regexp.MustCompile(`(?i)\b(fast|quickly|easy|easily|user-friendly|intuitive|appropriate|as needed|etc)\b`)
+
+// LintOptions are the quality gates of lint
+type LintOptions struct {
+	MaxPoints int // stories above this many points fail
+	MinScore  int // stories scoring below this fail (0 = no score gate)
+}
+
+// LintBreakdown scores every story of a breakdown against the INVEST criteria. Stories above
+// MaxPoints, or of size L in a Kanban breakdown, or without acceptance criteria fail the quality
+// gates, as do stories scoring below MinScore. The checks of English wording are skipped for
+// stories written in another processing.language.
+func LintBreakdown(breakdown *models.ProjectBreakdown, options LintOptions) *models.LintReport {
+	scale := breakdown.EstimationScale
+	if len(scale) == 0 {
+		scale = fibonacciPoints
+	}
+
+	report := &models.LintReport{}
+	total := 0
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			lint := lintStory(&story, breakdown.Methodology == config.MethodologyKanban, isEnglish(breakdown.Language), scale, options)
+			if lint.Failed {
+				report.Failed++
+			}
+			total += lint.Score
+			report.Stories = append(report.Stories, lint)
+		}
+	}
+
+	if len(report.Stories) > 0 {
+		report.AverageScore = float64(total) / float64(len(report.Stories))
+	}
+	return report
+}
+
+// lintStory scores a story against the INVEST criteria
+func lintStory(story *models.Story, kanban, english bool, scale []int, options LintOptions) models.StoryLint {
+	var findings []models.LintFinding
+	find := func(criterion string, gate bool, message string, args ...interface{}) {
+		findings = append(findings, models.LintFinding{Criterion: criterion, Message: fmt.Sprintf(message, args...), Gate: gate})
+	}
+
+	if len(story.Dependencies) > lintMaxDependencies {
+		find(models.InvestIndependent, false, "depends on %d other stories", len(story.Dependencies))
+	}
+	if len(story.AcceptanceCriteria) > lintMaxCriteria {
+		find(models.InvestNegotiable, false, "%d acceptance criteria leave little to negotiate", len(story.AcceptanceCriteria))
+	}
+	if english && !strings.Contains(strings.ToLower(story.Description), "so that") {
+		find(models.InvestValuable, false, "the description names no benefit (\"so that ...\")")
+	}
+	switch {
+	case !kanban:
+		if !slices.Contains(scale, story.StoryPoints) {
+			find(models.InvestEstimable, false, "%d story points is not on the estimation scale", story.StoryPoints)
+		}
+		if story.StoryPoints > options.MaxPoints {
+			find(models.InvestSmall, true, "%d story points is more than %d; split the story", story.StoryPoints, options.MaxPoints)
+		}
+	case story.Size == sizeClasses[len(sizeClasses)-1]:
+		// Kanban stories are not estimated; the largest size is too big to flow
+		find(models.InvestSmall, true, "size %s takes more than a week; split the story", story.Size)
+	}
+	if len(story.AcceptanceCriteria) == 0 {
+		find(models.InvestTestable, true, "no acceptance criteria")
+	}
+	for _, criterion := range story.AcceptanceCriteria {
+		if word := vagueCriterion.FindString(criterion); english && word != "" {
+			find(models.InvestTestable, false, "\"%s\" is hard to test in: %s", word, criterion)
+		}
+	}
+
+	missed := make(map[string]bool)
+	failed := false
+	for _, finding := range findings {
+		missed[finding.Criterion] = true
+		failed = failed || finding.Gate
+	}
+	score := len(investCriteria) - len(missed)
+
+	return models.StoryLint{
+		StoryID:  story.ID,
+		Story:    story.Title,
+		Score:    score,
+		Findings: findings,
+		Failed:   failed || score < options.MinScore,
+	}
+}
+
+// isEnglish tells whether a processing.language, as a code or a name, is English
+func isEnglish(language string) bool {
+	language = strings.ToLower(strings.TrimSpace(language))
+	return language == "" || language == "en" || strings.HasPrefix(language, "en-") || strings.HasPrefix(language, "en_") || language == "english"
+}
+
+// DisplayLintReport lists the INVEST score and findings of every story
+func DisplayLintReport(report *models.LintReport) {
+	for _, story := range report.Stories {
+		line := fmt.Sprintf("%s %s: %d/%d", story.StoryID, story.Story, story.Score, len(investCriteria))
+		switch {
+		case story.Failed:
+			helpers.PrintError("%s", line)
+		case len(story.Findings) > 0:
+			helpers.PrintWarning("%s", line)
+		default:
+			helpers.PrintSuccess("%s", line)
+		}
+		for _, finding := range story.Findings {
+			helpers.PrintInfo("    %s: %s", finding.Criterion, finding.Message)
+		}
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("Average INVEST score: %.1f of %d", report.AverageScore, len(investCriteria))
+	if report.Failed > 0 {
+		helpers.PrintError("%d of %d stories fail the quality gates", report.Failed, len(report.Stories))
+	} else {
+		helpers.PrintSuccess("All %d stories pass the quality gates", len(report.Stories))
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+)
+
+func TestLintBreakdown(t *testing.T) {
+	// good is a story that meets every INVEST criterion
+	good := models.Story{
+		ID:                 "E1-S1",
+		Title:              "Pay by card",
+		Description:        "As a buyer I pay by card so that I need no account",
+		StoryPoints:        3,
+		AcceptanceCriteria: []string{"Visa and Mastercard are accepted"},
+	}
+	with := func(change func(story *models.Story)) models.Story {
+		story := good
+		story.AcceptanceCriteria = slices.Clone(good.AcceptanceCriteria)
+		change(&story)
+		return story
+	}
+
+	tests := []struct {
+		name         string
+		breakdown    models.ProjectBreakdown
+		options      LintOptions
+		wantScore    int
+		wantFailed   bool
+		wantCriteria []string // Criteria of the findings, in order
+	}{
+		{
+			name:      "good story",
+			breakdown: models.ProjectBreakdown{Epics: []models.Epic{{Stories: []models.Story{good}}}},
+			options:   LintOptions{MaxPoints: 8},
+			wantScore: 6,
+		},
+		{
+			name: "findings that pass the gates",
+			breakdown: models.ProjectBreakdown{Epics: []models.Epic{{Stories: []models.Story{with(func(story *models.Story) {
+				story.Description = "As a buyer I pay by card"
+				story.StoryPoints = 4
+				story.Dependencies = []string{"E1-S2", "E1-S3", "E2-S1"}
+				story.AcceptanceCriteria = append(story.AcceptanceCriteria, "Payment is fast")
+			})}}}},
+			options:      LintOptions{MaxPoints: 8},
+			wantScore:    2,
+			wantCriteria: []string{models.InvestIndependent, models.InvestValuable, models.InvestEstimable, models.InvestTestable},
+		},
+		{
+			name: "oversized story fails the gate",
+			breakdown: models.ProjectBreakdown{Epics: []models.Epic{{Stories: []models.Story{with(func(story *models.Story) {
+				story.StoryPoints = 13
+			})}}}},
+			options:      LintOptions{MaxPoints: 8},
+			wantScore:    5,
+			wantFailed:   true,
+			wantCriteria: []string{models.InvestSmall},
+		},
+		{
+			name: "story without criteria fails the gate",
+			breakdown: models.ProjectBreakdown{Epics: []models.Epic{{Stories: []models.Story{with(func(story *models.Story) {
+				story.AcceptanceCriteria = nil
+			})}}}},
+			options:      LintOptions{MaxPoints: 8},
+			wantScore:    5,
+			wantFailed:   true,
+			wantCriteria: []string{models.InvestTestable},
+		},
+		{
+			name:         "score below the minimum fails",
+			breakdown:    models.ProjectBreakdown{Epics: []models.Epic{{Stories: []models.Story{with(func(story *models.Story) { story.StoryPoints = 4 })}}}},
+			options:      LintOptions{MaxPoints: 8, MinScore: 6},
+			wantScore:    5,
+			wantFailed:   true,
+			wantCriteria: []string{models.InvestEstimable},
+		},
+		{
+			name: "estimation scale of the breakdown",
+			breakdown: models.ProjectBreakdown{EstimationScale: []int{1, 2, 4, 8}, Epics: []models.Epic{{Stories: []models.Story{with(func(story *models.Story) {
+				story.StoryPoints = 4
+			})}}}},
+			options:   LintOptions{MaxPoints: 8},
+			wantScore: 6,
+		},
+		{
+			name: "kanban stories sized instead of estimated",
+			breakdown: models.ProjectBreakdown{Methodology: config.MethodologyKanban, Epics: []models.Epic{{Stories: []models.Story{
+				with(func(story *models.Story) { story.StoryPoints, story.Size = 0, "L" }),
+			}}}},
+			options:      LintOptions{MaxPoints: 8},
+			wantScore:    5,
+			wantFailed:   true,
+			wantCriteria: []string{models.InvestSmall},
+		},
+		{
+			name: "English wording not checked in other languages",
+			breakdown: models.ProjectBreakdown{Language: "de", Epics: []models.Epic{{Stories: []models.Story{with(func(story *models.Story) {
+				story.Description = "Als Käufer zahle ich mit Karte, damit ich kein Konto brauche"
+				story.AcceptanceCriteria = []string{"Die Zahlung ist etc"}
+			})}}}},
+			options:   LintOptions{MaxPoints: 8},
+			wantScore: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := LintBreakdown(&tt.breakdown, tt.options)
+			if len(report.Stories) != 1 {
+				t.Fatalf("LintBreakdown() linted %d stories, want 1", len(report.Stories))
+			}
+			lint := report.Stories[0]
+
+			var criteria []string
+			for _, finding := range lint.Findings {
+				criteria = append(criteria, finding.Criterion)
+			}
+			if lint.Score != tt.wantScore || lint.Failed != tt.wantFailed || !slices.Equal(criteria, tt.wantCriteria) {
+				t.Errorf("LintBreakdown() = score %d, failed %v, findings %q, want score %d, failed %v, findings %q",
+					lint.Score, lint.Failed, criteria, tt.wantScore, tt.wantFailed, tt.wantCriteria)
+			}
+			if failed := report.Failed == 1; failed != tt.wantFailed {
+				t.Errorf("LintBreakdown() failed stories = %d, want failed %v", report.Failed, tt.wantFailed)
+			}
+		})
+	}
+}
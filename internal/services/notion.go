@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+	"scrum-master/internal/transport"
+)
+
+// notionID matches the ID of a Notion page or database, with or without dashes, as given or at
+// the end of its URL
+var notionID = regexp.MustCompile(`[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}`)
+
+// NotionService reads Notion pages and databases as input documents
+type NotionService struct {
+	config *config.NotionConfig
+	repo   *repositories.NotionRepository
+}
+
+// NewNotionService creates a new Notion service
+func NewNotionService(cfg *config.Config) *NotionService {
+	return &NotionService{
+		config: &cfg.Notion,
+		repo:   repositories.NewNotionRepository(&cfg.Notion, &cfg.HTTP),
+	}
+}
+
+// FetchPage reads a page or database, given by ID or URL, and saves it as markdown in the output
+// directory for analysis. A database becomes one document with a section per page. It returns
+// the path of the markdown file and the web address of the page.
+func (s *NotionService) FetchPage(ctx context.Context, ref, outputDir string) (string, string, error) {
+	if s.config.APIToken == "" {
+		return "", "", fmt.Errorf("notion API token is required (notion.api_token, or 'scrum-master auth login notion.api_token')")
+	}
+
+	id, err := notionPageID(ref)
+	if err != nil {
+		return "", "", err
+	}
+
+	var title, content, pageURL string
+	page, err := s.repo.GetPage(ctx, id)
+	if err == nil {
+		title, pageURL = page.Title(), page.URL
+		content, err = s.pageMarkdown(ctx, id, 1)
+	} else if notionNotPage(err) {
+		// Database IDs look like page IDs, and the pages API refuses them
+		title, content, pageURL, err = s.databaseMarkdown(ctx, id, err)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	saved, err := saveInput(outputDir, "md", fmt.Sprintf("# %s\n\n%s", title, content))
+	if err != nil {
+		return "", "", err
+	}
+
+	helpers.PrintSuccess("Fetched Notion page '%s' to: %s", title, saved)
+	return saved, pageURL, nil
+}
+
+// notionPageID returns the ID of the page or database a page ID or URL refers to. In a URL the
+// ID ends the path, and the query may hold the ID of a database view.
+func notionPageID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	path := ref
+	if parsed, err := url.Parse(ref); err == nil && parsed.Host != "" {
+		path = parsed.Path
+	}
+
+	ids := notionID.FindAllString(path, -1)
+	if len(ids) == 0 {
+		return "", fmt.Errorf("'%s' is not a Notion page ID or page URL", ref)
+	}
+	return strings.ReplaceAll(ids[len(ids)-1], "-", ""), nil
+}
+
+// notionNotPage reports whether a page request failed because the ID may be that of a database
+func notionNotPage(err error) bool {
+	var statusErr *transport.StatusError
+	return errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusBadRequest || statusErr.StatusCode == http.StatusNotFound)
+}
+
+// databaseMarkdown returns the title, content and address of a database, with a section per page.
+// When the ID is no database either, it returns pageErr, the error of reading it as a page.
+func (s *NotionService) databaseMarkdown(ctx context.Context, id string, pageErr error) (string, string, string, error) {
+	database, err := s.repo.GetDatabase(ctx, id)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to read Notion page %s: %w", id, pageErr)
+	}
+
+	pages, err := s.repo.QueryDatabase(ctx, id)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to read the pages of database '%s': %w", models.NotionPlainText(database.Title), err)
+	}
+
+	var sections []string
+	for _, page := range pages {
+		content, err := s.pageMarkdown(ctx, page.ID, 2)
+		if err != nil {
+			return "", "", "", err
+		}
+		sections = append(sections, strings.TrimRight(fmt.Sprintf("## %s\n\n%s", page.Title(), content), "\n"))
+	}
+	return models.NotionPlainText(database.Title), strings.Join(sections, "\n\n") + "\n", database.URL, nil
+}
+
+// pageMarkdown returns the content of a page as markdown, its headings below the given level
+func (s *NotionService) pageMarkdown(ctx context.Context, pageID string, level int) (string, error) {
+	blocks, err := s.readBlocks(ctx, pageID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the content of Notion page %s: %w", pageID, err)
+	}
+	return notionMarkdown(blocks, "", level) + "\n", nil
+}
+
+// readBlocks returns the blocks of a page or block with their children. Child pages and
+// databases are separate documents and are not read.
+func (s *NotionService) readBlocks(ctx context.Context, parentID string) ([]models.NotionBlock, error) {
+	blocks, err := s.repo.GetBlockChildren(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range blocks {
+		block := &blocks[i]
+		if !block.HasChildren || block.Type == "child_page" || block.Type == "child_database" {
+			continue
+		}
+		if block.Children, err = s.readBlocks(ctx, block.ID); err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// notionMarkdown converts blocks into markdown. Items of the same list are kept together and the
+// children of list items are indented below them; the children of other blocks, such as toggles
+// and callouts, follow them. Headings are shifted below level, the level of the page title.
+func notionMarkdown(blocks []models.NotionBlock, indent string, level int) string {
+	var out strings.Builder
+	number := 0
+	previousItem := false
+
+	for _, block := range blocks {
+		text := notionText(block.Content.RichText)
+		item, nested := false, ""
+		var markdown string
+
+		if block.Type != "numbered_list_item" {
+			number = 0
+		}
+
+		switch block.Type {
+		case "heading_1", "heading_2", "heading_3":
+			markdown = strings.Repeat("#", min(level+int(block.Type[len(block.Type)-1]-'0'), 6)) + " " + text
+		case "paragraph", "toggle":
+			markdown = text
+		case "bulleted_list_item":
+			item, markdown = true, "- "+text
+		case "numbered_list_item":
+			number++
+			item, markdown = true, fmt.Sprintf("%d. %s", number, text)
+		case "to_do":
+			box := "[ ]"
+			if block.Content.Checked {
+				box = "[x]"
+			}
+			item, markdown = true, "- "+box+" "+text
+		case "quote", "callout":
+			markdown = "> " + strings.ReplaceAll(text, "\n", "\n> ")
+		case "code":
+			markdown = "```" + block.Content.Language + "\n" + models.NotionPlainText(block.Content.RichText) + "\n```"
+		case "equation":
+			markdown = "```\n" + block.Content.Expression + "\n```"
+		case "divider":
+			markdown = "---"
+		case "table":
+			markdown = notionTable(block)
+		case "bookmark", "embed", "link_preview":
+			markdown = block.Content.URL
+		case "child_page", "child_database", "image", "video", "audio", "file", "pdf", "table_of_contents", "breadcrumb":
+		default:
+			markdown = text
+		}
+
+		// The children of list items are nested in them; those of other blocks follow them
+		if len(block.Children) > 0 && block.Type != "table" {
+			if item {
+				markdown += "\n" + notionMarkdown(block.Children, strings.Repeat(" ", strings.Index(markdown, " ")+1), level)
+			} else {
+				nested = notionMarkdown(block.Children, "", level)
+			}
+		}
+
+		for _, part := range []string{markdown, nested} {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			if out.Len() > 0 {
+				if item && previousItem {
+					out.WriteString("\n")
+				} else {
+					out.WriteString("\n\n")
+				}
+			}
+			out.WriteString(part)
+			previousItem = item
+			item = false
+		}
+	}
+
+	if indent == "" {
+		return out.String()
+	}
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notionTable converts a table block with its rows into a markdown table whose first row is the
+// header
+func notionTable(table models.NotionBlock) string {
+	var lines []string
+	for i, row := range table.Children {
+		var cells []string
+		for _, cell := range row.Content.Cells {
+			cells = append(cells, strings.ReplaceAll(strings.ReplaceAll(notionText(cell), "|", `\|`), "\n", " "))
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat("---|", len(cells)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notionText converts rich text into markdown. Spaces at the edges of formatted runs are moved
+// outside of the markers, which markdown does not allow inside them.
+func notionText(text []models.NotionRichText) string {
+	var out strings.Builder
+	for _, run := range text {
+		content := strings.TrimSpace(run.PlainText)
+		if content == "" {
+			out.WriteString(run.PlainText)
+			continue
+		}
+		lead := run.PlainText[:strings.Index(run.PlainText, content)]
+		trail := run.PlainText[len(lead)+len(content):]
+
+		annotations := run.Annotations
+		switch {
+		case annotations.Code:
+			content = "`" + content + "`"
+		default:
+			if annotations.Bold {
+				content = "**" + content + "**"
+			}
+			if annotations.Italic {
+				content = "*" + content + "*"
+			}
+			if annotations.Strikethrough {
+				content = "~~" + content + "~~"
+			}
+		}
+		if run.Href != "" {
+			content = "[" + content + "](" + run.Href + ")"
+		}
+		out.WriteString(lead + content + trail)
+	}
+	return out.String()
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"testing"
+
+	"scrum-master/internal/models"
+)
+
+// notionRun returns an unformatted run of rich text
+func notionRun(text string) models.NotionRichText {
+	return models.NotionRichText{PlainText: text}
+}
+
+// notionBlock returns a block of the given type with its text as one unformatted run
+func notionBlock(blockType, text string, children ...models.NotionBlock) models.NotionBlock {
+	var richText []models.NotionRichText
+	if text != "" {
+		richText = []models.NotionRichText{notionRun(text)}
+	}
+	return models.NotionBlock{Type: blockType, Content: models.NotionBlockContent{RichText: richText}, Children: children}
+}
+
+func TestNotionText(t *testing.T) {
+	bold := models.NotionAnnotations{Bold: true}
+	tests := []struct {
+		name string
+		text []models.NotionRichText
+		want string
+	}{
+		{
+			name: "plain runs",
+			text: []models.NotionRichText{notionRun("Pay "), notionRun("now")},
+			want: "Pay now",
+		},
+		{
+			name: "annotations combined",
+			text: []models.NotionRichText{{PlainText: "all", Annotations: models.NotionAnnotations{Bold: true, Italic: true, Strikethrough: true}}},
+			want: "~~***all***~~",
+		},
+		{
+			name: "code ignores other annotations",
+			text: []models.NotionRichText{{PlainText: "go test", Annotations: models.NotionAnnotations{Code: true, Bold: true}}},
+			want: "`go test`",
+		},
+		{
+			name: "spaces moved outside of markers",
+			text: []models.NotionRichText{notionRun("Pay"), {PlainText: " now ", Annotations: bold}, notionRun("or later")},
+			want: "Pay **now** or later",
+		},
+		{
+			name: "formatted whitespace kept as is",
+			text: []models.NotionRichText{notionRun("Pay"), {PlainText: " ", Annotations: bold}, notionRun("now")},
+			want: "Pay now",
+		},
+		{
+			name: "link around formatting",
+			text: []models.NotionRichText{notionRun("See "), {PlainText: "the spec ", Href: "https://example.com/spec", Annotations: bold}, notionRun("first")},
+			want: "See [**the spec**](https://example.com/spec) first",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notionText(tt.text); got != tt.want {
+				t.Errorf("notionText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotionMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []models.NotionBlock
+		level  int
+		want   string
+	}{
+		{
+			name:   "headings shifted below the page title",
+			blocks: []models.NotionBlock{notionBlock("heading_1", "Checkout"), notionBlock("paragraph", "Pay by card."), notionBlock("heading_3", "Refunds")},
+			level:  1,
+			want:   "## Checkout\n\nPay by card.\n\n#### Refunds",
+		},
+		{
+			name:   "heading levels capped at six",
+			blocks: []models.NotionBlock{notionBlock("heading_3", "Deep")},
+			level:  4,
+			want:   "###### Deep",
+		},
+		{
+			name: "nested lists",
+			blocks: []models.NotionBlock{
+				notionBlock("bulleted_list_item", "Cards", notionBlock("bulleted_list_item", "Visa"), notionBlock("bulleted_list_item", "Mastercard")),
+				notionBlock("bulleted_list_item", "Invoice"),
+				notionBlock("numbered_list_item", "Order"),
+				notionBlock("numbered_list_item", "Pay", notionBlock("numbered_list_item", "Confirm")),
+			},
+			want: "- Cards\n  - Visa\n  - Mastercard\n- Invoice\n1. Order\n2. Pay\n   1. Confirm",
+		},
+		{
+			name: "numbering restarts after other blocks",
+			blocks: []models.NotionBlock{
+				notionBlock("numbered_list_item", "One"),
+				notionBlock("numbered_list_item", "Two"),
+				notionBlock("paragraph", "Break"),
+				notionBlock("numbered_list_item", "One again"),
+			},
+			want: "1. One\n2. Two\n\nBreak\n\n1. One again",
+		},
+		{
+			name: "to-dos",
+			blocks: []models.NotionBlock{
+				{Type: "to_do", Content: models.NotionBlockContent{RichText: []models.NotionRichText{notionRun("Write spec")}, Checked: true}},
+				notionBlock("to_do", "Review", notionBlock("paragraph", "By Friday")),
+			},
+			want: "- [x] Write spec\n- [ ] Review\n  By Friday",
+		},
+		{
+			name:   "children of toggles follow them",
+			blocks: []models.NotionBlock{notionBlock("toggle", "Details", notionBlock("paragraph", "Hidden text")), notionBlock("paragraph", "After")},
+			want:   "Details\n\nHidden text\n\nAfter",
+		},
+		{
+			name: "quotes, code and dividers",
+			blocks: []models.NotionBlock{
+				notionBlock("quote", "Keep it\nsimple"),
+				notionBlock("divider", ""),
+				{Type: "code", Content: models.NotionBlockContent{
+					RichText: []models.NotionRichText{{PlainText: "if a < b {\n\treturn\n}", Annotations: models.NotionAnnotations{Code: true}}},
+					Language: "go",
+				}},
+			},
+			want: "> Keep it\n> simple\n\n---\n\n```go\nif a < b {\n\treturn\n}\n```",
+		},
+		{
+			name: "table with header row",
+			blocks: []models.NotionBlock{{Type: "table", Children: []models.NotionBlock{
+				{Type: "table_row", Content: models.NotionBlockContent{Cells: [][]models.NotionRichText{{notionRun("Field")}, {notionRun("Rule")}}}},
+				{Type: "table_row", Content: models.NotionBlockContent{Cells: [][]models.NotionRichText{{notionRun("Email")}, {notionRun("Required | unique\nalways")}}}},
+			}}},
+			want: "| Field | Rule |\n|---|---|\n| Email | Required \\| unique always |",
+		},
+		{
+			name: "media and child pages left out",
+			blocks: []models.NotionBlock{
+				notionBlock("image", ""),
+				notionBlock("paragraph", "Intro"),
+				{Type: "child_page", Content: models.NotionBlockContent{Title: "Appendix"}},
+				{Type: "bookmark", Content: models.NotionBlockContent{URL: "https://example.com"}},
+				notionBlock("paragraph", ""),
+			},
+			want: "Intro\n\nhttps://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notionMarkdown(tt.blocks, "", tt.level); got != tt.want {
+				t.Errorf("notionMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+)
+
+// modelPrices are the list prices of known models in USD per million tokens, keyed by model name
+// prefix. Dated and suffixed model versions match the longest prefix.
+var modelPrices = map[string]config.PricingConfig{
+	"claude-opus-4":     {InputPerMillion: 15, OutputPerMillion: 75},
+	"claude-opus-4-5":   {InputPerMillion: 5, OutputPerMillion: 25},
+	"claude-sonnet-4":   {InputPerMillion: 3, OutputPerMillion: 15},
+	"claude-3-7-sonnet": {InputPerMillion: 3, OutputPerMillion: 15},
+	"claude-3-5-sonnet": {InputPerMillion: 3, OutputPerMillion: 15},
+	"claude-haiku-4-5":  {InputPerMillion: 1, OutputPerMillion: 5},
+	"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4},
+	"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+	"gpt-4o":            {InputPerMillion: 2.50, OutputPerMillion: 10},
+	"gpt-4o-mini":       {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4.1":           {InputPerMillion: 2, OutputPerMillion: 8},
+	"gpt-4.1-mini":      {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	"gpt-4.1-nano":      {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gemini-1.5-pro":    {InputPerMillion: 1.25, OutputPerMillion: 5},
+	"gemini-1.5-flash":  {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+	"gemini-2.0-flash":  {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gemini-2.5-pro":    {InputPerMillion: 1.25, OutputPerMillion: 10},
+	"gemini-2.5-flash":  {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+}
+
+// modelPricing returns the prices a run is charged: the configured processing.pricing, or the
+// list price of the model when none is configured. Local and unknown models cost nothing.
+func modelPricing(providerName, model string, configured config.PricingConfig) config.PricingConfig {
+	if configured.InputPerMillion > 0 || configured.OutputPerMillion > 0 {
+		return configured
+	}
+	if providerName == "ollama" {
+		return config.PricingConfig{}
+	}
+
+	var pricing config.PricingConfig
+	longest := 0
+	for prefix, prices := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longest {
+			pricing, longest = prices, len(prefix)
+		}
+	}
+	return pricing
+}
+
+// Prices of prompt cache writes and reads relative to the input price, as Anthropic bills its
+// five-minute cache
+const (
+	cacheWritePrice = 1.25
+	cacheReadPrice  = 0.10
+)
+
+// cost returns the cost in USD of a number of input and output tokens
+func cost(pricing config.PricingConfig, inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*pricing.InputPerMillion + float64(outputTokens)*pricing.OutputPerMillion) / 1e6
+}
+
+// cacheCost returns the cost in USD of input tokens written to and read from the prompt cache
+func cacheCost(pricing config.PricingConfig, writeTokens, readTokens int) float64 {
+	return (float64(writeTokens)*cacheWritePrice + float64(readTokens)*cacheReadPrice) * pricing.InputPerMillion / 1e6
+}
+
+// usageCost returns the cost in USD of the usage of a run
+func usageCost(pricing config.PricingConfig, usage models.TokenUsage) float64 {
+	return cost(pricing, usage.InputTokens, usage.OutputTokens) + cacheCost(pricing, usage.CacheWriteTokens, usage.CacheReadTokens)
+}
+
+// cacheSavings returns what the prompt cache saved compared to billing the cached tokens at the
+// input price. Writing to the cache costs more, so a run that reads too little from it to make up
+// for the writes saves nothing.
+func cacheSavings(pricing config.PricingConfig, writeTokens, readTokens int) float64 {
+	return max(0, float64(writeTokens+readTokens)*pricing.InputPerMillion/1e6-cacheCost(pricing, writeTokens, readTokens))
+}
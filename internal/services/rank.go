@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// issueKeys returns the keys of the epics and stories of a breakdown that have a JIRA issue
+func issueKeys(breakdown *models.ProjectBreakdown) map[string]bool {
+	keys := make(map[string]bool)
+	for _, epic := range breakdown.Epics {
+		if epic.Key != "" {
+			keys[epic.Key] = true
+		}
+		for _, story := range epic.Stories {
+			if story.Key != "" {
+				keys[story.Key] = true
+			}
+		}
+	}
+	return keys
+}
+
+// RankBacklog orders the epics and stories of a breakdown that were created after existing
+// was taken: epics by priority, and stories by the priority of their epic, their own priority
+// and then their story points, smallest first. Issues that existed before keep their rank, so
+// a backlog the team has ordered is not reshuffled.
+func (s *JiraService) RankBacklog(ctx context.Context, breakdown *models.ProjectBreakdown, existing map[string]bool) error {
+	epics := make([]*models.Epic, len(breakdown.Epics))
+	for i := range breakdown.Epics {
+		epics[i] = &breakdown.Epics[i]
+	}
+	sort.SliceStable(epics, func(i, j int) bool {
+		return priorityRanks[normalizePriority(epics[i].Priority)] > priorityRanks[normalizePriority(epics[j].Priority)]
+	})
+
+	var epicKeys, storyKeys []string
+	for _, epic := range epics {
+		if epic.Key != "" && !existing[epic.Key] {
+			epicKeys = append(epicKeys, epic.Key)
+		}
+
+		stories := make([]*models.Story, len(epic.Stories))
+		for i := range epic.Stories {
+			stories[i] = &epic.Stories[i]
+		}
+		sort.SliceStable(stories, func(i, j int) bool {
+			a, b := priorityRanks[normalizePriority(stories[i].Priority)], priorityRanks[normalizePriority(stories[j].Priority)]
+			if a != b {
+				return a > b
+			}
+			return stories[i].StoryPoints < stories[j].StoryPoints
+		})
+		for _, story := range stories {
+			if story.Key != "" && !existing[story.Key] {
+				storyKeys = append(storyKeys, story.Key)
+			}
+		}
+	}
+
+	if err := s.repo.RankIssues(ctx, epicKeys); err != nil {
+		return fmt.Errorf("failed to rank epics: %w", err)
+	}
+	if err := s.repo.RankIssues(ctx, storyKeys); err != nil {
+		return fmt.Errorf("failed to rank stories: %w", err)
+	}
+	if len(storyKeys) > 1 {
+		helpers.PrintSuccess("Ranked %d stories in the backlog by priority and story points", len(storyKeys))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// VerifyLedger checks every issue recorded in a run ledger against JIRA. Issues that were
+// deleted are marked in the ledger so they are created again; issues that were moved, renamed or
+// lost their scrum-master property are reported as drift but kept.
+func (s *JiraService) VerifyLedger(ctx context.Context, ledger *repositories.LedgerRepository) ([]models.LedgerDrift, error) {
+	var drifts []models.LedgerDrift
+
+	for _, entry := range ledger.Ledger().Entries {
+		if entry.RolledBack || entry.Deleted {
+			continue
+		}
+
+		issue, err := s.repo.GetIssueWithProperty(ctx, entry.Key, models.IssuePropertyKey)
+		if errors.Is(err, repositories.ErrIssueNotFound) {
+			drifts = append(drifts, models.LedgerDrift{Entry: entry, Kind: models.DriftDeleted, Detail: "will be created again"})
+			if err := ledger.MarkDeleted(entry.Key); err != nil {
+				return drifts, err
+			}
+			continue
+		}
+		if err != nil {
+			return drifts, fmt.Errorf("failed to verify %s: %w", entry.Key, err)
+		}
+
+		if issue.Key != "" && issue.Key != entry.Key {
+			drifts = append(drifts, models.LedgerDrift{Entry: entry, Kind: models.DriftMoved, Detail: fmt.Sprintf("now %s", issue.Key)})
+		}
+
+		if !strings.EqualFold(strings.TrimSpace(issue.Fields.Summary), strings.TrimSpace(entry.Title)) {
+			drifts = append(drifts, models.LedgerDrift{Entry: entry, Kind: models.DriftRenamed, Detail: fmt.Sprintf("summary is now '%s'", issue.Fields.Summary)})
+		}
+
+		var property models.IssueProperty
+		raw, exists := issue.Properties[models.IssuePropertyKey]
+		switch {
+		case !exists:
+			drifts = append(drifts, models.LedgerDrift{Entry: entry, Kind: models.DriftUnmarked, Detail: fmt.Sprintf("has no %s property", models.IssuePropertyKey)})
+		case json.Unmarshal(raw, &property) != nil || !sameItem(property, entry):
+			drifts = append(drifts, models.LedgerDrift{Entry: entry, Kind: models.DriftUnmarked, Detail: fmt.Sprintf("%s property belongs to another item", models.IssuePropertyKey)})
+		}
+	}
+
+	return drifts, nil
+}
+
+// sameItem reports whether an issue property names the item of a ledger entry, by ID when both
+// have one and by titles otherwise
+func sameItem(property models.IssueProperty, entry models.LedgerEntry) bool {
+	if property.ItemID != "" && entry.ItemID != "" {
+		return property.ItemID == entry.ItemID
+	}
+	return property.Epic == entry.Epic && property.Title == entry.Title
+}
+
+// DisplayLedgerDrift lists the differences between a run ledger and JIRA
+func DisplayLedgerDrift(drifts []models.LedgerDrift) {
+	if len(drifts) == 0 {
+		helpers.PrintSuccess("Every recorded issue still exists unchanged")
+		return
+	}
+
+	for _, drift := range drifts {
+		helpers.PrintWarning("%s %s (%s): %s, %s", drift.Entry.ItemType, drift.Entry.Key, drift.Entry.Title, drift.Kind, drift.Detail)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// planReleases assigns the stories of a breakdown to releases: those of jira.releases and of the
+// analysis being extended, or with processing.suggest_releases and no releases yet, releases the
+// AI proposes. A failed planning pass leaves the stories without a release.
+func (s *AnalysisService) planReleases(ctx context.Context, breakdown *models.ProjectBreakdown) error {
+	releases := append([]models.Release(nil), s.releases...)
+	for _, configured := range s.config.Jira.Releases {
+		if findRelease(releases, configured.Name) < 0 {
+			releases = append(releases, models.Release{Name: configured.Name, Description: configured.Description, ReleaseDate: configured.ReleaseDate})
+		}
+	}
+	if len(releases) == 0 && !s.config.Processing.SuggestReleases {
+		return nil
+	}
+
+	helpers.PrintInfo("Planning releases...")
+	plan, err := s.aiService.PlanReleases(ctx, breakdown, releases)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		helpers.PrintWarning("Release planning failed, stories are left without a release: %v", err)
+		return nil
+	}
+
+	var stories []*models.Story
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			stories = append(stories, &breakdown.Epics[i].Stories[j])
+		}
+	}
+
+	proposed := len(releases) == 0
+	for _, planned := range plan.Releases {
+		index := findRelease(releases, planned.Name)
+		switch {
+		case index < 0 && proposed && planned.Name != "":
+			releases = append(releases, planned.Release)
+			index = len(releases) - 1
+		case index < 0:
+			helpers.PrintWarning("Ignoring unknown release '%s' in the release plan", planned.Name)
+			continue
+		}
+
+		for _, ref := range planned.Stories {
+			n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(ref), "S"))
+			if err != nil || n < 1 || n > len(stories) {
+				continue
+			}
+			if stories[n-1].Release == "" {
+				stories[n-1].Release = releases[index].Name
+			}
+		}
+	}
+
+	unassigned := 0
+	for _, story := range stories {
+		if story.Release == "" {
+			unassigned++
+		}
+	}
+	if unassigned > 0 {
+		helpers.PrintWarning("%d stories were not assigned to a release", unassigned)
+	}
+
+	breakdown.Releases = releases
+	return nil
+}
+
+// releaseLabel returns the name of a release with its date
+func releaseLabel(release models.Release) string {
+	if release.ReleaseDate == "" {
+		return release.Name
+	}
+	return fmt.Sprintf("%s (%s)", release.Name, release.ReleaseDate)
+}
+
+// findRelease returns the index of the release with the given name, or -1
+func findRelease(releases []models.Release, name string) int {
+	for i, release := range releases {
+		if strings.EqualFold(release.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResolveReleases finds the JIRA version of every release stories are assigned to, creating the
+// versions the project does not have yet with the description and date of the release. When the
+// project's versions cannot be read, fix versions are set by name.
+func (s *JiraService) ResolveReleases(ctx context.Context, breakdown *models.ProjectBreakdown) error {
+	var names []string
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			if story.Release != "" && !containsFold(names, story.Release) {
+				names = append(names, story.Release)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	versions, err := s.repo.GetVersions(ctx, s.config.ProjectKey)
+	if err != nil {
+		helpers.PrintWarning("Failed to read the versions of project %s, setting fix versions by name: %v", s.config.ProjectKey, err)
+		return nil
+	}
+
+	s.versions = make(map[string]string)
+	for _, version := range versions {
+		s.versions[strings.ToLower(version.Name)] = version.ID
+		if version.Released && containsFold(names, version.Name) {
+			helpers.PrintWarning("Version %s of project %s is already released", version.Name, s.config.ProjectKey)
+		}
+	}
+
+	for _, name := range names {
+		if id, exists := s.versions[strings.ToLower(name)]; exists {
+			helpers.PrintInfo("Release %s -> version %s", name, id)
+			continue
+		}
+
+		version := models.JiraVersion{Name: name, Project: s.config.ProjectKey}
+		if index := findRelease(breakdown.Releases, name); index >= 0 {
+			version.Description = breakdown.Releases[index].Description
+			version.ReleaseDate = breakdown.Releases[index].ReleaseDate
+		}
+
+		created, err := s.repo.CreateVersion(ctx, &version)
+		if err != nil {
+			return fmt.Errorf("failed to create version '%s': %w", name, err)
+		}
+		s.versions[strings.ToLower(name)] = created.ID
+		helpers.PrintSuccess("Created version %s in project %s", name, s.config.ProjectKey)
+	}
+	return nil
+}
+
+// setRelease sets the fix version of an issue to the version of release
+func (s *JiraService) setRelease(custom map[string]interface{}, release string) error {
+	if release == "" {
+		return nil
+	}
+
+	version := models.JiraVersion{Name: release}
+	if s.versions != nil {
+		id, ok := s.versions[strings.ToLower(release)]
+		if !ok {
+			return fmt.Errorf("release '%s' has not been resolved", release)
+		}
+		version = models.JiraVersion{ID: id}
+	}
+
+	custom["fixVersions"] = []models.JiraVersion{version}
+	return nil
+}
+
+// containsFold reports whether values contain value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// remoteChunkSize is the size of the chunks a document is uploaded in
+const remoteChunkSize = 1 << 20
+
+// remoteAttempts is how many consecutive failures an upload or progress stream survives
+const remoteAttempts = 5
+
+// RemoteService runs analyses on a scrum-master server. Interrupted uploads continue from the
+// last byte the server received and lost progress streams reconnect after the last event seen,
+// so a flaky network does not restart a long analysis.
+type RemoteService struct {
+	config *config.Config
+	repo   *repositories.ServerRepository
+}
+
+// NewRemoteService creates a client for the server at serverURL, authenticated with
+// server.api_token
+func NewRemoteService(config *config.Config, serverURL string) *RemoteService {
+	return &RemoteService{
+		config: config,
+		repo:   repositories.NewServerRepository(serverURL, config.Server.APIToken, &config.HTTP),
+	}
+}
+
+// Process uploads a document, follows its analysis on the server and saves the result to the
+// output directory. It returns the saved analysis file and its breakdown.
+func (s *RemoteService) Process(ctx context.Context, inputFile string) (string, *models.ProjectBreakdown, error) {
+	uploadID, err := s.upload(ctx, inputFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to upload %s: %w", inputFile, err)
+	}
+
+	job, err := s.repo.StartJob(ctx, uploadID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start analysis: %w", err)
+	}
+	helpers.PrintInfo("Analysis job %s started on the server", job.ID)
+
+	job, err = s.follow(ctx, job)
+	if err != nil {
+		return "", nil, err
+	}
+	if job.Status != models.JobSucceeded {
+		return "", nil, fmt.Errorf("analysis failed on the server: %s", job.Error)
+	}
+
+	data, err := s.repo.DownloadResult(ctx, job.ID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download analysis: %w", err)
+	}
+
+	var result models.AnalysisResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", nil, fmt.Errorf("invalid analysis from server: %w", err)
+	}
+
+	if err := helpers.EnsureDir(s.config.Processing.OutputDir); err != nil {
+		return "", nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	path := filepath.Join(s.config.Processing.OutputDir, filepath.Base(job.AnalysisFile))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", nil, fmt.Errorf("failed to save analysis: %w", err)
+	}
+	return path, &result.ProjectBreakdown, nil
+}
+
+// upload sends a document in chunks and returns its upload ID. After a failed chunk it asks the
+// server how much arrived and continues from there.
+func (s *RemoteService) upload(ctx context.Context, inputFile string) (string, error) {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	upload, err := s.repo.CreateUpload(ctx, filepath.Base(inputFile), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	chunks := (len(data) + remoteChunkSize - 1) / remoteChunkSize
+	failures := 0
+	for !upload.Complete() {
+		end := min(upload.Offset+remoteChunkSize, upload.Size)
+		helpers.PrintProgress(int(upload.Offset/remoteChunkSize)+1, chunks, fmt.Sprintf("Uploading %s", upload.Name))
+
+		next, err := s.repo.AppendUpload(ctx, upload.ID, upload.Offset, data[upload.Offset:end])
+		if err == nil || errors.Is(err, repositories.ErrOffsetMismatch) {
+			upload = next
+			failures = 0
+			continue
+		}
+
+		if failures++; failures >= remoteAttempts {
+			return "", err
+		}
+		helpers.PrintWarning("Upload interrupted at %d of %d bytes, resuming: %v", upload.Offset, upload.Size, err)
+		if err := helpers.Sleep(ctx, retryDelay(failures)); err != nil {
+			return "", err
+		}
+
+		if current, err := s.repo.GetUpload(ctx, upload.ID); err == nil {
+			upload = current
+		}
+	}
+
+	helpers.PrintSuccess("Uploaded %s (%d bytes)", upload.Name, upload.Size)
+	return upload.ID, nil
+}
+
+// follow prints the progress of a job until it has finished. A progress stream that breaks off,
+// or stays silent for three heartbeats of the server, is reopened after the last event received.
+func (s *RemoteService) follow(ctx context.Context, started *models.Job) (*models.Job, error) {
+	jobID := started.ID
+	heartbeat := time.Duration(started.HeartbeatSeconds) * time.Second
+	if heartbeat <= 0 {
+		// Servers that do not report their interval are assumed to use ours
+		heartbeat = time.Duration(s.config.Server.HeartbeatSeconds) * time.Second
+	}
+	last := 0
+	failures := 0
+
+	for {
+		streamCtx, cancel := context.WithCancel(ctx)
+		watchdog := time.AfterFunc(3*heartbeat, cancel)
+
+		err := s.repo.StreamEvents(streamCtx, jobID, last, func(event models.JobEvent) {
+			watchdog.Reset(3 * heartbeat)
+			failures = 0
+			if event.ID > 0 {
+				last = event.ID
+			}
+			printJobEvent(event, heartbeat)
+		})
+		watchdog.Stop()
+		cancel()
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("stopped following job %s, which keeps running on the server: %w", jobID, ctx.Err())
+		}
+
+		job, jobErr := s.repo.GetJob(ctx, jobID)
+		if jobErr == nil && job.Finished() && job.Events <= last {
+			return job, nil
+		}
+		if err == nil {
+			err = jobErr
+		}
+		if err == nil {
+			err = errors.New("stream ended before the job finished")
+		}
+
+		if failures++; failures >= remoteAttempts {
+			return nil, fmt.Errorf("lost the connection to job %s, which keeps running on the server: %w", jobID, err)
+		}
+		helpers.PrintWarning("Progress stream interrupted after event %d, reconnecting: %v", last, err)
+		if err := helpers.Sleep(ctx, retryDelay(failures)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// printJobEvent prints the progress a job event reports. A heartbeat the server has not renewed
+// for three intervals means the job may be stuck.
+func printJobEvent(event models.JobEvent, heartbeat time.Duration) {
+	switch event.Type {
+	case models.EventChunkAnalyzed:
+		var e models.ChunkAnalyzed
+		if json.Unmarshal(event.Data, &e) == nil {
+			printEvent(e)
+		}
+	case models.EventEpicMerged:
+		var e models.EpicMerged
+		if json.Unmarshal(event.Data, &e) == nil {
+			printEvent(e)
+		}
+	case models.EventJobStarted:
+		helpers.PrintInfo("Analyzing on the server...")
+	case models.EventJobSucceeded:
+		helpers.PrintSuccess("Analysis finished on the server")
+	case models.EventJobFailed:
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(event.Data, &e)
+		helpers.PrintError("Analysis failed on the server: %s", e.Error)
+	case models.EventHeartbeat:
+		var e struct {
+			HeartbeatAt time.Time `json:"heartbeat_at"`
+		}
+		if json.Unmarshal(event.Data, &e) == nil && time.Since(e.HeartbeatAt) > 3*heartbeat {
+			helpers.PrintWarning("The job's last heartbeat was %s ago; the server may be stuck", time.Since(e.HeartbeatAt).Round(time.Second))
+		}
+	}
+}
+
+// retryDelay returns the wait before the given retry, doubling from two seconds
+func retryDelay(attempt int) time.Duration {
+	return time.Duration(1<<min(attempt, 5)) * time.Second
+}
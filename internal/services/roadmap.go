@@ -0,0 +1,254 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// roadmapDateLayout is the date format of roadmaps and of Mermaid gantt diagrams
+const roadmapDateLayout = "2006-01-02"
+
+// BuildRoadmap phases the epics of a breakdown over time for a team delivering velocity story
+// points per sprint of sprintDays days, from start. Epics are worked on one after another:
+// an epic comes after the epics its stories depend on, and otherwise by priority. Each epic is
+// placed in the quarter its work ends in, and every release of the breakdown becomes a milestone
+// reached when the last epic with stories of the release is done.
+func BuildRoadmap(breakdown *models.ProjectBreakdown, velocity, sprintDays int, start time.Time) *models.Roadmap {
+	roadmap := &models.Roadmap{
+		ProjectName: breakdown.ProjectName,
+		Start:       start,
+		Velocity:    velocity,
+		SprintDays:  sprintDays,
+	}
+	pointsPerDay := float64(velocity) / float64(sprintDays)
+
+	dependsOn := epicDependencies(breakdown)
+	scheduled := make(map[string]bool)
+	done := 0
+	for len(scheduled) < len(breakdown.Epics) {
+		epic := nextRoadmapEpic(breakdown, dependsOn, scheduled)
+		scheduled[epic.ID] = true
+
+		points := 0
+		for _, story := range epic.Stories {
+			points += story.StoryPoints
+		}
+		startDay := int(math.Floor(float64(done) / pointsPerDay))
+		done += points
+		endDay := max(int(math.Ceil(float64(done)/pointsPerDay)), startDay+1)
+
+		end := start.AddDate(0, 0, endDay)
+		roadmap.Epics = append(roadmap.Epics, models.RoadmapEpic{
+			ID:        epic.ID,
+			Title:     epic.Title,
+			Priority:  epic.Priority,
+			Points:    points,
+			Start:     start.AddDate(0, 0, startDay),
+			End:       end,
+			Quarter:   quarter(end),
+			DependsOn: dependsOn[epic.ID],
+		})
+	}
+
+	roadmap.Milestones = releaseMilestones(breakdown, roadmap.Epics)
+	return roadmap
+}
+
+// releaseMilestones returns the milestones of the releases of a breakdown, in release order.
+// Releases without stories have none.
+func releaseMilestones(breakdown *models.ProjectBreakdown, epics []models.RoadmapEpic) []models.RoadmapMilestone {
+	ends := make(map[string]time.Time)
+	for _, epic := range epics {
+		ends[epic.ID] = epic.End
+	}
+
+	var milestones []models.RoadmapMilestone
+	for _, release := range breakdown.Releases {
+		milestone := models.RoadmapMilestone{Name: release.Name, Target: release.ReleaseDate}
+		for _, epic := range breakdown.Epics {
+			for _, story := range epic.Stories {
+				if story.Release == release.Name && ends[epic.ID].After(milestone.Date) {
+					milestone.Date = ends[epic.ID]
+				}
+			}
+		}
+		if !milestone.Date.IsZero() {
+			milestones = append(milestones, milestone)
+		}
+	}
+	return milestones
+}
+
+// epicDependencies returns the IDs of the epics each epic depends on through the dependencies of
+// its stories
+func epicDependencies(breakdown *models.ProjectBreakdown) map[string][]string {
+	epicOf := make(map[string]string)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			epicOf[story.ID] = epic.ID
+		}
+	}
+
+	dependsOn := make(map[string][]string)
+	for _, epic := range breakdown.Epics {
+		seen := make(map[string]bool)
+		for _, story := range epic.Stories {
+			for _, dependency := range story.Dependencies {
+				other, exists := epicOf[dependency]
+				if !exists || other == epic.ID || seen[other] {
+					continue
+				}
+				seen[other] = true
+				dependsOn[epic.ID] = append(dependsOn[epic.ID], other)
+			}
+		}
+	}
+	return dependsOn
+}
+
+// nextRoadmapEpic returns the highest priority epic not yet scheduled whose dependencies are.
+// When dependencies are circular, none is ready and the highest priority epic goes first.
+func nextRoadmapEpic(breakdown *models.ProjectBreakdown, dependsOn map[string][]string, scheduled map[string]bool) *models.Epic {
+	var next, fallback *models.Epic
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+		if scheduled[epic.ID] {
+			continue
+		}
+		if fallback == nil || epicRank(epic) > epicRank(fallback) {
+			fallback = epic
+		}
+
+		ready := true
+		for _, dependency := range dependsOn[epic.ID] {
+			ready = ready && scheduled[dependency]
+		}
+		if ready && (next == nil || epicRank(epic) > epicRank(next)) {
+			next = epic
+		}
+	}
+
+	if next == nil {
+		return fallback
+	}
+	return next
+}
+
+// epicRank returns the rank of the priority of an epic, counting epics without one as Low
+func epicRank(epic *models.Epic) int {
+	return max(priorityRanks[normalizePriority(epic.Priority)], priorityRanks["Low"])
+}
+
+// quarter returns the calendar quarter of a date, such as "2026 Q3"
+func quarter(date time.Time) string {
+	return fmt.Sprintf("%d Q%d", date.Year(), (int(date.Month())-1)/3+1)
+}
+
+// RoadmapMarkdown renders a roadmap as markdown: the epics of every quarter as a table, followed
+// by the Mermaid gantt diagram of the timeline
+func RoadmapMarkdown(roadmap *models.Roadmap) string {
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# Roadmap: %s\n\n", roadmap.ProjectName))
+	markdown.WriteString(fmt.Sprintf("A velocity of %d story points per %d-day sprint, starting %s.\n",
+		roadmap.Velocity, roadmap.SprintDays, roadmap.Start.Format(roadmapDateLayout)))
+
+	current := ""
+	for _, epic := range roadmap.Epics {
+		if epic.Quarter != current {
+			current = epic.Quarter
+			markdown.WriteString(fmt.Sprintf("\n## %s\n\n", current))
+			markdown.WriteString("| Epic | Priority | Points | Start | End | Depends on |\n|---|---|---|---|---|---|\n")
+		}
+		markdown.WriteString(fmt.Sprintf("| %s %s | %s | %d | %s | %s | %s |\n", epic.ID, epic.Title, epic.Priority, epic.Points,
+			epic.Start.Format(roadmapDateLayout), epic.End.Format(roadmapDateLayout), strings.Join(epic.DependsOn, ", ")))
+	}
+
+	if len(roadmap.Milestones) > 0 {
+		markdown.WriteString("\n## Milestones\n\n| Release | Reached | Target |\n|---|---|---|\n")
+		for _, milestone := range roadmap.Milestones {
+			markdown.WriteString(fmt.Sprintf("| %s | %s | %s |\n", milestone.Name, milestone.Date.Format(roadmapDateLayout), milestone.Target))
+		}
+	}
+
+	markdown.WriteString("\n## Timeline\n\n```mermaid\n" + RoadmapGantt(roadmap) + "```\n")
+	return markdown.String()
+}
+
+// RoadmapGantt renders a roadmap as a Mermaid gantt diagram with a section per quarter
+func RoadmapGantt(roadmap *models.Roadmap) string {
+	// Colons and hashes end task names in Mermaid
+	name := strings.NewReplacer(":", " -", "#", "", ";", ",")
+
+	var gantt strings.Builder
+	gantt.WriteString("gantt\n")
+	gantt.WriteString(fmt.Sprintf("    title %s\n", name.Replace(roadmap.ProjectName)))
+	gantt.WriteString("    dateFormat YYYY-MM-DD\n")
+
+	current := ""
+	for _, epic := range roadmap.Epics {
+		if epic.Quarter != current {
+			current = epic.Quarter
+			gantt.WriteString(fmt.Sprintf("    section %s\n", current))
+		}
+		gantt.WriteString(fmt.Sprintf("    %s %s :%s, %s, %s\n", epic.ID, name.Replace(epic.Title), epic.ID,
+			epic.Start.Format(roadmapDateLayout), epic.End.Format(roadmapDateLayout)))
+	}
+
+	if len(roadmap.Milestones) > 0 {
+		gantt.WriteString("    section Releases\n")
+		for i, milestone := range roadmap.Milestones {
+			gantt.WriteString(fmt.Sprintf("    %s :milestone, m%d, %s, 0d\n", name.Replace(milestone.Name), i+1, milestone.Date.Format(roadmapDateLayout)))
+		}
+	}
+	return gantt.String()
+}
+
+// DisplayRoadmap lists the epics of every quarter of a roadmap
+func DisplayRoadmap(roadmap *models.Roadmap) {
+	current := ""
+	for _, epic := range roadmap.Epics {
+		if epic.Quarter != current {
+			current = epic.Quarter
+			helpers.PrintTitle("%s", current)
+		}
+		helpers.PrintInfo("%s %s: %s to %s (%d points)", epic.ID, epic.Title,
+			epic.Start.Format(roadmapDateLayout), epic.End.Format(roadmapDateLayout), epic.Points)
+	}
+
+	if len(roadmap.Milestones) > 0 {
+		helpers.PrintTitle("Releases")
+	}
+	for _, milestone := range roadmap.Milestones {
+		helpers.PrintInfo("Release %s reached on %s", milestone.Name, milestone.Date.Format(roadmapDateLayout))
+	}
+
+	if len(roadmap.Epics) > 0 {
+		helpers.PrintSeparator()
+		helpers.PrintInfo("Summary: %d epics, done by %s", len(roadmap.Epics), roadmap.Epics[len(roadmap.Epics)-1].End.Format(roadmapDateLayout))
+	}
+}
+
+// SaveRoadmap saves a roadmap as markdown and as a Mermaid gantt diagram in the output directory
+// and returns the path of the markdown file
+func SaveRoadmap(roadmap *models.Roadmap, outputDir string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("roadmap", "md"))
+	if err := helpers.WriteFile(path, RoadmapMarkdown(roadmap)); err != nil {
+		return "", fmt.Errorf("failed to save roadmap: %w", err)
+	}
+	ganttPath := strings.TrimSuffix(path, ".md") + ".mmd"
+	if err := helpers.WriteFile(ganttPath, RoadmapGantt(roadmap)); err != nil {
+		return "", fmt.Errorf("failed to save roadmap: %w", err)
+	}
+
+	helpers.PrintSuccess("Saved roadmap to: %s and %s", path, ganttPath)
+	return path, nil
+}
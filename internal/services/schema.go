@@ -0,0 +1,306 @@
+package services
+
+import (
+	"sort"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+	"scrum-master/internal/providers"
+)
+
+// breakdownFields are the optional fields of the breakdown schema
+type breakdownFields struct {
+	Subtasks    bool     // Implementation subtasks of stories
+	Labels      bool     // Labels of epics and stories, and components of epics
+	DoneGaps    bool     // Definition of done items stories leave unmet
+	Risks       bool     // Risks of the project
+	Spikes      bool     // Story types marking research spikes
+	Initiatives bool     // Initiatives above epics
+	Sections    bool     // Document sections stories are derived from
+	Kanban      bool     // Stories without story points
+	Sizes       bool     // Size classes of Kanban stories
+	TShirt      bool     // T-shirt sizes instead of story points
+	Points      []int    // Story points of the estimation scale
+	Teams       []string // Teams epics and stories are assigned to
+}
+
+// breakdownTool declares the ProjectBreakdown JSON schema for providers with structured output.
+// Optional fields are only declared when they are asked for.
+func breakdownTool(fields breakdownFields) providers.Tool {
+	priority := map[string]interface{}{
+		"type": "string",
+		"enum": []string{"High", "Medium", "Low"},
+	}
+	stringList := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "string"},
+	}
+	confidence := map[string]interface{}{
+		"type":    "integer",
+		"minimum": 0,
+		"maximum": 100,
+	}
+
+	story := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":               map[string]interface{}{"type": "string"},
+			"description":         map[string]interface{}{"type": "string"},
+			"priority":            priority,
+			"story_points":        map[string]interface{}{"type": "integer"},
+			"acceptance_criteria": stringList,
+			"dependencies":        stringList,
+			"rationale":           map[string]interface{}{"type": "string"},
+			"confidence":          confidence,
+		},
+		"required": []string{"title", "description", "priority", "story_points", "acceptance_criteria"},
+	}
+
+	estimateFields(story, fields)
+
+	if fields.Subtasks {
+		story["properties"].(map[string]interface{})["subtasks"] = map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title":       map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"component":   map[string]interface{}{"type": "string", "enum": subtaskComponents},
+				},
+				"required": []string{"title", "component"},
+			},
+		}
+		story["required"] = append(story["required"].([]string), "subtasks")
+	}
+
+	if fields.DoneGaps {
+		story["properties"].(map[string]interface{})["done_gaps"] = stringList
+	}
+
+	if fields.Sections {
+		story["properties"].(map[string]interface{})["section"] = map[string]interface{}{"type": "string"}
+		story["required"] = append(story["required"].([]string), "section")
+	}
+
+	if fields.Spikes {
+		story["properties"].(map[string]interface{})["type"] = map[string]interface{}{
+			"type": "string",
+			"enum": []string{"story", models.StoryTypeSpike},
+		}
+	}
+
+	epic := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":       map[string]interface{}{"type": "string"},
+			"description": map[string]interface{}{"type": "string"},
+			"priority":    priority,
+			"rationale":   map[string]interface{}{"type": "string"},
+			"confidence":  confidence,
+			"stories": map[string]interface{}{
+				"type":  "array",
+				"items": story,
+			},
+		},
+		"required": []string{"title", "description", "priority", "stories"},
+	}
+
+	if len(fields.Teams) > 0 {
+		team := map[string]interface{}{"type": "string", "enum": fields.Teams}
+		epic["properties"].(map[string]interface{})["team"] = team
+		story["properties"].(map[string]interface{})["team"] = team
+	}
+
+	if fields.Labels {
+		story["properties"].(map[string]interface{})["labels"] = stringList
+		epic["properties"].(map[string]interface{})["labels"] = stringList
+		epic["properties"].(map[string]interface{})["component"] = map[string]interface{}{"type": "string"}
+	}
+
+	properties := map[string]interface{}{
+		"project_name": map[string]interface{}{"type": "string"},
+		"overview":     map[string]interface{}{"type": "string"},
+		"epics": map[string]interface{}{
+			"type":  "array",
+			"items": epic,
+		},
+	}
+
+	if fields.Risks {
+		properties["risks"] = map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title":       map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"likelihood":  priority,
+					"impact":      priority,
+					"mitigation":  map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"title", "description", "likelihood", "impact", "mitigation"},
+			},
+		}
+	}
+
+	if fields.Initiatives {
+		epic["properties"].(map[string]interface{})["initiative"] = map[string]interface{}{"type": "string"}
+		properties["initiatives"] = map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title":       map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"title", "description"},
+			},
+		}
+	}
+
+	return providers.Tool{
+		Name:        "record_project_breakdown",
+		Description: "Record the breakdown of the project description into epics and user stories",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   []string{"project_name", "overview", "epics"},
+		},
+	}
+}
+
+// releaseTool declares the ReleasePlan JSON schema for providers with structured output
+func releaseTool() providers.Tool {
+	return providers.Tool{
+		Name:        "record_release_plan",
+		Description: "Record which release delivers each story of the project",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"releases": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"name":        map[string]interface{}{"type": "string"},
+							"description": map[string]interface{}{"type": "string"},
+							"stories": map[string]interface{}{
+								"type":  "array",
+								"items": map[string]interface{}{"type": "string"},
+							},
+						},
+						"required": []string{"name", "stories"},
+					},
+				},
+			},
+			"required": []string{"releases"},
+		},
+	}
+}
+
+// testCaseTool declares the test case JSON schema for providers with structured output
+func testCaseTool() providers.Tool {
+	return providers.Tool{
+		Name:        "record_test_cases",
+		Description: "Record the QA test cases of a user story",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"test_cases": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"type":          map[string]interface{}{"type": "string", "enum": testCaseTypes},
+							"title":         map[string]interface{}{"type": "string"},
+							"preconditions": map[string]interface{}{"type": "string"},
+							"steps": map[string]interface{}{
+								"type":  "array",
+								"items": map[string]interface{}{"type": "string"},
+							},
+							"expected": map[string]interface{}{"type": "string"},
+						},
+						"required": []string{"type", "title", "steps", "expected"},
+					},
+				},
+			},
+			"required": []string{"test_cases"},
+		},
+	}
+}
+
+// estimateFields limits the story points of a story schema to the estimation scale, or replaces
+// them with a size: a T-shirt size, or a size class or nothing for Kanban teams
+func estimateFields(story map[string]interface{}, fields breakdownFields) {
+	properties := story["properties"].(map[string]interface{})
+	var sizes []string
+	switch {
+	case fields.TShirt:
+		sizes = config.TShirtSizes
+	case fields.Sizes:
+		sizes = sizeClasses
+	case !fields.Kanban:
+		properties["story_points"] = map[string]interface{}{"type": "integer", "enum": fields.Points}
+		return
+	}
+
+	delete(properties, "story_points")
+	var required []string
+	for _, name := range story["required"].([]string) {
+		if name != "story_points" {
+			required = append(required, name)
+		}
+	}
+
+	if sizes != nil {
+		properties["size"] = map[string]interface{}{"type": "string", "enum": sizes}
+		required = append(required, "size")
+	}
+	story["required"] = required
+}
+
+// splitTool declares the JSON schema of a story split for providers with structured output
+func splitTool(fields breakdownFields) providers.Tool {
+	story := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":        map[string]interface{}{"type": "string"},
+			"description":  map[string]interface{}{"type": "string"},
+			"priority":     map[string]interface{}{"type": "string", "enum": []string{"High", "Medium", "Low"}},
+			"story_points": map[string]interface{}{"type": "integer"},
+			"acceptance_criteria": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"rationale": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"title", "description", "priority", "story_points", "acceptance_criteria"},
+	}
+	estimateFields(story, fields)
+
+	return providers.Tool{
+		Name:        "record_story_split",
+		Description: "Record the smaller stories an oversized user story is split into",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"stories": map[string]interface{}{
+					"type":  "array",
+					"items": story,
+				},
+			},
+			"required": []string{"stories"},
+		},
+	}
+}
+
+// teamNames returns the names of the configured jira.teams in order
+func teamNames(teams map[string]string) []string {
+	var names []string
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
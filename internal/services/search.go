@@ -0,0 +1,231 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/repositories"
+)
+
+// SearchService searches the epics and stories of stored analyses
+type SearchService struct {
+	config   *config.Config
+	feedback *repositories.FeedbackRepository
+	archive  *repositories.ArchiveRepository
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(config *config.Config) *SearchService {
+	return &SearchService{
+		config:   config,
+		feedback: repositories.NewFeedbackRepository(config.Processing.OutputDir),
+		archive:  repositories.NewArchiveRepository(config.Processing.OutputDir),
+	}
+}
+
+// Search finds epics and stories whose title, description or acceptance criteria contain every
+// word of the query, across all analyses in the output directory, newest first. Matches are
+// linked to the JIRA issue created for them when one was tracked.
+func (s *SearchService) Search(query string) ([]models.SearchMatch, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	files, err := s.analysisFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked, err := s.feedback.GetTrackedIssues()
+	if err != nil {
+		return nil, err
+	}
+
+	// Created issues are tracked by summary, so index them by type and title
+	created := make(map[string]string)
+	for _, issue := range tracked {
+		created[issue.IssueType+":"+strings.ToLower(issue.Summary)] = issue.Key
+	}
+
+	var matches []models.SearchMatch
+	for _, file := range files {
+		var result models.AnalysisResult
+		if err := helpers.LoadJSON(file, &result); err != nil {
+			helpers.PrintWarning("Skipping unreadable analysis %s: %v", file, err)
+			continue
+		}
+
+		for i, epic := range result.ProjectBreakdown.Epics {
+			fields := map[string]string{
+				"title":       epic.Title,
+				"description": epic.Description,
+			}
+			if matchedIn := matchFields(fields, terms); len(matchedIn) > 0 {
+				key := epic.Key
+				if key == "" {
+					key = created["epic:"+strings.ToLower(epic.Title)]
+				}
+
+				matches = append(matches, models.SearchMatch{
+					AnalysisFile: filepath.Base(file),
+					AnalysisTime: result.AnalysisTime,
+					ItemType:     "epic",
+					ItemRef:      itemLabel(epic.ID, i+1, 0),
+					Title:        epic.Title,
+					Epic:         epic.Title,
+					MatchedIn:    matchedIn,
+					JiraKey:      key,
+				})
+			}
+
+			for j, story := range epic.Stories {
+				fields := map[string]string{
+					"title":               story.Title,
+					"description":         story.Description,
+					"acceptance criteria": strings.Join(story.AcceptanceCriteria, "\n"),
+				}
+				matchedIn := matchFields(fields, terms)
+				if len(matchedIn) == 0 {
+					continue
+				}
+
+				key := story.Key
+				if key == "" {
+					key = created["story:"+strings.ToLower(story.Title)]
+				}
+
+				matches = append(matches, models.SearchMatch{
+					AnalysisFile: filepath.Base(file),
+					AnalysisTime: result.AnalysisTime,
+					ItemType:     "story",
+					ItemRef:      itemLabel(story.ID, i+1, j+1),
+					Title:        story.Title,
+					Epic:         epic.Title,
+					MatchedIn:    matchedIn,
+					JiraKey:      key,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].AnalysisTime.After(matches[j].AnalysisTime)
+	})
+
+	return matches, nil
+}
+
+// ArchivedAnalyses returns how many analyses were archived out of the output directory and are
+// therefore not searched
+func (s *SearchService) ArchivedAnalyses() (int, error) {
+	runs, err := s.archive.GetRuns()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, run := range runs {
+		for _, file := range run.Files {
+			if isAnalysisFile(file) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// DisplayMatches displays search matches grouped by analysis
+func (s *SearchService) DisplayMatches(matches []models.SearchMatch) {
+	if len(matches) == 0 {
+		helpers.PrintInfo("No matches")
+		return
+	}
+
+	currentFile := ""
+	createdKeys := make(map[string]bool)
+	analyses := make(map[string]bool)
+
+	for _, match := range matches {
+		if match.AnalysisFile != currentFile {
+			currentFile = match.AnalysisFile
+			analyses[currentFile] = true
+			helpers.PrintSeparator()
+			helpers.PrintInfo("%s (%s)", match.AnalysisFile, match.AnalysisTime.Format("2006-01-02 15:04"))
+		}
+
+		status := "not created in JIRA"
+		if match.JiraKey != "" {
+			status = "created as " + match.JiraKey
+			createdKeys[match.JiraKey] = true
+		}
+
+		if match.ItemType == "epic" {
+			helpers.PrintInfo("  Epic %s: %s - %s", match.ItemRef, match.Title, status)
+		} else {
+			helpers.PrintInfo("  Story %s: %s (epic: %s) - %s", match.ItemRef, match.Title, match.Epic, status)
+		}
+		helpers.PrintInfo("    Matched in: %s", strings.Join(match.MatchedIn, ", "))
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("%d matches in %d analyses, %d created in JIRA", len(matches), len(analyses), len(createdKeys))
+}
+
+// analysisFiles lists the analysis and review queue files in the output directory
+func (s *SearchService) analysisFiles() ([]string, error) {
+	entries, err := os.ReadDir(s.config.Processing.OutputDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isAnalysisFile(entry.Name()) {
+			files = append(files, filepath.Join(s.config.Processing.OutputDir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// isAnalysisFile reports whether an output filename holds an analysis result
+func isAnalysisFile(name string) bool {
+	return strings.HasSuffix(name, ".json") &&
+		(strings.HasPrefix(name, "project-desc-analysis-") || strings.HasPrefix(name, "review-queue-"))
+}
+
+// matchFields returns the names of the fields that together contain every term, or nil if some
+// term appears in none of them
+func matchFields(fields map[string]string, terms []string) []string {
+	matched := make(map[string]bool)
+
+	for _, term := range terms {
+		found := false
+		for name, text := range fields {
+			if strings.Contains(strings.ToLower(text), term) {
+				matched[name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	var names []string
+	for _, name := range []string{"title", "description", "acceptance criteria"} {
+		if matched[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
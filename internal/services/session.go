@@ -0,0 +1,311 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+// Layout of a session bundle
+const (
+	sessionManifestName  = "session.json"
+	sessionConfigName    = "config.yaml"
+	sessionExchangesName = "exchanges.json"
+	sessionLogName       = "session.log"
+	sessionInputName     = "stdin.txt"
+	sessionOutputDir     = "output"
+)
+
+// sessionFlags are left out of a recorded command line, as replaying sets them itself
+var sessionFlags = map[string]bool{
+	"--record-session": true,
+	"--replay-dir":     true,
+	"--config":         true,
+	"-c":               true,
+	"--profile":        true,
+	"-p":               true,
+}
+
+// replayCommands are the commands a session bundle may rerun. Commands that write keys,
+// workspaces or templates, delete files or run git are left out, as a bundle is untrusted.
+var replayCommands = map[string]bool{
+	"process": true, "create-from-analysis": true, "sync": true, "export": true, "confluence": true,
+	"rollback": true, "ask": true, "feedback": true, "generate-tests": true, "lint": true,
+	"plan": true, "roadmap": true, "doctor": true,
+}
+
+// sessionValueFlags are the global flags allowed in a replayed command line that take their value
+// from the next argument, so it is not taken for the command
+var sessionValueFlags = map[string]bool{"--ci-summary": true}
+
+// SessionRecording records a run for a bug report: every HTTP request with its response,
+// everything printed and everything typed, with credentials removed
+type SessionRecording struct {
+	configFiles []string
+	profile     string
+	args        []string
+	recorder    *transport.Recorder
+	stopOutput  func() []byte
+	stopInput   func() []byte
+	startedAt   time.Time
+}
+
+// StartSessionRecording starts recording a run of the given command line arguments. The
+// credentials of the configuration with the profile are removed from everything recorded, so
+// nothing is recorded when the configuration cannot be loaded.
+func StartSessionRecording(configPath, profile string, args []string) (*SessionRecording, error) {
+	cfg, err := config.LoadConfig(configPath, profile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot record a session without its configuration, whose credentials must be removed: %w", err)
+	}
+	configFiles, err := config.ConfigFiles(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find configuration files: %w", err)
+	}
+
+	stopOutput, err := helpers.CaptureOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture output: %w", err)
+	}
+	stopInput, err := helpers.CaptureInput()
+	if err != nil {
+		stopOutput()
+		return nil, fmt.Errorf("failed to capture input: %w", err)
+	}
+
+	recorder := transport.NewRecorder(cfg.Secrets())
+	transport.UseSession(recorder.Middleware())
+
+	return &SessionRecording{
+		configFiles: configFiles,
+		profile:     profile,
+		args:        args,
+		recorder:    recorder,
+		stopOutput:  stopOutput,
+		stopInput:   stopInput,
+		startedAt:   time.Now(),
+	}, nil
+}
+
+// Finish stops recording and writes the session bundle: the command line with its input files,
+// the configuration with credentials redacted, the HTTP exchanges, the log and the answers typed
+// at prompts. runErr is the error the run ended with, if any.
+func (r *SessionRecording) Finish(bundlePath string, runErr error) (*models.SessionBundle, error) {
+	transport.UseSession(nil)
+	log := r.stopOutput()
+	input := r.stopInput()
+
+	exchanges := r.recorder.Exchanges()
+	bundle := &models.SessionBundle{
+		CreatedAt: r.startedAt,
+		Profile:   r.profile,
+		Stdin:     sessionInputName,
+		Exchanges: len(exchanges),
+	}
+	if runErr != nil {
+		bundle.Error = runErr.Error()
+	}
+
+	var entries []helpers.ArchiveEntry
+	if configData, err := config.ReadConfigFiles(r.configFiles); err == nil {
+		redacted, err := config.RedactSecrets(configData, "./"+sessionOutputDir)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Config = sessionConfigName
+		entries = append(entries, helpers.ArchiveEntry{Name: sessionConfigName, Data: redacted})
+	}
+
+	args, inputs, err := sessionArgs(r.args)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Args = args
+	for _, entry := range inputs {
+		bundle.Inputs = append(bundle.Inputs, entry.Name)
+	}
+	entries = append(entries, inputs...)
+
+	exchangeData, err := json.MarshalIndent(exchanges, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal exchanges: %w", err)
+	}
+	entries = append(entries,
+		helpers.ArchiveEntry{Name: sessionExchangesName, Data: exchangeData},
+		helpers.ArchiveEntry{Name: sessionLogName, Data: log},
+		helpers.ArchiveEntry{Name: sessionInputName, Data: input})
+
+	for _, entry := range entries {
+		bundle.Files = append(bundle.Files, entry.Name)
+	}
+
+	bundleData, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session manifest: %w", err)
+	}
+	entries = append([]helpers.ArchiveEntry{{Name: sessionManifestName, Data: bundleData}}, entries...)
+
+	if err := helpers.WriteTarGz(bundlePath, entries); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// sessionArgs returns the command line to replay and the input files it needs: the flags
+// replaying sets itself are dropped and every argument naming a file, or a manifest and its
+// documents, is replaced by the file's place in the bundle
+func sessionArgs(args []string) ([]string, []helpers.ArchiveEntry, error) {
+	var replayArgs []string
+	var inputs []helpers.ArchiveEntry
+
+	bundleFile := func(path string) (string, error) {
+		info, err := os.Stat(path)
+		if strings.HasPrefix(path, "-") || err != nil || !info.Mode().IsRegular() {
+			return path, nil
+		}
+
+		entries, err := inputEntries(path)
+		if err != nil {
+			return "", err
+		}
+		inputs = append(inputs, entries...)
+		return entries[0].Name, nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if sessionFlags[name] {
+			if !hasValue {
+				i++
+			}
+			continue
+		}
+
+		if hasValue && strings.HasPrefix(name, "-") {
+			path, err := bundleFile(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			replayArgs = append(replayArgs, name+"="+path)
+			continue
+		}
+
+		arg, err := bundleFile(args[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		replayArgs = append(replayArgs, arg)
+	}
+
+	return replayArgs, inputs, nil
+}
+
+// ReplayArgs returns the command line that reruns a session extracted into a directory, run
+// from that directory. A bundle is untrusted, so its command must be one of replayCommands, it
+// cannot set the flags replaying sets itself, and its arguments, configuration and input cannot
+// name files outside the directory. The replay flags follow the recorded arguments.
+func ReplayArgs(bundle *models.SessionBundle) ([]string, error) {
+	command := ""
+	for i := 0; i < len(bundle.Args); i++ {
+		arg := bundle.Args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "-") {
+			name, value, hasValue = "", arg, true
+		}
+
+		switch {
+		case arg == "--":
+			return nil, fmt.Errorf("the command line ends its flags with '--'")
+		case replayFlag(name):
+			return nil, fmt.Errorf("the command line sets %s, which replaying sets itself", name)
+		case hasValue && !insideReplay(value):
+			return nil, fmt.Errorf("argument '%s' names a file outside the session", arg)
+		}
+
+		if command == "" && sessionValueFlags[name] && !hasValue && i+1 < len(bundle.Args) {
+			i++
+			if !insideReplay(bundle.Args[i]) {
+				return nil, fmt.Errorf("argument '%s' names a file outside the session", bundle.Args[i])
+			}
+			continue
+		}
+		if command == "" && name == "" {
+			if !replayCommands[value] {
+				return nil, fmt.Errorf("command '%s' cannot be replayed", value)
+			}
+			command = value
+		}
+	}
+	if command == "" {
+		return nil, fmt.Errorf("the command line has no command")
+	}
+
+	if !insideReplay(bundle.Config) || !insideReplay(bundle.Stdin) {
+		return nil, fmt.Errorf("the configuration or input of the session is outside of it")
+	}
+	if strings.HasPrefix(bundle.Profile, "-") {
+		return nil, fmt.Errorf("invalid profile '%s'", bundle.Profile)
+	}
+
+	args := append(slices.Clone(bundle.Args), "--replay-dir=.")
+	if bundle.Config != "" {
+		args = append(args, "--config="+bundle.Config)
+	}
+	if bundle.Profile != "" {
+		args = append(args, "--profile="+bundle.Profile)
+	}
+	return args, nil
+}
+
+// replayFlag reports whether a flag is one replaying sets itself. Shorthand flags are refused
+// when they could hold -c or -p, as in "-dc other.yaml".
+func replayFlag(name string) bool {
+	if sessionFlags[name] {
+		return true
+	}
+	return strings.HasPrefix(name, "-") && !strings.HasPrefix(name, "--") && strings.ContainsAny(name, "cp")
+}
+
+// insideReplay reports whether an argument, taken as a path, stays inside the directory a
+// session is replayed in. Arguments that are not paths, such as URLs, do.
+func insideReplay(arg string) bool {
+	return arg == "" || filepath.IsLocal(arg)
+}
+
+// OpenSession extracts a session bundle into dir and returns its description
+func OpenSession(bundlePath, dir string) (*models.SessionBundle, error) {
+	if err := helpers.EnsureDir(dir); err != nil {
+		return nil, err
+	}
+	if _, _, err := helpers.ExtractTarGz(bundlePath, dir, true); err != nil {
+		return nil, err
+	}
+
+	var bundle models.SessionBundle
+	if err := helpers.LoadJSON(filepath.Join(dir, sessionManifestName), &bundle); err != nil {
+		return nil, fmt.Errorf("not a session bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// StartSessionReplay answers every HTTP request of this run from the exchanges of the session
+// extracted into dir, so nothing is sent to JIRA or the AI provider
+func StartSessionReplay(dir string) (*transport.Replayer, error) {
+	var exchanges []models.SessionExchange
+	if err := helpers.LoadJSON(filepath.Join(dir, sessionExchangesName), &exchanges); err != nil {
+		return nil, fmt.Errorf("failed to load recorded exchanges: %w", err)
+	}
+
+	replayer := transport.NewReplayer(exchanges)
+	transport.UseSession(replayer.Middleware())
+	return replayer, nil
+}
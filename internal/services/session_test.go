@@ -0,0 +1,117 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"scrum-master/internal/models"
+)
+
+func TestReplayArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		bundle  models.SessionBundle
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "recorded command with replay flags after it",
+			bundle: models.SessionBundle{Args: []string{"--ci", "process", "-m", "chunked", "inputs/doc.md"}, Config: "config.yaml", Stdin: "stdin.txt"},
+			want:   []string{"--ci", "process", "-m", "chunked", "inputs/doc.md", "--replay-dir=.", "--config=config.yaml"},
+		},
+		{
+			name:   "recorded profile",
+			bundle: models.SessionBundle{Args: []string{"sync", "inputs/analysis.json", "-dy"}, Config: "config.yaml", Profile: "staging", Stdin: "stdin.txt"},
+			want:   []string{"sync", "inputs/analysis.json", "-dy", "--replay-dir=.", "--config=config.yaml", "--profile=staging"},
+		},
+		{
+			name:   "URL documents and global flag values",
+			bundle: models.SessionBundle{Args: []string{"--ci-summary", "summary.md", "process", "https://example.com/spec"}, Stdin: "stdin.txt"},
+			want:   []string{"--ci-summary", "summary.md", "process", "https://example.com/spec", "--replay-dir=."},
+		},
+		{
+			name:    "replay switched off",
+			bundle:  models.SessionBundle{Args: []string{"process", "doc.md", "--replay-dir="}, Stdin: "stdin.txt"},
+			wantErr: "sets --replay-dir",
+		},
+		{
+			name:    "configuration of the bundle replaced",
+			bundle:  models.SessionBundle{Args: []string{"--config", "/etc/other.yaml", "process", "doc.md"}, Stdin: "stdin.txt"},
+			wantErr: "sets --config",
+		},
+		{
+			name:    "configuration in a shorthand cluster",
+			bundle:  models.SessionBundle{Args: []string{"create-from-analysis", "-yc", "other.yaml", "analysis.json"}, Stdin: "stdin.txt"},
+			wantErr: "sets -yc",
+		},
+		{
+			name:    "profile chosen by the bundle",
+			bundle:  models.SessionBundle{Args: []string{"process", "--profile=prod", "doc.md"}, Stdin: "stdin.txt"},
+			wantErr: "sets --profile",
+		},
+		{
+			name:    "recording started by the bundle",
+			bundle:  models.SessionBundle{Args: []string{"--record-session", "x.tar.gz", "process", "doc.md"}, Stdin: "stdin.txt"},
+			wantErr: "sets --record-session",
+		},
+		{
+			name:    "command not replayable",
+			bundle:  models.SessionBundle{Args: []string{"workspace", "import", "--dir", "home", "--force"}, Stdin: "stdin.txt"},
+			wantErr: "command 'workspace' cannot be replayed",
+		},
+		{
+			name:    "key generation",
+			bundle:  models.SessionBundle{Args: []string{"signing", "keygen", "--force"}, Stdin: "stdin.txt"},
+			wantErr: "command 'signing' cannot be replayed",
+		},
+		{
+			name:    "absolute path argument",
+			bundle:  models.SessionBundle{Args: []string{"export", "analysis.json", "-o", "/home/user/.bashrc"}, Stdin: "stdin.txt"},
+			wantErr: "outside the session",
+		},
+		{
+			name:    "path leaving the directory",
+			bundle:  models.SessionBundle{Args: []string{"export", "analysis.json", "--output=../../.ssh/authorized_keys"}, Stdin: "stdin.txt"},
+			wantErr: "outside the session",
+		},
+		{
+			name:    "input read from outside",
+			bundle:  models.SessionBundle{Args: []string{"process", "doc.md"}, Stdin: "../../.aws/credentials"},
+			wantErr: "outside of it",
+		},
+		{
+			name:    "configuration read from outside",
+			bundle:  models.SessionBundle{Args: []string{"process", "doc.md"}, Config: "/etc/scrum-master.yaml", Stdin: "stdin.txt"},
+			wantErr: "outside of it",
+		},
+		{
+			name:    "flags ended early",
+			bundle:  models.SessionBundle{Args: []string{"process", "--", "doc.md"}, Stdin: "stdin.txt"},
+			wantErr: "'--'",
+		},
+		{
+			name:    "no command",
+			bundle:  models.SessionBundle{Args: []string{"--ci"}, Stdin: "stdin.txt"},
+			wantErr: "no command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReplayArgs(&tt.bundle)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReplayArgs(%q) error = %v, want one containing %q", tt.bundle.Args, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReplayArgs(%q) error = %v", tt.bundle.Args, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ReplayArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
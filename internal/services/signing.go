@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+)
+
+// signatureExtension is appended to a file's name to get its signature file
+const signatureExtension = ".minisig"
+
+// ErrUnsigned is returned when a file has no signature
+var ErrUnsigned = errors.New("file is not signed")
+
+// SigningService signs analyses and run ledgers and verifies them before they are applied, so
+// a file cannot be changed unnoticed between approval and creation
+type SigningService struct {
+	config *config.SigningConfig
+}
+
+// NewSigningService creates a new signing service
+func NewSigningService(config *config.Config) *SigningService {
+	return &SigningService{config: &config.Signing}
+}
+
+// SignaturePath returns the signature file of a file
+func SignaturePath(path string) string {
+	return path + signatureExtension
+}
+
+// GenerateSigningKeys writes a new private key and its public key. Existing keys are only
+// replaced with force.
+func GenerateSigningKeys(keyPath, publicKeyPath string, force bool) error {
+	if !force {
+		for _, path := range []string{keyPath, publicKeyPath} {
+			if helpers.FileExists(path) {
+				return fmt.Errorf("%s already exists (use --force to replace it)", path)
+			}
+		}
+	}
+
+	public, private, err := helpers.GenerateSigningKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	encoded, err := helpers.EncodeSigningKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := os.WriteFile(keyPath, encoded, 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := os.WriteFile(publicKeyPath, []byte(helpers.EncodePublicKey(public)), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+	return nil
+}
+
+// Enabled reports whether a signing key is configured
+func (s *SigningService) Enabled() bool {
+	return s.config.KeyFile != ""
+}
+
+// SignFile writes the signature of a file next to it and returns the signature's path
+func (s *SigningService) SignFile(path string) (string, error) {
+	if !s.Enabled() {
+		return "", fmt.Errorf("no signing key configured (set signing.key_file)")
+	}
+
+	keyData, err := os.ReadFile(s.config.KeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := helpers.DecodeSigningKey(keyData)
+	if err != nil {
+		return "", fmt.Errorf("invalid signing key %s: %w", s.config.KeyFile, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	trustedComment := fmt.Sprintf("timestamp:%d\tfile:%s\tsigner:scrum-master", time.Now().Unix(), filepath.Base(path))
+	signaturePath := SignaturePath(path)
+	if err := os.WriteFile(signaturePath, []byte(helpers.SignMinisign(key, data, trustedComment)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	return signaturePath, nil
+}
+
+// SignIfEnabled signs a file when a signing key is configured. Failures are reported as
+// warnings since the file itself was saved.
+func (s *SigningService) SignIfEnabled(path string) {
+	if !s.Enabled() {
+		return
+	}
+
+	signaturePath, err := s.SignFile(path)
+	if err != nil {
+		helpers.PrintWarning("Failed to sign %s: %v", path, err)
+		return
+	}
+	helpers.PrintSuccess("Signed: %s", signaturePath)
+}
+
+// VerifyFile checks a file against its signature and the configured public key and returns
+// the signature's trusted comment. It returns ErrUnsigned when the file has no signature.
+func (s *SigningService) VerifyFile(path string) (string, error) {
+	if s.config.PublicKeyFile == "" {
+		return "", fmt.Errorf("no public key configured (set signing.public_key_file)")
+	}
+
+	keyData, err := os.ReadFile(s.config.PublicKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read public key: %w", err)
+	}
+	key, err := helpers.DecodePublicKey(keyData)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key %s: %w", s.config.PublicKeyFile, err)
+	}
+
+	signature, err := os.ReadFile(SignaturePath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrUnsigned
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return helpers.VerifyMinisign(key, data, signature)
+}
+
+// CheckFile verifies a file before it is applied. Without a public key nothing is checked.
+// An unsigned file is accepted with a warning unless signing.require is set; a file whose
+// signature does not match is always rejected.
+func (s *SigningService) CheckFile(path string) error {
+	if s.config.PublicKeyFile == "" {
+		return nil
+	}
+
+	trustedComment, err := s.VerifyFile(path)
+	if errors.Is(err, ErrUnsigned) && !s.config.Require {
+		helpers.PrintWarning("%s is not signed", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("signature check of %s failed: %w", path, err)
+	}
+
+	helpers.PrintSuccess("Verified signature of %s (%s)", filepath.Base(path), trustedComment)
+	return nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+	"scrum-master/internal/transport"
+)
+
+// fetchTimeoutSeconds limits the download of a document given by URL
+const fetchTimeoutSeconds = 60
+
+// IsDocumentURL reports whether an input is the web address of a document rather than a file
+func IsDocumentURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// DownloadError is a failure to download a document given by URL, as opposed to a URL the
+// configuration does not allow
+type DownloadError struct {
+	URL string
+	Err error
+}
+
+// Error names the URL and why the download failed
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("failed to download %s: %v", e.URL, e.Err)
+}
+
+// Unwrap returns the cause of the failure
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// FetchDocument downloads the document at a URL into the output directory and returns the path
+// of the copy, which is analyzed like any other input file. A web page is saved as markdown of its
+// main content. The URL, and any it redirects to, must be in fetch.allowed_domains, and the
+// document must not be larger than fetch.max_size_mb.
+func FetchDocument(ctx context.Context, cfg *config.Config, documentURL string) (string, error) {
+	parsed, err := url.Parse(documentURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid document URL '%s': %w", documentURL, err)
+	}
+	if err := checkAllowedDomain(&cfg.Fetch, parsed); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", documentURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	var refused error
+	client := transport.NewClient(&cfg.HTTP, fetchTimeoutSeconds)
+	client.CheckRedirect = func(redirect *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		refused = checkAllowedDomain(&cfg.Fetch, redirect.URL)
+		return refused
+	}
+	resp, err := client.Do(req)
+	if refused != nil {
+		return "", refused
+	}
+	if err != nil {
+		return "", &DownloadError{URL: documentURL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", &DownloadError{URL: documentURL, Err: &transport.StatusError{API: "document server", StatusCode: resp.StatusCode, Body: string(body)}}
+	}
+	maxSize := int64(cfg.Fetch.MaxSizeMB) << 20
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", &DownloadError{URL: documentURL, Err: err}
+	}
+	if int64(len(content)) > maxSize {
+		return "", &DownloadError{URL: documentURL, Err: fmt.Errorf("the document is larger than fetch.max_size_mb (%d MB)", cfg.Fetch.MaxSizeMB)}
+	}
+
+	// Keep the extension, which tells plain text from markdown
+	extension := strings.TrimPrefix(path.Ext(parsed.Path), ".")
+	if extension == "" {
+		extension = "md"
+	}
+
+	if isWebPage(resp.Header.Get("Content-Type"), extension, content) {
+		title, markdown, err := helpers.HTMLToMarkdown(string(content))
+		if err != nil {
+			return "", fmt.Errorf("failed to extract the text of %s: %w", documentURL, err)
+		}
+		saved, err := saveInput(cfg.Processing.OutputDir, "md", markdown)
+		if err != nil {
+			return "", err
+		}
+		if title == "" {
+			title = documentURL
+		}
+		helpers.PrintSuccess("Extracted the text of web page '%s' (%d characters) to: %s", title, len(markdown), saved)
+		return saved, nil
+	}
+
+	downloaded, err := saveInput(cfg.Processing.OutputDir, extension, string(content))
+	if err != nil {
+		return "", err
+	}
+
+	helpers.PrintSuccess("Downloaded %s (%d bytes) to: %s", documentURL, len(content), downloaded)
+	return downloaded, nil
+}
+
+// checkAllowedDomain checks that a URL points to a host of fetch.allowed_domains or one of their
+// subdomains. Nothing is downloaded while the list is empty; "*" allows any host.
+func checkAllowedDomain(fetch *config.FetchConfig, target *url.URL) error {
+	if len(fetch.AllowedDomains) == 0 {
+		return fmt.Errorf("fetch.allowed_domains is empty, so documents cannot be downloaded from %s; list the hosts to download from, or [\"*\"] to allow any", target.Hostname())
+	}
+
+	host := strings.ToLower(target.Hostname())
+	for _, domain := range fetch.AllowedDomains {
+		if domain == config.AnyDomain {
+			return nil
+		}
+		domain = strings.ToLower(strings.TrimPrefix(domain, "*."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not in fetch.allowed_domains, so documents cannot be downloaded from it", target.Hostname())
+}
+
+// isWebPage reports whether downloaded content is an HTML page, by its media type, its extension
+// or its start
+func isWebPage(contentType, extension string, content []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		return true
+	}
+	if extension == "html" || extension == "htm" {
+		return true
+	}
+
+	start := strings.ToLower(strings.TrimSpace(string(content[:min(len(content), 512)])))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+// saveInput saves a document fetched for analysis in the output directory and returns its path
+func saveInput(outputDir, extension, content string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	saved := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("project-desc-input", extension))
+	if err := helpers.WriteFile(saved, content); err != nil {
+		return "", fmt.Errorf("failed to save downloaded document: %w", err)
+	}
+	return saved, nil
+}
+
+// SourceFiles are the files a breakdown was created from, which created issues point back to with
+// jira.source
+type SourceFiles struct {
+	Document string // Path or URL of the source document as it was given
+	Snapshot string // Copy of the source document saved with the analysis
+	Analysis string // The analysis file
+}
+
+// SourceFilesOf returns the source files of an analysis loaded from a file. The snapshot of the
+// source document is saved next to the analysis.
+func SourceFilesOf(result *models.AnalysisResult, analysisFile string) SourceFiles {
+	files := SourceFiles{Document: result.SourceFile, Analysis: analysisFile}
+	if result.SourceSnapshot != "" {
+		files.Snapshot = filepath.Join(filepath.Dir(analysisFile), result.SourceSnapshot)
+	}
+	return files
+}
+
+// SetSource makes the issues created from now on point back to the files their breakdown was
+// created from, as jira.source configures. Without jira.source.link_url, issues link to the source
+// document when it was downloaded from a URL.
+func (s *JiraService) SetSource(files SourceFiles) {
+	s.source = files
+	s.sourceLink = s.config.Source.LinkURL
+	if s.sourceLink == "" {
+		if IsDocumentURL(files.Document) {
+			s.sourceLink = files.Document
+		}
+		return
+	}
+
+	if strings.Contains(s.sourceLink, config.SourceFilePlaceholder) && files.Document == "" {
+		helpers.PrintWarning("The analysis does not record its source document, so created epics get no link to it")
+		s.sourceLink = ""
+		return
+	}
+	// A document downloaded from a URL is named by the URL as given; cleaning it as a path would
+	// turn "https://" into "https:/"
+	document := files.Document
+	if !IsDocumentURL(document) {
+		document = filepath.ToSlash(filepath.Clean(document))
+	}
+	s.sourceLink = strings.NewReplacer(
+		config.SourceFilePlaceholder, document,
+		config.SourceAnalysisPlaceholder, filepath.Base(files.Analysis),
+	).Replace(s.sourceLink)
+}
+
+// attachSource attaches the source document and the analysis to a created epic. Failures only
+// warn, as the epic itself was created.
+func (s *JiraService) attachSource(ctx context.Context, epicKey string) {
+	if s.config.Source.Attach {
+		attachments := []struct{ path, name string }{
+			{s.source.Snapshot, path.Base(filepath.ToSlash(s.source.Document))},
+			{s.source.Analysis, filepath.Base(s.source.Analysis)},
+		}
+		for _, attachment := range attachments {
+			if attachment.path == "" {
+				continue
+			}
+			if s.source.Document == "" {
+				attachment.name = filepath.Base(attachment.path)
+			}
+
+			content, err := os.ReadFile(attachment.path)
+			if err == nil {
+				err = s.repo.AddAttachment(ctx, epicKey, attachment.name, content)
+			}
+			if err != nil {
+				helpers.PrintWarning("Failed to attach %s to %s: %v", attachment.name, epicKey, err)
+			}
+		}
+	}
+}
+
+// linkSource links a created epic or story to where its source document is published. A
+// failure only warns, as the issue itself was created.
+func (s *JiraService) linkSource(ctx context.Context, key string) {
+	if s.sourceLink == "" {
+		return
+	}
+
+	title := "Source document"
+	if s.source.Document != "" {
+		title = "Source document: " + path.Base(filepath.ToSlash(s.source.Document))
+	}
+	if err := s.repo.AddRemoteLink(ctx, key, s.sourceLink, title); err != nil {
+		helpers.PrintWarning("Failed to link %s to its source document: %v", key, err)
+	}
+}
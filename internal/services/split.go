@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// splitMinPoints returns the estimate from which a story is proposed for splitting: the first
+// point of the upper third of the estimation scale, which is 8 on the Fibonacci scale. Analyses
+// that do not record their scale were estimated on the Fibonacci scale.
+func splitMinPoints(scale []int) int {
+	if len(scale) == 0 {
+		scale = fibonacciPoints
+	}
+	return scale[len(scale)*2/3]
+}
+
+// oversized reports whether a story is too large for one sprint: it is estimated at minPoints
+// or more, is a Kanban story of the largest size class, or has more acceptance criteria than lint
+// accepts. T-shirt sizes count by their story points.
+func oversized(story *models.Story, minPoints int) bool {
+	largest := story.StoryPoints == 0 && story.Size == sizeClasses[len(sizeClasses)-1]
+	return story.StoryPoints >= minPoints || largest || len(story.AcceptanceCriteria) > lintMaxCriteria
+}
+
+// SuggestSplits asks the AI how to split every oversized story of a breakdown, measured on its
+// estimation scale, into smaller ones. Stories already created in JIRA and those the AI keeps
+// whole get no proposal. IDs are assigned first, so proposals name the story they replace.
+func (s *AnalysisService) SuggestSplits(ctx context.Context, breakdown *models.ProjectBreakdown) ([]models.StorySplit, error) {
+	AssignItemIDs(breakdown)
+	minPoints := splitMinPoints(breakdown.EstimationScale)
+
+	var splits []models.StorySplit
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			story := &breakdown.Epics[i].Stories[j]
+			if story.Key != "" || !oversized(story, minPoints) {
+				continue
+			}
+
+			helpers.PrintInfo("Proposing a split of %s: %s (%s, %d acceptance criteria)",
+				story.ID, story.Title, splitEstimate(story), len(story.AcceptanceCriteria))
+			stories, err := s.aiService.SplitStory(ctx, story)
+			if err != nil {
+				return splits, fmt.Errorf("failed to split %s: %w", story.ID, err)
+			}
+			if len(stories) < 2 {
+				helpers.PrintInfo("%s cannot be split sensibly, keeping it", story.ID)
+				continue
+			}
+			splits = append(splits, models.StorySplit{StoryID: story.ID, Story: story.Title, Stories: stories})
+		}
+	}
+	return splits, nil
+}
+
+// ApplySplit replaces a story with the smaller stories of its split. They take its place in the
+// epic, keep its team, release, labels, dependencies, incremental update and confidence, and
+// get the next free IDs. Stories that depended on it depend on all of them instead.
+func ApplySplit(breakdown *models.ProjectBreakdown, split models.StorySplit) error {
+	epic, story, err := FindItem(breakdown, split.StoryID)
+	if err != nil {
+		return err
+	}
+	if story == nil {
+		return fmt.Errorf("%s is an epic, not a story", split.StoryID)
+	}
+
+	replacements := make([]models.Story, len(split.Stories))
+	for i, replacement := range split.Stories {
+		replacement.ID = ""
+		replacement.Team = story.Team
+		replacement.Release = story.Release
+		replacement.Labels = slices.Clone(story.Labels)
+		replacement.Dependencies = slices.Clone(story.Dependencies)
+		replacement.AddedIn = story.AddedIn
+		if replacement.Confidence == 0 {
+			replacement.Confidence = story.Confidence
+		}
+		replacements[i] = replacement
+	}
+
+	id, position := story.ID, 0
+	for i := range epic.Stories {
+		if &epic.Stories[i] == story {
+			position = i
+			break
+		}
+	}
+	epic.Stories = slices.Replace(slices.Clone(epic.Stories), position, position+1, replacements...)
+
+	recalculateTotals(breakdown)
+	AssignItemIDs(breakdown)
+
+	var replacementIDs []string
+	for _, replacement := range epic.Stories[position : position+len(replacements)] {
+		replacementIDs = append(replacementIDs, replacement.ID)
+	}
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			dependent := &breakdown.Epics[i].Stories[j]
+			if !slices.Contains(dependent.Dependencies, id) {
+				continue
+			}
+			var dependencies []string
+			for _, dependency := range dependent.Dependencies {
+				if dependency != id {
+					dependencies = append(dependencies, dependency)
+					continue
+				}
+				for _, id := range replacementIDs {
+					if !slices.Contains(dependencies, id) {
+						dependencies = append(dependencies, id)
+					}
+				}
+			}
+			dependent.Dependencies = dependencies
+		}
+	}
+	return nil
+}
+
+// DisplaySplit displays the stories proposed to replace an oversized story
+func DisplaySplit(split models.StorySplit) {
+	helpers.PrintTitle("Split %s: %s", split.StoryID, split.Story)
+	for _, story := range split.Stories {
+		helpers.PrintInfo("  %s (%s)", story.Title, splitEstimate(&story))
+		helpers.PrintInfo("    %s", story.Description)
+		for _, criterion := range story.AcceptanceCriteria {
+			helpers.PrintInfo("    - %s", criterion)
+		}
+	}
+}
+
+// splitEstimate describes the estimate of a story in split proposals: its size when it has one,
+// otherwise its story points
+func splitEstimate(story *models.Story) string {
+	if story.Size != "" {
+		return "size " + story.Size
+	}
+	return fmt.Sprintf("%d points", story.StoryPoints)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"scrum-master/internal/models"
+)
+
+func TestApplySplit(t *testing.T) {
+	// breakdown returns a breakdown whose story E1-S2 is oversized and depended on
+	breakdown := func() models.ProjectBreakdown {
+		return models.ProjectBreakdown{Epics: []models.Epic{
+			{ID: "E1", Stories: []models.Story{
+				{ID: "E1-S1", Title: "Cart"},
+				{ID: "E1-S2", Title: "Checkout", StoryPoints: 13, Team: "payments", Release: "MVP", Labels: []string{"pci"}, Dependencies: []string{"E1-S1"}, Confidence: 60},
+				{ID: "E1-S3", Title: "Receipt", Dependencies: []string{"E1-S2"}},
+			}},
+			{ID: "E2", Stories: []models.Story{
+				{ID: "E2-S1", Title: "Refund", Dependencies: []string{"E1-S4", "E1-S2", "Payment provider"}},
+			}},
+		}}
+	}
+	replacements := []models.Story{
+		{ID: "X1", Title: "Pay by card", StoryPoints: 5},
+		{Title: "Pay by invoice", StoryPoints: 5, Confidence: 80},
+	}
+
+	tests := []struct {
+		name    string
+		split   models.StorySplit
+		want    []string // Item IDs after the split, with dependencies
+		wantErr string
+	}{
+		{
+			name:  "story replaced in place",
+			split: models.StorySplit{StoryID: "E1-S2", Stories: replacements},
+			want: []string{
+				"E1", "E1-S1", "E1-S4 [E1-S1]", "E1-S5 [E1-S1]", "E1-S3 [E1-S4 E1-S5]",
+				"E2", "E2-S1 [E1-S4 E1-S5 Payment provider]",
+			},
+		},
+		{
+			name:  "story found by position",
+			split: models.StorySplit{StoryID: "1.2", Stories: replacements},
+			want: []string{
+				"E1", "E1-S1", "E1-S4 [E1-S1]", "E1-S5 [E1-S1]", "E1-S3 [E1-S4 E1-S5]",
+				"E2", "E2-S1 [E1-S4 E1-S5 Payment provider]",
+			},
+		},
+		{
+			name:    "epic refused",
+			split:   models.StorySplit{StoryID: "E1", Stories: replacements},
+			wantErr: "is an epic",
+		},
+		{
+			name:    "unknown story",
+			split:   models.StorySplit{StoryID: "E9-S1", Stories: replacements},
+			wantErr: "no item",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := breakdown()
+			original := breakdown.Epics[0].Stories[1]
+
+			err := ApplySplit(&breakdown, tt.split)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplySplit() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplySplit() error = %v", err)
+			}
+
+			if got := itemIDs(breakdown); !slices.Equal(got, tt.want) {
+				t.Errorf("ApplySplit() IDs = %q, want %q", got, tt.want)
+			}
+			if breakdown.TotalStories != 5 {
+				t.Errorf("ApplySplit() total stories = %d, want 5", breakdown.TotalStories)
+			}
+
+			for i, story := range breakdown.Epics[0].Stories[1:3] {
+				if story.Title != replacements[i].Title || story.Team != original.Team || story.Release != original.Release || !slices.Equal(story.Labels, original.Labels) {
+					t.Errorf("ApplySplit() story %s = %+v, want %q with the team, release and labels of %s", story.ID, story, replacements[i].Title, original.ID)
+				}
+			}
+			if confidence := []int{breakdown.Epics[0].Stories[1].Confidence, breakdown.Epics[0].Stories[2].Confidence}; !slices.Equal(confidence, []int{60, 80}) {
+				t.Errorf("ApplySplit() confidence = %v, want [60 80]", confidence)
+			}
+
+			// The replacements have lists of their own
+			breakdown.Epics[0].Stories[1].Labels[0] = "changed"
+			if breakdown.Epics[0].Stories[2].Labels[0] != "pci" {
+				t.Errorf("ApplySplit() replacements share their labels")
+			}
+		})
+	}
+}
+
+func TestOversized(t *testing.T) {
+	tests := []struct {
+		name  string
+		scale []int
+		story models.Story
+		want  bool
+	}{
+		{name: "8 points on the Fibonacci scale", scale: []int{1, 2, 3, 5, 8, 13, 21}, story: models.Story{StoryPoints: 8}, want: true},
+		{name: "5 points on the Fibonacci scale", scale: []int{1, 2, 3, 5, 8, 13, 21}, story: models.Story{StoryPoints: 5}},
+		{name: "scale not recorded", story: models.Story{StoryPoints: 8}, want: true},
+		{name: "8 points on the powers of two scale", scale: []int{1, 2, 4, 8, 16}, story: models.Story{StoryPoints: 8}, want: true},
+		{name: "4 points on a short custom scale", scale: []int{1, 2, 3, 4, 5}, story: models.Story{StoryPoints: 4}, want: true},
+		{name: "3 points on a short custom scale", scale: []int{1, 2, 3, 4, 5}, story: models.Story{StoryPoints: 3}},
+		{name: "20 points on a large custom scale", scale: []int{10, 20, 40}, story: models.Story{StoryPoints: 20}},
+		{name: "40 points on a large custom scale", scale: []int{10, 20, 40}, story: models.Story{StoryPoints: 40}, want: true},
+		{name: "largest Kanban size", story: models.Story{Size: "L"}, want: true},
+		{name: "too many acceptance criteria", scale: []int{10, 20, 40}, story: models.Story{StoryPoints: 10, AcceptanceCriteria: make([]string, lintMaxCriteria+1)}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := oversized(&tt.story, splitMinPoints(tt.scale)); got != tt.want {
+				t.Errorf("oversized(%d points on %v) = %v, want %v", tt.story.StoryPoints, tt.scale, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// sprintDateLayout is the date format of the JIRA Agile API
+const sprintDateLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// sprintNumber matches a sprint name ending in its number, such as "PROJ Sprint 12"
+var sprintNumber = regexp.MustCompile(`^(.*?)(\d+)$`)
+
+// LoadSprints reads the future sprints of the configured board, or of the project's first scrum
+// board, with the story points their issues already take. With jira.sprints.create it also reads
+// the board's last sprint, which created sprints follow. It fails when sprints cannot be filled,
+// so a run can check this before creating anything.
+func (s *JiraService) LoadSprints(ctx context.Context) (*models.SprintPlan, error) {
+	pointsField := s.config.FieldMapping[config.FieldStoryPoints]
+	if pointsField == "" {
+		return nil, fmt.Errorf("filling sprints needs the story points field (set jira.field_mapping.story_points)")
+	}
+	if s.config.Sprints.Capacity == 0 {
+		return nil, fmt.Errorf("filling sprints needs the story points a sprint holds (set jira.sprints.capacity)")
+	}
+
+	boardID, err := s.sprintBoard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sprints, err := s.repo.GetSprints(ctx, boardID, "future")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sprints of board %d: %w", boardID, err)
+	}
+	if len(sprints) == 0 && !s.config.Sprints.Create {
+		return nil, fmt.Errorf("board %d has no future sprints to fill (set jira.sprints.create to create them)", boardID)
+	}
+
+	plan := &models.SprintPlan{
+		BoardID:       boardID,
+		CreateSprints: s.config.Sprints.Create,
+		Capacity:      s.config.Sprints.Capacity,
+		LengthDays:    s.config.Sprints.LengthDays,
+	}
+	for _, sprint := range sprints {
+		issues, err := s.repo.GetSprintIssues(ctx, sprint.ID, pointsField)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issues of sprint '%s': %w", sprint.Name, err)
+		}
+
+		load := models.SprintLoad{Sprint: sprint, Capacity: s.config.Sprints.Capacity}
+		for _, issue := range issues {
+			load.Used += issue.Points
+			load.Issues = append(load.Issues, issue.Key)
+		}
+		plan.Sprints = append(plan.Sprints, load)
+	}
+
+	if plan.CreateSprints {
+		if plan.Previous, err = s.lastSprint(ctx, boardID, sprints); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// sprintBoard returns the configured board, or the project's first scrum board
+func (s *JiraService) sprintBoard(ctx context.Context) (int, error) {
+	if s.config.Sprints.BoardID != 0 {
+		return s.config.Sprints.BoardID, nil
+	}
+
+	boards, err := s.repo.GetScrumBoards(ctx, s.config.ProjectKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list boards: %w", err)
+	}
+	if len(boards) == 0 {
+		return 0, fmt.Errorf("project %s has no scrum board (set jira.sprints.board_id)", s.config.ProjectKey)
+	}
+	helpers.PrintInfo("Using board %d: %s", boards[0].ID, boards[0].Name)
+	return boards[0].ID, nil
+}
+
+// LastBoardSprint returns the board sprints are planned on and its last sprint, which planned
+// sprints follow. A board without sprints has no last sprint.
+func (s *JiraService) LastBoardSprint(ctx context.Context) (int, *models.JiraSprint, error) {
+	boardID, err := s.sprintBoard(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	future, err := s.repo.GetSprints(ctx, boardID, "future")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list sprints of board %d: %w", boardID, err)
+	}
+	last, err := s.lastSprint(ctx, boardID, future)
+	return boardID, last, err
+}
+
+// lastSprint returns the last sprint of a board: its last future sprint, or else its last active
+// or closed one. A board without sprints has none.
+func (s *JiraService) lastSprint(ctx context.Context, boardID int, future []models.JiraSprint) (*models.JiraSprint, error) {
+	if len(future) > 0 {
+		return &future[len(future)-1], nil
+	}
+
+	for _, state := range []string{"active", "closed"} {
+		sprints, err := s.repo.GetSprints(ctx, boardID, state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sprints of board %d: %w", boardID, err)
+		}
+		if len(sprints) > 0 {
+			return &sprints[len(sprints)-1], nil
+		}
+	}
+	return nil, nil
+}
+
+// PlanSprints distributes the created stories of a breakdown over the sprints of a plan by
+// weighted shortest job first: stories with the highest cost of delay per story point come
+// first and go to the earliest sprint with room left. Stories already in one of the sprints are
+// left where they are. Stories that fit nowhere go to a new sprint when the plan may create
+// sprints, and otherwise stay in the backlog, as do stories larger than a sprint.
+func PlanSprints(plan *models.SprintPlan, breakdown *models.ProjectBreakdown) {
+	inSprint := make(map[string]bool)
+	for _, load := range plan.Sprints {
+		for _, key := range load.Issues {
+			inSprint[key] = true
+		}
+	}
+
+	plan.Assignments = nil
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			if story.Key == "" || inSprint[story.Key] {
+				continue
+			}
+			plan.Assignments = append(plan.Assignments, models.SprintAssignment{
+				ID:       story.ID,
+				Key:      story.Key,
+				Epic:     epic.Title,
+				Title:    story.Title,
+				Points:   story.StoryPoints,
+				Priority: story.Priority,
+				WSJF:     wsjf(&story),
+			})
+		}
+	}
+
+	sort.SliceStable(plan.Assignments, func(i, j int) bool {
+		return plan.Assignments[i].WSJF > plan.Assignments[j].WSJF
+	})
+
+	for i := range plan.Assignments {
+		assignment := &plan.Assignments[i]
+		for j := range plan.Sprints {
+			load := &plan.Sprints[j]
+			if load.Remaining() >= float64(assignment.Points) {
+				load.Planned += assignment.Points
+				assignment.SprintID = load.Sprint.ID
+				assignment.Sprint = load.Sprint.Name
+				break
+			}
+		}
+
+		if assignment.SprintID == 0 && plan.CreateSprints && assignment.Points <= plan.Capacity {
+			load := addSprint(plan)
+			load.Planned += assignment.Points
+			assignment.SprintID = load.Sprint.ID
+			assignment.Sprint = load.Sprint.Name
+		}
+	}
+}
+
+// PlanBacklog distributes all stories of a breakdown over numbered sprints of velocity story
+// points, following previous, the board's last sprint, or else starting at Sprint 1 today.
+// Stories are taken by weighted shortest job first, and each goes to the earliest sprint with
+// room left that is not before the sprints of the stories it depends on. With count 0, sprints
+// are added until every story that fits into one is planned; otherwise there are count sprints.
+// Stories larger than a sprint, that fit nowhere or whose dependencies stay in the backlog stay
+// there too.
+func PlanBacklog(breakdown *models.ProjectBreakdown, velocity, count, lengthDays int, previous *models.JiraSprint) *models.SprintPlan {
+	plan := &models.SprintPlan{
+		CreateSprints: count == 0,
+		Capacity:      velocity,
+		LengthDays:    lengthDays,
+		Previous:      previous,
+	}
+	for i := 0; i < count; i++ {
+		addSprint(plan)
+	}
+
+	dependencies := make(map[string][]string)
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			plan.Assignments = append(plan.Assignments, models.SprintAssignment{
+				ID:       story.ID,
+				Key:      story.Key,
+				Epic:     epic.Title,
+				Title:    story.Title,
+				Points:   story.StoryPoints,
+				Priority: story.Priority,
+				WSJF:     wsjf(&story),
+			})
+			dependencies[story.ID] = story.Dependencies
+		}
+	}
+	sort.SliceStable(plan.Assignments, func(i, j int) bool {
+		return plan.Assignments[i].WSJF > plan.Assignments[j].WSJF
+	})
+
+	// sprintOf is the index of the sprint a story is planned into; dependencies that are not
+	// stories of the breakdown, such as external systems, do not hold a story back
+	sprintOf := make(map[string]int)
+	isStory := make(map[string]bool)
+	for _, assignment := range plan.Assignments {
+		isStory[assignment.ID] = true
+	}
+
+	// A story can only be planned once its dependencies are, so repeat until nothing changes
+	placed := make([]bool, len(plan.Assignments))
+	for changed := true; changed; {
+		changed = false
+		for i := range plan.Assignments {
+			assignment := &plan.Assignments[i]
+			if placed[i] {
+				continue
+			}
+
+			earliest, ready := 0, true
+			for _, dependency := range dependencies[assignment.ID] {
+				if !isStory[dependency] {
+					continue
+				}
+				sprint, planned := sprintOf[dependency]
+				if !planned {
+					ready = false
+					break
+				}
+				earliest = max(earliest, sprint)
+			}
+			if !ready {
+				continue
+			}
+			placed[i], changed = true, true
+
+			for j := earliest; j < len(plan.Sprints); j++ {
+				if plan.Sprints[j].Remaining() >= float64(assignment.Points) {
+					planInto(plan, j, assignment, sprintOf)
+					break
+				}
+			}
+			if assignment.SprintID == 0 && plan.CreateSprints && assignment.Points <= plan.Capacity {
+				addSprint(plan)
+				planInto(plan, len(plan.Sprints)-1, assignment, sprintOf)
+			}
+		}
+	}
+	return plan
+}
+
+// planInto plans a story into the sprint at index of a plan
+func planInto(plan *models.SprintPlan, index int, assignment *models.SprintAssignment, sprintOf map[string]int) {
+	load := &plan.Sprints[index]
+	load.Planned += assignment.Points
+	assignment.SprintID = load.Sprint.ID
+	assignment.Sprint = load.Sprint.Name
+	sprintOf[assignment.ID] = index
+}
+
+// addSprint adds a sprint to be created after the last sprint of a plan
+func addSprint(plan *models.SprintPlan) *models.SprintLoad {
+	previous := plan.Previous
+	created := 0
+	for i := range plan.Sprints {
+		previous = &plan.Sprints[i].Sprint
+		if plan.Sprints[i].New {
+			created++
+		}
+	}
+
+	sprint := nextSprint(previous, plan.LengthDays)
+	sprint.ID = -(created + 1)
+	plan.Sprints = append(plan.Sprints, models.SprintLoad{Sprint: sprint, Capacity: plan.Capacity, New: true})
+	return &plan.Sprints[len(plan.Sprints)-1]
+}
+
+// nextSprint returns the sprint following previous: its name with the number counted up,
+// starting when previous ends and lasting length days. Without a previous sprint it is Sprint 1,
+// starting now; after a sprint without dates it has none either.
+func nextSprint(previous *models.JiraSprint, length int) models.JiraSprint {
+	if previous == nil {
+		start := time.Now()
+		return models.JiraSprint{
+			Name:      "Sprint 1",
+			State:     "future",
+			StartDate: start.Format(sprintDateLayout),
+			EndDate:   start.AddDate(0, 0, length).Format(sprintDateLayout),
+		}
+	}
+
+	next := models.JiraSprint{Name: previous.Name + " 2", State: "future"}
+	if match := sprintNumber.FindStringSubmatch(previous.Name); match != nil {
+		number, _ := strconv.Atoi(match[2])
+		next.Name = match[1] + strconv.Itoa(number+1)
+	}
+
+	if end, err := parseSprintDate(previous.EndDate); err == nil {
+		next.StartDate = end.Format(sprintDateLayout)
+		next.EndDate = end.AddDate(0, 0, length).Format(sprintDateLayout)
+	}
+	return next
+}
+
+// parseSprintDate reads a date of the JIRA Agile API
+func parseSprintDate(date string) (time.Time, error) {
+	if parsed, err := time.Parse(sprintDateLayout, date); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, date)
+}
+
+// wsjf returns the weighted shortest job first score of a story: its cost of delay, taken from
+// its priority with stories without one counted as Low, divided by its size in story points
+func wsjf(story *models.Story) float64 {
+	costOfDelay := priorityRanks[normalizePriority(story.Priority)]
+	if costOfDelay == 0 {
+		costOfDelay = priorityRanks["Low"]
+	}
+	return float64(costOfDelay) / float64(max(story.StoryPoints, 1))
+}
+
+// ApplySprintPlan creates the planned new sprints and moves the planned stories into their
+// sprints, returning how many were moved. Stories without a JIRA issue are left out, and new
+// sprints left with no stories are not created.
+func (s *JiraService) ApplySprintPlan(ctx context.Context, plan *models.SprintPlan) (int, error) {
+	moved := 0
+	for i := range plan.Sprints {
+		load := &plan.Sprints[i]
+		keys := plannedKeys(plan, load.Sprint.ID)
+		if len(keys) == 0 {
+			if load.New && load.Sprint.ID < 0 {
+				helpers.PrintWarning("Not creating sprint %s, none of its stories has a JIRA issue", load.Sprint.Name)
+			}
+			continue
+		}
+
+		if load.New && load.Sprint.ID < 0 {
+			if err := s.createPlannedSprint(ctx, plan, load); err != nil {
+				return moved, err
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return moved, fmt.Errorf("interrupted before filling sprint '%s': %w", load.Sprint.Name, err)
+		}
+
+		if err := s.repo.MoveIssuesToSprint(ctx, load.Sprint.ID, keys); err != nil {
+			return moved, fmt.Errorf("failed to move stories to sprint '%s': %w", load.Sprint.Name, err)
+		}
+		moved += len(keys)
+		helpers.PrintSuccess("Moved %d stories to %s", len(keys), load.Sprint.Name)
+	}
+	return moved, nil
+}
+
+// plannedKeys returns the JIRA keys of the stories a plan assigns to a sprint
+func plannedKeys(plan *models.SprintPlan, sprintID int) []string {
+	var keys []string
+	for _, assignment := range plan.Assignments {
+		if assignment.SprintID == sprintID && assignment.Key != "" {
+			keys = append(keys, assignment.Key)
+		}
+	}
+	return keys
+}
+
+// createPlannedSprint creates a sprint a plan added and points its assignments to it
+func (s *JiraService) createPlannedSprint(ctx context.Context, plan *models.SprintPlan, load *models.SprintLoad) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("interrupted before creating sprint '%s': %w", load.Sprint.Name, err)
+	}
+
+	created, err := s.repo.CreateSprint(ctx, plan.BoardID, load.Sprint)
+	if err != nil {
+		return fmt.Errorf("failed to create sprint '%s': %w", load.Sprint.Name, err)
+	}
+
+	for i := range plan.Assignments {
+		if plan.Assignments[i].SprintID == load.Sprint.ID {
+			plan.Assignments[i].SprintID = created.ID
+		}
+	}
+	load.Sprint.ID = created.ID
+	helpers.PrintSuccess("Created sprint %s", load.Sprint.Name)
+	return nil
+}
+
+// DisplaySprintPlan lists the stories planned into each sprint with its load, and the stories
+// that stay in the backlog
+func DisplaySprintPlan(plan *models.SprintPlan) {
+	for _, load := range plan.Sprints {
+		if load.New {
+			helpers.PrintInfo("%s (new): %d of %d points planned", load.Sprint.Name, load.Planned, load.Capacity)
+		} else {
+			helpers.PrintInfo("%s: %g of %d points taken, %d planned", load.Sprint.Name, load.Used, load.Capacity, load.Planned)
+		}
+		for _, assignment := range plan.Assignments {
+			if assignment.SprintID == load.Sprint.ID {
+				helpers.PrintInfo("  %s %s (%d points, WSJF %.2f)", assignmentLabel(assignment), assignment.Title, assignment.Points, assignment.WSJF)
+			}
+		}
+	}
+
+	var backlog []models.SprintAssignment
+	for _, assignment := range plan.Assignments {
+		if assignment.SprintID == 0 {
+			backlog = append(backlog, assignment)
+		}
+	}
+	if len(backlog) == 0 {
+		return
+	}
+
+	if plan.CreateSprints {
+		helpers.PrintWarning("%d stories are larger than a sprint, or depend on such a story, and stay in the backlog:", len(backlog))
+	} else {
+		helpers.PrintWarning("%d stories do not fit into the sprints and stay in the backlog:", len(backlog))
+	}
+	for _, assignment := range backlog {
+		helpers.PrintInfo("  %s %s (%d points)", assignmentLabel(assignment), assignment.Title, assignment.Points)
+	}
+}
+
+// assignmentLabel names a planned story by its JIRA key, or by its item ID before it is created
+func assignmentLabel(assignment models.SprintAssignment) string {
+	if assignment.Key != "" {
+		return assignment.Key
+	}
+	return assignment.ID
+}
+
+// SaveSprintPlan saves a sprint plan as JSON and as markdown in the output directory and returns
+// the path of the JSON file
+func SaveSprintPlan(plan *models.SprintPlan, breakdown *models.ProjectBreakdown, outputDir string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("sprint-plan", "json"))
+	if err := helpers.SaveJSON(plan, path); err != nil {
+		return "", fmt.Errorf("failed to save sprint plan: %w", err)
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# Sprint Plan: %s\n", breakdown.ProjectName))
+	for _, load := range plan.Sprints {
+		markdown.WriteString(fmt.Sprintf("\n## %s\n\n", load.Sprint.Name))
+		if start, err := parseSprintDate(load.Sprint.StartDate); err == nil {
+			if end, err := parseSprintDate(load.Sprint.EndDate); err == nil {
+				markdown.WriteString(fmt.Sprintf("%s to %s, ", start.Format("2006-01-02"), end.Format("2006-01-02")))
+			}
+		}
+		markdown.WriteString(fmt.Sprintf("%d of %d points planned\n\n", load.Planned, load.Capacity))
+		for _, assignment := range plan.Assignments {
+			if assignment.SprintID == load.Sprint.ID {
+				markdown.WriteString(fmt.Sprintf("- **%s** %s (%d points, %s)\n", assignmentLabel(assignment), assignment.Title, assignment.Points, assignment.Epic))
+			}
+		}
+	}
+
+	var backlog strings.Builder
+	for _, assignment := range plan.Assignments {
+		if assignment.SprintID == 0 {
+			backlog.WriteString(fmt.Sprintf("- **%s** %s (%d points, %s)\n", assignmentLabel(assignment), assignment.Title, assignment.Points, assignment.Epic))
+		}
+	}
+	if backlog.Len() > 0 {
+		markdown.WriteString("\n## Backlog\n\n" + backlog.String())
+	}
+
+	markdownPath := strings.TrimSuffix(path, ".json") + ".md"
+	if err := helpers.WriteFile(markdownPath, markdown.String()); err != nil {
+		return "", fmt.Errorf("failed to save sprint plan: %w", err)
+	}
+
+	helpers.PrintSuccess("Saved sprint plan to: %s and %s", path, markdownPath)
+	return path, nil
+}
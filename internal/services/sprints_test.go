@@ -0,0 +1,107 @@
+package services
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"scrum-master/internal/models"
+)
+
+// futureSprint returns the load of an existing future sprint
+func futureSprint(id int, name string, capacity int, used float64, issues ...string) models.SprintLoad {
+	return models.SprintLoad{Sprint: models.JiraSprint{ID: id, Name: name, State: "future"}, Capacity: capacity, Used: used, Issues: issues}
+}
+
+func TestPlanSprints(t *testing.T) {
+	tests := []struct {
+		name        string
+		plan        models.SprintPlan
+		stories     []models.Story
+		want        []string // Key and sprint of every assignment, in planning order
+		wantSprints []string // Sprints of the plan with their planned points
+	}{
+		{
+			name: "highest cost of delay per point first",
+			plan: models.SprintPlan{Sprints: []models.SprintLoad{futureSprint(5, "Sprint 5", 10, 0)}},
+			stories: []models.Story{
+				{Key: "P-1", Priority: "Low", StoryPoints: 8},
+				{Key: "P-2", Priority: "High", StoryPoints: 2},
+				{Key: "P-3", Priority: "Medium", StoryPoints: 1},
+			},
+			want:        []string{"P-3 Sprint 5", "P-2 Sprint 5", "P-1 backlog"},
+			wantSprints: []string{"Sprint 5 3"},
+		},
+		{
+			name: "stories without issue or already in a sprint left out",
+			plan: models.SprintPlan{Sprints: []models.SprintLoad{futureSprint(5, "Sprint 5", 10, 3, "P-1"), futureSprint(6, "Sprint 6", 10, 0)}},
+			stories: []models.Story{
+				{Key: "P-1", Priority: "High", StoryPoints: 3},
+				{Priority: "High", StoryPoints: 1},
+				{Key: "P-2", Priority: "High", StoryPoints: 3},
+			},
+			want:        []string{"P-2 Sprint 5"},
+			wantSprints: []string{"Sprint 5 3", "Sprint 6 0"},
+		},
+		{
+			name: "points already in a sprint count against it",
+			plan: models.SprintPlan{Sprints: []models.SprintLoad{futureSprint(5, "Sprint 5", 10, 8.5), futureSprint(6, "Sprint 6", 10, 0)}},
+			stories: []models.Story{
+				{Key: "P-1", Priority: "Medium", StoryPoints: 2},
+				{Key: "P-2", Priority: "Medium", StoryPoints: 1},
+			},
+			want:        []string{"P-2 Sprint 5", "P-1 Sprint 6"},
+			wantSprints: []string{"Sprint 5 1", "Sprint 6 2"},
+		},
+		{
+			name: "new sprints for stories that fit nowhere",
+			plan: models.SprintPlan{Sprints: []models.SprintLoad{futureSprint(5, "Sprint 5", 5, 0)}, CreateSprints: true, Capacity: 5},
+			stories: []models.Story{
+				{Key: "P-1", Priority: "High", StoryPoints: 3},
+				{Key: "P-2", Priority: "High", StoryPoints: 3},
+				{Key: "P-3", Priority: "High", StoryPoints: 8},
+				{Key: "P-4", Priority: "Low", StoryPoints: 2},
+			},
+			want:        []string{"P-1 Sprint 5", "P-2 Sprint 6", "P-4 Sprint 5", "P-3 backlog"},
+			wantSprints: []string{"Sprint 5 5", "Sprint 6 3"},
+		},
+		{
+			name: "ties keep the order of the breakdown",
+			plan: models.SprintPlan{Sprints: []models.SprintLoad{futureSprint(5, "Sprint 5", 4, 0)}},
+			stories: []models.Story{
+				{Key: "P-1", Priority: "Medium", StoryPoints: 3},
+				{Key: "P-2", StoryPoints: 3},
+				{Key: "P-3", Priority: "Medium", StoryPoints: 3},
+			},
+			want:        []string{"P-1 Sprint 5", "P-3 backlog", "P-2 backlog"},
+			wantSprints: []string{"Sprint 5 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := &models.ProjectBreakdown{Epics: []models.Epic{{Title: "Checkout", Stories: tt.stories}}}
+			PlanSprints(&tt.plan, breakdown)
+
+			var got []string
+			for _, assignment := range tt.plan.Assignments {
+				sprint := assignment.Sprint
+				if assignment.SprintID == 0 {
+					sprint = "backlog"
+				}
+				got = append(got, assignment.Key+" "+sprint)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PlanSprints() assignments = %q, want %q", got, tt.want)
+			}
+
+			var gotSprints []string
+			for _, load := range tt.plan.Sprints {
+				gotSprints = append(gotSprints, load.Sprint.Name+" "+strconv.Itoa(load.Planned))
+			}
+			if !slices.Equal(gotSprints, tt.wantSprints) {
+				t.Errorf("PlanSprints() sprints = %q, want %q", gotSprints, tt.wantSprints)
+			}
+		})
+	}
+}
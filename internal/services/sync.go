@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// traceLabelPrefix starts the labels that tie JIRA issues to the items of a breakdown
+const traceLabelPrefix = "sm-"
+
+// syncFields are the issue fields sync reads from JIRA
+var syncFields = []string{"summary", "description", "labels", "issuetype", "parent"}
+
+// TraceLabel returns the traceability label of an epic, or of a story of that epic when story
+// is set. It depends only on the titles, so it survives renaming the issue in JIRA.
+func TraceLabel(epic, story string) string {
+	key := strings.ToLower(strings.TrimSpace(epic))
+	if story != "" {
+		key += "\x00" + strings.ToLower(strings.TrimSpace(story))
+	}
+
+	sum := sha1.Sum([]byte(key))
+	return traceLabelPrefix + hex.EncodeToString(sum[:])[:10]
+}
+
+// IDLabel returns the traceability label of the epic or story with an ID in the breakdown of a
+// project. Unlike the title label, it survives renaming the item in the analysis.
+func IDLabel(project, id string) string {
+	sum := sha1.Sum([]byte(strings.ToLower(strings.TrimSpace(project)) + "\x00" + id))
+	return traceLabelPrefix + "id-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// traceLabels returns the labels to add to a created issue, if trace labels are enabled
+func (s *JiraService) traceLabels(epic, story string) []string {
+	if !s.config.TraceLabels {
+		return nil
+	}
+	return []string{TraceLabel(epic, story)}
+}
+
+// idLabels returns the ID label to add to the issue of an item, if trace labels are enabled and
+// the item has an ID
+func (s *JiraService) idLabels(breakdown *models.ProjectBreakdown, id string) []string {
+	if !s.config.TraceLabels || id == "" {
+		return nil
+	}
+	return []string{IDLabel(breakdown.ProjectName, id)}
+}
+
+// issueIndex looks up existing issues by key, traceability label and summary, and subtasks by
+// their parent. Each issue can be claimed by only one item.
+type issueIndex struct {
+	epicType  string
+	byKey     map[string]*models.JiraIssueDetails
+	byLabel   map[string]*models.JiraIssueDetails
+	bySummary map[string][]*models.JiraIssueDetails
+	byParent  map[string][]*models.JiraIssueDetails
+	claimed   map[string]bool
+}
+
+// newIssueIndex indexes the issues of a project
+func newIssueIndex(issues []models.JiraIssueDetails, epicType string) *issueIndex {
+	index := &issueIndex{
+		epicType:  epicType,
+		byKey:     make(map[string]*models.JiraIssueDetails),
+		byLabel:   make(map[string]*models.JiraIssueDetails),
+		bySummary: make(map[string][]*models.JiraIssueDetails),
+		byParent:  make(map[string][]*models.JiraIssueDetails),
+		claimed:   make(map[string]bool),
+	}
+
+	for i := range issues {
+		issue := &issues[i]
+		index.byKey[issue.Key] = issue
+
+		// Subtasks carry the title label of their story, so they are only found by ID label or
+		// under their parent
+		subtask := issue.Fields.IssueType.Subtask
+		for _, label := range issue.Fields.Labels {
+			if strings.HasPrefix(label, traceLabelPrefix+"id-") || (!subtask && strings.HasPrefix(label, traceLabelPrefix)) {
+				index.byLabel[label] = issue
+			}
+		}
+		if subtask {
+			if issue.Fields.Parent != nil {
+				index.byParent[issue.Fields.Parent.Key] = append(index.byParent[issue.Fields.Parent.Key], issue)
+			}
+			continue
+		}
+		summary := strings.ToLower(strings.TrimSpace(issue.Fields.Summary))
+		index.bySummary[summary] = append(index.bySummary[summary], issue)
+	}
+
+	return index
+}
+
+// match finds the existing issue of an item by its known key, its ID label, its title label, or
+// its summary among issues of the same kind (epic or not). It returns how the issue was matched.
+func (x *issueIndex) match(key, idLabel, label, summary string, epic bool) (*models.JiraIssueDetails, string) {
+	if issue := x.byKey[key]; issue != nil && !x.claimed[issue.Key] {
+		x.claimed[issue.Key] = true
+		return issue, "key"
+	}
+
+	if issue := x.byLabel[idLabel]; idLabel != "" && issue != nil && !x.claimed[issue.Key] {
+		x.claimed[issue.Key] = true
+		return issue, "ID label"
+	}
+
+	if issue := x.byLabel[label]; issue != nil && !x.claimed[issue.Key] {
+		x.claimed[issue.Key] = true
+		return issue, "label"
+	}
+
+	for _, issue := range x.bySummary[strings.ToLower(strings.TrimSpace(summary))] {
+		isEpic := strings.EqualFold(issue.Fields.IssueType.Name, x.epicType)
+		if isEpic == epic && !x.claimed[issue.Key] {
+			x.claimed[issue.Key] = true
+			return issue, "summary"
+		}
+	}
+
+	return nil, ""
+}
+
+// matchSubtask finds the existing issue of a subtask by its known key, its ID label, or its
+// summary among the subtasks of its story's issue. It returns how the issue was matched.
+func (x *issueIndex) matchSubtask(key, idLabel, summary, storyKey string) (*models.JiraIssueDetails, string) {
+	if issue := x.byKey[key]; issue != nil && !x.claimed[issue.Key] {
+		x.claimed[issue.Key] = true
+		return issue, "key"
+	}
+
+	if issue := x.byLabel[idLabel]; idLabel != "" && issue != nil && !x.claimed[issue.Key] {
+		x.claimed[issue.Key] = true
+		return issue, "ID label"
+	}
+
+	for _, issue := range x.byParent[storyKey] {
+		if strings.EqualFold(strings.TrimSpace(issue.Fields.Summary), strings.TrimSpace(summary)) && !x.claimed[issue.Key] {
+			x.claimed[issue.Key] = true
+			return issue, "summary"
+		}
+	}
+
+	return nil, ""
+}
+
+// Sync matches the breakdown against the project's existing issues, updates the descriptions
+// of issues that changed and creates only the missing ones. jql selects the existing issues and
+// defaults to the whole project. With dryRun nothing is changed and the planned actions are
+// returned.
+func (s *JiraService) Sync(ctx context.Context, breakdown *models.ProjectBreakdown, jql string, dryRun bool) ([]models.SyncAction, error) {
+	if jql == "" {
+		jql = fmt.Sprintf("project = %s", jqlString(s.config.ProjectKey))
+	}
+
+	helpers.PrintInfo("Fetching existing issues: %s", jql)
+	issues, err := s.repo.SearchIssues(ctx, jql, syncFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search issues: %w", err)
+	}
+	helpers.PrintInfo("Found %d existing issues", len(issues))
+
+	index := newIssueIndex(issues, s.issueTypes.EpicType())
+
+	var actions []models.SyncAction
+	for i := range breakdown.Epics {
+		epic := &breakdown.Epics[i]
+
+		labels := append(s.idLabels(breakdown, epic.ID), TraceLabel(epic.Title, ""))
+		issue, matchedBy := index.match(epic.Key, itemIDLabel(breakdown, epic.ID), TraceLabel(epic.Title, ""), epic.Title, true)
+		action, err := s.syncItem(ctx, issue, s.epicDescription(epic), labels, dryRun)
+		if err != nil {
+			return actions, err
+		}
+		if issue != nil && !dryRun {
+			epic.Key = issue.Key
+		}
+		actions = append(actions, models.SyncAction{
+			ItemType:  models.ItemTypeEpic,
+			Epic:      epic.Title,
+			Title:     epic.Title,
+			Action:    action,
+			Key:       issueKey(issue),
+			MatchedBy: matchedBy,
+		})
+
+		for j := range epic.Stories {
+			story := &epic.Stories[j]
+
+			labels := append(s.idLabels(breakdown, story.ID), TraceLabel(epic.Title, story.Title))
+			issue, matchedBy := index.match(story.Key, itemIDLabel(breakdown, story.ID), TraceLabel(epic.Title, story.Title), story.Title, false)
+			action, err := s.syncItem(ctx, issue, s.storyDescription(breakdown, story), labels, dryRun)
+			if err != nil {
+				return actions, err
+			}
+			if issue != nil && !dryRun {
+				story.Key = issue.Key
+			}
+			actions = append(actions, models.SyncAction{
+				ItemType:  models.ItemTypeStory,
+				Epic:      epic.Title,
+				Title:     story.Title,
+				Action:    action,
+				Key:       issueKey(issue),
+				MatchedBy: matchedBy,
+			})
+
+			// Subtasks of a new story are all new; those of an existing one are matched under it
+			for k := range story.Subtasks {
+				subtask := &story.Subtasks[k]
+
+				var subtaskIssue *models.JiraIssueDetails
+				var matchedBy string
+				if storyKey := issueKey(issue); storyKey != "" || subtask.Key != "" {
+					subtaskIssue, matchedBy = index.matchSubtask(subtask.Key, itemIDLabel(breakdown, subtask.ID), subtask.Title, storyKey)
+				}
+				labels := append(s.idLabels(breakdown, subtask.ID), TraceLabel(epic.Title, story.Title))
+				action, err := s.syncItem(ctx, subtaskIssue, subtaskDescription(subtask), labels, dryRun)
+				if err != nil {
+					return actions, err
+				}
+				if subtaskIssue != nil && !dryRun {
+					subtask.Key = subtaskIssue.Key
+				}
+				actions = append(actions, models.SyncAction{
+					ItemType:  models.ItemTypeSubtask,
+					Epic:      epic.Title,
+					Story:     story.Title,
+					Title:     subtask.Title,
+					Action:    action,
+					Key:       issueKey(subtaskIssue),
+					MatchedBy: matchedBy,
+				})
+			}
+		}
+	}
+
+	if dryRun {
+		return actions, nil
+	}
+
+	// Everything matched now carries its key, so only missing items are created
+	if err := s.CreateTicketsFromBreakdown(ctx, breakdown); err != nil {
+		return actions, err
+	}
+
+	for i := range actions {
+		if actions[i].Action == models.SyncCreate {
+			actions[i].Key = createdKey(breakdown, actions[i])
+		}
+	}
+	return actions, nil
+}
+
+// syncItem updates the description of an existing issue when it differs, keeping its test cases
+// section, and adds the trace labels if enabled and missing. It returns the sync action for the item.
+func (s *JiraService) syncItem(ctx context.Context, issue *models.JiraIssueDetails, description string, labels []string, dryRun bool) (string, error) {
+	if issue == nil {
+		return models.SyncCreate, nil
+	}
+
+	// Test cases written by generate-tests are not part of the analysis, so their section is kept
+	fields := make(map[string]interface{})
+	before, testCases, after := splitTestCases(issue.Fields.Description, s.repo.StoredDescription)
+	if stored := s.repo.StoredDescription(description); joinDescription(before, after) != strings.TrimSpace(stored) {
+		fields["description"] = description
+		if testCases != "" {
+			fields["description"] = s.repo.RawDescription(joinDescription(stored, testCases))
+		}
+	}
+
+	if s.config.TraceLabels {
+		var missing []string
+		for _, label := range labels {
+			if !containsString(issue.Fields.Labels, label) {
+				missing = append(missing, label)
+			}
+		}
+		if len(missing) > 0 {
+			fields["labels"] = append(append([]string(nil), issue.Fields.Labels...), missing...)
+		}
+	}
+
+	if len(fields) == 0 {
+		return models.SyncUnchanged, nil
+	}
+	if dryRun {
+		return models.SyncUpdate, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("interrupted before updating %s: %w", issue.Key, err)
+	}
+
+	if err := s.repo.UpdateIssue(ctx, issue.Key, fields); err != nil {
+		return "", fmt.Errorf("failed to update %s: %w", issue.Key, err)
+	}
+	helpers.PrintSuccess("Updated %s: %s", issue.Key, issue.Fields.Summary)
+	return models.SyncUpdate, nil
+}
+
+// DisplaySyncActions lists what a sync did, or would do with dryRun, per item with totals
+func DisplaySyncActions(actions []models.SyncAction, dryRun bool) {
+	counts := make(map[string]int)
+	for _, action := range actions {
+		counts[action.Action]++
+
+		item := "Epic"
+		switch action.ItemType {
+		case models.ItemTypeStory:
+			item = "  Story"
+		case models.ItemTypeSubtask:
+			item = "    Subtask"
+		}
+
+		switch action.Action {
+		case models.SyncCreate:
+			helpers.PrintInfo("%s %s: create %s", item, action.Title, action.Key)
+		case models.SyncUpdate:
+			helpers.PrintInfo("%s %s: update %s (matched by %s)", item, action.Title, action.Key, action.MatchedBy)
+		default:
+			helpers.PrintInfo("%s %s: unchanged %s (matched by %s)", item, action.Title, action.Key, action.MatchedBy)
+		}
+	}
+
+	format := "%d created, %d updated, %d unchanged"
+	if dryRun {
+		format = "%d to create, %d to update, %d unchanged"
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo(format, counts[models.SyncCreate], counts[models.SyncUpdate], counts[models.SyncUnchanged])
+}
+
+// itemIDLabel returns the ID label of an item to match issues by, or nothing without an ID
+func itemIDLabel(breakdown *models.ProjectBreakdown, id string) string {
+	if id == "" {
+		return ""
+	}
+	return IDLabel(breakdown.ProjectName, id)
+}
+
+// issueKey returns the key of a matched issue, or nothing for an item to be created
+func issueKey(issue *models.JiraIssueDetails) string {
+	if issue == nil {
+		return ""
+	}
+	return issue.Key
+}
+
+// createdKey returns the key an item was created with
+func createdKey(breakdown *models.ProjectBreakdown, action models.SyncAction) string {
+	for _, epic := range breakdown.Epics {
+		if epic.Title != action.Epic {
+			continue
+		}
+		if action.ItemType == models.ItemTypeEpic {
+			return epic.Key
+		}
+		for _, story := range epic.Stories {
+			if action.ItemType == models.ItemTypeStory && story.Title == action.Title {
+				return story.Key
+			}
+			if action.ItemType != models.ItemTypeSubtask || story.Title != action.Story {
+				continue
+			}
+			for _, subtask := range story.Subtasks {
+				if subtask.Title == action.Title {
+					return subtask.Key
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// Names of the prompt templates, each loaded from <name>.tmpl
+const (
+	TemplateBreakdown = "breakdown"
+	TemplateChunk     = "chunk"
+	TemplateSynthesis = "synthesis"
+	TemplateReleases  = "releases"
+	TemplateVerify    = "verify"
+	TemplateSummarize = "summarize"
+	TemplateTests     = "tests"
+	TemplateSplit     = "split"
+)
+
+// TemplateNames are the names of all prompt templates
+var TemplateNames = []string{TemplateBreakdown, TemplateChunk, TemplateSynthesis, TemplateReleases, TemplateVerify, TemplateSummarize, TemplateTests, TemplateSplit}
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// templateFuncs are the functions prompt templates can call besides the text/template builtins
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// promptData holds the variables of the prompt templates. Every template sees all of them;
+// those that do not apply to a prompt are empty.
+type promptData struct {
+	ChunkIndex     int               // Number of the chunk, counting from 1
+	TotalChunks    int               // Chunks the document is analyzed in, or chunk breakdowns synthesized
+	Content        string            // Chunk or document content
+	Context        []contextDocument // processing.context_files
+	Guidance       []string          // Additional guidance from annotations, calibration, teams and manifests
+	Glossary       map[string]string // processing.glossary
+	Examples       string            // processing.examples_file
+	Language       string            // processing.language, the language of the generated text
+	CriteriaFormat string            // processing.acceptance_criteria_format, "list" or "gherkin"
+	Methodology    string            // processing.methodology, "scrum" or "kanban"
+	Sizing         string            // Sizing of Kanban stories, "classes" or "none"
+	Scale          string            // processing.estimation_scale: fibonacci, powers_of_two, linear, tshirt or custom
+	ScalePoints    []string          // Story points of the estimation scale
+	Breakdowns     string            // Chunk breakdowns to synthesize, as JSON
+	Stories        string            // Stories to plan releases for, one per line
+	Releases       []models.Release  // Releases the stories are planned into, if any
+	Story          string            // Summary of the story a diff is verified against, tests are written for or split
+	Description    string            // Description of that story
+	Criteria       []string          // Acceptance criteria of the story tests are written for or split
+	Diff           string            // Diff verified against a story
+	Focus          string            // Topic a summary focuses on
+}
+
+// contextDocument is an organization context file given to every prompt
+type contextDocument struct {
+	Name    string // File name
+	Content string
+}
+
+// loadContext reads the organization context files
+func loadContext(files []string) ([]contextDocument, error) {
+	var documents []contextDocument
+	for _, path := range files {
+		content, err := helpers.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read context file %s: %w", path, err)
+		}
+		documents = append(documents, contextDocument{Name: filepath.Base(path), Content: strings.TrimSpace(content)})
+	}
+	return documents, nil
+}
+
+// loadExamples reads the example epics and stories of the breakdown prompts. The file must be a
+// breakdown in YAML or JSON; it is given to the prompts as written, so its wording is kept.
+func loadExamples(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	content, err := helpers.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read examples file %s: %w", path, err)
+	}
+	examples, err := decodeBreakdown([]byte(content), EditFormatYAML)
+	if err != nil {
+		return "", fmt.Errorf("examples file %s is not a breakdown: %w", path, err)
+	}
+	if len(examples.Epics) == 0 {
+		return "", fmt.Errorf("examples file %s has no epics", path)
+	}
+	return strings.TrimSpace(content), nil
+}
+
+// promptTemplates renders the prompts sent to the AI
+type promptTemplates map[string]*template.Template
+
+// loadTemplates parses the prompt templates, taking each from <dir>/<name>.tmpl of the first of
+// dirs that has it and from the embedded default otherwise
+func loadTemplates(dirs ...string) (promptTemplates, error) {
+	templates := make(promptTemplates)
+	for _, name := range TemplateNames {
+		var path string
+		var data []byte
+		err := os.ErrNotExist
+		for _, dir := range dirs {
+			if dir == "" {
+				continue
+			}
+			path = filepath.Join(dir, name+".tmpl")
+			if data, err = os.ReadFile(path); !os.IsNotExist(err) {
+				break
+			}
+		}
+		if os.IsNotExist(err) {
+			path = "default " + name + ".tmpl"
+			data, err = DefaultTemplate(name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+
+		parsed, err := template.New(name).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %s: %w", path, err)
+		}
+		// Catch unknown variables before any prompt is sent
+		if err := parsed.Execute(io.Discard, promptData{}); err != nil {
+			return nil, fmt.Errorf("invalid prompt template %s: %w", path, err)
+		}
+		templates[name] = parsed
+	}
+	return templates, nil
+}
+
+// templateSettings are the template variables of settings that only reach the AI through the
+// templates that use them
+var templateSettings = []struct {
+	variable *regexp.Regexp
+	setting  string
+}{
+	{regexp.MustCompile(`\.Context\b`), "processing.context_files"},
+	{regexp.MustCompile(`\.Glossary\b`), "processing.glossary"},
+	{regexp.MustCompile(`\.Examples\b`), "processing.examples_file"},
+	{regexp.MustCompile(`\.Language\b`), "processing.language"},
+	{regexp.MustCompile(`\.CriteriaFormat\b`), "processing.acceptance_criteria_format"},
+}
+
+// warnIncompleteOverrides warns about every template override in dir that leaves out a variable
+// its default uses, while the setting behind it is configured, since the setting then has no
+// effect on that prompt
+func warnIncompleteOverrides(dir string, configured map[string]bool) {
+	if dir == "" {
+		return
+	}
+	for _, name := range TemplateNames {
+		path := filepath.Join(dir, name+".tmpl")
+		override, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		defaults, err := DefaultTemplate(name)
+		if err != nil {
+			continue
+		}
+
+		var missing []string
+		for _, variable := range templateSettings {
+			if configured[variable.setting] && variable.variable.Match(defaults) && !variable.variable.Match(override) {
+				missing = append(missing, variable.setting)
+			}
+		}
+		if len(missing) > 0 {
+			helpers.PrintWarning("Prompt template %s leaves out the variables of %s, so its prompt goes without them (see the default with 'templates export')",
+				path, strings.Join(missing, ", "))
+		}
+	}
+}
+
+// DefaultTemplate returns the embedded default of a prompt template
+func DefaultTemplate(name string) ([]byte, error) {
+	return defaultTemplates.ReadFile("templates/" + name + ".tmpl")
+}
+
+// render renders a prompt template with its surrounding whitespace removed
+func (t promptTemplates) render(name string, data promptData) (string, error) {
+	var prompt bytes.Buffer
+	if err := t[name].Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return strings.TrimSpace(prompt.String()), nil
+}
+
+// ExportTemplates writes the default prompt templates to dir for customizing, keeping existing
+// files unless force is set. It returns the paths written.
+func ExportTemplates(dir string, force bool) ([]string, error) {
+	if err := helpers.EnsureDir(dir); err != nil {
+		return nil, fmt.Errorf("failed to create template directory: %w", err)
+	}
+
+	var written []string
+	for _, name := range TemplateNames {
+		path := filepath.Join(dir, name+".tmpl")
+		if helpers.FileExists(path) && !force {
+			helpers.PrintWarning("Keeping existing %s", path)
+			continue
+		}
+		data, err := DefaultTemplate(name)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// testCaseTypes are the kinds of test cases written for a story
+var testCaseTypes = []string{models.TestPositive, models.TestNegative, models.TestEdge}
+
+// TestCaseService writes QA test cases for the stories of an analysis
+type TestCaseService struct {
+	config      *config.Config
+	aiService   *AIService
+	jiraService *JiraService
+}
+
+// NewTestCaseService creates a new test case service
+func NewTestCaseService(config *config.Config) (*TestCaseService, error) {
+	aiService, err := NewAIService(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TestCaseService{
+		config:      config,
+		aiService:   aiService,
+		jiraService: NewJiraService(&config.Jira, &config.HTTP),
+	}, nil
+}
+
+// Generate asks the AI for the test cases of the stories of a breakdown. With refs, only the
+// stories they name are covered; an epic reference covers all of its stories.
+func (s *TestCaseService) Generate(ctx context.Context, breakdown *models.ProjectBreakdown, refs []string) (*models.TestSuite, error) {
+	stories, err := selectStories(breakdown, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	suite := &models.TestSuite{ProjectName: breakdown.ProjectName, GeneratedAt: time.Now()}
+	for i, story := range stories {
+		helpers.PrintInfo("Writing test cases for %s: %s (%d/%d)", story.ID, story.Title, i+1, len(stories))
+		testCases, err := s.aiService.GenerateTestCases(ctx, story)
+		if err != nil {
+			return suite, fmt.Errorf("failed to write test cases for %s: %w", story.ID, err)
+		}
+
+		suite.Stories = append(suite.Stories, models.StoryTestCases{
+			StoryID:   story.ID,
+			Story:     story.Title,
+			JiraKey:   story.Key,
+			TestCases: testCases,
+		})
+	}
+	return suite, nil
+}
+
+// selectStories returns the stories named by refs, in breakdown order, or every story without refs
+func selectStories(breakdown *models.ProjectBreakdown, refs []string) ([]*models.Story, error) {
+	selected := make(map[*models.Story]bool)
+	for _, ref := range refs {
+		epic, story, err := FindItem(breakdown, strings.TrimSpace(ref))
+		if err != nil {
+			return nil, err
+		}
+		if story != nil {
+			selected[story] = true
+			continue
+		}
+		for j := range epic.Stories {
+			selected[&epic.Stories[j]] = true
+		}
+	}
+
+	var stories []*models.Story
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			story := &breakdown.Epics[i].Stories[j]
+			if len(refs) == 0 || selected[story] {
+				stories = append(stories, story)
+			}
+		}
+	}
+	if len(stories) == 0 {
+		return nil, fmt.Errorf("the analysis has no stories to write test cases for")
+	}
+	return stories, nil
+}
+
+// Usage returns the AI tokens and cost of the generated test cases
+func (s *TestCaseService) Usage() models.TokenUsage {
+	return s.aiService.Usage()
+}
+
+// DisplayTestSuite displays the test cases of every story
+func (s *TestCaseService) DisplayTestSuite(suite *models.TestSuite) {
+	total := 0
+	for _, story := range suite.Stories {
+		helpers.PrintTitle("%s: %s", story.StoryID, story.Story)
+		for _, testCase := range story.TestCases {
+			total++
+			helpers.PrintInfo("[%s] %s", testCase.Type, testCase.Title)
+			for i, step := range testCase.Steps {
+				helpers.PrintInfo("    %d. %s", i+1, step)
+			}
+			helpers.PrintInfo("    Expected: %s", testCase.Expected)
+		}
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("Summary: %d test cases for %d stories", total, len(suite.Stories))
+}
+
+// SaveTestSuite saves the test cases as JSON and as markdown for QA in the output directory and
+// returns the path of the JSON file
+func (s *TestCaseService) SaveTestSuite(suite *models.TestSuite, outputDir string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("test-cases", "json"))
+	if err := helpers.SaveJSON(suite, path); err != nil {
+		return "", fmt.Errorf("failed to save test cases: %w", err)
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# Test Cases: %s\n", suite.ProjectName))
+	for _, story := range suite.Stories {
+		markdown.WriteString(fmt.Sprintf("\n## %s: %s\n\n", story.StoryID, story.Story))
+		markdown.WriteString(formatTestCases(story.TestCases))
+	}
+	markdownPath := strings.TrimSuffix(path, ".json") + ".md"
+	if err := helpers.WriteFile(markdownPath, markdown.String()); err != nil {
+		return "", fmt.Errorf("failed to save test cases: %w", err)
+	}
+
+	helpers.PrintSuccess("Saved test cases to: %s and %s", path, markdownPath)
+	return path, nil
+}
+
+// Markers of the test cases section of a JIRA description, each on a line of its own
+const (
+	testCasesStart = "**Test Cases:**"
+	testCasesEnd   = "**End of Test Cases**"
+)
+
+// UpdateJira writes the test cases into the JIRA descriptions of the stories of a breakdown that
+// have an issue. The test cases go in a section between markers, replacing that section if the
+// description has one and appended otherwise; the rest of the description is kept, and sync
+// keeps the section. It returns the number of updated issues.
+func (s *TestCaseService) UpdateJira(ctx context.Context, breakdown *models.ProjectBreakdown, suite *models.TestSuite) (int, error) {
+	repo := s.jiraService.repo
+	updated := 0
+	for _, tests := range suite.Stories {
+		_, story, err := FindItem(breakdown, tests.StoryID)
+		if err != nil {
+			return updated, err
+		}
+		if story == nil || story.Key == "" {
+			helpers.PrintWarning("%s has no JIRA issue, skipping it", tests.StoryID)
+			continue
+		}
+
+		issue, err := repo.GetIssue(ctx, story.Key)
+		if err != nil {
+			return updated, fmt.Errorf("failed to read issue '%s': %w", story.Key, err)
+		}
+		before, _, after := splitTestCases(issue.Fields.Description, repo.StoredDescription)
+		section := repo.StoredDescription(testCasesStart + "\n" + formatTestCases(tests.TestCases) + testCasesEnd)
+		description := joinDescription(before, section, after)
+
+		if err := repo.UpdateIssue(ctx, story.Key, map[string]interface{}{"description": repo.RawDescription(description)}); err != nil {
+			return updated, fmt.Errorf("failed to update issue '%s': %w", story.Key, err)
+		}
+		helpers.PrintSuccess("Added %d test cases to %s", len(tests.TestCases), story.Key)
+		updated++
+	}
+	return updated, nil
+}
+
+// splitTestCases splits a description read from JIRA into the text before its test cases
+// section, the section with its markers, and the text after it. stored converts the markers to
+// the form the description is read in. Without a complete section, all of it is before.
+func splitTestCases(description string, stored func(string) string) (before, section, after string) {
+	lines := strings.Split(description, "\n")
+	start, end := -1, -1
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if start < 0 && line == stored(testCasesStart) {
+			start = i
+		} else if start >= 0 && line == stored(testCasesEnd) {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return description, "", ""
+	}
+	return strings.Join(lines[:start], "\n"), strings.Join(lines[start:end+1], "\n"), strings.Join(lines[end+1:], "\n")
+}
+
+// joinDescription joins the non-empty parts of a description with blank lines between them
+func joinDescription(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// formatTestCases formats test cases as markdown, each with its steps as a numbered list
+func formatTestCases(testCases []models.TestCase) string {
+	var text strings.Builder
+	for _, testCase := range testCases {
+		text.WriteString(fmt.Sprintf("**%s** (%s)\n", testCase.Title, testCase.Type))
+		if testCase.Preconditions != "" {
+			text.WriteString(fmt.Sprintf("Preconditions: %s\n", testCase.Preconditions))
+		}
+		for i, step := range testCase.Steps {
+			text.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+		}
+		text.WriteString(fmt.Sprintf("Expected: %s\n\n", testCase.Expected))
+	}
+	return text.String()
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// requirementPrefix starts the IDs of the requirements stories are traced to
+const requirementPrefix = "REQ-"
+
+// documentRequirements numbers the sections of a document as requirements. IDs follow the
+// outline of the document, so they stay the same as long as its headings do.
+func documentRequirements(document string) []models.Requirement {
+	sections := helpers.SplitMarkdownSections(document)
+	helpers.NumberSections(sections)
+
+	var requirements []models.Requirement
+	for _, section := range sections {
+		if section.Number != "" {
+			requirements = append(requirements, models.Requirement{ID: requirementPrefix + section.Number, Heading: section.Heading})
+		}
+	}
+	return requirements
+}
+
+// traceStories records the requirement of every story of a breakdown from the section heading
+// the AI named, spelling the heading as the document does. Stories of a synthesized breakdown
+// lose their chunk, so stories without one get the first chunk with their section. It returns
+// the number of stories that could not be traced.
+func traceStories(breakdown *models.ProjectBreakdown, chunks []string) int {
+	requirements := make(map[string]models.Requirement)
+	for _, requirement := range breakdown.Requirements {
+		key := headingKey(requirement.Heading)
+		if _, exists := requirements[key]; !exists {
+			requirements[key] = requirement
+		}
+	}
+
+	chunkOf := make(map[string]int)
+	for i, chunk := range chunks {
+		for _, section := range helpers.SplitMarkdownSections(chunk) {
+			key := headingKey(section.Heading)
+			if _, exists := chunkOf[key]; !exists && section.Level > 0 {
+				chunkOf[key] = i + 1
+			}
+		}
+	}
+
+	untraced := 0
+	for i := range breakdown.Epics {
+		for j := range breakdown.Epics[i].Stories {
+			story := &breakdown.Epics[i].Stories[j]
+			key := headingKey(story.Section)
+			requirement, traced := requirements[key]
+			story.Requirement = requirement.ID
+			if traced {
+				story.Section = requirement.Heading
+			} else {
+				untraced++
+			}
+			if story.Chunk == 0 {
+				story.Chunk = chunkOf[key]
+			}
+		}
+	}
+	return untraced
+}
+
+// headingKey normalizes a heading for matching, as the AI may vary its case or keep the #s
+func headingKey(heading string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(heading), "#")))
+}
+
+// traceRow is a line of the traceability matrix: a story and the requirement it is derived
+// from, or a requirement no story is derived from
+type traceRow struct {
+	requirement, section string
+	chunk                int
+	epic, storyID, story string
+	key                  string
+}
+
+// traceabilityMatrix returns the rows of the traceability matrix of a breakdown in document
+// order, followed by the stories that could not be traced
+func traceabilityMatrix(breakdown *models.ProjectBreakdown) []traceRow {
+	stories := make(map[string][]traceRow)
+	var untraced []traceRow
+	for _, epic := range breakdown.Epics {
+		for _, story := range epic.Stories {
+			row := traceRow{requirement: story.Requirement, section: story.Section, chunk: story.Chunk,
+				epic: epic.Title, storyID: story.ID, story: story.Title, key: story.Key}
+			if story.Requirement == "" {
+				untraced = append(untraced, row)
+			} else {
+				stories[story.Requirement] = append(stories[story.Requirement], row)
+			}
+		}
+	}
+
+	var rows []traceRow
+	for _, requirement := range breakdown.Requirements {
+		if len(stories[requirement.ID]) == 0 {
+			rows = append(rows, traceRow{requirement: requirement.ID, section: requirement.Heading})
+		}
+		rows = append(rows, stories[requirement.ID]...)
+	}
+	return append(rows, untraced...)
+}
+
+// TraceabilityMarkdown renders the traceability matrix of a breakdown as a markdown table
+func TraceabilityMarkdown(breakdown *models.ProjectBreakdown) string {
+	rows := traceabilityMatrix(breakdown)
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+
+	covered := make(map[string]bool)
+	for _, row := range rows {
+		if row.storyID != "" && row.requirement != "" {
+			covered[row.requirement] = true
+		}
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# Traceability Matrix: %s\n\n", breakdown.ProjectName))
+	markdown.WriteString(fmt.Sprintf("%d of %d requirements are covered by stories.\n\n", len(covered), len(breakdown.Requirements)))
+	markdown.WriteString("| Requirement | Section | Chunk | Story | Title | JIRA |\n")
+	markdown.WriteString("|---|---|---|---|---|---|\n")
+	for _, row := range rows {
+		requirement, story := row.requirement, row.story
+		if requirement == "" {
+			requirement = "*untraced*"
+		}
+		if row.storyID == "" {
+			story = "*no story*"
+		}
+		chunk := ""
+		if row.chunk > 0 {
+			chunk = strconv.Itoa(row.chunk)
+		}
+		markdown.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			requirement, cell.Replace(row.section), chunk, row.storyID, cell.Replace(story), row.key))
+	}
+	return markdown.String()
+}
+
+// TraceabilityCSV renders the traceability matrix of a breakdown as CSV for spreadsheets and
+// audit tools
+func TraceabilityCSV(breakdown *models.ProjectBreakdown) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"Requirement", "Section", "Chunk", "Epic", "Story ID", "Story", "JIRA Key"}); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	for _, row := range traceabilityMatrix(breakdown) {
+		chunk := ""
+		if row.chunk > 0 {
+			chunk = strconv.Itoa(row.chunk)
+		}
+		if err := writer.Write([]string{row.requirement, row.section, chunk, row.epic, row.storyID, row.story, row.key}); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveTraceability saves the traceability matrix of a breakdown as markdown and CSV in the
+// output directory and returns the path of the markdown file
+func SaveTraceability(breakdown *models.ProjectBreakdown, outputDir string) (string, error) {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := helpers.GetOutputPath(outputDir, helpers.GenerateOutputFilename("project-desc-traceability", "md"))
+	if err := helpers.WriteFile(path, TraceabilityMarkdown(breakdown)); err != nil {
+		return "", fmt.Errorf("failed to save traceability matrix: %w", err)
+	}
+
+	data, err := TraceabilityCSV(breakdown)
+	if err != nil {
+		return "", err
+	}
+	csvPath := strings.TrimSuffix(path, ".md") + ".csv"
+	if err := helpers.WriteFile(csvPath, string(data)); err != nil {
+		return "", fmt.Errorf("failed to save traceability matrix: %w", err)
+	}
+
+	helpers.PrintSuccess("Saved traceability matrix to: %s and %s", path, csvPath)
+	return path, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// VerificationService checks delivered code against story acceptance criteria
+type VerificationService struct {
+	config      *config.Config
+	aiService   *AIService
+	jiraService *JiraService
+}
+
+// NewVerificationService creates a new verification service
+func NewVerificationService(config *config.Config) (*VerificationService, error) {
+	aiService, err := NewAIService(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerificationService{
+		config:      config,
+		aiService:   aiService,
+		jiraService: NewJiraService(&config.Jira, &config.HTTP),
+	}, nil
+}
+
+// VerifyStory evaluates a git diff against the acceptance criteria of a JIRA story
+func (s *VerificationService) VerifyStory(ctx context.Context, storyKey, diffRange string) (*models.VerificationResult, error) {
+	issue, err := s.jiraService.GetIssue(ctx, storyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	helpers.PrintInfo("Story: %s - %s", issue.Key, issue.Fields.Summary)
+
+	diff, err := helpers.GitDiff(diffRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code changes: %w", err)
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		return nil, fmt.Errorf("no code changes found in range '%s'", diffRange)
+	}
+
+	helpers.PrintInfo("Read %d bytes of diff for range %s", len(diff), diffRange)
+
+	// Keep the diff within a single request
+	if limit := s.config.Anthropic.ChunkSizeTokens; helpers.EstimateTokens(diff) > limit {
+		helpers.PrintWarning("Diff is larger than %d tokens, only the first part will be evaluated", limit)
+		diff = helpers.TruncateToTokens(diff, limit)
+	}
+
+	helpers.PrintInfo("Evaluating acceptance criteria with AI...")
+	result, err := s.aiService.VerifyAcceptanceCriteria(ctx, issue.Fields.Summary, issue.Fields.Description, diff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify acceptance criteria: %w", err)
+	}
+
+	result.StoryKey = issue.Key
+	result.StorySummary = issue.Fields.Summary
+	result.DiffRange = diffRange
+	result.VerifiedAt = time.Now()
+
+	return result, nil
+}
+
+// DisplayVerificationResult displays the acceptance-criteria checklist
+func (s *VerificationService) DisplayVerificationResult(result *models.VerificationResult) {
+	helpers.PrintTitle("Verification: %s - %s", result.StoryKey, result.StorySummary)
+	helpers.PrintInfo("Diff range: %s", result.DiffRange)
+	helpers.PrintSeparator()
+
+	satisfied := 0
+	for i, check := range result.Criteria {
+		line := fmt.Sprintf("%d. %s (confidence %d%%)", i+1, check.Criterion, check.Confidence)
+
+		switch check.Status {
+		case models.CriterionSatisfied:
+			satisfied++
+			helpers.PrintSuccess("%s", line)
+		case models.CriterionPartial, models.CriterionUnclear:
+			helpers.PrintWarning("%s [%s]", line, check.Status)
+		default:
+			helpers.PrintError("%s [%s]", line, check.Status)
+		}
+
+		if check.Evidence != "" {
+			helpers.PrintInfo("    Evidence: %s", check.Evidence)
+		}
+	}
+
+	helpers.PrintSeparator()
+	helpers.PrintInfo("Summary: %s", result.Summary)
+	helpers.PrintInfo("%d of %d acceptance criteria satisfied", satisfied, len(result.Criteria))
+}
+
+// SaveVerificationResult saves the verification checklist as JSON in the output directory
+func (s *VerificationService) SaveVerificationResult(result *models.VerificationResult, outputDir string) error {
+	if err := helpers.EnsureDir(outputDir); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	filename := helpers.GenerateOutputFilename(fmt.Sprintf("verify-%s", result.StoryKey), "json")
+	path := helpers.GetOutputPath(outputDir, filename)
+
+	if err := helpers.SaveJSON(result, path); err != nil {
+		return fmt.Errorf("failed to save verification result: %w", err)
+	}
+
+	helpers.PrintSuccess("Saved verification result to: %s", path)
+	return nil
+}
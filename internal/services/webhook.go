@@ -0,0 +1,91 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+)
+
+// maxWebhookBytes is the largest webhook body accepted; issue events with their changelog are
+// far smaller
+const maxWebhookBytes = 5 << 20
+
+// WebhookHandler receives JIRA webhooks and records human edits to tracked issues
+type WebhookHandler struct {
+	feedbackService *FeedbackService
+	secret          string
+}
+
+// NewWebhookHandler creates a new JIRA webhook handler. Requests must be signed with the secret
+// (X-Hub-Signature), which must not be empty.
+func NewWebhookHandler(feedbackService *FeedbackService, secret string) *WebhookHandler {
+	return &WebhookHandler{
+		feedbackService: feedbackService,
+		secret:          secret,
+	}
+}
+
+// ServeHTTP handles a single JIRA webhook delivery
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.authorized(r, body) {
+		helpers.PrintWarning("Rejected webhook with a missing or invalid signature from %s", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var event models.JiraWebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	recorded, err := h.feedbackService.RecordWebhookEvent(&event)
+	if err != nil {
+		helpers.PrintError("Failed to record webhook for %s: %v", event.Issue.Key, err)
+		http.Error(w, "failed to record event", http.StatusInternalServerError)
+		return
+	}
+
+	if recorded > 0 {
+		helpers.PrintInfo("Recorded %d human edits on %s by %s", recorded, event.Issue.Key, event.User.DisplayName)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized checks the X-Hub-Signature HMAC of the body. Without a secret nothing is authorized.
+func (h *WebhookHandler) authorized(r *http.Request, body []byte) bool {
+	signature := r.Header.Get("X-Hub-Signature")
+	if h.secret == "" || signature == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected))
+}
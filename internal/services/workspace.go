@@ -0,0 +1,253 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+	"scrum-master/internal/models"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Layout of a workspace bundle
+const (
+	bundleManifestName = "workspace.json"
+	bundleConfigName   = "config.yaml"
+	bundleOutputDir    = "output"
+	bundleInputsDir    = "inputs"
+	bundleTemplatesDir = "templates"
+	bundleContextDir   = "context"
+	bundleExamplesDir  = "examples"
+)
+
+// WorkspaceService exports and imports complete planning workspaces
+type WorkspaceService struct {
+	config *config.Config
+}
+
+// NewWorkspaceService creates a new workspace service for a loaded configuration
+func NewWorkspaceService(config *config.Config) *WorkspaceService {
+	return &WorkspaceService{config: config}
+}
+
+// Export writes the configuration with credentials stripped, the output directory (analyses,
+// conversations, feedback data), the prompt template overrides, context files and examples file
+// the prompts are built from, and the given input documents into a single archive. Manifests
+// among the inputs bring the documents they list.
+func (s *WorkspaceService) Export(bundlePath string, inputs []string) (*models.WorkspaceBundle, error) {
+	bundle := &models.WorkspaceBundle{
+		CreatedAt: time.Now(),
+		Config:    bundleConfigName,
+	}
+
+	configData, err := config.ReadConfigFiles(s.config.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	strippedConfig, err := config.StripSecrets(configData, "./"+bundleOutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The files prompts are built from are bundled, and the configuration points at the copies
+	promptEntries, paths, err := s.promptEntries()
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) > 0 {
+		if strippedConfig, err = config.SetProcessingFiles(strippedConfig, paths); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []helpers.ArchiveEntry{{Name: bundleConfigName, Data: strippedConfig}}
+	entries = append(entries, promptEntries...)
+
+	outputEntries, err := s.outputEntries()
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, outputEntries...)
+
+	for _, input := range inputs {
+		documentEntries, err := inputEntries(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range documentEntries {
+			bundle.Inputs = append(bundle.Inputs, entry.Name)
+		}
+		entries = append(entries, documentEntries...)
+	}
+
+	for _, entry := range entries {
+		bundle.Files = append(bundle.Files, entry.Name)
+	}
+
+	bundleData, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	entries = append([]helpers.ArchiveEntry{{Name: bundleManifestName, Data: bundleData}}, entries...)
+
+	if err := helpers.WriteTarGz(bundlePath, entries); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// ImportWorkspace extracts a workspace bundle into dir. Existing files are kept unless overwrite
+// is set. It returns the extracted and skipped file names.
+func ImportWorkspace(bundlePath, dir string, overwrite bool) ([]string, []string, error) {
+	if err := helpers.EnsureDir(dir); err != nil {
+		return nil, nil, err
+	}
+
+	extracted, skipped, err := helpers.ExtractTarGz(bundlePath, dir, overwrite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return extracted, skipped, nil
+}
+
+// outputEntries lists every file of the output directory except earlier archives
+func (s *WorkspaceService) outputEntries() ([]helpers.ArchiveEntry, error) {
+	outputDir := s.config.Processing.OutputDir
+	if outputDir == "" {
+		helpers.PrintWarning("processing.output_dir is not set, so no analyses or feedback are bundled")
+		return nil, nil
+	}
+
+	if !helpers.FileExists(outputDir) {
+		return nil, nil
+	}
+
+	var entries []helpers.ArchiveEntry
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(d.Name(), ".tar.gz") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, helpers.ArchiveEntry{
+			Name: filepath.ToSlash(filepath.Join(bundleOutputDir, rel)),
+			Path: path,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list output directory: %w", err)
+	}
+
+	return entries, nil
+}
+
+// promptEntries lists the prompt template overrides, context files and examples file of the
+// configuration, with the processing settings that name their bundled copies
+func (s *WorkspaceService) promptEntries() ([]helpers.ArchiveEntry, map[string]interface{}, error) {
+	processing := s.config.Processing
+	var entries []helpers.ArchiveEntry
+	paths := make(map[string]interface{})
+
+	if processing.TemplatesDir != "" && helpers.FileExists(processing.TemplatesDir) {
+		var overrides []helpers.ArchiveEntry
+		for _, name := range TemplateNames {
+			path := filepath.Join(processing.TemplatesDir, name+".tmpl")
+			if helpers.FileExists(path) {
+				overrides = append(overrides, helpers.ArchiveEntry{Name: bundleTemplatesDir + "/" + name + ".tmpl", Path: path})
+			}
+		}
+		if len(overrides) > 0 {
+			entries = append(entries, overrides...)
+			paths["templates_dir"] = "./" + bundleTemplatesDir
+		}
+	}
+
+	if len(processing.ContextFiles) > 0 {
+		var contextFiles []string
+		seen := make(map[string]bool)
+		for i, path := range processing.ContextFiles {
+			if !helpers.FileExists(path) {
+				return nil, nil, fmt.Errorf("context file %s does not exist", path)
+			}
+			name := bundleContextDir + "/" + filepath.Base(path)
+			if seen[name] {
+				name = fmt.Sprintf("%s/%d-%s", bundleContextDir, i+1, filepath.Base(path))
+			}
+			seen[name] = true
+
+			entries = append(entries, helpers.ArchiveEntry{Name: name, Path: path})
+			contextFiles = append(contextFiles, "./"+name)
+		}
+		paths["context_files"] = contextFiles
+	}
+
+	if processing.ExamplesFile != "" {
+		if !helpers.FileExists(processing.ExamplesFile) {
+			return nil, nil, fmt.Errorf("examples file %s does not exist", processing.ExamplesFile)
+		}
+		name := bundleExamplesDir + "/" + filepath.Base(processing.ExamplesFile)
+		entries = append(entries, helpers.ArchiveEntry{Name: name, Path: processing.ExamplesFile})
+		paths["examples_file"] = "./" + name
+	}
+
+	return entries, paths, nil
+}
+
+// inputEntries returns the archive entries for an input document, or for a manifest and the
+// documents it lists, keeping their relative layout so the manifest still resolves
+func inputEntries(input string) ([]helpers.ArchiveEntry, error) {
+	if !helpers.FileExists(input) {
+		return nil, fmt.Errorf("input '%s' does not exist", input)
+	}
+
+	entries := []helpers.ArchiveEntry{{
+		Name: filepath.ToSlash(filepath.Join(bundleInputsDir, filepath.Base(input))),
+		Path: input,
+	}}
+
+	if !IsManifestFile(input) {
+		return entries, nil
+	}
+
+	data, err := helpers.ReadFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest models.ProjectManifest
+	if err := yaml.Unmarshal([]byte(data), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest '%s': %w", input, err)
+	}
+
+	for _, document := range manifest.Documents {
+		rel := filepath.Clean(document.Path)
+		if filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+			helpers.PrintWarning("Not bundling '%s': only documents next to or below the manifest are included", document.Path)
+			continue
+		}
+
+		entries = append(entries, helpers.ArchiveEntry{
+			Name: filepath.ToSlash(filepath.Join(bundleInputsDir, rel)),
+			Path: filepath.Join(filepath.Dir(input), rel),
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"scrum-master/internal/helpers"
+)
+
+// StatusOverloaded is the status Anthropic's API answers with while it is overloaded
+const StatusOverloaded = 529
+
+// StatusError is returned when an API answers a request with an unexpected status
+type StatusError struct {
+	API        string
+	StatusCode int
+	Body       string
+}
+
+// Error names the API, the status and the response body
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.API, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether a failed request may succeed when it is repeated. Requests the
+// server rejected as invalid, unauthorized or not found (4xx statuses other than 408, 425 and
+// 429) never will, and neither will cancelled ones. Throttled and overloaded requests, server
+// errors and requests that got no response may.
+func Retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var status *StatusError
+	if !errors.As(err, &status) {
+		return true
+	}
+
+	switch status.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return status.StatusCode >= 500
+}
+
+// Overloaded reports whether a request failed because the server is overloaded rather than
+// because of the request
+func Overloaded(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) &&
+		(status.StatusCode == StatusOverloaded || status.StatusCode == http.StatusServiceUnavailable)
+}
+
+// Backoff returns the delay before the given retry, counting from 1: base, doubled for every
+// earlier retry, with jitter
+func Backoff(base time.Duration, retry int) time.Duration {
+	return helpers.Jitter(base << (retry - 1))
+}
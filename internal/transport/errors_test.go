@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no response", err: errors.New("connection reset by peer"), want: true},
+		{name: "cancelled", err: fmt.Errorf("request failed: %w", context.Canceled), want: false},
+		{name: "timed out", err: context.DeadlineExceeded, want: true},
+		{name: "bad request", err: &StatusError{API: "JIRA", StatusCode: 400}, want: false},
+		{name: "unauthorized", err: &StatusError{API: "JIRA", StatusCode: 401}, want: false},
+		{name: "not found", err: &StatusError{API: "JIRA", StatusCode: 404}, want: false},
+		{name: "request timeout", err: &StatusError{API: "JIRA", StatusCode: 408}, want: true},
+		{name: "too early", err: &StatusError{API: "JIRA", StatusCode: 425}, want: true},
+		{name: "throttled", err: &StatusError{API: "Claude", StatusCode: 429}, want: true},
+		{name: "server error", err: &StatusError{API: "Claude", StatusCode: 500}, want: true},
+		{name: "overloaded", err: &StatusError{API: "Claude", StatusCode: StatusOverloaded}, want: true},
+		{name: "wrapped status", err: fmt.Errorf("failed to create issue: %w", &StatusError{API: "JIRA", StatusCode: 403}), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
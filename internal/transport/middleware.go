@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"scrum-master/internal/helpers"
+)
+
+// maxRetryAfter caps how long a Retry-After header can make a request wait
+const maxRetryAfter = time.Minute
+
+// Retry retries requests the server throttled or was too overloaded to handle (429, 529, and 503
+// with Retry-After) and requests whose connection was refused. Idempotent methods are also
+// retried after any 503, a gateway error (502, 504) or no response at all. Issue creation and
+// other POSTs are never retried after the server may have acted on them. The delay doubles after
+// each attempt, with jitter, unless the server says when to retry.
+func Retry(retries int, delay time.Duration) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for attempt := 0; ; attempt++ {
+				attemptReq := req
+				if attempt > 0 {
+					var err error
+					if attemptReq, err = rewind(req); err != nil {
+						return nil, err
+					}
+				}
+
+				resp, err := next.RoundTrip(attemptReq)
+				if attempt >= retries || !shouldRetry(req, resp, err) {
+					return resp, err
+				}
+
+				wait := Backoff(delay, attempt+1)
+				var reason string
+				if err != nil {
+					reason = err.Error()
+				} else {
+					reason = resp.Status
+					if after := retryAfter(resp); after > 0 {
+						wait = after
+					}
+					resp.Body.Close()
+				}
+
+				helpers.PrintWarning("%s %s: %s, retrying in %s (%d/%d)", req.Method, req.URL.Path, reason, wait.Round(100*time.Millisecond), attempt+1, retries)
+				if err := helpers.Sleep(req.Context(), wait); err != nil {
+					return nil, err
+				}
+			}
+		})
+	}
+}
+
+// RateLimit spaces out requests with a rate limiter shared by every request of the client. It
+// follows the server's rate limit headers: when a request is throttled or no requests are left
+// (X-RateLimit-Remaining: 0), all requests wait until the server allows them again, and when
+// the limit is nearly used up (X-RateLimit-NearLimit), requests are slowed to the rate it refills
+// at (X-RateLimit-FillRate per X-RateLimit-Interval-Seconds).
+func RateLimit(limiter *helpers.RateLimiter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				throttle(limiter, req, resp)
+			}
+			return resp, err
+		})
+	}
+}
+
+// throttle adapts a rate limiter to the rate limit headers of a response
+func throttle(limiter *helpers.RateLimiter, req *http.Request, resp *http.Response) {
+	throttled := resp.StatusCode == http.StatusTooManyRequests
+	if throttled || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if after := retryAfter(resp); after > 0 {
+			limiter.PauseUntil(time.Now().Add(after))
+		}
+	}
+
+	if !throttled && !strings.EqualFold(resp.Header.Get("X-RateLimit-NearLimit"), "true") {
+		return
+	}
+	fillRate, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-FillRate"))
+	intervalSeconds, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Interval-Seconds"))
+	if fillRate <= 0 || intervalSeconds <= 0 {
+		return
+	}
+	if limiter.SlowDown(time.Duration(intervalSeconds) * time.Second / time.Duration(fillRate)) {
+		helpers.PrintWarning("%s is limiting the request rate, slowing down to %d requests every %ds", req.URL.Host, fillRate, intervalSeconds)
+	}
+}
+
+// BasicAuth adds HTTP basic authentication to every request
+func BasicAuth(username, password string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.SetBasicAuth(username, password)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// BearerToken adds a bearer token to every request
+func BearerToken(token string) Middleware {
+	return Header("Authorization", "Bearer "+token)
+}
+
+// Header sets a header, such as an API key, on every request
+func Header(name, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set(name, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// AuditLog appends one line per request attempt to a file: time, method, URL without query,
+// status or error, and duration. Headers and bodies are never logged.
+func AuditLog(path string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+
+			var outcome string
+			if err != nil {
+				outcome = "error: " + err.Error()
+			} else {
+				outcome = strconv.Itoa(resp.StatusCode)
+			}
+
+			line := fmt.Sprintf("%s %s %s://%s%s %s %s\n", start.Format(time.RFC3339), req.Method,
+				req.URL.Scheme, req.URL.Host, req.URL.Path, outcome, time.Since(start).Round(time.Millisecond))
+			if logErr := appendLine(path, line); logErr != nil {
+				helpers.PrintWarning("Failed to write HTTP audit log: %v", logErr)
+			}
+
+			return resp, err
+		})
+	}
+}
+
+// shouldRetry reports whether a failed attempt is safe and worth repeating
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		req.Method == http.MethodPut || req.Method == http.MethodDelete
+
+	// A refused connection never reached the server
+	if err != nil {
+		return idempotent || errors.Is(err, syscall.ECONNREFUSED)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, StatusOverloaded:
+		return true
+	case http.StatusServiceUnavailable:
+		return idempotent || resp.Header.Get("Retry-After") != ""
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// rewind returns a copy of a request with a fresh body for another attempt
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry %s %s: request body cannot be replayed", req.Method, req.URL.Path)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("cannot retry %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+// retryAfter returns the delay the server asks for before the next request, capped at a
+// minute: Retry-After in seconds or as a date, or else the time X-RateLimit-Reset names
+func retryAfter(resp *http.Response) time.Duration {
+	var after time.Duration
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		after = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		after = time.Until(date)
+	} else if reset, err := time.Parse(time.RFC3339, resp.Header.Get("X-RateLimit-Reset")); err == nil {
+		after = time.Until(reset)
+	}
+
+	if after <= 0 {
+		return 0
+	}
+	return min(after, maxRetryAfter)
+}
+
+// appendLine appends a line to a file, creating it if needed
+func appendLine(path, line string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(line)
+	return err
+}
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// attempt is the outcome of one attempt at a request: a response with a status and headers, or
+// an error
+type attempt struct {
+	status  int
+	headers map[string]string
+	err     error
+}
+
+func TestRetry(t *testing.T) {
+	refused := fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED)
+	reset := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name       string
+		method     string
+		attempts   []attempt
+		retries    int
+		wantStatus int
+		wantErr    bool
+		wantCalls  int
+	}{
+		{
+			name:       "success is not retried",
+			method:     http.MethodGet,
+			attempts:   []attempt{{status: 200}},
+			retries:    3,
+			wantStatus: 200,
+			wantCalls:  1,
+		},
+		{
+			name:       "throttled POST retried",
+			method:     http.MethodPost,
+			attempts:   []attempt{{status: 429}, {status: StatusOverloaded}, {status: 201}},
+			retries:    3,
+			wantStatus: 201,
+			wantCalls:  3,
+		},
+		{
+			name:       "retries exhausted",
+			method:     http.MethodGet,
+			attempts:   []attempt{{status: 502}, {status: 502}, {status: 502}},
+			retries:    2,
+			wantStatus: 502,
+			wantCalls:  3,
+		},
+		{
+			name:       "client errors not retried",
+			method:     http.MethodGet,
+			attempts:   []attempt{{status: 404}},
+			retries:    3,
+			wantStatus: 404,
+			wantCalls:  1,
+		},
+		{
+			name:       "gateway error retried for GET",
+			method:     http.MethodGet,
+			attempts:   []attempt{{status: 504}, {status: 200}},
+			retries:    3,
+			wantStatus: 200,
+			wantCalls:  2,
+		},
+		{
+			name:       "gateway error not retried for POST",
+			method:     http.MethodPost,
+			attempts:   []attempt{{status: 504}},
+			retries:    3,
+			wantStatus: 504,
+			wantCalls:  1,
+		},
+		{
+			name:       "unavailable POST retried only with Retry-After",
+			method:     http.MethodPost,
+			attempts:   []attempt{{status: 503, headers: map[string]string{"Retry-After": "0"}}, {status: 503}},
+			retries:    3,
+			wantStatus: 503,
+			wantCalls:  2,
+		},
+		{
+			name:       "no response retried for PUT",
+			method:     http.MethodPut,
+			attempts:   []attempt{{err: reset}, {status: 200}},
+			retries:    3,
+			wantStatus: 200,
+			wantCalls:  2,
+		},
+		{
+			name:      "no response not retried for POST",
+			method:    http.MethodPost,
+			attempts:  []attempt{{err: reset}},
+			retries:   3,
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:       "refused connection retried for POST",
+			method:     http.MethodPost,
+			attempts:   []attempt{{err: refused}, {status: 201}},
+			retries:    3,
+			wantStatus: 201,
+			wantCalls:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(body))
+
+				outcome := tt.attempts[len(bodies)-1]
+				if outcome.err != nil {
+					return nil, outcome.err
+				}
+				resp := &http.Response{StatusCode: outcome.status, Status: http.StatusText(outcome.status), Header: http.Header{}, Body: http.NoBody}
+				for name, value := range outcome.headers {
+					resp.Header.Set(name, value)
+				}
+				return resp, nil
+			})
+
+			req, err := http.NewRequest(tt.method, "https://example.com/rest/api/2/issue", strings.NewReader(`{"a":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := Retry(tt.retries, 0)(next).RoundTrip(req)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Retry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.StatusCode != tt.wantStatus {
+				t.Errorf("Retry() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(bodies) != tt.wantCalls {
+				t.Errorf("Retry() made %d attempts, want %d", len(bodies), tt.wantCalls)
+			}
+			// Every attempt sends the whole body again
+			for i, body := range bodies {
+				if body != `{"a":1}` {
+					t.Errorf("Retry() attempt %d sent body %q, want %q", i+1, body, `{"a":1}`)
+				}
+			}
+		})
+	}
+}
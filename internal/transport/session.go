@@ -0,0 +1,301 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/models"
+)
+
+// session records or replays the traffic of every client created after UseSession
+var session Middleware
+
+// sensitiveHeaders are never recorded
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Goog-Api-Key":      true,
+}
+
+// sensitiveParams are query parameters whose values are never recorded
+var sensitiveParams = []string{"key", "api_key", "token", "access_token"}
+
+// UseSession sends the requests of every client created from now on through a recorder or
+// replayer, below retries and authentication so each attempt is seen as sent
+func UseSession(middleware Middleware) {
+	session = middleware
+}
+
+// Recorder records the requests of every client and the responses they get, with credentials
+// removed: sensitive headers are dropped and the given secrets are replaced wherever they appear
+type Recorder struct {
+	mu        sync.Mutex
+	secrets   []string
+	exchanges []*models.SessionExchange
+}
+
+// minSecretLength is the length below which a secret is not searched for in recordings, as
+// replacing such short strings would mangle them rather than protect anything
+const minSecretLength = 6
+
+// NewRecorder creates a recorder that removes the given secrets
+func NewRecorder(secrets []string) *Recorder {
+	r := &Recorder{}
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// Middleware returns the middleware that records requests. Response bodies are recorded as they
+// are read, so progress streams still arrive as they happen.
+func (r *Recorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req, body, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			exchange := &models.SessionExchange{
+				Time:           time.Now(),
+				Method:         req.Method,
+				URL:            r.redact(sanitizeURL(req)),
+				RequestHeaders: r.headers(req.Header),
+				RequestBody:    r.redact(string(body)),
+			}
+			r.add(exchange)
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				r.update(func() {
+					exchange.Error = r.redact(err.Error())
+					exchange.DurationMS = time.Since(exchange.Time).Milliseconds()
+				})
+				return resp, err
+			}
+
+			r.update(func() {
+				exchange.Status = resp.StatusCode
+				exchange.ResponseHeaders = r.headers(resp.Header)
+			})
+			resp.Body = &recordedBody{ReadCloser: resp.Body, recorder: r, exchange: exchange}
+			return resp, nil
+		})
+	}
+}
+
+// Exchanges returns the recorded exchanges in the order their requests were sent
+func (r *Recorder) Exchanges() []models.SessionExchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exchanges := make([]models.SessionExchange, len(r.exchanges))
+	for i, exchange := range r.exchanges {
+		exchanges[i] = *exchange
+	}
+	return exchanges
+}
+
+// add numbers and stores a new exchange
+func (r *Recorder) add(exchange *models.SessionExchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exchange.Seq = len(r.exchanges) + 1
+	r.exchanges = append(r.exchanges, exchange)
+}
+
+// update changes a stored exchange
+func (r *Recorder) update(change func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change()
+}
+
+// headers returns the headers worth recording, without credentials
+func (r *Recorder) headers(header http.Header) map[string]string {
+	recorded := make(map[string]string)
+	for name, values := range header {
+		if !sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			recorded[name] = r.redact(strings.Join(values, ", "))
+		}
+	}
+	return recorded
+}
+
+// redact replaces every secret in s
+func (r *Recorder) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, config.RedactedValue)
+	}
+	return s
+}
+
+// recordedBody records a response body as it is read, storing it with the time the response
+// took once it has been read to the end or closed
+type recordedBody struct {
+	io.ReadCloser
+	recorder *Recorder
+	exchange *models.SessionExchange
+	data     bytes.Buffer
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.data.Write(p[:n])
+	if err != nil {
+		b.store()
+	}
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	b.store()
+	return b.ReadCloser.Close()
+}
+
+// store records the body read so far
+func (b *recordedBody) store() {
+	b.recorder.update(func() {
+		b.exchange.ResponseBody = b.recorder.redact(b.data.String())
+		b.exchange.DurationMS = time.Since(b.exchange.Time).Milliseconds()
+	})
+}
+
+// Replayer answers requests with the responses of a recorded session instead of sending them.
+// A request gets the first unused response recorded for the same method, URL and body, or
+// failing that for the same method and URL, so concurrent requests still get their own answers.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges []models.SessionExchange
+	used      []bool
+	missed    int
+}
+
+// NewReplayer creates a replayer for the exchanges of a recorded session
+func NewReplayer(exchanges []models.SessionExchange) *Replayer {
+	return &Replayer{exchanges: exchanges, used: make([]bool, len(exchanges))}
+}
+
+// Middleware returns the middleware that answers requests from the recording. A request the
+// session never sent fails.
+func (r *Replayer) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req, body, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			exchange := r.match(req.Method, sanitizeURL(req), string(body))
+			if exchange == nil {
+				return nil, fmt.Errorf("the recorded session has no response for %s %s", req.Method, req.URL.Path)
+			}
+			if exchange.Error != "" {
+				return nil, errors.New(exchange.Error)
+			}
+
+			header := make(http.Header)
+			for name, value := range exchange.ResponseHeaders {
+				header.Set(name, value)
+			}
+
+			return &http.Response{
+				Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+				StatusCode:    exchange.Status,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        header,
+				Body:          io.NopCloser(strings.NewReader(exchange.ResponseBody)),
+				ContentLength: int64(len(exchange.ResponseBody)),
+				Request:       req,
+			}, nil
+		})
+	}
+}
+
+// Replayed returns how many of the recorded responses were used and how many requests had none
+func (r *Replayer) Replayed() (used, recorded, missed int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.used {
+		if u {
+			used++
+		}
+	}
+	return used, len(r.exchanges), r.missed
+}
+
+// match takes the recorded exchange for a request
+func (r *Replayer) match(method, url, body string) *models.SessionExchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, sameBody := range []bool{true, false} {
+		for i, exchange := range r.exchanges {
+			if r.used[i] || exchange.Method != method || exchange.URL != url {
+				continue
+			}
+			if sameBody && exchange.RequestBody != body {
+				continue
+			}
+			r.used[i] = true
+			return &r.exchanges[i]
+		}
+	}
+
+	r.missed++
+	return nil
+}
+
+// readRequestBody reads the body of a request and returns a copy of the request that can still
+// send it
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return clone, body, nil
+}
+
+// sanitizeURL returns the URL of a request with the values of sensitive query parameters replaced
+func sanitizeURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+
+	query := u.Query()
+	for _, param := range sensitiveParams {
+		if query.Has(param) {
+			query.Set(param, config.RedactedValue)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
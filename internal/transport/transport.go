@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"scrum-master/internal/config"
+	"scrum-master/internal/helpers"
+)
+
+// Middleware wraps a round tripper with extra behaviour, such as retries or authentication
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base in the middlewares so that the first middleware sees a request first
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
+
+// NewClient creates the HTTP client of an API. Every request is retried as configured, then
+// passes through the client's own middlewares (rate limit, authentication), is written to the
+// audit log, recorded or replayed in a session, and is sent through the configured proxy. The
+// timeout covers retries.
+func NewClient(cfg *config.HTTPConfig, timeoutSeconds int, middlewares ...Middleware) *http.Client {
+	chain := []Middleware{Retry(cfg.RetryCount, time.Duration(cfg.RetryDelaySeconds)*time.Second)}
+	chain = append(chain, middlewares...)
+	if cfg.AuditLog != "" {
+		chain = append(chain, AuditLog(cfg.AuditLog))
+	}
+	if session != nil {
+		chain = append(chain, session)
+	}
+
+	return &http.Client{
+		Transport: Chain(baseTransport(cfg), chain...),
+		Timeout:   time.Duration(timeoutSeconds) * time.Second,
+	}
+}
+
+// baseTransport returns the default transport with the configured proxy and TLS settings
+func baseTransport(cfg *config.HTTPConfig) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = cfg.Proxy()
+	transport.TLSClientConfig = cfg.TLSConfig()
+
+	if transport.TLSClientConfig != nil && transport.TLSClientConfig.InsecureSkipVerify {
+		insecureWarning.Do(func() {
+			helpers.PrintWarning("TLS certificate verification of the JIRA or Confluence server is disabled (insecure_skip_verify); use http.ca_file instead where possible")
+		})
+	}
+
+	return transport
+}
+
+// insecureWarning warns about disabled certificate verification once per run
+var insecureWarning sync.Once